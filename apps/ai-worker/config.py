@@ -72,6 +72,45 @@ class Settings(BaseSettings):
     CPU環境推奨値: 16-32
     """
     
+    reranker_model: str = Field(
+        default="BAAI/bge-reranker-v2-m3",
+        description="Cross-encoder model name or local path for reranking"
+    )
+    """
+    リランキングに使う Cross-Encoder モデル
+    - 初回の /api/v1/rerank 呼び出し時にロード（起動時には読み込まない）
+    - 多言語対応: BAAI/bge-reranker-v2-m3
+    """
+    
+    reranker_max_length: int = Field(
+        default=512,
+        ge=64,
+        description="Maximum token length of a (query, text) pair"
+    )
+    """
+    Cross-Encoder に渡す (query, text) ペアの最大トークン長
+    - 超えた分は切り捨て
+    """
+    
+    ocr_languages: str = Field(
+        default="ja,en",
+        description="Comma separated EasyOCR language codes"
+    )
+    """
+    OCR（/api/v1/ocr）で認識する言語（EasyOCR の言語コード）
+    - 初回の /api/v1/ocr 呼び出し時にロード（起動時には読み込まない）
+    - 日本語と英語: ja,en
+    """
+    
+    ocr_gpu: bool = Field(
+        default=False,
+        description="Use GPU for OCR"
+    )
+    """
+    OCR に GPU を使うか
+    - CPU環境では False
+    """
+    
     # ========================================
     # Server Settings
     # ========================================
@@ -1,7 +1,7 @@
 # アプリケーションのエントリーポイント (=>API)
 import logging
 from fastapi import FastAPI
-from routers import embedding, embed_query, generate
+from routers import embedding, embed_query, generate, rerank, ocr, tokenize
 from api.deps import get_model
 from utils.logging import setup_logging
 
@@ -31,6 +31,24 @@ app.include_router(
     tags=["generate"]
 )
 
+app.include_router(
+    rerank.router,
+    prefix="/api/v1",
+    tags=["rerank"]
+)
+
+app.include_router(
+    ocr.router,
+    prefix="/api/v1",
+    tags=["ocr"]
+)
+
+app.include_router(
+    tokenize.router,
+    prefix="/api/v1",
+    tags=["tokenize"]
+)
+
 @app.get("/health")
 async def health():
     return {"status": "ok"}
@@ -38,6 +38,30 @@ class EmbeddingModel():
             raise RuntimeError("Model is not loaded")
         text = f"query: {text}"
         return self.model.encode(text, convert_to_numpy=True, normalize_embeddings=True)
+
+    def count_tokens(self, texts: list[str]) -> list[int]:
+        """
+        Count tokens of documents as encode_documents feeds them to the model.
+
+        Args:
+            texts (list[str]): List of document texts
+
+        Returns:
+            list[int]: Token counts including the "passage: " prefix and special tokens (not truncated)
+        """
+        if not self.is_loaded or self.model is None:
+            raise RuntimeError("Model is not loaded")
+        texts = [f"passage: {t}" for t in texts]
+        encoded = self.model.tokenizer(texts, add_special_tokens=True, truncation=False)
+        return [len(ids) for ids in encoded["input_ids"]]
+
+    def max_seq_length(self) -> int:
+        """
+        Maximum sequence length of the model (longer inputs are truncated by encode)
+        """
+        if not self.is_loaded or self.model is None:
+            raise RuntimeError("Model is not loaded")
+        return int(self.model.max_seq_length)
         
     
     # Utility Methods
@@ -0,0 +1,97 @@
+import logging
+from typing import Optional
+from functools import lru_cache
+import numpy as np
+from config import get_settings
+from exceptions import ModelLoadError
+
+logger = logging.getLogger(__name__)
+
+class OCRModel():
+    def __init__(self):
+        self.settings = get_settings()
+        self.reader = None
+        self.languages: list[str] = [l.strip() for l in self.settings.ocr_languages.split(",") if l.strip()]
+        self.model_name: str = "easyocr:" + "+".join(self.languages)
+        self.is_loaded: bool = False
+
+        self._load_model()
+
+    def recognize(self, image: bytes) -> tuple[list[dict], float]:
+        """
+        Recognize text lines in an image.
+
+        Args:
+            image (bytes): Encoded image (PNG / JPEG)
+
+        Returns:
+            tuple[list[dict], float]: Lines in reading order
+                ({text, bbox: [x0, y0, x1, y1] in pixels, confidence, paragraph})
+                and the mean confidence in [0, 1] weighted by text length
+        """
+        if not self.is_loaded or self.reader is None:
+            raise RuntimeError("Model is not loaded")
+
+        results = self.reader.readtext(image, detail=1, paragraph=False)
+
+        lines = []
+        for points, text, confidence in results:
+            text = text.strip()
+            if not text:
+                continue
+            xs = [float(p[0]) for p in points]
+            ys = [float(p[1]) for p in points]
+            lines.append({
+                "text": text,
+                "bbox": [min(xs), min(ys), max(xs), max(ys)],
+                "confidence": float(confidence),
+            })
+
+        lines.sort(key=lambda l: (l["bbox"][1], l["bbox"][0]))
+        self._assign_paragraphs(lines)
+
+        weights = np.array([len(l["text"]) for l in lines], dtype=np.float32)
+        if weights.sum() == 0:
+            return lines, 0.0
+        scores = np.array([l["confidence"] for l in lines], dtype=np.float32)
+        return lines, float((scores * weights).sum() / weights.sum())
+
+    @staticmethod
+    def _assign_paragraphs(lines: list[dict]) -> None:
+        """
+        行を段落にまとめる（上の行と横方向に重なり、行間が行の高さより狭ければ同じ段落）
+        """
+        paragraph = -1
+        prev = None
+        for line in lines:
+            x0, y0, x1, y1 = line["bbox"]
+            if prev is not None:
+                px0, py0, px1, py1 = prev["bbox"]
+                height = max(y1 - y0, py1 - py0)
+                if x0 < px1 and px0 < x1 and 0 <= y0 - py1 < 0.8 * height:
+                    line["paragraph"] = paragraph
+                    prev = line
+                    continue
+            paragraph += 1
+            line["paragraph"] = paragraph
+            prev = line
+
+    def _load_model(self):
+        try:
+            import easyocr
+
+            self.reader = easyocr.Reader(
+                self.languages,
+                gpu=self.settings.ocr_gpu,
+                model_storage_directory=self.settings.model_cache_dir,
+            )
+            self.is_loaded = True
+            logger.info(f"OCR loaded: {self.model_name}")
+        except Exception as e:
+            logger.error(f"Failed to load OCR: {e}")
+            raise ModelLoadError(f"Cannot load {self.model_name}") from e
+
+
+@lru_cache()
+def get_ocr_model() -> OCRModel:
+    return OCRModel()
@@ -0,0 +1,56 @@
+import logging
+from sentence_transformers import CrossEncoder
+from typing import Optional
+from functools import lru_cache
+import numpy as np
+from config import get_settings
+from exceptions import ModelLoadError
+
+logger = logging.getLogger(__name__)
+
+class RerankerModel():
+    def __init__(self):
+        self.settings = get_settings()
+        self.model: Optional[CrossEncoder] = None
+        self.model_name: str = self.settings.reranker_model
+        self.is_loaded: bool = False
+        
+        self._load_model()
+    
+    def score(self, query: str, texts: list[str]) -> np.ndarray:
+        """
+        Score (query, text) pairs with the cross-encoder.
+
+        Args:
+            query (str): Search query
+            texts (list[str]): Candidate texts
+
+        Returns:
+            np.ndarray: Shape (len(texts),), relevance in [0, 1]
+        """
+        if not self.is_loaded or self.model is None:
+            raise RuntimeError("Model is not loaded")
+        if not texts:
+            return np.array([], dtype=np.float32)
+        pairs = [(query, t) for t in texts]
+        # num_labels=1 のモデルは predict がシグモイドを適用済みのスコアを返す
+        return self.model.predict(pairs, batch_size=self.settings.max_batch_size, convert_to_numpy=True)
+    
+    def _load_model(self):
+        try:
+            self.model = CrossEncoder(
+                self.model_name,
+                max_length=self.settings.reranker_max_length,
+                automodel_args={"cache_dir": self.settings.model_cache_dir},
+                tokenizer_args={"cache_dir": self.settings.model_cache_dir},
+            )
+            self.is_loaded = True
+            logger.info(f"Reranker loaded: {self.model_name}")
+        except Exception as e:
+            logger.error(f"Failed to load reranker: {e}")
+            raise ModelLoadError(f"Cannot load {self.model_name}") from e
+
+
+@lru_cache()
+def get_reranker_model() -> RerankerModel:
+    return RerankerModel()
@@ -0,0 +1,41 @@
+import base64
+import binascii
+from fastapi import APIRouter, HTTPException
+from pydantic import BaseModel
+from models.ocr import get_ocr_model
+
+router = APIRouter()
+
+class OCRRequest(BaseModel):
+    image: str  # base64 encoded PNG / JPEG
+
+class OCRLine(BaseModel):
+    text: str
+    bbox: list[float]  # [x0, y0, x1, y1] in pixels, top-left origin
+    confidence: float
+    paragraph: int
+
+class OCRResponse(BaseModel):
+    lines: list[OCRLine]
+    confidence: float
+    model: str
+
+@router.post("/ocr", response_model=OCRResponse)
+async def ocr(request: OCRRequest):
+    try:
+        image = base64.b64decode(request.image, validate=True)
+    except (binascii.Error, ValueError) as e:
+        raise HTTPException(status_code=400, detail=f"invalid image: {e}")
+
+    try:
+        model = get_ocr_model()
+
+        lines, confidence = model.recognize(image)
+
+        return OCRResponse(
+            lines=[OCRLine(**l) for l in lines],
+            confidence=confidence,
+            model=model.model_name
+        )
+    except Exception as e:
+        raise HTTPException(status_code=500, detail=str(e))
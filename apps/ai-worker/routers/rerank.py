@@ -0,0 +1,27 @@
+from fastapi import APIRouter, HTTPException
+from pydantic import BaseModel
+from models.reranker import get_reranker_model
+
+router = APIRouter()
+
+class RerankRequest(BaseModel):
+    query: str
+    texts: list[str]
+    
+class RerankResponse(BaseModel):
+    scores: list[float]
+    model: str
+
+@router.post("/rerank", response_model=RerankResponse)
+async def rerank(request: RerankRequest):
+    try:
+        model = get_reranker_model()
+        
+        scores = model.score(request.query, request.texts)
+        
+        return RerankResponse(
+            scores=[float(s) for s in scores],
+            model=model.model_name
+        )
+    except Exception as e:
+        raise HTTPException(status_code=500, detail=str(e))
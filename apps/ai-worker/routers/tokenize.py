@@ -0,0 +1,28 @@
+from fastapi import APIRouter, HTTPException
+from pydantic import BaseModel
+from models.embedding import get_embedding_model
+
+router = APIRouter()
+
+class TokenizeRequest(BaseModel):
+    texts: list[str]
+
+class TokenizeResponse(BaseModel):
+    counts: list[int]  # including the "passage: " prefix and special tokens
+    max_length: int
+    model: str
+
+@router.post("/tokenize", response_model=TokenizeResponse)
+async def tokenize(request: TokenizeRequest):
+    try:
+        model = get_embedding_model()
+
+        counts = model.count_tokens(request.texts)
+
+        return TokenizeResponse(
+            counts=counts,
+            max_length=model.max_seq_length(),
+            model=model.model_name
+        )
+    except Exception as e:
+        raise HTTPException(status_code=500, detail=str(e))
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"io"
+	"log"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/config"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/service"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/storage"
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+)
+
+// ベクトル payload の directory_id / tags / metadata を documents の値で埋め直します。
+// これらのキーが入る前にインデックスしたドキュメントは、このコマンドを一度実行するまで
+// ディレクトリ・タグでの絞り込み（filter_config）で検索に出てきません。
+// 接続先はサーバーと同じ環境変数（DB_* / VECTOR_STORE など）で決まります。
+// VECTOR_STORE=local の場合はサーバーを止めてから実行してください（ストアのファイルは1プロセスからしか開けません）。
+//
+//	go run ./cmd/backfill_payloads -workspace <id>
+//	go run ./cmd/backfill_payloads -all
+func main() {
+	workspace := flag.String("workspace", "", "対象のワークスペース ID")
+	all := flag.Bool("all", false, "全ワークスペースを対象にする")
+	flag.Parse()
+
+	if *workspace == "" && !*all {
+		log.Fatal("either -workspace or -all is required")
+	}
+
+	_ = godotenv.Load()
+	cfg := config.Load()
+	ctx := context.Background()
+
+	connStr := "host=" + cfg.Database.Host +
+		" port=" + cfg.Database.Port +
+		" user=" + cfg.Database.User +
+		" password=" + cfg.Database.Password +
+		" dbname=" + cfg.Database.DBName +
+		" sslmode=disable"
+
+	database, err := sql.Open("postgres", connStr)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+	queries := db.New(database)
+
+	vectorStore, err := storage.NewVectorStore(storage.VectorStoreOptions{
+		Backend:   storage.VectorStoreBackend(cfg.VectorStore.Backend),
+		QdrantURL: cfg.VectorStore.QdrantURL,
+		LocalPath: cfg.VectorStore.LocalPath,
+		HNSW: storage.HNSWParams{
+			M:              cfg.VectorStore.HNSWM,
+			EfConstruction: cfg.VectorStore.HNSWEfConstruction,
+			EfSearch:       cfg.VectorStore.HNSWEfSearch,
+		},
+	})
+	if err != nil {
+		log.Fatalf("Failed to create vector store: %v", err)
+	}
+
+	// Step 1: 対象のワークスペースを決める
+	var workspaceIDs []uuid.UUID
+	if *all {
+		workspaces, err := queries.ListWorkspaces(ctx)
+		if err != nil {
+			log.Fatalf("Failed to list workspaces: %v", err)
+		}
+		for _, w := range workspaces {
+			workspaceIDs = append(workspaceIDs, w.ID)
+		}
+	} else {
+		id, err := uuid.Parse(*workspace)
+		if err != nil {
+			log.Fatalf("Invalid workspace ID: %v", err)
+		}
+		workspaceIDs = []uuid.UUID{id}
+	}
+	log.Printf("📦 Backfilling payloads for %d workspace(s) (backend=%s)", len(workspaceIDs), cfg.VectorStore.Backend)
+
+	// Step 2: ワークスペースごとに payload を書き込む
+	failed := 0
+	for _, id := range workspaceIDs {
+		n, err := service.BackfillDocumentPayloads(ctx, queries, vectorStore, id)
+		if err != nil {
+			log.Printf("❌ workspace_%s: %v (%d documents updated)", id, err, n)
+			failed++
+			continue
+		}
+		log.Printf("✅ workspace_%s: %d documents", id, n)
+	}
+
+	// Step 3: ローカルのベクトルストアはスナップショットを書いて閉じる
+	if closer, ok := vectorStore.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Fatalf("Failed to close vector store: %v", err)
+		}
+	}
+
+	if failed > 0 {
+		log.Fatalf("❌ %d workspace(s) failed", failed)
+	}
+	log.Println("✅ Backfill completed")
+}
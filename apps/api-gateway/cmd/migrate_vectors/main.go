@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/storage"
+)
+
+// Qdrant のコレクションをローカルのベクトルストア（VECTOR_STORE=local）にコピーします。
+//
+//	go run ./cmd/migrate_vectors -collection workspace_<id>
+//	go run ./cmd/migrate_vectors -all -dest ./data/vectors
+func main() {
+	qdrantURL := flag.String("qdrant", "http://localhost:6333", "コピー元の Qdrant の URL")
+	dest := flag.String("dest", "./data/vectors", "コピー先のローカルベクトルストアのディレクトリ")
+	collection := flag.String("collection", "", "コピーするコレクション名")
+	all := flag.Bool("all", false, "workspace_ で始まる全コレクションをコピーする")
+	batchSize := flag.Int("batch", 256, "1回の scroll / upsert の件数")
+	flag.Parse()
+
+	if *collection == "" && !*all {
+		log.Fatal("either -collection or -all is required")
+	}
+
+	ctx := context.Background()
+	src := storage.NewQdrantVectorStore(*qdrantURL)
+	dst, err := storage.NewLocalVectorStore(*dest, storage.HNSWParams{})
+	if err != nil {
+		log.Fatalf("Failed to open local vector store: %v", err)
+	}
+
+	// Step 1: コピーするコレクションを決める
+	collections := []string{*collection}
+	if *all {
+		names, err := src.ListCollections(ctx)
+		if err != nil {
+			log.Fatalf("Failed to list collections: %v", err)
+		}
+		collections = nil
+		for _, name := range names {
+			if strings.HasPrefix(name, "workspace_") {
+				collections = append(collections, name)
+			}
+		}
+	}
+	log.Printf("📦 Migrating %d collection(s) from %s to %s", len(collections), *qdrantURL, *dest)
+
+	// Step 2: コレクションごとにコピーする
+	failed := 0
+	for _, name := range collections {
+		if err := migrateCollection(ctx, src, dst, name, *batchSize); err != nil {
+			log.Printf("❌ %s: %v", name, err)
+			failed++
+		}
+	}
+
+	// Step 3: スナップショットを書いて閉じる
+	if err := dst.Close(); err != nil {
+		log.Fatalf("Failed to close local vector store: %v", err)
+	}
+
+	if failed > 0 {
+		log.Fatalf("❌ %d collection(s) failed", failed)
+	}
+	log.Println("✅ Migration completed")
+}
+
+// migrateCollection は1コレクション分のポイントをベクトルごとコピーし、件数が一致するか確認します
+func migrateCollection(ctx context.Context, src, dst storage.VectorStore, name string, batchSize int) error {
+	config, err := src.GetCollection(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to get collection: %w", err)
+	}
+	if err := dst.EnsureCollection(ctx, name, config); err != nil {
+		return fmt.Errorf("failed to create collection: %w", err)
+	}
+
+	copied := 0
+	offset := ""
+	for {
+		page, err := src.Scroll(ctx, name, storage.ScrollRequest{
+			Limit:      batchSize,
+			Offset:     offset,
+			WithVector: true,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to scroll points: %w", err)
+		}
+
+		points := make([]storage.Point, len(page.Points))
+		for i, r := range page.Points {
+			points[i] = storage.Point{
+				ID:           r.ID,
+				Vector:       r.Vector,
+				NamedVectors: r.NamedVectors,
+				Payload:      r.Payload,
+			}
+		}
+		if err := dst.Upsert(ctx, name, points); err != nil {
+			return fmt.Errorf("failed to upsert points: %w", err)
+		}
+		copied += len(points)
+
+		if page.NextOffset == "" {
+			break
+		}
+		offset = page.NextOffset
+	}
+
+	srcCount, err := src.Count(ctx, name, nil)
+	if err != nil {
+		return fmt.Errorf("failed to count source points: %w", err)
+	}
+	dstCount, err := dst.Count(ctx, name, nil)
+	if err != nil {
+		return fmt.Errorf("failed to count migrated points: %w", err)
+	}
+	if srcCount != dstCount {
+		return fmt.Errorf("point count mismatch: qdrant=%d local=%d", srcCount, dstCount)
+	}
+
+	log.Printf("✅ %s: %d points", name, copied)
+	return nil
+}
@@ -3,9 +3,14 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"expvar"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -64,6 +69,13 @@ func main() {
 	}
 	log.Println("✅ MinIO client initialized")
 
+	if cfg.MinIO.PublicEndpoint != "" {
+		if err := minioClient.SetPublicEndpoint(cfg.MinIO.PublicEndpoint, cfg.MinIO.User, cfg.MinIO.Password, cfg.MinIO.PublicUseSSL); err != nil {
+			log.Fatal("failed to configure MinIO public endpoint:", err)
+		}
+		log.Println("✅ Presigned URLs use public endpoint:", cfg.MinIO.PublicEndpoint)
+	}
+
 	// Ensure bucket exists
 	ctx := context.Background()
 	bucketName := "nexus-files"
@@ -85,13 +97,21 @@ func main() {
 	aiClient := client.NewAIWorkerClient("http://localhost:8001")
 	log.Println("✅ AI Worker client created")
 
-	// Step 6: Qdrant Client作成
-	qdrantClient := client.NewQdrantClient("http://localhost:6333")
-	log.Println("✅ Qdrant client created")
-
-	// Step 4: File Service作成
-	fileService := service.NewFileService(queries, minioClient, bucketName, qdrantClient)
-	log.Println("✅ File service created")
+	// Step 6: VectorStore作成（VECTOR_STORE=qdrant / local / memory）
+	vectorStore, err := storage.NewVectorStore(storage.VectorStoreOptions{
+		Backend:   storage.VectorStoreBackend(cfg.VectorStore.Backend),
+		QdrantURL: cfg.VectorStore.QdrantURL,
+		LocalPath: cfg.VectorStore.LocalPath,
+		HNSW: storage.HNSWParams{
+			M:              cfg.VectorStore.HNSWM,
+			EfConstruction: cfg.VectorStore.HNSWEfConstruction,
+			EfSearch:       cfg.VectorStore.HNSWEfSearch,
+		},
+	})
+	if err != nil {
+		log.Fatal("failed to create vector store:", err)
+	}
+	log.Printf("✅ Vector store created (backend=%s)", cfg.VectorStore.Backend)
 
 	// Step8: OllamaClient ChatService のインスタンス作成
 	ollamaBaseURL := fmt.Sprintf("http://%s:%s", cfg.Ollama.Host, cfg.Ollama.Port)
@@ -99,10 +119,81 @@ func main() {
 	ollamaClient := client.NewOllamaClient(ollamaBaseURL)
 	log.Println("✅ Ollama client created")
 
+	// テキストレイヤーのない PDF のページの OCR（OCR_PROVIDER=none / tesseract / ai_worker）
+	pdfExtractor, err := service.NewPDFExtractorWithOCR(service.OCROptions{
+		Provider:      service.OCRProvider(cfg.OCR.Provider),
+		Languages:     cfg.OCR.Languages,
+		DPI:           cfg.OCR.DPI,
+		TesseractPath: cfg.OCR.TesseractPath,
+		PdftoppmPath:  cfg.OCR.PdftoppmPath,
+	}, aiClient)
+	if err != nil {
+		log.Fatal("failed to create PDF extractor:", err)
+	}
+	extractors := service.DefaultExtractorRegistry()
+	extractors.Register(pdfExtractor, []string{"application/pdf"}, []string{".pdf"})
+	log.Printf("✅ PDF extractor created (ocr=%s)", cfg.OCR.Provider)
+
+	// embedding モデルのトークナイザ（EMBEDDING_TOKENIZER=auto / local / ai_worker / none）
+	oversizeChunks := service.OversizeChunkPolicy(cfg.Processing.OversizeChunks)
+	if oversizeChunks != service.OversizeChunkSplit && oversizeChunks != service.OversizeChunkReject {
+		log.Fatalf("unknown OVERSIZE_CHUNK_POLICY %q", cfg.Processing.OversizeChunks)
+	}
+	tokenizer, err := service.NewTokenizer(service.TokenizerOptions{
+		Provider:  service.TokenizerProvider(cfg.Processing.Tokenizer),
+		VocabPath: cfg.Processing.TokenizerPath,
+		MaxTokens: cfg.Processing.MaxTokens,
+	}, aiClient)
+	if err != nil {
+		log.Fatal("failed to create tokenizer:", err)
+	}
+	log.Printf("✅ Tokenizer created (provider=%s, max_tokens=%d, oversize=%s)",
+		cfg.Processing.Tokenizer, cfg.Processing.MaxTokens, cfg.Processing.OversizeChunks)
+
 	// Step 7: Document Processor作成
-	documentProcessor := service.NewDocumentProcessor(queries, aiClient, qdrantClient, minioClient, ollamaClient)
+	documentProcessor := service.NewDocumentProcessor(database, queries, aiClient, vectorStore, minioClient, service.ProcessorOptions{
+		EmbedBatchSize:   cfg.Processing.EmbedBatchSize,
+		EmbedParallelism: cfg.Processing.EmbedParallelism,
+		BatchRetries:     cfg.Processing.BatchRetries,
+		RetryDelay:       cfg.Processing.RetryDelay,
+		IndexBatchSize:   cfg.Processing.IndexBatchSize,
+		Extractors:       extractors,
+		Tokenizer:        tokenizer,
+		OversizeChunks:   oversizeChunks,
+	})
 	log.Println("✅ Document processor created")
 
+	// Step 7.5: ドキュメント処理ジョブキュー作成・ワーカー起動
+	jobQueue := service.NewDocumentJobQueue(queries, documentProcessor, service.JobQueueOptions{
+		Workers:      cfg.Jobs.Workers,
+		PollInterval: cfg.Jobs.PollInterval,
+		MaxAttempts:  cfg.Jobs.MaxAttempts,
+		RetryBase:    cfg.Jobs.RetryBase,
+		RetryMax:     cfg.Jobs.RetryMax,
+		LockTimeout:  cfg.Jobs.LockTimeout,
+	})
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	jobQueue.Start(jobCtx)
+
+	// Step 4: File Service作成（アップロード後に処理ジョブを登録する）
+	fileService := service.NewFileService(queries, minioClient, bucketName, vectorStore, jobQueue, service.FileServiceOptions{
+		MaxUploadBytes:   cfg.Upload.MaxBytes,
+		UploadSessionTTL: cfg.Upload.SessionTTL,
+		PresignExpiry:    cfg.Upload.PresignExpiry,
+	})
+	log.Println("✅ File service created")
+
+	// 参照されなくなったファイル実体の GC（無効でも /maintenance/blob-gc から手動実行できる）
+	blobGC := service.NewBlobGC(database, queries, minioClient, bucketName, vectorStore, service.BlobGCOptions{
+		Interval:    cfg.GC.Interval,
+		GracePeriod: cfg.GC.GracePeriod,
+		BatchSize:   cfg.GC.BatchSize,
+	})
+	if cfg.GC.Enabled {
+		blobGC.Start(jobCtx)
+	}
+
 	warmupCtx, warmupCancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer warmupCancel()
 
@@ -113,24 +204,43 @@ func main() {
 		// log.Fatalf("❌ Ollama warmup failed: %v", err)
 	}
 
-	chatService := service.NewChatService(queries, aiClient, qdrantClient, ollamaClient)
+	// 検索結果のリランキング（RERANKER=none / cross_encoder / llm）
+	reranker, err := service.NewReranker(service.RerankOptions{
+		Provider:       service.RerankerProvider(cfg.Rerank.Provider),
+		LLMModel:       cfg.Rerank.LLMModel,
+		LLMParallelism: cfg.Rerank.LLMParallelism,
+	}, aiClient, ollamaClient)
+	if err != nil {
+		log.Fatal("failed to create reranker:", err)
+	}
+	log.Printf("✅ Reranker created (provider=%s, candidates=%d)", cfg.Rerank.Provider, cfg.Rerank.Candidates)
+
+	chatService := service.NewChatService(queries, aiClient, vectorStore, ollamaClient, reranker, cfg.Rerank.Candidates)
 	log.Println("✅ Chat service created")
 
 	searchService := service.NewSearchService(
 		queries,
 		aiClient,
-		qdrantClient,
+		vectorStore,
+		reranker,
+		cfg.Rerank.Candidates,
 	)
 	log.Println("✅ Search service created")
 
-	analysisService := service.NewAnalysisService(queries, aiClient, qdrantClient, ollamaClient)
+	analysisService := service.NewAnalysisService(queries, aiClient, vectorStore, ollamaClient)
 	log.Println("✅ Analysis service created")
 
 	sourceService := service.NewSourceService(queries)
 	log.Println("✅ Source service created")
 
+	directoryService := service.NewDirectoryService(database, queries, vectorStore)
+	log.Println("✅ Directory service created")
+
+	versionService := service.NewDocumentVersionService(queries, vectorStore, jobQueue)
+	log.Println("✅ Document version service created")
+
 	// --- Handler ---
-	h := handler.NewHandler(database, fileService, documentProcessor, searchService, chatService, analysisService, sourceService)
+	h := handler.NewHandler(database, fileService, documentProcessor, jobQueue, searchService, chatService, analysisService, sourceService, blobGC, directoryService, versionService)
 	log.Println("✅ Handler created")
 
 	// --- Router Setup ---
@@ -152,5 +262,56 @@ func main() {
 	log.Println("🔍 RAG search: POST http://localhost:" + port + "/api/v1/workspaces/{id}/search")
 	log.Println("💬 Chat: POST http://localhost:" + port + "/api/v1/workspaces/{id}/chats/{chatId}/messages")
 	log.Println("💬 Health check: GET http://localhost:8080/api/v1/health")
-	log.Fatal(http.ListenAndServe(":"+port, r))
+
+	srv := &http.Server{Addr: ":" + port, Handler: r}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("server failed:", err)
+		}
+	}()
+
+	// expvar のメトリクス（blob_gc など）は API とは別の管理用リスナーでだけ公開する
+	// （/debug/vars はコマンドライン引数やメモリの統計も含むため、API のポートには載せない）
+	var adminSrv *http.Server
+	if cfg.Server.AdminAddr != "" {
+		adminMux := http.NewServeMux()
+		adminMux.Handle("/debug/vars", expvar.Handler())
+		adminSrv = &http.Server{Addr: cfg.Server.AdminAddr, Handler: adminMux}
+		log.Println("📊 Metrics: GET http://" + cfg.Server.AdminAddr + "/debug/vars")
+		go func() {
+			if err := adminSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("⚠️ Admin server failed: %v", err)
+			}
+		}()
+	}
+
+	// SIGINT / SIGTERM を受けたら、新しいリクエストの受付を止めてから実行中のジョブを終えて停止する。
+	// 途中で止めたジョブはロックの期限切れまで running のまま残り、試行回数を1回無駄にするため
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	<-signalCtx.Done()
+	log.Printf("🛑 Shutting down (timeout=%s)", cfg.Server.ShutdownTimeout)
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancelShutdown()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("⚠️ Failed to finish in-flight requests: %v", err)
+	}
+	if adminSrv != nil {
+		_ = adminSrv.Shutdown(shutdownCtx)
+	}
+
+	stopJobs()
+	jobsDone := make(chan struct{})
+	go func() {
+		jobQueue.Wait()
+		close(jobsDone)
+	}()
+	select {
+	case <-jobsDone:
+		log.Println("✅ Document job workers stopped")
+	case <-shutdownCtx.Done():
+		log.Println("⚠️ Document jobs still running at shutdown; they will be requeued after the lock timeout")
+	}
 }
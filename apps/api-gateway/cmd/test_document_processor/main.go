@@ -13,6 +13,7 @@ import (
 	"github.com/Martin4208/Nexus/apps/api-gateway/internal/client"
 	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
 	"github.com/Martin4208/Nexus/apps/api-gateway/internal/service"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/storage"
 )
 
 func main() {
@@ -37,13 +38,20 @@ func main() {
 	aiClient := client.NewAIWorkerClient("http://localhost:8001")
 	fmt.Println("✅ AI Worker Client created")
 
-	// Step 3: Qdrant Client作成
-	qdrantClient := client.NewQdrantClient("http://localhost:6333")
-	fmt.Println("✅ Qdrant Client created")
+	// Step 3: Qdrant VectorStore作成
+	vectorStore := storage.NewQdrantVectorStore("http://localhost:6333")
+	fmt.Println("✅ Qdrant VectorStore created")
 
-	// Step 4: Document Processor作成
-	processor := service.NewDocumentProcessor(queries, aiClient, qdrantClient)
-	fmt.Println("✅ Document Processor created: %T\n", processor)
+	// Step 4: MinIO Client作成
+	minioClient, err := storage.NewMinIOClient("localhost:9000", "admin", "password123", false)
+	if err != nil {
+		log.Fatalf("Failed to create MinIO client: %v", err)
+	}
+	fmt.Println("✅ MinIO Client created")
+
+	// Step 4.5: Document Processor作成
+	processor := service.NewDocumentProcessor(database, queries, aiClient, vectorStore, minioClient, service.ProcessorOptions{})
+	fmt.Printf("✅ Document Processor created: %T\n", processor)
 
 	// Step 5: テスト用のWorkspaceを作成
 	workspace, err := queries.CreateWorkspace(ctx, db.CreateWorkspaceParams{
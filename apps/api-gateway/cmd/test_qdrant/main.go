@@ -6,21 +6,21 @@ import (
 	"log"
 	"time"
 
-	"github.com/Martin4208/Nexus/apps/api-gateway/internal/client"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/storage"
 	"github.com/google/uuid"
 )
 
 func main() {
 	ctx := context.Background()
 
-	// Qdrant Clientを作成
-	qdrantClient := client.NewQdrantClient("http://localhost:6333")
+	// Qdrant VectorStoreを作成
+	vectorStore := storage.NewQdrantVectorStore("http://localhost:6333")
 
 	fmt.Println("=== Step 1: Collection作成 ===")
 	collectionName := "test_embeddings"
 	vectorSize := 1024 // multilingual-e5-largeの次元数
 
-	err := qdrantClient.EnsureCollection(ctx, collectionName, vectorSize)
+	err := vectorStore.EnsureCollection(ctx, collectionName, storage.CollectionConfig{Size: vectorSize})
 	if err != nil {
 		log.Fatalf("Failed to ensure collection: %v", err)
 	}
@@ -37,7 +37,7 @@ func main() {
 		dummyVector2[i] = float64(i+1) / 1000.0
 	}
 
-	points := []client.Point{
+	points := []storage.Point{
 		{
 			ID:     uuid.New().String(),
 			Vector: dummyVector1,
@@ -58,7 +58,7 @@ func main() {
 		},
 	}
 
-	err = qdrantClient.UpsertPoints(ctx, collectionName, points)
+	err = vectorStore.Upsert(ctx, collectionName, points)
 	if err != nil {
 		log.Fatalf("Failed to upsert points: %v", err)
 	}
@@ -72,13 +72,13 @@ func main() {
 
 	time.Sleep(1 * time.Second) // Qdrantがインデックスを更新するまで少し待つ
 
-	results, err := qdrantClient.Search(ctx, collectionName, searchVector, 5)
+	results, err := vectorStore.Search(ctx, collectionName, storage.SearchRequest{Vector: searchVector, Limit: 5})
 	if err != nil {
 		log.Fatalf("Failed to search: %v", err)
 	}
 
-	fmt.Printf("Found %d results:\n", len(results.Result))
-	for i, result := range results.Result {
+	fmt.Printf("Found %d results:\n", len(results))
+	for i, result := range results {
 		text := result.Payload["text"]
 		fmt.Printf("%d. Score: %.4f, Text: %v\n", i+1, result.Score, text)
 	}
@@ -12,6 +12,8 @@ require (
 	github.com/minio/minio-go/v7 v7.0.97
 	github.com/oapi-codegen/runtime v1.1.2
 	github.com/sqlc-dev/pqtype v0.3.0
+	golang.org/x/net v0.45.0
+	golang.org/x/text v0.30.0
 )
 
 require (
@@ -37,8 +39,6 @@ require (
 	github.com/tinylib/msgp v1.3.0 // indirect
 	github.com/woodsbury/decimal128 v1.3.0 // indirect
 	golang.org/x/crypto v0.43.0 // indirect
-	golang.org/x/net v0.45.0 // indirect
 	golang.org/x/sys v0.37.0 // indirect
-	golang.org/x/text v0.30.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -52,14 +52,37 @@ const (
 	User      ChatMessageRole = "user"
 )
 
+// Defines values for ContextExpansion.
+const (
+	None   ContextExpansion = "none"
+	Parent ContextExpansion = "parent"
+	Window ContextExpansion = "window"
+)
+
 // Defines values for FileMetadataStatus.
 const (
 	FileMetadataStatusFailed     FileMetadataStatus = "failed"
 	FileMetadataStatusProcessed  FileMetadataStatus = "processed"
 	FileMetadataStatusProcessing FileMetadataStatus = "processing"
+	FileMetadataStatusQueued     FileMetadataStatus = "queued"
 	FileMetadataStatusUploaded   FileMetadataStatus = "uploaded"
 )
 
+// Defines values for RetrievalMode.
+const (
+	Dense   RetrievalMode = "dense"
+	Hybrid  RetrievalMode = "hybrid"
+	Lexical RetrievalMode = "lexical"
+)
+
+// Defines values for UploadSessionStatus.
+const (
+	UploadSessionStatusAborted    UploadSessionStatus = "aborted"
+	UploadSessionStatusCompleted  UploadSessionStatus = "completed"
+	UploadSessionStatusCompleting UploadSessionStatus = "completing"
+	UploadSessionStatusUploading  UploadSessionStatus = "uploading"
+)
+
 // Defines values for CreateAnalysisJSONBodyAnalysisType.
 const (
 	CreateAnalysisJSONBodyAnalysisTypeEntityRecognition CreateAnalysisJSONBodyAnalysisType = "entity_recognition"
@@ -75,6 +98,15 @@ const (
 	Ja CreateAnalysisJSONBodyConfigLanguage = "ja"
 )
 
+// Defines values for ProcessDocumentJSONBodyChunkStrategy.
+const (
+	Fixed     ProcessDocumentJSONBodyChunkStrategy = "fixed"
+	Markdown  ProcessDocumentJSONBodyChunkStrategy = "markdown"
+	Recursive ProcessDocumentJSONBodyChunkStrategy = "recursive"
+	Semantic  ProcessDocumentJSONBodyChunkStrategy = "semantic"
+	Token     ProcessDocumentJSONBodyChunkStrategy = "token"
+)
+
 // Analysis defines model for Analysis.
 type Analysis struct {
 	AnalysisType AnalysisAnalysisType `json:"analysis_type"`
@@ -125,6 +157,36 @@ type AnalysisResultResultType string
 // AnalysisStatus Analysis job status
 type AnalysisStatus string
 
+// BlobGcFile defines model for BlobGcFile.
+type BlobGcFile struct {
+	FileId openapi_types.UUID `json:"fileId"`
+
+	// LastUsedAt Latest of creation, last access and deletion of the last referencing document
+	LastUsedAt time.Time `json:"lastUsedAt"`
+	ObjectKey  string    `json:"objectKey"`
+	Sha256Hash string    `json:"sha256Hash"`
+	SizeBytes  int64     `json:"sizeBytes"`
+}
+
+// BlobGcReport defines model for BlobGcReport.
+type BlobGcReport struct {
+	DryRun bool `json:"dryRun"`
+
+	// ExpiredUploadSessions Expired upload sessions whose chunks were removed
+	ExpiredUploadSessions int `json:"expiredUploadSessions"`
+
+	// FailedFiles Files that could not be deleted or were referenced again
+	FailedFiles int `json:"failedFiles"`
+
+	// Files Deleted files (in a dry run, the files that would be deleted)
+	Files      []BlobGcFile `json:"files"`
+	FinishedAt time.Time    `json:"finishedAt"`
+
+	// ReclaimedBytes Total size of files
+	ReclaimedBytes int64     `json:"reclaimedBytes"`
+	StartedAt      time.Time `json:"startedAt"`
+}
+
 // Chat defines model for Chat.
 type Chat struct {
 	CreatedAt time.Time `json:"created_at"`
@@ -137,10 +199,13 @@ type Chat struct {
 	LastMessageAt *time.Time `json:"last_message_at"`
 
 	// MessageCount Total number of messages in this chat
-	MessageCount *int               `json:"message_count,omitempty"`
-	Title        string             `json:"title"`
-	UpdatedAt    time.Time          `json:"updated_at"`
-	WorkspaceId  openapi_types.UUID `json:"workspace_id"`
+	MessageCount *int `json:"message_count,omitempty"`
+
+	// Settings Conversation memory and retrieval settings for RAG chat (omitted fields use server defaults)
+	Settings    *ChatSettings      `json:"settings,omitempty"`
+	Title       string             `json:"title"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	WorkspaceId openapi_types.UUID `json:"workspace_id"`
 }
 
 // ChatMessage defines model for ChatMessage.
@@ -161,12 +226,76 @@ type ChatMessage struct {
 // ChatMessageRole defines model for ChatMessage.Role.
 type ChatMessageRole string
 
+// ChatSettings Conversation memory and retrieval settings for RAG chat (omitted fields use server defaults)
+type ChatSettings struct {
+	// ContextExpansion How matched chunks are expanded before building the prompt: none (the matched chunk only), parent (its parent section, deduplicated) or window (neighbouring chunks joined together). Citations always point to the matched chunk
+	ContextExpansion *ContextExpansion `json:"context_expansion,omitempty"`
+
+	// ContextWindow Neighbouring chunks on each side of a match when context_expansion is window (default 1)
+	ContextWindow *int `json:"context_window,omitempty"`
+
+	// DenseWeight Weight of the vector search ranking in hybrid mode (reciprocal rank fusion)
+	DenseWeight *float32 `json:"dense_weight,omitempty"`
+
+	// HistoryTokenBudget Approximate token budget for the conversation history; older turns are dropped first
+	HistoryTokenBudget *int `json:"history_token_budget,omitempty"`
+
+	// HistoryTurns Number of previous turns (user + assistant pairs) included in the prompt (0 = none)
+	HistoryTurns *int `json:"history_turns,omitempty"`
+
+	// LexicalWeight Weight of the full-text (pg_trgm) search ranking in hybrid mode (reciprocal rank fusion)
+	LexicalWeight *float32 `json:"lexical_weight,omitempty"`
+
+	// RetrievalMode Retrieval strategy: dense (vector search), lexical (full-text search over chunk content) or hybrid (both, fused by reciprocal rank fusion)
+	RetrievalMode *RetrievalMode `json:"retrieval_mode,omitempty"`
+
+	// RewriteQuery Rewrite follow-up questions into a standalone search query using the conversation history
+	RewriteQuery *bool `json:"rewrite_query,omitempty"`
+}
+
+// ChunkRegion Area of a page in points, with the origin at the top-left corner and y growing downwards
+type ChunkRegion struct {
+	PageNumber int     `json:"page_number"`
+	X0         float64 `json:"x0"`
+	X1         float64 `json:"x1"`
+	Y0         float64 `json:"y0"`
+	Y1         float64 `json:"y1"`
+}
+
+// ContextExpansion How matched chunks are expanded before building the prompt: none (the matched chunk only), parent (its parent section, deduplicated) or window (neighbouring chunks joined together). Citations always point to the matched chunk
+type ContextExpansion string
+
 // CreateGraphRequest defines model for CreateGraphRequest.
 type CreateGraphRequest struct {
 	GraphType *string `json:"graph_type"`
 	Title     string  `json:"title"`
 }
 
+// Directory defines model for Directory.
+type Directory struct {
+	// Children Subdirectories (tree listing only)
+	Children  *[]Directory `json:"children,omitempty"`
+	CreatedAt time.Time    `json:"createdAt"`
+
+	// DocumentCount Number of documents directly in this directory (tree listing only)
+	DocumentCount *int64              `json:"documentCount,omitempty"`
+	Id            openapi_types.UUID  `json:"id"`
+	Name          string              `json:"name"`
+	ParentId      *openapi_types.UUID `json:"parentId"`
+	UpdatedAt     time.Time           `json:"updatedAt"`
+}
+
+// DirectoryDeleteResult defines model for DirectoryDeleteResult.
+type DirectoryDeleteResult struct {
+	DeletedDirectories int `json:"deletedDirectories"`
+	DeletedDocuments   int `json:"deletedDocuments"`
+}
+
+// DirectoryTree defines model for DirectoryTree.
+type DirectoryTree struct {
+	Directories []Directory `json:"directories"`
+}
+
 // DocumentReference defines model for DocumentReference.
 type DocumentReference struct {
 	ChunkIndex int `json:"chunk_index"`
@@ -176,11 +305,26 @@ type DocumentReference struct {
 	DocumentId     openapi_types.UUID `json:"document_id"`
 	DocumentName   *string            `json:"document_name,omitempty"`
 
+	// OcrConfidence Recognition confidence (0.0 - 1.0) when the chunk's page was read with OCR; absent for pages with a text layer
+	OcrConfidence *float32 `json:"ocr_confidence,omitempty"`
+
+	// OriginalScore Retrieval score before reranking (dense, lexical or hybrid)
+	OriginalScore *float32 `json:"original_score"`
+
 	// PageNumber Page number in the source document (1-based)
 	PageNumber *int `json:"page_number"`
 
-	// Score Relevance score (0.0 - 1.0)
+	// Regions Areas of the page the chunk was extracted from (PDF only), for highlighting the citation
+	Regions *[]ChunkRegion `json:"regions,omitempty"`
+
+	// RerankScore Score assigned by the reranker (null when reranking is disabled)
+	RerankScore *float32 `json:"rerank_score"`
+
+	// Score Final relevance score (0.0 - 1.0); equals rerank_score when reranking is enabled
 	Score float32 `json:"score"`
+
+	// VersionId Document version the chunk was cited from (absent for references made before versioning)
+	VersionId *openapi_types.UUID `json:"version_id,omitempty"`
 }
 
 // Error defines model for Error.
@@ -210,6 +354,9 @@ type FileMetadata struct {
 	FileName string             `json:"fileName"`
 	Id       openapi_types.UUID `json:"id"`
 
+	// Metadata Arbitrary user-defined metadata
+	Metadata *map[string]interface{} `json:"metadata,omitempty"`
+
 	// MimeType MIME type (e.g., application/pdf)
 	MimeType string `json:"mimeType"`
 
@@ -241,15 +388,69 @@ type FileUploadResponse struct {
 	SizeBytes  int64   `json:"sizeBytes"`
 }
 
+// FileVersion defines model for FileVersion.
+type FileVersion struct {
+	// ChunkCount Number of chunks of this version (0 if not processed yet)
+	ChunkCount int64     `json:"chunkCount"`
+	CreatedAt  time.Time `json:"createdAt"`
+
+	// FileName Filename of this version as uploaded
+	FileName string             `json:"fileName"`
+	Id       openapi_types.UUID `json:"id"`
+
+	// IsCurrent Whether search and chat use this version
+	IsCurrent     bool    `json:"isCurrent"`
+	MimeType      string  `json:"mimeType"`
+	Sha256Hash    *string `json:"sha256Hash,omitempty"`
+	SizeBytes     int64   `json:"sizeBytes"`
+	VersionNumber int     `json:"versionNumber"`
+}
+
+// FileVersionChunk defines model for FileVersionChunk.
+type FileVersionChunk struct {
+	ChunkIndex int    `json:"chunkIndex"`
+	Content    string `json:"content"`
+	PageNumber int    `json:"pageNumber"`
+}
+
+// FileVersionDiff defines model for FileVersionDiff.
+type FileVersionDiff struct {
+	// Added Chunks only in the `to` version
+	Added       []FileVersionChunk `json:"added"`
+	FromVersion int                `json:"fromVersion"`
+
+	// Removed Chunks only in the `from` version
+	Removed   []FileVersionChunk `json:"removed"`
+	ToVersion int                `json:"toVersion"`
+
+	// Truncated The versions were too large to align; changed chunks are reported without matching
+	Truncated *bool `json:"truncated,omitempty"`
+
+	// UnchangedCount Number of chunks with identical text in both versions
+	UnchangedCount int `json:"unchangedCount"`
+}
+
+// FileVersionListResponse defines model for FileVersionListResponse.
+type FileVersionListResponse struct {
+	Versions []FileVersion `json:"versions"`
+}
+
+// FileVersionRollbackResult defines model for FileVersionRollbackResult.
+type FileVersionRollbackResult struct {
+	// JobId Processing job, when the version had to be processed again
+	JobId   *openapi_types.UUID `json:"jobId,omitempty"`
+	Version FileVersion         `json:"version"`
+}
+
 // FilterConfig Configuration for filtering RAG search scope
 type FilterConfig struct {
-	// DirectoryIds Search within specific directories
+	// DirectoryIds Search within specific directories (including subdirectories)
 	DirectoryIds *[]openapi_types.UUID `json:"directory_ids,omitempty"`
 
 	// DocumentIds Specific documents to search (empty = all)
 	DocumentIds *[]openapi_types.UUID `json:"document_ids,omitempty"`
 
-	// Tags Filter by document tags
+	// Tags Filter by document tags (documents having any of the tags)
 	Tags *[]string `json:"tags,omitempty"`
 }
 
@@ -339,25 +540,49 @@ type GraphNode struct {
 	Style *map[string]interface{} `json:"style"`
 }
 
+// PresignedDownload defines model for PresignedDownload.
+type PresignedDownload struct {
+	ExpiresAt time.Time `json:"expiresAt"`
+	Url       string    `json:"url"`
+}
+
+// PresignedUpload defines model for PresignedUpload.
+type PresignedUpload struct {
+	// ExpiresAt When the URL stops accepting the PUT
+	ExpiresAt time.Time     `json:"expiresAt"`
+	Upload    UploadSession `json:"upload"`
+
+	// Url Presigned URL to PUT the file content to
+	Url string `json:"url"`
+}
+
+// RetrievalMode Retrieval strategy: dense (vector search), lexical (full-text search over chunk content) or hybrid (both, fused by reciprocal rank fusion)
+type RetrievalMode string
+
+// SourceChunk defines model for SourceChunk.
+type SourceChunk struct {
+	ChunkIndex     int    `json:"chunk_index"`
+	ContentPreview string `json:"content_preview"`
+
+	// PageNumber Page number this chunk belongs to (1-based)
+	PageNumber *int    `json:"page_number"`
+	Score      float32 `json:"score"`
+}
+
 // SourceDocument defines model for SourceDocument.
 type SourceDocument struct {
-	Chunks []struct {
-		ChunkIndex     int    `json:"chunk_index"`
-		ContentPreview string `json:"content_preview"`
-
-		// PageNumber Page number this chunk belongs to (1-based)
-		PageNumber *int    `json:"page_number"`
-		Score      float32 `json:"score"`
-	} `json:"chunks"`
+	Chunks       []SourceChunk      `json:"chunks"`
 	CreatedAt    *time.Time         `json:"created_at,omitempty"`
 	DocumentId   openapi_types.UUID `json:"document_id"`
 	DocumentName string             `json:"document_name"`
 	MimeType     string             `json:"mime_type"`
 
 	// ReferencedPages Page numbers referenced in this document (deduplicated, sorted)
-	ReferencedPages *[]int     `json:"referenced_pages,omitempty"`
-	SizeBytes       *int64     `json:"size_bytes,omitempty"`
-	UpdatedAt       *time.Time `json:"updated_at,omitempty"`
+	ReferencedPages *[]int              `json:"referenced_pages,omitempty"`
+	SizeBytes       *int64              `json:"size_bytes,omitempty"`
+	UpdatedAt       *time.Time          `json:"updated_at,omitempty"`
+	VersionId       *openapi_types.UUID `json:"version_id,omitempty"`
+	VersionNumber   *int                `json:"version_number,omitempty"`
 }
 
 // SourcesResponse defines model for SourcesResponse.
@@ -367,12 +592,58 @@ type SourcesResponse struct {
 	TotalDocuments int              `json:"total_documents"`
 }
 
+// TagCount defines model for TagCount.
+type TagCount struct {
+	FileCount int64  `json:"fileCount"`
+	Tag       string `json:"tag"`
+}
+
+// TagListResponse defines model for TagListResponse.
+type TagListResponse struct {
+	Tags []TagCount `json:"tags"`
+}
+
+// UpdateFileRequest defines model for UpdateFileRequest.
+type UpdateFileRequest struct {
+	// Metadata Merged into the current metadata; keys set to null are removed
+	Metadata *map[string]interface{} `json:"metadata,omitempty"`
+	Name     *string                 `json:"name,omitempty"`
+
+	// Tags Replaces all tags
+	Tags *[]string `json:"tags,omitempty"`
+}
+
 // UpdateGraphRequest defines model for UpdateGraphRequest.
 type UpdateGraphRequest struct {
 	LayoutConfig *map[string]interface{} `json:"layout_config"`
 	Title        *string                 `json:"title,omitempty"`
 }
 
+// UploadSession defines model for UploadSession.
+type UploadSession struct {
+	CreatedAt time.Time `json:"createdAt"`
+
+	// DocumentId Created document (set when completed)
+	DocumentId *openapi_types.UUID `json:"documentId"`
+	ExpiresAt  time.Time           `json:"expiresAt"`
+	FileName   string              `json:"fileName"`
+	Id         openapi_types.UUID  `json:"id"`
+
+	// MimeType Detected content type (set after the first chunk)
+	MimeType *string `json:"mimeType"`
+
+	// MinChunkSize Minimum size of every chunk except the last
+	MinChunkSize int64 `json:"minChunkSize"`
+
+	// Offset Number of bytes stored so far
+	Offset    int64               `json:"offset"`
+	SizeBytes int64               `json:"sizeBytes"`
+	Status    UploadSessionStatus `json:"status"`
+}
+
+// UploadSessionStatus defines model for UploadSession.Status.
+type UploadSessionStatus string
+
 // Workspace defines model for Workspace.
 type Workspace struct {
 	CreatedAt   time.Time               `json:"created_at"`
@@ -396,12 +667,18 @@ type NotFound = Error
 type CreateWorkspaceJSONBody struct {
 	Description *string `json:"description,omitempty"`
 	Name        string  `json:"name"`
+
+	// Settings Workspace settings. allowed_mime_types restricts which file types can be uploaded (e.g. ["application/pdf", "text/*"]); max_upload_bytes lowers the upload size limit
+	Settings *map[string]interface{} `json:"settings,omitempty"`
 }
 
 // UpdateWorkspaceJSONBody defines parameters for UpdateWorkspace.
 type UpdateWorkspaceJSONBody struct {
 	Description *string `json:"description,omitempty"`
 	Name        *string `json:"name,omitempty"`
+
+	// Settings Workspace settings. allowed_mime_types restricts which file types can be uploaded (e.g. ["application/pdf", "text/*"]); max_upload_bytes lowers the upload size limit
+	Settings *map[string]interface{} `json:"settings,omitempty"`
 }
 
 // ListAnalysesParams defines parameters for ListAnalyses.
@@ -443,6 +720,9 @@ type CreateChatJSONBody struct {
 	// FilterConfig Configuration for filtering RAG search scope
 	FilterConfig *FilterConfig `json:"filter_config,omitempty"`
 
+	// Settings Conversation memory and retrieval settings for RAG chat (omitted fields use server defaults)
+	Settings *ChatSettings `json:"settings,omitempty"`
+
 	// Title Chat title (user-defined or auto-generated)
 	Title string `json:"title"`
 }
@@ -459,6 +739,38 @@ type SendMessageJSONBody struct {
 	Content string `json:"content"`
 }
 
+// ListDirectoriesParams defines parameters for ListDirectories.
+type ListDirectoriesParams struct {
+	// RootId Return only the subtree rooted at this directory
+	RootId *openapi_types.UUID `form:"rootId,omitempty" json:"rootId,omitempty"`
+}
+
+// CreateDirectoryJSONBody defines parameters for CreateDirectory.
+type CreateDirectoryJSONBody struct {
+	Name string `json:"name"`
+
+	// ParentId Parent directory (omit or null for the workspace root)
+	ParentId *openapi_types.UUID `json:"parentId"`
+}
+
+// RenameDirectoryJSONBody defines parameters for RenameDirectory.
+type RenameDirectoryJSONBody struct {
+	Name string `json:"name"`
+}
+
+// MoveDirectoryJSONBody defines parameters for MoveDirectory.
+type MoveDirectoryJSONBody struct {
+	// ParentId New parent directory (omit or null for the workspace root)
+	ParentId *openapi_types.UUID `json:"parentId"`
+}
+
+// MoveDocumentsJSONBody defines parameters for MoveDocuments.
+type MoveDocumentsJSONBody struct {
+	// DirectoryId Target directory (omit or null for the workspace root)
+	DirectoryId *openapi_types.UUID  `json:"directoryId"`
+	DocumentIds []openapi_types.UUID `json:"documentIds"`
+}
+
 // GetDocumentChunksParams defines parameters for GetDocumentChunks.
 type GetDocumentChunksParams struct {
 	Page  *int `form:"page,omitempty" json:"page,omitempty"`
@@ -467,17 +779,33 @@ type GetDocumentChunksParams struct {
 
 // ProcessDocumentJSONBody defines parameters for ProcessDocument.
 type ProcessDocumentJSONBody struct {
-	ChunkOverlap   *int  `json:"chunk_overlap,omitempty"`
-	ChunkSize      *int  `json:"chunk_size,omitempty"`
-	ForceReprocess *bool `json:"force_reprocess,omitempty"`
+	ChunkOverlap *int `json:"chunk_overlap,omitempty"`
+	ChunkSize    *int `json:"chunk_size,omitempty"`
+
+	// ChunkStrategy How to split pages into chunks (chunk_size / chunk_overlap count tokens for `token`)
+	ChunkStrategy  *ProcessDocumentJSONBodyChunkStrategy `json:"chunk_strategy,omitempty"`
+	ForceReprocess *bool                                 `json:"force_reprocess,omitempty"`
+
+	// ParentChunkSize Characters per parent section; matching child chunks are expanded to their parent in chat and search (0 disables parents)
+	ParentChunkSize *int `json:"parent_chunk_size,omitempty"`
 }
 
+// ProcessDocumentJSONBodyChunkStrategy defines parameters for ProcessDocument.
+type ProcessDocumentJSONBodyChunkStrategy string
+
 // ListFilesParams defines parameters for ListFiles.
 type ListFilesParams struct {
 	// DirectoryId Filter by directory ID
 	DirectoryId *openapi_types.UUID `form:"directoryId,omitempty" json:"directoryId,omitempty"`
 	Limit       *int                `form:"limit,omitempty" json:"limit,omitempty"`
 	Offset      *int                `form:"offset,omitempty" json:"offset,omitempty"`
+
+	// Tag Filter by tag (repeatable; files must have all given tags)
+	Tag *[]string `form:"tag,omitempty" json:"tag,omitempty"`
+
+	// Metadata Filter by metadata (repeatable). `key` requires the key to exist;
+	// `key=value` requires the key to have that string value.
+	Metadata *[]string `form:"metadata,omitempty" json:"metadata,omitempty"`
 }
 
 // UploadFileMultipartBody defines parameters for UploadFile.
@@ -492,10 +820,80 @@ type UploadFileMultipartBody struct {
 	Tags *[]string `json:"tags,omitempty"`
 }
 
+// UploadFileVersionMultipartBody defines parameters for UploadFileVersion.
+type UploadFileVersionMultipartBody struct {
+	// File The new content of the file
+	File openapi_types.File `json:"file"`
+}
+
+// DiffFileVersionsParams defines parameters for DiffFileVersions.
+type DiffFileVersionsParams struct {
+	// From Version number to compare from
+	From int `form:"from" json:"from"`
+
+	// To Version number to compare to
+	To int `form:"to" json:"to"`
+}
+
 // SearchWorkspaceJSONBody defines parameters for SearchWorkspace.
 type SearchWorkspaceJSONBody struct {
-	Query string `json:"query"`
-	TopK  *int   `json:"top_k,omitempty"`
+	// ContextExpansion How matched chunks are expanded before building the prompt: none (the matched chunk only), parent (its parent section, deduplicated) or window (neighbouring chunks joined together). Citations always point to the matched chunk
+	ContextExpansion *ContextExpansion `json:"context_expansion,omitempty"`
+
+	// ContextWindow Neighbouring chunks on each side of a match when context_expansion is window
+	ContextWindow *int `json:"context_window,omitempty"`
+
+	// DenseWeight Weight of the vector search ranking in hybrid mode
+	DenseWeight *float32 `json:"dense_weight,omitempty"`
+
+	// LexicalWeight Weight of the full-text search ranking in hybrid mode
+	LexicalWeight *float32 `json:"lexical_weight,omitempty"`
+
+	// Mode Retrieval strategy: dense (vector search), lexical (full-text search over chunk content) or hybrid (both, fused by reciprocal rank fusion)
+	Mode  *RetrievalMode `json:"mode,omitempty"`
+	Query string         `json:"query"`
+	TopK  *int           `json:"top_k,omitempty"`
+}
+
+// CreateUploadSessionJSONBody defines parameters for CreateUploadSession.
+type CreateUploadSessionJSONBody struct {
+	// DirectoryId Target directory (optional)
+	DirectoryId *openapi_types.UUID `json:"directoryId"`
+	FileName    string              `json:"fileName"`
+
+	// MimeType Declared content type (checked against the content of the first chunk)
+	MimeType *string `json:"mimeType,omitempty"`
+
+	// SizeBytes Total size of the file in bytes
+	SizeBytes int64 `json:"sizeBytes"`
+
+	// Tags File tags (optional)
+	Tags *[]string `json:"tags,omitempty"`
+}
+
+// CreatePresignedUploadJSONBody defines parameters for CreatePresignedUpload.
+type CreatePresignedUploadJSONBody struct {
+	// DirectoryId Target directory (optional)
+	DirectoryId *openapi_types.UUID `json:"directoryId"`
+	FileName    string              `json:"fileName"`
+
+	// MimeType Declared content type (checked against the uploaded content)
+	MimeType *string `json:"mimeType,omitempty"`
+
+	// Sha256Hash Hex SHA256 of the file (verified on completion)
+	Sha256Hash string `json:"sha256Hash"`
+
+	// SizeBytes Size of the file in bytes
+	SizeBytes int64 `json:"sizeBytes"`
+
+	// Tags File tags (optional)
+	Tags *[]string `json:"tags,omitempty"`
+}
+
+// UploadChunkParams defines parameters for UploadChunk.
+type UploadChunkParams struct {
+	// UploadOffset Byte offset of this chunk (must equal the current offset of the session)
+	UploadOffset int64 `json:"Upload-Offset"`
 }
 
 // CreateWorkspaceJSONRequestBody defines body for CreateWorkspace for application/json ContentType.
@@ -513,15 +911,39 @@ type CreateChatJSONRequestBody CreateChatJSONBody
 // SendMessageJSONRequestBody defines body for SendMessage for application/json ContentType.
 type SendMessageJSONRequestBody SendMessageJSONBody
 
+// CreateDirectoryJSONRequestBody defines body for CreateDirectory for application/json ContentType.
+type CreateDirectoryJSONRequestBody CreateDirectoryJSONBody
+
+// RenameDirectoryJSONRequestBody defines body for RenameDirectory for application/json ContentType.
+type RenameDirectoryJSONRequestBody RenameDirectoryJSONBody
+
+// MoveDirectoryJSONRequestBody defines body for MoveDirectory for application/json ContentType.
+type MoveDirectoryJSONRequestBody MoveDirectoryJSONBody
+
+// MoveDocumentsJSONRequestBody defines body for MoveDocuments for application/json ContentType.
+type MoveDocumentsJSONRequestBody MoveDocumentsJSONBody
+
 // ProcessDocumentJSONRequestBody defines body for ProcessDocument for application/json ContentType.
 type ProcessDocumentJSONRequestBody ProcessDocumentJSONBody
 
 // UploadFileMultipartRequestBody defines body for UploadFile for multipart/form-data ContentType.
 type UploadFileMultipartRequestBody UploadFileMultipartBody
 
+// UpdateFileJSONRequestBody defines body for UpdateFile for application/json ContentType.
+type UpdateFileJSONRequestBody = UpdateFileRequest
+
+// UploadFileVersionMultipartRequestBody defines body for UploadFileVersion for multipart/form-data ContentType.
+type UploadFileVersionMultipartRequestBody UploadFileVersionMultipartBody
+
 // SearchWorkspaceJSONRequestBody defines body for SearchWorkspace for application/json ContentType.
 type SearchWorkspaceJSONRequestBody SearchWorkspaceJSONBody
 
+// CreateUploadSessionJSONRequestBody defines body for CreateUploadSession for application/json ContentType.
+type CreateUploadSessionJSONRequestBody CreateUploadSessionJSONBody
+
+// CreatePresignedUploadJSONRequestBody defines body for CreatePresignedUpload for application/json ContentType.
+type CreatePresignedUploadJSONRequestBody CreatePresignedUploadJSONBody
+
 // CreateGraphJSONRequestBody defines body for CreateGraph for application/json ContentType.
 type CreateGraphJSONRequestBody = CreateGraphRequest
 
@@ -533,6 +955,12 @@ type ServerInterface interface {
 	// API health check
 	// (GET /health)
 	HealthCheck(w http.ResponseWriter, r *http.Request)
+	// Blob GC dry-run report
+	// (GET /maintenance/blob-gc)
+	GetBlobGcReport(w http.ResponseWriter, r *http.Request)
+	// Run blob GC now
+	// (POST /maintenance/blob-gc)
+	RunBlobGc(w http.ResponseWriter, r *http.Request)
 	// List all workspaces
 	// (GET /workspaces)
 	ListWorkspaces(w http.ResponseWriter, r *http.Request)
@@ -581,6 +1009,27 @@ type ServerInterface interface {
 	// Get sources used in chat messages
 	// (GET /workspaces/{workspaceId}/chats/{chatId}/sources)
 	GetChatSources(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, chatId openapi_types.UUID)
+	// List the directory tree
+	// (GET /workspaces/{workspaceId}/directories)
+	ListDirectories(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, params ListDirectoriesParams)
+	// Create a directory
+	// (POST /workspaces/{workspaceId}/directories)
+	CreateDirectory(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID)
+	// Delete a directory
+	// (DELETE /workspaces/{workspaceId}/directories/{directoryId})
+	DeleteDirectory(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, directoryId openapi_types.UUID)
+	// Get a directory
+	// (GET /workspaces/{workspaceId}/directories/{directoryId})
+	GetDirectory(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, directoryId openapi_types.UUID)
+	// Rename a directory
+	// (PATCH /workspaces/{workspaceId}/directories/{directoryId})
+	RenameDirectory(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, directoryId openapi_types.UUID)
+	// Move a directory
+	// (POST /workspaces/{workspaceId}/directories/{directoryId}/move)
+	MoveDirectory(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, directoryId openapi_types.UUID)
+	// Move documents to a directory
+	// (POST /workspaces/{workspaceId}/documents/move)
+	MoveDocuments(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID)
 	// Get document chunks
 	// (GET /workspaces/{workspaceId}/documents/{documentId}/chunks)
 	GetDocumentChunks(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, documentId openapi_types.UUID, params GetDocumentChunksParams)
@@ -602,12 +1051,51 @@ type ServerInterface interface {
 	// Get file metadata
 	// (GET /workspaces/{workspaceId}/files/{fileId})
 	GetFile(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, fileId openapi_types.UUID)
+	// Update file name, tags and metadata
+	// (PATCH /workspaces/{workspaceId}/files/{fileId})
+	UpdateFile(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, fileId openapi_types.UUID)
 	// Download file content
 	// (GET /workspaces/{workspaceId}/files/{fileId}/download)
 	DownloadFile(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, fileId openapi_types.UUID)
+	// Get a presigned download URL
+	// (GET /workspaces/{workspaceId}/files/{fileId}/download-url)
+	GetFileDownloadUrl(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, fileId openapi_types.UUID)
+	// List file versions
+	// (GET /workspaces/{workspaceId}/files/{fileId}/versions)
+	ListFileVersions(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, fileId openapi_types.UUID)
+	// Upload a new file version
+	// (POST /workspaces/{workspaceId}/files/{fileId}/versions)
+	UploadFileVersion(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, fileId openapi_types.UUID)
+	// Diff the chunks of two file versions
+	// (GET /workspaces/{workspaceId}/files/{fileId}/versions/diff)
+	DiffFileVersions(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, fileId openapi_types.UUID, params DiffFileVersionsParams)
+	// Roll back to a file version
+	// (POST /workspaces/{workspaceId}/files/{fileId}/versions/{versionNumber}/rollback)
+	RollbackFileVersion(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, fileId openapi_types.UUID, versionNumber int)
 	// RAG search
 	// (POST /workspaces/{workspaceId}/search)
 	SearchWorkspace(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID)
+	// List tags in workspace
+	// (GET /workspaces/{workspaceId}/tags)
+	ListTags(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID)
+	// Create a resumable upload session
+	// (POST /workspaces/{workspaceId}/uploads)
+	CreateUploadSession(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID)
+	// Create a presigned upload URL
+	// (POST /workspaces/{workspaceId}/uploads/presigned)
+	CreatePresignedUpload(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID)
+	// Abort a resumable upload
+	// (DELETE /workspaces/{workspaceId}/uploads/{uploadId})
+	AbortUploadSession(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, uploadId openapi_types.UUID)
+	// Get upload session and current offset
+	// (GET /workspaces/{workspaceId}/uploads/{uploadId})
+	GetUploadSession(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, uploadId openapi_types.UUID)
+	// Upload a chunk of a resumable upload
+	// (PATCH /workspaces/{workspaceId}/uploads/{uploadId})
+	UploadChunk(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, uploadId openapi_types.UUID, params UploadChunkParams)
+	// Finalize a resumable or presigned upload
+	// (POST /workspaces/{workspaceId}/uploads/{uploadId}/complete)
+	CompleteUploadSession(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, uploadId openapi_types.UUID)
 	// List all graphs in workspace
 	// (GET /workspaces/{workspace_id}/graphs)
 	ListGraphs(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID)
@@ -641,6 +1129,18 @@ func (_ Unimplemented) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNotImplemented)
 }
 
+// Blob GC dry-run report
+// (GET /maintenance/blob-gc)
+func (_ Unimplemented) GetBlobGcReport(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Run blob GC now
+// (POST /maintenance/blob-gc)
+func (_ Unimplemented) RunBlobGc(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
 // List all workspaces
 // (GET /workspaces)
 func (_ Unimplemented) ListWorkspaces(w http.ResponseWriter, r *http.Request) {
@@ -737,6 +1237,48 @@ func (_ Unimplemented) GetChatSources(w http.ResponseWriter, r *http.Request, wo
 	w.WriteHeader(http.StatusNotImplemented)
 }
 
+// List the directory tree
+// (GET /workspaces/{workspaceId}/directories)
+func (_ Unimplemented) ListDirectories(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, params ListDirectoriesParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Create a directory
+// (POST /workspaces/{workspaceId}/directories)
+func (_ Unimplemented) CreateDirectory(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Delete a directory
+// (DELETE /workspaces/{workspaceId}/directories/{directoryId})
+func (_ Unimplemented) DeleteDirectory(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, directoryId openapi_types.UUID) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Get a directory
+// (GET /workspaces/{workspaceId}/directories/{directoryId})
+func (_ Unimplemented) GetDirectory(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, directoryId openapi_types.UUID) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Rename a directory
+// (PATCH /workspaces/{workspaceId}/directories/{directoryId})
+func (_ Unimplemented) RenameDirectory(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, directoryId openapi_types.UUID) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Move a directory
+// (POST /workspaces/{workspaceId}/directories/{directoryId}/move)
+func (_ Unimplemented) MoveDirectory(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, directoryId openapi_types.UUID) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Move documents to a directory
+// (POST /workspaces/{workspaceId}/documents/move)
+func (_ Unimplemented) MoveDocuments(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
 // Get document chunks
 // (GET /workspaces/{workspaceId}/documents/{documentId}/chunks)
 func (_ Unimplemented) GetDocumentChunks(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, documentId openapi_types.UUID, params GetDocumentChunksParams) {
@@ -779,18 +1321,96 @@ func (_ Unimplemented) GetFile(w http.ResponseWriter, r *http.Request, workspace
 	w.WriteHeader(http.StatusNotImplemented)
 }
 
+// Update file name, tags and metadata
+// (PATCH /workspaces/{workspaceId}/files/{fileId})
+func (_ Unimplemented) UpdateFile(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, fileId openapi_types.UUID) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
 // Download file content
 // (GET /workspaces/{workspaceId}/files/{fileId}/download)
 func (_ Unimplemented) DownloadFile(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, fileId openapi_types.UUID) {
 	w.WriteHeader(http.StatusNotImplemented)
 }
 
+// Get a presigned download URL
+// (GET /workspaces/{workspaceId}/files/{fileId}/download-url)
+func (_ Unimplemented) GetFileDownloadUrl(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, fileId openapi_types.UUID) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// List file versions
+// (GET /workspaces/{workspaceId}/files/{fileId}/versions)
+func (_ Unimplemented) ListFileVersions(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, fileId openapi_types.UUID) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Upload a new file version
+// (POST /workspaces/{workspaceId}/files/{fileId}/versions)
+func (_ Unimplemented) UploadFileVersion(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, fileId openapi_types.UUID) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Diff the chunks of two file versions
+// (GET /workspaces/{workspaceId}/files/{fileId}/versions/diff)
+func (_ Unimplemented) DiffFileVersions(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, fileId openapi_types.UUID, params DiffFileVersionsParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Roll back to a file version
+// (POST /workspaces/{workspaceId}/files/{fileId}/versions/{versionNumber}/rollback)
+func (_ Unimplemented) RollbackFileVersion(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, fileId openapi_types.UUID, versionNumber int) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
 // RAG search
 // (POST /workspaces/{workspaceId}/search)
 func (_ Unimplemented) SearchWorkspace(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID) {
 	w.WriteHeader(http.StatusNotImplemented)
 }
 
+// List tags in workspace
+// (GET /workspaces/{workspaceId}/tags)
+func (_ Unimplemented) ListTags(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Create a resumable upload session
+// (POST /workspaces/{workspaceId}/uploads)
+func (_ Unimplemented) CreateUploadSession(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Create a presigned upload URL
+// (POST /workspaces/{workspaceId}/uploads/presigned)
+func (_ Unimplemented) CreatePresignedUpload(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Abort a resumable upload
+// (DELETE /workspaces/{workspaceId}/uploads/{uploadId})
+func (_ Unimplemented) AbortUploadSession(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, uploadId openapi_types.UUID) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Get upload session and current offset
+// (GET /workspaces/{workspaceId}/uploads/{uploadId})
+func (_ Unimplemented) GetUploadSession(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, uploadId openapi_types.UUID) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Upload a chunk of a resumable upload
+// (PATCH /workspaces/{workspaceId}/uploads/{uploadId})
+func (_ Unimplemented) UploadChunk(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, uploadId openapi_types.UUID, params UploadChunkParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Finalize a resumable or presigned upload
+// (POST /workspaces/{workspaceId}/uploads/{uploadId}/complete)
+func (_ Unimplemented) CompleteUploadSession(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID, uploadId openapi_types.UUID) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
 // List all graphs in workspace
 // (GET /workspaces/{workspace_id}/graphs)
 func (_ Unimplemented) ListGraphs(w http.ResponseWriter, r *http.Request, workspaceId openapi_types.UUID) {
@@ -856,6 +1476,34 @@ func (siw *ServerInterfaceWrapper) HealthCheck(w http.ResponseWriter, r *http.Re
 	handler.ServeHTTP(w, r)
 }
 
+// GetBlobGcReport operation middleware
+func (siw *ServerInterfaceWrapper) GetBlobGcReport(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetBlobGcReport(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// RunBlobGc operation middleware
+func (siw *ServerInterfaceWrapper) RunBlobGc(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.RunBlobGc(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
 // ListWorkspaces operation middleware
 func (siw *ServerInterfaceWrapper) ListWorkspaces(w http.ResponseWriter, r *http.Request) {
 
@@ -1354,8 +2002,8 @@ func (siw *ServerInterfaceWrapper) GetChatSources(w http.ResponseWriter, r *http
 	handler.ServeHTTP(w, r)
 }
 
-// GetDocumentChunks operation middleware
-func (siw *ServerInterfaceWrapper) GetDocumentChunks(w http.ResponseWriter, r *http.Request) {
+// ListDirectories operation middleware
+func (siw *ServerInterfaceWrapper) ListDirectories(w http.ResponseWriter, r *http.Request) {
 
 	var err error
 
@@ -1368,36 +2016,589 @@ func (siw *ServerInterfaceWrapper) GetDocumentChunks(w http.ResponseWriter, r *h
 		return
 	}
 
-	// ------------- Path parameter "documentId" -------------
-	var documentId openapi_types.UUID
-
-	err = runtime.BindStyledParameterWithOptions("simple", "documentId", chi.URLParam(r, "documentId"), &documentId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
-	if err != nil {
-		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "documentId", Err: err})
-		return
-	}
-
 	// Parameter object where we will unmarshal all parameters from the context
-	var params GetDocumentChunksParams
+	var params ListDirectoriesParams
 
-	// ------------- Optional query parameter "page" -------------
+	// ------------- Optional query parameter "rootId" -------------
 
-	err = runtime.BindQueryParameter("form", true, false, "page", r.URL.Query(), &params.Page)
+	err = runtime.BindQueryParameter("form", true, false, "rootId", r.URL.Query(), &params.RootId)
 	if err != nil {
-		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "page", Err: err})
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "rootId", Err: err})
 		return
 	}
 
-	// ------------- Optional query parameter "limit" -------------
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListDirectories(w, r, workspaceId, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// CreateDirectory operation middleware
+func (siw *ServerInterfaceWrapper) CreateDirectory(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "workspaceId" -------------
+	var workspaceId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "workspaceId", chi.URLParam(r, "workspaceId"), &workspaceId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "workspaceId", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CreateDirectory(w, r, workspaceId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// DeleteDirectory operation middleware
+func (siw *ServerInterfaceWrapper) DeleteDirectory(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "workspaceId" -------------
+	var workspaceId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "workspaceId", chi.URLParam(r, "workspaceId"), &workspaceId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "workspaceId", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "directoryId" -------------
+	var directoryId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "directoryId", chi.URLParam(r, "directoryId"), &directoryId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "directoryId", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteDirectory(w, r, workspaceId, directoryId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetDirectory operation middleware
+func (siw *ServerInterfaceWrapper) GetDirectory(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "workspaceId" -------------
+	var workspaceId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "workspaceId", chi.URLParam(r, "workspaceId"), &workspaceId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "workspaceId", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "directoryId" -------------
+	var directoryId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "directoryId", chi.URLParam(r, "directoryId"), &directoryId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "directoryId", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetDirectory(w, r, workspaceId, directoryId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// RenameDirectory operation middleware
+func (siw *ServerInterfaceWrapper) RenameDirectory(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "workspaceId" -------------
+	var workspaceId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "workspaceId", chi.URLParam(r, "workspaceId"), &workspaceId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "workspaceId", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "directoryId" -------------
+	var directoryId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "directoryId", chi.URLParam(r, "directoryId"), &directoryId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "directoryId", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.RenameDirectory(w, r, workspaceId, directoryId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// MoveDirectory operation middleware
+func (siw *ServerInterfaceWrapper) MoveDirectory(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "workspaceId" -------------
+	var workspaceId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "workspaceId", chi.URLParam(r, "workspaceId"), &workspaceId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "workspaceId", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "directoryId" -------------
+	var directoryId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "directoryId", chi.URLParam(r, "directoryId"), &directoryId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "directoryId", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.MoveDirectory(w, r, workspaceId, directoryId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// MoveDocuments operation middleware
+func (siw *ServerInterfaceWrapper) MoveDocuments(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "workspaceId" -------------
+	var workspaceId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "workspaceId", chi.URLParam(r, "workspaceId"), &workspaceId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "workspaceId", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.MoveDocuments(w, r, workspaceId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetDocumentChunks operation middleware
+func (siw *ServerInterfaceWrapper) GetDocumentChunks(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "workspaceId" -------------
+	var workspaceId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "workspaceId", chi.URLParam(r, "workspaceId"), &workspaceId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "workspaceId", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "documentId" -------------
+	var documentId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "documentId", chi.URLParam(r, "documentId"), &documentId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "documentId", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetDocumentChunksParams
+
+	// ------------- Optional query parameter "page" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "page", r.URL.Query(), &params.Page)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "page", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetDocumentChunks(w, r, workspaceId, documentId, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ProcessDocument operation middleware
+func (siw *ServerInterfaceWrapper) ProcessDocument(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "workspaceId" -------------
+	var workspaceId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "workspaceId", chi.URLParam(r, "workspaceId"), &workspaceId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "workspaceId", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "documentId" -------------
+	var documentId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "documentId", chi.URLParam(r, "documentId"), &documentId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "documentId", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ProcessDocument(w, r, workspaceId, documentId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetDocumentStatus operation middleware
+func (siw *ServerInterfaceWrapper) GetDocumentStatus(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "workspaceId" -------------
+	var workspaceId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "workspaceId", chi.URLParam(r, "workspaceId"), &workspaceId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "workspaceId", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "documentId" -------------
+	var documentId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "documentId", chi.URLParam(r, "documentId"), &documentId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "documentId", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetDocumentStatus(w, r, workspaceId, documentId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ListFiles operation middleware
+func (siw *ServerInterfaceWrapper) ListFiles(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "workspaceId" -------------
+	var workspaceId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "workspaceId", chi.URLParam(r, "workspaceId"), &workspaceId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "workspaceId", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListFilesParams
+
+	// ------------- Optional query parameter "directoryId" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "directoryId", r.URL.Query(), &params.DirectoryId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "directoryId", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "offset" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "offset", r.URL.Query(), &params.Offset)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "offset", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "tag" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "tag", r.URL.Query(), &params.Tag)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "tag", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "metadata" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "metadata", r.URL.Query(), &params.Metadata)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "metadata", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListFiles(w, r, workspaceId, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// UploadFile operation middleware
+func (siw *ServerInterfaceWrapper) UploadFile(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "workspaceId" -------------
+	var workspaceId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "workspaceId", chi.URLParam(r, "workspaceId"), &workspaceId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "workspaceId", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.UploadFile(w, r, workspaceId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// DeleteFile operation middleware
+func (siw *ServerInterfaceWrapper) DeleteFile(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "workspaceId" -------------
+	var workspaceId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "workspaceId", chi.URLParam(r, "workspaceId"), &workspaceId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "workspaceId", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "fileId" -------------
+	var fileId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "fileId", chi.URLParam(r, "fileId"), &fileId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "fileId", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteFile(w, r, workspaceId, fileId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetFile operation middleware
+func (siw *ServerInterfaceWrapper) GetFile(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "workspaceId" -------------
+	var workspaceId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "workspaceId", chi.URLParam(r, "workspaceId"), &workspaceId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "workspaceId", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "fileId" -------------
+	var fileId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "fileId", chi.URLParam(r, "fileId"), &fileId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "fileId", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetFile(w, r, workspaceId, fileId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// UpdateFile operation middleware
+func (siw *ServerInterfaceWrapper) UpdateFile(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "workspaceId" -------------
+	var workspaceId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "workspaceId", chi.URLParam(r, "workspaceId"), &workspaceId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "workspaceId", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "fileId" -------------
+	var fileId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "fileId", chi.URLParam(r, "fileId"), &fileId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "fileId", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.UpdateFile(w, r, workspaceId, fileId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// DownloadFile operation middleware
+func (siw *ServerInterfaceWrapper) DownloadFile(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "workspaceId" -------------
+	var workspaceId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "workspaceId", chi.URLParam(r, "workspaceId"), &workspaceId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "workspaceId", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "fileId" -------------
+	var fileId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "fileId", chi.URLParam(r, "fileId"), &fileId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "fileId", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DownloadFile(w, r, workspaceId, fileId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
 
-	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	handler.ServeHTTP(w, r)
+}
+
+// GetFileDownloadUrl operation middleware
+func (siw *ServerInterfaceWrapper) GetFileDownloadUrl(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "workspaceId" -------------
+	var workspaceId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "workspaceId", chi.URLParam(r, "workspaceId"), &workspaceId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
 	if err != nil {
-		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "workspaceId", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "fileId" -------------
+	var fileId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "fileId", chi.URLParam(r, "fileId"), &fileId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "fileId", Err: err})
 		return
 	}
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.GetDocumentChunks(w, r, workspaceId, documentId, params)
+		siw.Handler.GetFileDownloadUrl(w, r, workspaceId, fileId)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -1407,8 +2608,8 @@ func (siw *ServerInterfaceWrapper) GetDocumentChunks(w http.ResponseWriter, r *h
 	handler.ServeHTTP(w, r)
 }
 
-// ProcessDocument operation middleware
-func (siw *ServerInterfaceWrapper) ProcessDocument(w http.ResponseWriter, r *http.Request) {
+// ListFileVersions operation middleware
+func (siw *ServerInterfaceWrapper) ListFileVersions(w http.ResponseWriter, r *http.Request) {
 
 	var err error
 
@@ -1421,17 +2622,17 @@ func (siw *ServerInterfaceWrapper) ProcessDocument(w http.ResponseWriter, r *htt
 		return
 	}
 
-	// ------------- Path parameter "documentId" -------------
-	var documentId openapi_types.UUID
+	// ------------- Path parameter "fileId" -------------
+	var fileId openapi_types.UUID
 
-	err = runtime.BindStyledParameterWithOptions("simple", "documentId", chi.URLParam(r, "documentId"), &documentId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	err = runtime.BindStyledParameterWithOptions("simple", "fileId", chi.URLParam(r, "fileId"), &fileId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
 	if err != nil {
-		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "documentId", Err: err})
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "fileId", Err: err})
 		return
 	}
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.ProcessDocument(w, r, workspaceId, documentId)
+		siw.Handler.ListFileVersions(w, r, workspaceId, fileId)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -1441,8 +2642,8 @@ func (siw *ServerInterfaceWrapper) ProcessDocument(w http.ResponseWriter, r *htt
 	handler.ServeHTTP(w, r)
 }
 
-// GetDocumentStatus operation middleware
-func (siw *ServerInterfaceWrapper) GetDocumentStatus(w http.ResponseWriter, r *http.Request) {
+// UploadFileVersion operation middleware
+func (siw *ServerInterfaceWrapper) UploadFileVersion(w http.ResponseWriter, r *http.Request) {
 
 	var err error
 
@@ -1455,17 +2656,17 @@ func (siw *ServerInterfaceWrapper) GetDocumentStatus(w http.ResponseWriter, r *h
 		return
 	}
 
-	// ------------- Path parameter "documentId" -------------
-	var documentId openapi_types.UUID
+	// ------------- Path parameter "fileId" -------------
+	var fileId openapi_types.UUID
 
-	err = runtime.BindStyledParameterWithOptions("simple", "documentId", chi.URLParam(r, "documentId"), &documentId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	err = runtime.BindStyledParameterWithOptions("simple", "fileId", chi.URLParam(r, "fileId"), &fileId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
 	if err != nil {
-		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "documentId", Err: err})
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "fileId", Err: err})
 		return
 	}
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.GetDocumentStatus(w, r, workspaceId, documentId)
+		siw.Handler.UploadFileVersion(w, r, workspaceId, fileId)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -1475,8 +2676,8 @@ func (siw *ServerInterfaceWrapper) GetDocumentStatus(w http.ResponseWriter, r *h
 	handler.ServeHTTP(w, r)
 }
 
-// ListFiles operation middleware
-func (siw *ServerInterfaceWrapper) ListFiles(w http.ResponseWriter, r *http.Request) {
+// DiffFileVersions operation middleware
+func (siw *ServerInterfaceWrapper) DiffFileVersions(w http.ResponseWriter, r *http.Request) {
 
 	var err error
 
@@ -1489,35 +2690,79 @@ func (siw *ServerInterfaceWrapper) ListFiles(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	// ------------- Path parameter "fileId" -------------
+	var fileId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "fileId", chi.URLParam(r, "fileId"), &fileId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "fileId", Err: err})
+		return
+	}
+
 	// Parameter object where we will unmarshal all parameters from the context
-	var params ListFilesParams
+	var params DiffFileVersionsParams
 
-	// ------------- Optional query parameter "directoryId" -------------
+	// ------------- Required query parameter "from" -------------
 
-	err = runtime.BindQueryParameter("form", true, false, "directoryId", r.URL.Query(), &params.DirectoryId)
+	err = runtime.BindQueryParameter("form", true, true, "from", r.URL.Query(), &params.From)
 	if err != nil {
-		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "directoryId", Err: err})
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "from", Err: err})
 		return
 	}
 
-	// ------------- Optional query parameter "limit" -------------
+	// ------------- Required query parameter "to" -------------
 
-	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	err = runtime.BindQueryParameter("form", true, true, "to", r.URL.Query(), &params.To)
 	if err != nil {
-		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "to", Err: err})
 		return
 	}
 
-	// ------------- Optional query parameter "offset" -------------
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DiffFileVersions(w, r, workspaceId, fileId, params)
+	}))
 
-	err = runtime.BindQueryParameter("form", true, false, "offset", r.URL.Query(), &params.Offset)
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// RollbackFileVersion operation middleware
+func (siw *ServerInterfaceWrapper) RollbackFileVersion(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "workspaceId" -------------
+	var workspaceId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "workspaceId", chi.URLParam(r, "workspaceId"), &workspaceId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
 	if err != nil {
-		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "offset", Err: err})
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "workspaceId", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "fileId" -------------
+	var fileId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "fileId", chi.URLParam(r, "fileId"), &fileId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "fileId", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "versionNumber" -------------
+	var versionNumber int
+
+	err = runtime.BindStyledParameterWithOptions("simple", "versionNumber", chi.URLParam(r, "versionNumber"), &versionNumber, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "versionNumber", Err: err})
 		return
 	}
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.ListFiles(w, r, workspaceId, params)
+		siw.Handler.RollbackFileVersion(w, r, workspaceId, fileId, versionNumber)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -1527,8 +2772,8 @@ func (siw *ServerInterfaceWrapper) ListFiles(w http.ResponseWriter, r *http.Requ
 	handler.ServeHTTP(w, r)
 }
 
-// UploadFile operation middleware
-func (siw *ServerInterfaceWrapper) UploadFile(w http.ResponseWriter, r *http.Request) {
+// SearchWorkspace operation middleware
+func (siw *ServerInterfaceWrapper) SearchWorkspace(w http.ResponseWriter, r *http.Request) {
 
 	var err error
 
@@ -1542,7 +2787,7 @@ func (siw *ServerInterfaceWrapper) UploadFile(w http.ResponseWriter, r *http.Req
 	}
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.UploadFile(w, r, workspaceId)
+		siw.Handler.SearchWorkspace(w, r, workspaceId)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -1552,8 +2797,8 @@ func (siw *ServerInterfaceWrapper) UploadFile(w http.ResponseWriter, r *http.Req
 	handler.ServeHTTP(w, r)
 }
 
-// DeleteFile operation middleware
-func (siw *ServerInterfaceWrapper) DeleteFile(w http.ResponseWriter, r *http.Request) {
+// ListTags operation middleware
+func (siw *ServerInterfaceWrapper) ListTags(w http.ResponseWriter, r *http.Request) {
 
 	var err error
 
@@ -1566,17 +2811,33 @@ func (siw *ServerInterfaceWrapper) DeleteFile(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	// ------------- Path parameter "fileId" -------------
-	var fileId openapi_types.UUID
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListTags(w, r, workspaceId)
+	}))
 
-	err = runtime.BindStyledParameterWithOptions("simple", "fileId", chi.URLParam(r, "fileId"), &fileId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// CreateUploadSession operation middleware
+func (siw *ServerInterfaceWrapper) CreateUploadSession(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "workspaceId" -------------
+	var workspaceId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "workspaceId", chi.URLParam(r, "workspaceId"), &workspaceId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
 	if err != nil {
-		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "fileId", Err: err})
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "workspaceId", Err: err})
 		return
 	}
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.DeleteFile(w, r, workspaceId, fileId)
+		siw.Handler.CreateUploadSession(w, r, workspaceId)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -1586,8 +2847,8 @@ func (siw *ServerInterfaceWrapper) DeleteFile(w http.ResponseWriter, r *http.Req
 	handler.ServeHTTP(w, r)
 }
 
-// GetFile operation middleware
-func (siw *ServerInterfaceWrapper) GetFile(w http.ResponseWriter, r *http.Request) {
+// CreatePresignedUpload operation middleware
+func (siw *ServerInterfaceWrapper) CreatePresignedUpload(w http.ResponseWriter, r *http.Request) {
 
 	var err error
 
@@ -1600,17 +2861,42 @@ func (siw *ServerInterfaceWrapper) GetFile(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// ------------- Path parameter "fileId" -------------
-	var fileId openapi_types.UUID
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CreatePresignedUpload(w, r, workspaceId)
+	}))
 
-	err = runtime.BindStyledParameterWithOptions("simple", "fileId", chi.URLParam(r, "fileId"), &fileId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// AbortUploadSession operation middleware
+func (siw *ServerInterfaceWrapper) AbortUploadSession(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "workspaceId" -------------
+	var workspaceId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "workspaceId", chi.URLParam(r, "workspaceId"), &workspaceId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
 	if err != nil {
-		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "fileId", Err: err})
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "workspaceId", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "uploadId" -------------
+	var uploadId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "uploadId", chi.URLParam(r, "uploadId"), &uploadId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "uploadId", Err: err})
 		return
 	}
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.GetFile(w, r, workspaceId, fileId)
+		siw.Handler.AbortUploadSession(w, r, workspaceId, uploadId)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -1620,8 +2906,8 @@ func (siw *ServerInterfaceWrapper) GetFile(w http.ResponseWriter, r *http.Reques
 	handler.ServeHTTP(w, r)
 }
 
-// DownloadFile operation middleware
-func (siw *ServerInterfaceWrapper) DownloadFile(w http.ResponseWriter, r *http.Request) {
+// GetUploadSession operation middleware
+func (siw *ServerInterfaceWrapper) GetUploadSession(w http.ResponseWriter, r *http.Request) {
 
 	var err error
 
@@ -1634,17 +2920,17 @@ func (siw *ServerInterfaceWrapper) DownloadFile(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	// ------------- Path parameter "fileId" -------------
-	var fileId openapi_types.UUID
+	// ------------- Path parameter "uploadId" -------------
+	var uploadId openapi_types.UUID
 
-	err = runtime.BindStyledParameterWithOptions("simple", "fileId", chi.URLParam(r, "fileId"), &fileId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	err = runtime.BindStyledParameterWithOptions("simple", "uploadId", chi.URLParam(r, "uploadId"), &uploadId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
 	if err != nil {
-		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "fileId", Err: err})
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "uploadId", Err: err})
 		return
 	}
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.DownloadFile(w, r, workspaceId, fileId)
+		siw.Handler.GetUploadSession(w, r, workspaceId, uploadId)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -1654,8 +2940,8 @@ func (siw *ServerInterfaceWrapper) DownloadFile(w http.ResponseWriter, r *http.R
 	handler.ServeHTTP(w, r)
 }
 
-// SearchWorkspace operation middleware
-func (siw *ServerInterfaceWrapper) SearchWorkspace(w http.ResponseWriter, r *http.Request) {
+// UploadChunk operation middleware
+func (siw *ServerInterfaceWrapper) UploadChunk(w http.ResponseWriter, r *http.Request) {
 
 	var err error
 
@@ -1668,8 +2954,79 @@ func (siw *ServerInterfaceWrapper) SearchWorkspace(w http.ResponseWriter, r *htt
 		return
 	}
 
+	// ------------- Path parameter "uploadId" -------------
+	var uploadId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "uploadId", chi.URLParam(r, "uploadId"), &uploadId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "uploadId", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params UploadChunkParams
+
+	headers := r.Header
+
+	// ------------- Required header parameter "Upload-Offset" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Upload-Offset")]; found {
+		var UploadOffset int64
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "Upload-Offset", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "Upload-Offset", valueList[0], &UploadOffset, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: true})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "Upload-Offset", Err: err})
+			return
+		}
+
+		params.UploadOffset = UploadOffset
+
+	} else {
+		err := fmt.Errorf("Header parameter Upload-Offset is required, but not found")
+		siw.ErrorHandlerFunc(w, r, &RequiredHeaderError{ParamName: "Upload-Offset", Err: err})
+		return
+	}
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.SearchWorkspace(w, r, workspaceId)
+		siw.Handler.UploadChunk(w, r, workspaceId, uploadId, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// CompleteUploadSession operation middleware
+func (siw *ServerInterfaceWrapper) CompleteUploadSession(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "workspaceId" -------------
+	var workspaceId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "workspaceId", chi.URLParam(r, "workspaceId"), &workspaceId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "workspaceId", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "uploadId" -------------
+	var uploadId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "uploadId", chi.URLParam(r, "uploadId"), &uploadId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "uploadId", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CompleteUploadSession(w, r, workspaceId, uploadId)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -2015,6 +3372,12 @@ func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handl
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/health", wrapper.HealthCheck)
 	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/maintenance/blob-gc", wrapper.GetBlobGcReport)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/maintenance/blob-gc", wrapper.RunBlobGc)
+	})
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/workspaces", wrapper.ListWorkspaces)
 	})
@@ -2063,6 +3426,27 @@ func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handl
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/workspaces/{workspaceId}/chats/{chatId}/sources", wrapper.GetChatSources)
 	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/workspaces/{workspaceId}/directories", wrapper.ListDirectories)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/workspaces/{workspaceId}/directories", wrapper.CreateDirectory)
+	})
+	r.Group(func(r chi.Router) {
+		r.Delete(options.BaseURL+"/workspaces/{workspaceId}/directories/{directoryId}", wrapper.DeleteDirectory)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/workspaces/{workspaceId}/directories/{directoryId}", wrapper.GetDirectory)
+	})
+	r.Group(func(r chi.Router) {
+		r.Patch(options.BaseURL+"/workspaces/{workspaceId}/directories/{directoryId}", wrapper.RenameDirectory)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/workspaces/{workspaceId}/directories/{directoryId}/move", wrapper.MoveDirectory)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/workspaces/{workspaceId}/documents/move", wrapper.MoveDocuments)
+	})
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/workspaces/{workspaceId}/documents/{documentId}/chunks", wrapper.GetDocumentChunks)
 	})
@@ -2084,12 +3468,51 @@ func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handl
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/workspaces/{workspaceId}/files/{fileId}", wrapper.GetFile)
 	})
+	r.Group(func(r chi.Router) {
+		r.Patch(options.BaseURL+"/workspaces/{workspaceId}/files/{fileId}", wrapper.UpdateFile)
+	})
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/workspaces/{workspaceId}/files/{fileId}/download", wrapper.DownloadFile)
 	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/workspaces/{workspaceId}/files/{fileId}/download-url", wrapper.GetFileDownloadUrl)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/workspaces/{workspaceId}/files/{fileId}/versions", wrapper.ListFileVersions)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/workspaces/{workspaceId}/files/{fileId}/versions", wrapper.UploadFileVersion)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/workspaces/{workspaceId}/files/{fileId}/versions/diff", wrapper.DiffFileVersions)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/workspaces/{workspaceId}/files/{fileId}/versions/{versionNumber}/rollback", wrapper.RollbackFileVersion)
+	})
 	r.Group(func(r chi.Router) {
 		r.Post(options.BaseURL+"/workspaces/{workspaceId}/search", wrapper.SearchWorkspace)
 	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/workspaces/{workspaceId}/tags", wrapper.ListTags)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/workspaces/{workspaceId}/uploads", wrapper.CreateUploadSession)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/workspaces/{workspaceId}/uploads/presigned", wrapper.CreatePresignedUpload)
+	})
+	r.Group(func(r chi.Router) {
+		r.Delete(options.BaseURL+"/workspaces/{workspaceId}/uploads/{uploadId}", wrapper.AbortUploadSession)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/workspaces/{workspaceId}/uploads/{uploadId}", wrapper.GetUploadSession)
+	})
+	r.Group(func(r chi.Router) {
+		r.Patch(options.BaseURL+"/workspaces/{workspaceId}/uploads/{uploadId}", wrapper.UploadChunk)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/workspaces/{workspaceId}/uploads/{uploadId}/complete", wrapper.CompleteUploadSession)
+	})
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/workspaces/{workspace_id}/graphs", wrapper.ListGraphs)
 	})
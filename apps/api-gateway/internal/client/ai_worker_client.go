@@ -3,6 +3,7 @@ package client
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -13,6 +14,9 @@ type AIWorkerClient interface {
 	EmbedDocuments(ctx context.Context, texts []string) (*EmbedDocumentsResponse, error)
 	EmbedQuery(ctx context.Context, text string) ([]float64, error)
 	GenerateAnswer(ctx context.Context, query string, contextTexts []string) (*GenerateResponse, error)
+	Rerank(ctx context.Context, query string, texts []string) (*RerankResponse, error)
+	OCR(ctx context.Context, image []byte) (*OCRResponse, error)
+	Tokenize(ctx context.Context, texts []string) (*TokenizeResponse, error)
 }
 
 type aiWorkerClient struct {
@@ -167,3 +171,137 @@ func (c *aiWorkerClient) GenerateAnswer(
 
 	return &response, nil
 }
+
+// Rerank は Cross-Encoder で (query, text) の各ペアの関連度（0〜1）を計算します。
+// scores は texts と同じ順序で返ります
+func (c *aiWorkerClient) Rerank(ctx context.Context, query string, texts []string) (*RerankResponse, error) {
+	reqBody := RerankRequest{
+		Query: query,
+		Texts: texts,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		c.baseURL+"/api/v1/rerank",
+		bytes.NewBuffer(jsonData),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("AI Worker returned status %d", resp.StatusCode)
+	}
+
+	var response RerankResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(response.Scores) != len(texts) {
+		return nil, fmt.Errorf("rerank score count mismatch: got %d, want %d", len(response.Scores), len(texts))
+	}
+
+	return &response, nil
+}
+
+// OCR は画像（PNG / JPEG）の文字を行ごとに認識します。
+// 行は読み順に並び、confidence は 0〜1 です
+func (c *aiWorkerClient) OCR(ctx context.Context, image []byte) (*OCRResponse, error) {
+	reqBody := OCRRequest{
+		Image: base64.StdEncoding.EncodeToString(image),
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		c.baseURL+"/api/v1/ocr",
+		bytes.NewBuffer(jsonData),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("AI Worker returned status %d", resp.StatusCode)
+	}
+
+	var response OCRResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &response, nil
+}
+
+// Tokenize は embedding モデルのトークナイザで texts のトークン数を数えます。
+// 数はドキュメントとして embedding するときの入力（"passage: " の接頭辞と特殊トークンを含む）の長さです
+func (c *aiWorkerClient) Tokenize(ctx context.Context, texts []string) (*TokenizeResponse, error) {
+	reqBody := TokenizeRequest{
+		Texts: texts,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		c.baseURL+"/api/v1/tokenize",
+		bytes.NewBuffer(jsonData),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("AI Worker returned status %d", resp.StatusCode)
+	}
+
+	var response TokenizeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(response.Counts) != len(texts) {
+		return nil, fmt.Errorf("token count mismatch: got %d, want %d", len(response.Counts), len(texts))
+	}
+
+	return &response, nil
+}
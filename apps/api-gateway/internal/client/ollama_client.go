@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -16,22 +18,46 @@ import (
 type OllamaClient interface {
 	// Generate はLLMを使ってテキスト生成を行います
 	Generate(ctx context.Context, model string, prompt string) (string, error)
+
+	// GenerateStream はストリーミングモードでテキスト生成を行い、トークンを受信するたびに onToken を呼び出します。
+	// 途中で失敗・キャンセルされた場合も、それまでに受信したテキストを返します。
+	GenerateStream(ctx context.Context, model string, prompt string, onToken func(token string) error) (string, error)
+
 	WarmUp(ctx context.Context, model string) error
 }
 
+const (
+	// ollamaResponseHeaderTimeout はストリーミング生成でレスポンスヘッダーを待つ上限（モデルのロードを含む）
+	ollamaResponseHeaderTimeout = 3 * time.Minute
+	// ollamaStreamIdleTimeout はストリーミング生成で次のトークンを待つ上限
+	ollamaStreamIdleTimeout = 60 * time.Second
+)
+
+// errStreamIdle はストリーミング生成でトークンが途切れたときのキャンセル理由
+var errStreamIdle = errors.New("no tokens received from ollama")
+
 // ollamaClient はOllamaClientの実装
 type ollamaClient struct {
 	baseURL    string
 	httpClient *http.Client
+	// streamClient はストリーミング生成用。長い回答を途中で切らないよう全体のタイムアウトは設けず、
+	// ctx・レスポンスヘッダーのタイムアウト・トークン間のタイムアウト（streamIdleTimeout）で打ち切る
+	streamClient      *http.Client
+	streamIdleTimeout time.Duration
 }
 
 // NewOllamaClient は新しいOllama Clientを作成します
 func NewOllamaClient(baseURL string) OllamaClient {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = ollamaResponseHeaderTimeout
+
 	return &ollamaClient{
 		baseURL: baseURL,
 		httpClient: &http.Client{
 			Timeout: 3 * time.Minute, // LLM生成は時間がかかる可能性があるため長めに設定
 		},
+		streamClient:      &http.Client{Transport: transport},
+		streamIdleTimeout: ollamaStreamIdleTimeout,
 	}
 }
 
@@ -42,12 +68,14 @@ type OllamaGenerateRequest struct {
 	Stream bool   `json:"stream"`
 }
 
-// OllamaGenerateResponse はOllama APIのレスポンス形式（Non-streaming）
+// OllamaGenerateResponse はOllama APIのレスポンス形式
+// ストリーミングモードではNDJSONの1行ごとにこの形式で返ってくる
 type OllamaGenerateResponse struct {
 	Model     string `json:"model"`
 	CreatedAt string `json:"created_at"`
 	Response  string `json:"response"`
 	Done      bool   `json:"done"`
+	Error     string `json:"error,omitempty"`
 }
 
 // WarmUp はアプリ起動時にOllamaを準備状態にします
@@ -291,3 +319,92 @@ func (c *ollamaClient) Generate(ctx context.Context, model string, prompt string
 	// Step 6: 生成されたテキストを返す
 	return response.Response, nil
 }
+
+// GenerateStream は指定したモデルでストリーミング生成を実行します
+func (c *ollamaClient) GenerateStream(
+	ctx context.Context,
+	model string,
+	prompt string,
+	onToken func(token string) error,
+) (string, error) {
+	// Step 1: リクエストボディ作成
+	reqBody := OllamaGenerateRequest{
+		Model:  model,
+		Prompt: prompt,
+		Stream: true, // Streamingモード（NDJSONで1トークンずつ返る）
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// Step 2: HTTPリクエスト作成（トークンが途切れたときに errStreamIdle でキャンセルする）
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	url := fmt.Sprintf("%s/api/generate", c.baseURL)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		url,
+		bytes.NewBuffer(jsonData),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	// Step 3: リクエスト送信
+	resp, err := c.streamClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request to Ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Step 4: ステータスコードチェック
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("ollama API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	// Step 5: NDJSONを1行ずつデコードしてトークンを通知
+	// （streamIdleTimeout の間に次の行が届かなければ打ち切る）
+	var sb strings.Builder
+	decoder := json.NewDecoder(resp.Body)
+	idle := time.AfterFunc(c.streamIdleTimeout, func() { cancel(errStreamIdle) })
+	defer idle.Stop()
+
+	for {
+		var chunk OllamaGenerateResponse
+		if err := decoder.Decode(&chunk); err != nil {
+			if err == io.EOF {
+				break
+			}
+			if errors.Is(context.Cause(ctx), errStreamIdle) {
+				return sb.String(), fmt.Errorf("ollama stream stalled for %v: %w", c.streamIdleTimeout, errStreamIdle)
+			}
+			return sb.String(), fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		idle.Reset(c.streamIdleTimeout)
+
+		if chunk.Error != "" {
+			return sb.String(), fmt.Errorf("ollama stream error: %s", chunk.Error)
+		}
+
+		if chunk.Response != "" {
+			sb.WriteString(chunk.Response)
+			if err := onToken(chunk.Response); err != nil {
+				return sb.String(), err
+			}
+		}
+
+		if chunk.Done {
+			break
+		}
+	}
+
+	// Step 6: 生成された全文を返す
+	return sb.String(), nil
+}
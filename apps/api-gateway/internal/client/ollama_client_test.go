@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newStreamingOllama は tokens を gap おきに NDJSON で返し、stall なら最後の done を送らずに止まる Ollama を起動します
+func newStreamingOllama(t *testing.T, tokens []string, gap time.Duration, stall bool) *ollamaClient {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		enc := json.NewEncoder(w)
+		for _, token := range tokens {
+			enc.Encode(OllamaGenerateResponse{Response: token})
+			w.(http.Flusher).Flush()
+			time.Sleep(gap)
+		}
+		if stall {
+			<-r.Context().Done()
+			return
+		}
+		enc.Encode(OllamaGenerateResponse{Done: true})
+	}))
+	t.Cleanup(server.Close)
+
+	c := NewOllamaClient(server.URL).(*ollamaClient)
+	c.streamIdleTimeout = 100 * time.Millisecond
+	return c
+}
+
+func TestGenerateStream_OutlivesIdleTimeoutWhileTokensArrive(t *testing.T) {
+	tokens := []string{"a", "b", "c", "d", "e", "f"}
+	c := newStreamingOllama(t, tokens, 40*time.Millisecond, false)
+
+	// 全体では idle timeout より長くかかるが、トークンが届き続けている限り打ち切らない
+	start := time.Now()
+	got, err := c.GenerateStream(context.Background(), "model", "prompt", func(string) error { return nil })
+	if err != nil || got != "abcdef" {
+		t.Fatalf("GenerateStream() = %q, %v; want %q", got, err, "abcdef")
+	}
+	if elapsed := time.Since(start); elapsed < c.streamIdleTimeout {
+		t.Fatalf("Expected the stream to take longer than the idle timeout, took %s", elapsed)
+	}
+	if c.streamClient.Timeout != 0 {
+		t.Errorf("Expected no total timeout on the streaming client, got %s", c.streamClient.Timeout)
+	}
+}
+
+func TestGenerateStream_StopsWhenTokensStall(t *testing.T) {
+	c := newStreamingOllama(t, []string{"partial"}, 0, true)
+
+	start := time.Now()
+	got, err := c.GenerateStream(context.Background(), "model", "prompt", func(string) error { return nil })
+	if !errors.Is(err, errStreamIdle) {
+		t.Fatalf("Expected errStreamIdle, got %v", err)
+	}
+	if got != "partial" {
+		t.Errorf("Expected the text received so far, got %q", got)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the stalled stream to be cut off after the idle timeout, took %s", elapsed)
+	}
+}
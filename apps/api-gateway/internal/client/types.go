@@ -8,7 +8,7 @@ type EmbedDocumentsResponse struct {
 	Embeddings [][]float64 `json:"embeddings"`
 	Count      int         `json:"count"`
 	Model      *string     `json:"model,omitempty"`
-	Dim        *int        `json:"dim:omitempty"`
+	Dim        *int        `json:"dim,omitempty"`
 	ElapsedMs  *float64    `json:"elapsed_ms,omitempty"`
 }
 
@@ -23,6 +23,43 @@ type GenerateResponse struct {
 	Model  string `json:"model"`
 }
 
+type RerankRequest struct {
+	Query string   `json:"query"`
+	Texts []string `json:"texts"`
+}
+
+type RerankResponse struct {
+	Scores []float64 `json:"scores"`
+	Model  string    `json:"model"`
+}
+
+type OCRRequest struct {
+	Image string `json:"image"` // base64 エンコードした PNG / JPEG
+}
+
+type OCRLine struct {
+	Text       string     `json:"text"`
+	BBox       [4]float64 `json:"bbox"` // [x0, y0, x1, y1]（画像のピクセル、左上が原点）
+	Confidence float64    `json:"confidence"`
+	Paragraph  int        `json:"paragraph"`
+}
+
+type OCRResponse struct {
+	Lines      []OCRLine `json:"lines"`
+	Confidence float64   `json:"confidence"`
+	Model      string    `json:"model"`
+}
+
+type TokenizeRequest struct {
+	Texts []string `json:"texts"`
+}
+
+type TokenizeResponse struct {
+	Counts    []int  `json:"counts"`     // "passage: " の接頭辞と特殊トークンを含むトークン数
+	MaxLength int    `json:"max_length"` // モデルの最大シーケンス長
+	Model     string `json:"model"`
+}
+
 type AIWorkerError struct {
 	Code    string                 `json:"code"`
 	Message string                 `json:"message"`
@@ -1,7 +1,10 @@
 package config
 
 import (
+	"log"
 	"os"
+	"strconv"
+	"time"
 )
 
 type Config struct {
@@ -10,10 +13,22 @@ type Config struct {
 	MinIO            MinIOConfig
 	UniDocLicenseKey string
 	Ollama           OllamaConfig
+	Jobs             JobsConfig
+	Processing       ProcessingConfig
+	Rerank           RerankConfig
+	OCR              OCRConfig
+	VectorStore      VectorStoreConfig
+	Upload           UploadConfig
+	GC               GCConfig
 }
 
 type ServerConfig struct {
 	Port string
+	// ShutdownTimeout は停止シグナルを受けてから、処理中のリクエストと実行中のジョブの完了を待つ時間
+	ShutdownTimeout time.Duration
+	// AdminAddr は /debug/vars（expvar のメトリクス）を公開する管理用リスナーのアドレス。
+	// 既定はローカルホストのみ。空なら公開しない
+	AdminAddr string
 }
 
 type DatabaseConfig struct {
@@ -31,6 +46,10 @@ type MinIOConfig struct {
 	PortConsole string
 	User        string
 	Password    string
+	// PublicEndpoint はブラウザから MinIO に届くホスト（例: files.example.com）。
+	// 署名付き URL のホストは署名に含まれるため、ゲートウェイからの接続先と異なる場合に設定する
+	PublicEndpoint string
+	PublicUseSSL   bool
 }
 
 type OllamaConfig struct {
@@ -39,6 +58,71 @@ type OllamaConfig struct {
 	Model string
 }
 
+// JobsConfig はドキュメント処理ジョブのワーカー設定
+type JobsConfig struct {
+	Workers      int           // 同時に処理するワーカー数
+	PollInterval time.Duration // キューが空のときの待機間隔
+	MaxAttempts  int           // dead になるまでの最大試行回数
+	RetryBase    time.Duration // リトライ間隔の初期値（試行ごとに倍増）
+	RetryMax     time.Duration // リトライ間隔の上限
+	LockTimeout  time.Duration // この時間ロックが更新されないジョブはワーカー停止とみなして再キューする
+}
+
+// ProcessingConfig はドキュメント処理（embedding / 保存）のバッチ設定
+type ProcessingConfig struct {
+	EmbedBatchSize   int           // 1回の Embedding リクエストで送るチャンク数
+	EmbedParallelism int           // 同時に送る Embedding リクエスト数
+	BatchRetries     int           // 失敗したバッチの再試行回数
+	RetryDelay       time.Duration // バッチ再試行の初期待機時間（試行ごとに倍増）
+	IndexBatchSize   int           // Postgres INSERT / Qdrant upsert の1回あたりの件数
+	Tokenizer        string        // embedding モデルのトークン数の数え方（auto / local / ai_worker / none）
+	TokenizerPath    string        // provider=local / auto で読み込む tokenizer.json（または sentencepiece の語彙ファイル）
+	MaxTokens        int           // embedding モデルの最大シーケンス長
+	OversizeChunks   string        // 最大シーケンス長を超えるチャンクの扱い（split / reject）
+}
+
+// RerankConfig は検索結果のリランキング設定
+type RerankConfig struct {
+	Provider       string // none / cross_encoder / llm
+	Candidates     int    // リランキング前に取得する候補数
+	LLMModel       string // provider=llm で採点に使う Ollama のモデル
+	LLMParallelism int    // provider=llm で同時に投げる採点リクエスト数
+}
+
+// OCRConfig はテキストレイヤーのない PDF のページの OCR 設定
+type OCRConfig struct {
+	Provider      string // none / tesseract / ai_worker
+	Languages     string // provider=tesseract の言語（例: jpn+eng）
+	DPI           int    // ページを画像にするときの解像度
+	TesseractPath string
+	PdftoppmPath  string // ページを画像にする poppler の pdftoppm
+}
+
+// VectorStoreConfig はベクトルストアの設定
+type VectorStoreConfig struct {
+	Backend            string // qdrant / local / memory
+	QdrantURL          string
+	LocalPath          string // backend=local のデータディレクトリ
+	HNSWM              int    // backend=local の HNSW の1ノードあたりのリンク数
+	HNSWEfConstruction int    // backend=local の HNSW 構築時の候補数
+	HNSWEfSearch       int    // backend=local の HNSW 検索時の候補数
+}
+
+// UploadConfig はファイルアップロードの設定
+type UploadConfig struct {
+	MaxBytes      int64         // サーバー全体のアップロード上限（ワークスペースの max_upload_bytes はこれより小さくのみ設定できる）
+	SessionTTL    time.Duration // 再開可能なアップロードのセッションの有効期間
+	PresignExpiry time.Duration // 署名付きアップロード / ダウンロード URL の有効期間
+}
+
+// GCConfig は参照されなくなったファイル実体（files + MinIO オブジェクト）の GC 設定
+type GCConfig struct {
+	Enabled     bool          // バックグラウンドで定期実行するか（無効でも API から手動実行できる）
+	Interval    time.Duration // 実行間隔
+	GracePeriod time.Duration // 最後に使われてからこの期間が過ぎたファイルだけを削除する
+	BatchSize   int           // 1回の実行で削除する最大ファイル数
+}
+
 func getEnv(key string, defaultValue ...string) string {
 	value := os.Getenv(key)
 	if value == "" && len(defaultValue) > 0 {
@@ -47,10 +131,38 @@ func getEnv(key string, defaultValue ...string) string {
 	return value
 }
 
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("⚠️  Invalid %s=%q, using default %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("⚠️  Invalid %s=%q, using default %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
 func Load() Config {
 	cfg := Config{
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
+			Port:            getEnv("SERVER_PORT", "8080"),
+			ShutdownTimeout: getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+			AdminAddr:       getEnv("ADMIN_ADDR", "127.0.0.1:9090"),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -60,18 +172,71 @@ func Load() Config {
 			DBName:   getEnv("DB_NAME", "nexus"),
 		},
 		MinIO: MinIOConfig{
-			HostAPI:     getEnv("MINIO_HOST_API", "localhost"),
-			PortAPI:     getEnv("MINIO_PORT_API", "9000"),
-			HostConsole: getEnv("MINIO_HOST_CONSOLE", "localhost"),
-			PortConsole: getEnv("MINIO_PORT_CONSOLE", "9001"),
-			User:        getEnv("MINIO_USER", "admin"),
-			Password:    getEnv("MINIO_PASSWORD", "password123"),
+			HostAPI:        getEnv("MINIO_HOST_API", "localhost"),
+			PortAPI:        getEnv("MINIO_PORT_API", "9000"),
+			HostConsole:    getEnv("MINIO_HOST_CONSOLE", "localhost"),
+			PortConsole:    getEnv("MINIO_PORT_CONSOLE", "9001"),
+			User:           getEnv("MINIO_USER", "admin"),
+			Password:       getEnv("MINIO_PASSWORD", "password123"),
+			PublicEndpoint: getEnv("MINIO_PUBLIC_ENDPOINT", ""),
+			PublicUseSSL:   getEnv("MINIO_PUBLIC_USE_SSL", "false") == "true",
 		},
 		Ollama: OllamaConfig{
 			Host:  getEnv("OLLAMA_HOST", "localhost"),
 			Port:  getEnv("OLLAMA_PORT", "11434"),
 			Model: getEnv("OLLAMA_MODEL", "phi3:mini"),
 		},
+		Jobs: JobsConfig{
+			Workers:      getEnvInt("JOB_WORKERS", 2),
+			PollInterval: getEnvDuration("JOB_POLL_INTERVAL", 2*time.Second),
+			MaxAttempts:  getEnvInt("JOB_MAX_ATTEMPTS", 5),
+			RetryBase:    getEnvDuration("JOB_RETRY_BASE", 10*time.Second),
+			RetryMax:     getEnvDuration("JOB_RETRY_MAX", 10*time.Minute),
+			LockTimeout:  getEnvDuration("JOB_LOCK_TIMEOUT", 5*time.Minute),
+		},
+		Processing: ProcessingConfig{
+			EmbedBatchSize:   getEnvInt("EMBED_BATCH_SIZE", 32),
+			EmbedParallelism: getEnvInt("EMBED_PARALLELISM", 4),
+			BatchRetries:     getEnvInt("EMBED_BATCH_RETRIES", 3),
+			RetryDelay:       getEnvDuration("EMBED_RETRY_DELAY", time.Second),
+			IndexBatchSize:   getEnvInt("INDEX_BATCH_SIZE", 256),
+			Tokenizer:        getEnv("EMBEDDING_TOKENIZER", "auto"),
+			TokenizerPath:    getEnv("EMBEDDING_TOKENIZER_PATH", ""),
+			MaxTokens:        getEnvInt("EMBEDDING_MAX_TOKENS", 512),
+			OversizeChunks:   getEnv("OVERSIZE_CHUNK_POLICY", "split"),
+		},
+		Rerank: RerankConfig{
+			Provider:       getEnv("RERANKER", "none"),
+			Candidates:     getEnvInt("RERANK_CANDIDATES", 20),
+			LLMModel:       getEnv("RERANK_LLM_MODEL", getEnv("OLLAMA_MODEL", "phi3:mini")),
+			LLMParallelism: getEnvInt("RERANK_LLM_PARALLELISM", 4),
+		},
+		OCR: OCRConfig{
+			Provider:      getEnv("OCR_PROVIDER", "none"),
+			Languages:     getEnv("OCR_LANGUAGES", "jpn+eng"),
+			DPI:           getEnvInt("OCR_DPI", 300),
+			TesseractPath: getEnv("TESSERACT_PATH", "tesseract"),
+			PdftoppmPath:  getEnv("PDFTOPPM_PATH", "pdftoppm"),
+		},
+		VectorStore: VectorStoreConfig{
+			Backend:            getEnv("VECTOR_STORE", "qdrant"),
+			QdrantURL:          getEnv("QDRANT_URL", "http://localhost:6333"),
+			LocalPath:          getEnv("VECTOR_STORE_PATH", "./data/vectors"),
+			HNSWM:              getEnvInt("VECTOR_HNSW_M", 16),
+			HNSWEfConstruction: getEnvInt("VECTOR_HNSW_EF_CONSTRUCTION", 200),
+			HNSWEfSearch:       getEnvInt("VECTOR_HNSW_EF_SEARCH", 64),
+		},
+		Upload: UploadConfig{
+			MaxBytes:      int64(getEnvInt("MAX_UPLOAD_BYTES", 2<<30)),
+			SessionTTL:    getEnvDuration("UPLOAD_SESSION_TTL", 24*time.Hour),
+			PresignExpiry: getEnvDuration("UPLOAD_PRESIGN_EXPIRY", 15*time.Minute),
+		},
+		GC: GCConfig{
+			Enabled:     getEnv("BLOB_GC_ENABLED", "true") == "true",
+			Interval:    getEnvDuration("BLOB_GC_INTERVAL", 6*time.Hour),
+			GracePeriod: getEnvDuration("BLOB_GC_GRACE_PERIOD", 24*time.Hour),
+			BatchSize:   getEnvInt("BLOB_GC_BATCH_SIZE", 500),
+		},
 	}
 
 	return cfg
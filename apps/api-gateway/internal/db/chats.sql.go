@@ -34,25 +34,32 @@ INSERT INTO chats (
     workspace_id,
     title,
     filter_config,
+    settings,
     created_at,
     updated_at
 ) VALUES (
-    $1, $2, $3, now(), now()
+    $1, $2, $3, $4, now(), now()
 )
-RETURNING id, workspace_id, title, filter_config, created_at, updated_at, deleted_at
+RETURNING id, workspace_id, title, filter_config, created_at, updated_at, deleted_at, settings
 `
 
 type CreateChatParams struct {
 	WorkspaceID  uuid.UUID             `json:"workspace_id"`
 	Title        string                `json:"title"`
 	FilterConfig pqtype.NullRawMessage `json:"filter_config"`
+	Settings     pqtype.NullRawMessage `json:"settings"`
 }
 
 // ========================================
 // Chat Operations
 // ========================================
 func (q *Queries) CreateChat(ctx context.Context, arg CreateChatParams) (Chat, error) {
-	row := q.db.QueryRowContext(ctx, createChat, arg.WorkspaceID, arg.Title, arg.FilterConfig)
+	row := q.db.QueryRowContext(ctx, createChat,
+		arg.WorkspaceID,
+		arg.Title,
+		arg.FilterConfig,
+		arg.Settings,
+	)
 	var i Chat
 	err := row.Scan(
 		&i.ID,
@@ -62,6 +69,7 @@ func (q *Queries) CreateChat(ctx context.Context, arg CreateChatParams) (Chat, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Settings,
 	)
 	return i, err
 }
@@ -131,7 +139,7 @@ func (q *Queries) DeleteChat(ctx context.Context, arg DeleteChatParams) error {
 }
 
 const getChat = `-- name: GetChat :one
-SELECT id, workspace_id, title, filter_config, created_at, updated_at, deleted_at FROM chats
+SELECT id, workspace_id, title, filter_config, created_at, updated_at, deleted_at, settings FROM chats
 WHERE 
     id = $1 
     AND workspace_id = $2 
@@ -154,6 +162,7 @@ func (q *Queries) GetChat(ctx context.Context, arg GetChatParams) (Chat, error)
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Settings,
 	)
 	return i, err
 }
@@ -257,12 +266,66 @@ func (q *Queries) GetMaxMessageIndex(ctx context.Context, chatID uuid.UUID) (int
 	return max_index, err
 }
 
+const getRecentChatMessages = `-- name: GetRecentChatMessages :many
+SELECT 
+    id,
+    chat_id,
+    role,
+    content,
+    message_index,
+    document_refs,
+    created_at
+FROM chat_messages
+WHERE 
+    chat_id = $1
+ORDER BY message_index DESC
+LIMIT $2
+`
+
+type GetRecentChatMessagesParams struct {
+	ChatID uuid.UUID `json:"chat_id"`
+	Limit  int32     `json:"limit"`
+}
+
+// 直近のメッセージを新しい順に取得（会話履歴をプロンプトに含めるため）
+func (q *Queries) GetRecentChatMessages(ctx context.Context, arg GetRecentChatMessagesParams) ([]ChatMessage, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentChatMessages, arg.ChatID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChatMessage
+	for rows.Next() {
+		var i ChatMessage
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChatID,
+			&i.Role,
+			&i.Content,
+			&i.MessageIndex,
+			&i.DocumentRefs,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listChats = `-- name: ListChats :many
 SELECT 
     c.id,
     c.workspace_id,
     c.title,
     c.filter_config,
+    c.settings,
     c.created_at,
     c.updated_at
 FROM chats c
@@ -284,6 +347,7 @@ type ListChatsRow struct {
 	WorkspaceID  uuid.UUID             `json:"workspace_id"`
 	Title        string                `json:"title"`
 	FilterConfig pqtype.NullRawMessage `json:"filter_config"`
+	Settings     pqtype.NullRawMessage `json:"settings"`
 	CreatedAt    time.Time             `json:"created_at"`
 	UpdatedAt    time.Time             `json:"updated_at"`
 }
@@ -302,6 +366,7 @@ func (q *Queries) ListChats(ctx context.Context, arg ListChatsParams) ([]ListCha
 			&i.WorkspaceID,
 			&i.Title,
 			&i.FilterConfig,
+			&i.Settings,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 		); err != nil {
@@ -7,10 +7,12 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"github.com/sqlc-dev/pqtype"
 )
 
 const countDocuments = `-- name: CountDocuments :one
@@ -19,34 +21,54 @@ FROM documents
 WHERE workspace_id = $1 
   AND deleted_at IS NULL
   AND ($2::uuid IS NULL OR directory_id = $2)
+  AND (COALESCE(cardinality($3::text[]), 0) = 0 OR tags @> $3::text[])
+  AND (COALESCE(cardinality($4::text[]), 0) = 0 OR metadata ?& $4::text[])
+  AND ($5::jsonb IS NULL OR metadata @> $5::jsonb)
 `
 
 type CountDocumentsParams struct {
-	WorkspaceID uuid.UUID     `json:"workspace_id"`
-	DirectoryID uuid.NullUUID `json:"directory_id"`
+	WorkspaceID  uuid.UUID             `json:"workspace_id"`
+	DirectoryID  uuid.NullUUID         `json:"directory_id"`
+	Tags         []string              `json:"tags"`
+	MetadataKeys []string              `json:"metadata_keys"`
+	Metadata     pqtype.NullRawMessage `json:"metadata"`
 }
 
 func (q *Queries) CountDocuments(ctx context.Context, arg CountDocumentsParams) (int64, error) {
-	row := q.db.QueryRowContext(ctx, countDocuments, arg.WorkspaceID, arg.DirectoryID)
+	row := q.db.QueryRowContext(ctx, countDocuments,
+		arg.WorkspaceID,
+		arg.DirectoryID,
+		pq.Array(arg.Tags),
+		pq.Array(arg.MetadataKeys),
+		arg.Metadata,
+	)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
 }
 
 const createDocument = `-- name: CreateDocument :one
-INSERT INTO documents (
-    workspace_id, 
-    directory_id, 
-    file_id, 
-    name, 
-    tags, 
-    status,
-    created_at,
-    updated_at
-) VALUES (
-    $1, $2, $3, $4, $5, 'uploaded', now(), now()
+WITH doc AS (
+    INSERT INTO documents (
+        workspace_id, 
+        directory_id, 
+        file_id, 
+        name, 
+        tags, 
+        status,
+        current_version_id,
+        created_at,
+        updated_at
+    ) VALUES (
+        $1, $2, $3, $4, $5, 'uploaded', $6, now(), now()
+    )
+    RETURNING id, workspace_id, directory_id, file_id, name, tags, metadata, status, processed_at, created_at, updated_at, deleted_at, progress, error_message, current_version_id
+), version AS (
+    INSERT INTO document_versions (id, document_id, version_number, file_id, file_name)
+    SELECT $6, doc.id, 1, doc.file_id, $7::text
+    FROM doc
 )
-RETURNING id, workspace_id, directory_id, file_id, name, tags, metadata, status, processed_at, created_at, updated_at, deleted_at
+SELECT id, workspace_id, directory_id, file_id, name, tags, metadata, status, processed_at, created_at, updated_at, deleted_at, progress, error_message, current_version_id FROM doc
 `
 
 type CreateDocumentParams struct {
@@ -55,8 +77,11 @@ type CreateDocumentParams struct {
 	FileID      uuid.UUID     `json:"file_id"`
 	Name        string        `json:"name"`
 	Tags        []string      `json:"tags"`
+	VersionID   uuid.NullUUID `json:"version_id"`
+	FileName    string        `json:"file_name"`
 }
 
+// ドキュメントとバージョン1を1文で作成する（バージョンIDはアプリ側で採番し current_version_id に設定する）
 func (q *Queries) CreateDocument(ctx context.Context, arg CreateDocumentParams) (Document, error) {
 	row := q.db.QueryRowContext(ctx, createDocument,
 		arg.WorkspaceID,
@@ -64,6 +89,8 @@ func (q *Queries) CreateDocument(ctx context.Context, arg CreateDocumentParams)
 		arg.FileID,
 		arg.Name,
 		pq.Array(arg.Tags),
+		arg.VersionID,
+		arg.FileName,
 	)
 	var i Document
 	err := row.Scan(
@@ -79,6 +106,9 @@ func (q *Queries) CreateDocument(ctx context.Context, arg CreateDocumentParams)
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Progress,
+		&i.ErrorMessage,
+		&i.CurrentVersionID,
 	)
 	return i, err
 }
@@ -110,7 +140,12 @@ SELECT
     d.file_id,
     d.name,
     d.tags,
+    d.metadata,
     d.status,
+    d.processed_at,
+    d.progress,
+    d.error_message,
+    d.current_version_id,
     d.created_at,
     d.updated_at,
     f.size_bytes,
@@ -131,20 +166,25 @@ type GetDocumentParams struct {
 }
 
 type GetDocumentRow struct {
-	ID          uuid.UUID     `json:"id"`
-	WorkspaceID uuid.UUID     `json:"workspace_id"`
-	DirectoryID uuid.NullUUID `json:"directory_id"`
-	FileID      uuid.UUID     `json:"file_id"`
-	Name        string        `json:"name"`
-	Tags        []string      `json:"tags"`
-	Status      string        `json:"status"`
-	CreatedAt   time.Time     `json:"created_at"`
-	UpdatedAt   time.Time     `json:"updated_at"`
-	SizeBytes   int64         `json:"size_bytes"`
-	MimeType    string        `json:"mime_type"`
-	Sha256Hash  string        `json:"sha256_hash"`
-	MinioBucket string        `json:"minio_bucket"`
-	MinioKey    string        `json:"minio_key"`
+	ID               uuid.UUID             `json:"id"`
+	WorkspaceID      uuid.UUID             `json:"workspace_id"`
+	DirectoryID      uuid.NullUUID         `json:"directory_id"`
+	FileID           uuid.UUID             `json:"file_id"`
+	Name             string                `json:"name"`
+	Tags             []string              `json:"tags"`
+	Metadata         pqtype.NullRawMessage `json:"metadata"`
+	Status           string                `json:"status"`
+	ProcessedAt      sql.NullTime          `json:"processed_at"`
+	Progress         pqtype.NullRawMessage `json:"progress"`
+	ErrorMessage     sql.NullString        `json:"error_message"`
+	CurrentVersionID uuid.NullUUID         `json:"current_version_id"`
+	CreatedAt        time.Time             `json:"created_at"`
+	UpdatedAt        time.Time             `json:"updated_at"`
+	SizeBytes        int64                 `json:"size_bytes"`
+	MimeType         string                `json:"mime_type"`
+	Sha256Hash       string                `json:"sha256_hash"`
+	MinioBucket      string                `json:"minio_bucket"`
+	MinioKey         string                `json:"minio_key"`
 }
 
 func (q *Queries) GetDocument(ctx context.Context, arg GetDocumentParams) (GetDocumentRow, error) {
@@ -157,7 +197,12 @@ func (q *Queries) GetDocument(ctx context.Context, arg GetDocumentParams) (GetDo
 		&i.FileID,
 		&i.Name,
 		pq.Array(&i.Tags),
+		&i.Metadata,
 		&i.Status,
+		&i.ProcessedAt,
+		&i.Progress,
+		&i.ErrorMessage,
+		&i.CurrentVersionID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.SizeBytes,
@@ -169,6 +214,100 @@ func (q *Queries) GetDocument(ctx context.Context, arg GetDocumentParams) (GetDo
 	return i, err
 }
 
+const getDocumentsByFileID = `-- name: GetDocumentsByFileID :many
+SELECT id, workspace_id, directory_id, file_id, name, tags, metadata, status, processed_at, created_at, updated_at, deleted_at, progress, error_message, current_version_id FROM documents
+WHERE file_id = $1
+`
+
+func (q *Queries) GetDocumentsByFileID(ctx context.Context, fileID uuid.UUID) ([]Document, error) {
+	rows, err := q.db.QueryContext(ctx, getDocumentsByFileID, fileID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Document
+	for rows.Next() {
+		var i Document
+		if err := rows.Scan(
+			&i.ID,
+			&i.WorkspaceID,
+			&i.DirectoryID,
+			&i.FileID,
+			&i.Name,
+			pq.Array(&i.Tags),
+			&i.Metadata,
+			&i.Status,
+			&i.ProcessedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Progress,
+			&i.ErrorMessage,
+			&i.CurrentVersionID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const hardDeleteDocumentsByFileID = `-- name: HardDeleteDocumentsByFileID :exec
+DELETE FROM documents
+WHERE file_id = $1
+`
+
+func (q *Queries) HardDeleteDocumentsByFileID(ctx context.Context, fileID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, hardDeleteDocumentsByFileID, fileID)
+	return err
+}
+
+const listDocumentTags = `-- name: ListDocumentTags :many
+SELECT
+    tag::text AS tag,
+    COUNT(*) AS document_count
+FROM documents d, unnest(d.tags) AS tag
+WHERE d.workspace_id = $1
+  AND d.deleted_at IS NULL
+GROUP BY tag
+ORDER BY document_count DESC, tag
+`
+
+type ListDocumentTagsRow struct {
+	Tag           string `json:"tag"`
+	DocumentCount int64  `json:"document_count"`
+}
+
+// ワークスペース内のタグと、そのタグを持つドキュメント数
+func (q *Queries) ListDocumentTags(ctx context.Context, workspaceID uuid.UUID) ([]ListDocumentTagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDocumentTags, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDocumentTagsRow
+	for rows.Next() {
+		var i ListDocumentTagsRow
+		if err := rows.Scan(&i.Tag, &i.DocumentCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listDocuments = `-- name: ListDocuments :many
 SELECT 
     d.id,
@@ -177,6 +316,7 @@ SELECT
     d.file_id,
     d.name,
     d.tags,
+    d.metadata,
     d.status,
     d.created_at,
     d.updated_at,
@@ -187,29 +327,38 @@ INNER JOIN files f ON d.file_id = f.id
 WHERE d.workspace_id = $1 
   AND d.deleted_at IS NULL
   AND ($4::uuid IS NULL OR d.directory_id = $4)
+  -- tags: 指定タグをすべて持つ（空配列・NULL なら絞り込まない）
+  AND (COALESCE(cardinality($5::text[]), 0) = 0 OR d.tags @> $5::text[])
+  -- metadata_keys: 指定キーをすべて持つ / metadata: 指定の key-value を含む
+  AND (COALESCE(cardinality($6::text[]), 0) = 0 OR d.metadata ?& $6::text[])
+  AND ($7::jsonb IS NULL OR d.metadata @> $7::jsonb)
 ORDER BY d.created_at DESC
 LIMIT $2 OFFSET $3
 `
 
 type ListDocumentsParams struct {
-	WorkspaceID uuid.UUID     `json:"workspace_id"`
-	Limit       int32         `json:"limit"`
-	Offset      int32         `json:"offset"`
-	DirectoryID uuid.NullUUID `json:"directory_id"`
+	WorkspaceID  uuid.UUID             `json:"workspace_id"`
+	Limit        int32                 `json:"limit"`
+	Offset       int32                 `json:"offset"`
+	DirectoryID  uuid.NullUUID         `json:"directory_id"`
+	Tags         []string              `json:"tags"`
+	MetadataKeys []string              `json:"metadata_keys"`
+	Metadata     pqtype.NullRawMessage `json:"metadata"`
 }
 
 type ListDocumentsRow struct {
-	ID          uuid.UUID     `json:"id"`
-	WorkspaceID uuid.UUID     `json:"workspace_id"`
-	DirectoryID uuid.NullUUID `json:"directory_id"`
-	FileID      uuid.UUID     `json:"file_id"`
-	Name        string        `json:"name"`
-	Tags        []string      `json:"tags"`
-	Status      string        `json:"status"`
-	CreatedAt   time.Time     `json:"created_at"`
-	UpdatedAt   time.Time     `json:"updated_at"`
-	SizeBytes   int64         `json:"size_bytes"`
-	MimeType    string        `json:"mime_type"`
+	ID          uuid.UUID             `json:"id"`
+	WorkspaceID uuid.UUID             `json:"workspace_id"`
+	DirectoryID uuid.NullUUID         `json:"directory_id"`
+	FileID      uuid.UUID             `json:"file_id"`
+	Name        string                `json:"name"`
+	Tags        []string              `json:"tags"`
+	Metadata    pqtype.NullRawMessage `json:"metadata"`
+	Status      string                `json:"status"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+	SizeBytes   int64                 `json:"size_bytes"`
+	MimeType    string                `json:"mime_type"`
 }
 
 func (q *Queries) ListDocuments(ctx context.Context, arg ListDocumentsParams) ([]ListDocumentsRow, error) {
@@ -218,6 +367,9 @@ func (q *Queries) ListDocuments(ctx context.Context, arg ListDocumentsParams) ([
 		arg.Limit,
 		arg.Offset,
 		arg.DirectoryID,
+		pq.Array(arg.Tags),
+		pq.Array(arg.MetadataKeys),
+		arg.Metadata,
 	)
 	if err != nil {
 		return nil, err
@@ -233,6 +385,7 @@ func (q *Queries) ListDocuments(ctx context.Context, arg ListDocumentsParams) ([
 			&i.FileID,
 			&i.Name,
 			pq.Array(&i.Tags),
+			&i.Metadata,
 			&i.Status,
 			&i.CreatedAt,
 			&i.UpdatedAt,
@@ -251,3 +404,243 @@ func (q *Queries) ListDocuments(ctx context.Context, arg ListDocumentsParams) ([
 	}
 	return items, nil
 }
+
+const moveDocuments = `-- name: MoveDocuments :many
+UPDATE documents
+SET 
+    directory_id = $1,
+    updated_at = now()
+WHERE workspace_id = $2
+  AND id = ANY($3::uuid[])
+  AND deleted_at IS NULL
+RETURNING id
+`
+
+type MoveDocumentsParams struct {
+	DirectoryID uuid.NullUUID `json:"directory_id"`
+	WorkspaceID uuid.UUID     `json:"workspace_id"`
+	Ids         []uuid.UUID   `json:"ids"`
+}
+
+// directory_id が NULL の場合はワークスペース直下に移動する
+func (q *Queries) MoveDocuments(ctx context.Context, arg MoveDocumentsParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, moveDocuments, arg.DirectoryID, arg.WorkspaceID, pq.Array(arg.Ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeDeletedDocumentsByFileID = `-- name: PurgeDeletedDocumentsByFileID :many
+DELETE FROM documents
+WHERE (file_id = $1 OR id IN (SELECT document_id FROM document_versions WHERE file_id = $1))
+  AND deleted_at IS NOT NULL
+RETURNING id, workspace_id
+`
+
+type PurgeDeletedDocumentsByFileIDRow struct {
+	ID          uuid.UUID `json:"id"`
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+}
+
+// 論理削除済みの documents を物理削除する（versions / chunks / jobs は CASCADE で削除される）
+// 過去のバージョンとしてファイルを参照しているドキュメントも対象にする
+func (q *Queries) PurgeDeletedDocumentsByFileID(ctx context.Context, fileID uuid.UUID) ([]PurgeDeletedDocumentsByFileIDRow, error) {
+	rows, err := q.db.QueryContext(ctx, purgeDeletedDocumentsByFileID, fileID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PurgeDeletedDocumentsByFileIDRow
+	for rows.Next() {
+		var i PurgeDeletedDocumentsByFileIDRow
+		if err := rows.Scan(&i.ID, &i.WorkspaceID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setDocumentCurrentVersion = `-- name: SetDocumentCurrentVersion :execrows
+UPDATE documents d
+SET
+    current_version_id = v.id,
+    file_id = v.file_id,
+    updated_at = now()
+FROM document_versions v
+WHERE v.id = $1
+  AND d.id = v.document_id
+  AND d.deleted_at IS NULL
+`
+
+// 検索対象のバージョンを切り替える（documents.file_id もそのバージョンのファイルに合わせる）
+func (q *Queries) SetDocumentCurrentVersion(ctx context.Context, versionID uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setDocumentCurrentVersion, versionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const softDeleteDocumentsInDirectories = `-- name: SoftDeleteDocumentsInDirectories :many
+UPDATE documents
+SET 
+    deleted_at = now(),
+    updated_at = now()
+WHERE workspace_id = $1
+  AND directory_id = ANY($2::uuid[])
+  AND deleted_at IS NULL
+RETURNING id
+`
+
+type SoftDeleteDocumentsInDirectoriesParams struct {
+	WorkspaceID  uuid.UUID   `json:"workspace_id"`
+	DirectoryIds []uuid.UUID `json:"directory_ids"`
+}
+
+// ディレクトリ削除時に配下のドキュメントを論理削除する（ファイル実体は blob GC が回収する）
+func (q *Queries) SoftDeleteDocumentsInDirectories(ctx context.Context, arg SoftDeleteDocumentsInDirectoriesParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, softDeleteDocumentsInDirectories, arg.WorkspaceID, pq.Array(arg.DirectoryIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateDocumentAttributes = `-- name: UpdateDocumentAttributes :one
+UPDATE documents
+SET
+    name = COALESCE($1, name),
+    tags = COALESCE($2::text[], tags),
+    metadata = CASE
+        WHEN $3::jsonb IS NULL THEN metadata
+        ELSE jsonb_strip_nulls(COALESCE(metadata, '{}'::jsonb) || $3::jsonb)
+    END,
+    updated_at = now()
+WHERE id = $4
+  AND workspace_id = $5
+  AND deleted_at IS NULL
+RETURNING id, workspace_id, directory_id, file_id, name, tags, metadata, status, processed_at, created_at, updated_at, deleted_at, progress, error_message, current_version_id
+`
+
+type UpdateDocumentAttributesParams struct {
+	Name          sql.NullString        `json:"name"`
+	Tags          []string              `json:"tags"`
+	MetadataPatch pqtype.NullRawMessage `json:"metadata_patch"`
+	ID            uuid.UUID             `json:"id"`
+	WorkspaceID   uuid.UUID             `json:"workspace_id"`
+}
+
+// NULL の項目は変更しない。metadata_patch は既存の metadata にマージし、値が null のキーは削除する
+func (q *Queries) UpdateDocumentAttributes(ctx context.Context, arg UpdateDocumentAttributesParams) (Document, error) {
+	row := q.db.QueryRowContext(ctx, updateDocumentAttributes,
+		arg.Name,
+		pq.Array(arg.Tags),
+		arg.MetadataPatch,
+		arg.ID,
+		arg.WorkspaceID,
+	)
+	var i Document
+	err := row.Scan(
+		&i.ID,
+		&i.WorkspaceID,
+		&i.DirectoryID,
+		&i.FileID,
+		&i.Name,
+		pq.Array(&i.Tags),
+		&i.Metadata,
+		&i.Status,
+		&i.ProcessedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Progress,
+		&i.ErrorMessage,
+		&i.CurrentVersionID,
+	)
+	return i, err
+}
+
+const updateDocumentProgress = `-- name: UpdateDocumentProgress :exec
+UPDATE documents
+SET 
+    progress = $2,
+    updated_at = now()
+WHERE id = $1
+`
+
+type UpdateDocumentProgressParams struct {
+	ID       uuid.UUID             `json:"id"`
+	Progress pqtype.NullRawMessage `json:"progress"`
+}
+
+func (q *Queries) UpdateDocumentProgress(ctx context.Context, arg UpdateDocumentProgressParams) error {
+	_, err := q.db.ExecContext(ctx, updateDocumentProgress, arg.ID, arg.Progress)
+	return err
+}
+
+const updateDocumentStatus = `-- name: UpdateDocumentStatus :exec
+UPDATE documents
+SET 
+    status = $1,
+    error_message = $2,
+    processed_at = CASE WHEN $1 = 'processed' THEN now() ELSE processed_at END,
+    updated_at = now()
+WHERE id = $3 
+  AND workspace_id = $4
+`
+
+type UpdateDocumentStatusParams struct {
+	Status       string         `json:"status"`
+	ErrorMessage sql.NullString `json:"error_message"`
+	ID           uuid.UUID      `json:"id"`
+	WorkspaceID  uuid.UUID      `json:"workspace_id"`
+}
+
+// processed になった時点で processed_at を記録する（error_message は failed のときのみ設定）
+func (q *Queries) UpdateDocumentStatus(ctx context.Context, arg UpdateDocumentStatusParams) error {
+	_, err := q.db.ExecContext(ctx, updateDocumentStatus,
+		arg.Status,
+		arg.ErrorMessage,
+		arg.ID,
+		arg.WorkspaceID,
+	)
+	return err
+}
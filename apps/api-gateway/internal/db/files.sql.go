@@ -8,6 +8,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -61,6 +62,22 @@ func (q *Queries) CreateFile(ctx context.Context, arg CreateFileParams) (File, e
 	return i, err
 }
 
+const deleteUnreferencedFile = `-- name: DeleteUnreferencedFile :execrows
+DELETE FROM files f
+WHERE f.id = $1
+  AND NOT EXISTS (SELECT 1 FROM documents d WHERE d.file_id = f.id)
+  AND NOT EXISTS (SELECT 1 FROM document_versions v WHERE v.file_id = f.id)
+`
+
+// どの document / document_version からも参照されていない場合だけ削除する（判定後に参照が増えていれば0行）
+func (q *Queries) DeleteUnreferencedFile(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUnreferencedFile, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getFileByHash = `-- name: GetFileByHash :one
 SELECT id, sha256_hash, mime_type, size_bytes, original_filename, minio_bucket, minio_key, created_at, last_accessed_at FROM files 
 WHERE sha256_hash = $1 
@@ -106,6 +123,79 @@ func (q *Queries) GetFileById(ctx context.Context, id uuid.UUID) (File, error) {
 	return i, err
 }
 
+const listUnreferencedFiles = `-- name: ListUnreferencedFiles :many
+SELECT c.id, c.sha256_hash, c.size_bytes, c.minio_bucket, c.minio_key, c.last_used_at
+FROM (
+    SELECT
+        f.id,
+        f.sha256_hash,
+        f.size_bytes,
+        f.minio_bucket,
+        f.minio_key,
+        GREATEST(
+            f.created_at,
+            f.last_accessed_at,
+            (SELECT MAX(d.deleted_at) FROM documents d WHERE d.file_id = f.id),
+            (SELECT MAX(d.deleted_at) FROM document_versions v JOIN documents d ON d.id = v.document_id WHERE v.file_id = f.id)
+        )::timestamptz AS last_used_at
+    FROM files f
+    WHERE NOT EXISTS (SELECT 1 FROM documents d WHERE d.file_id = f.id AND d.deleted_at IS NULL)
+      AND NOT EXISTS (
+          SELECT 1 FROM document_versions v JOIN documents d ON d.id = v.document_id
+          WHERE v.file_id = f.id AND d.deleted_at IS NULL
+      )
+) c
+WHERE c.last_used_at < $1
+ORDER BY c.last_used_at
+LIMIT $2
+`
+
+type ListUnreferencedFilesParams struct {
+	UnusedSince time.Time `json:"unused_since"`
+	MaxFiles    int32     `json:"max_files"`
+}
+
+type ListUnreferencedFilesRow struct {
+	ID          uuid.UUID `json:"id"`
+	Sha256Hash  string    `json:"sha256_hash"`
+	SizeBytes   int64     `json:"size_bytes"`
+	MinioBucket string    `json:"minio_bucket"`
+	MinioKey    string    `json:"minio_key"`
+	LastUsedAt  time.Time `json:"last_used_at"`
+}
+
+// 生きている（deleted_at IS NULL の）documents から、現在・過去のどのバージョンとしても参照されていないファイルを、最後に使われた順に返す。
+// 最後に使われた時刻 = 作成・アクセス・参照していた document の削除のうち最も新しいもの（GREATEST は NULL を無視する）
+func (q *Queries) ListUnreferencedFiles(ctx context.Context, arg ListUnreferencedFilesParams) ([]ListUnreferencedFilesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUnreferencedFiles, arg.UnusedSince, arg.MaxFiles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUnreferencedFilesRow
+	for rows.Next() {
+		var i ListUnreferencedFilesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Sha256Hash,
+			&i.SizeBytes,
+			&i.MinioBucket,
+			&i.MinioKey,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateFileLastAccessed = `-- name: UpdateFileLastAccessed :exec
 UPDATE files 
 SET last_accessed_at = now() 
@@ -73,6 +73,7 @@ type Chat struct {
 	CreatedAt    time.Time             `json:"created_at"`
 	UpdatedAt    time.Time             `json:"updated_at"`
 	DeletedAt    sql.NullTime          `json:"deleted_at"`
+	Settings     pqtype.NullRawMessage `json:"settings"`
 }
 
 type ChatMessage struct {
@@ -96,18 +97,21 @@ type Directory struct {
 }
 
 type Document struct {
-	ID          uuid.UUID             `json:"id"`
-	WorkspaceID uuid.UUID             `json:"workspace_id"`
-	DirectoryID uuid.NullUUID         `json:"directory_id"`
-	FileID      uuid.UUID             `json:"file_id"`
-	Name        string                `json:"name"`
-	Tags        []string              `json:"tags"`
-	Metadata    pqtype.NullRawMessage `json:"metadata"`
-	Status      string                `json:"status"`
-	ProcessedAt sql.NullTime          `json:"processed_at"`
-	CreatedAt   time.Time             `json:"created_at"`
-	UpdatedAt   time.Time             `json:"updated_at"`
-	DeletedAt   sql.NullTime          `json:"deleted_at"`
+	ID               uuid.UUID             `json:"id"`
+	WorkspaceID      uuid.UUID             `json:"workspace_id"`
+	DirectoryID      uuid.NullUUID         `json:"directory_id"`
+	FileID           uuid.UUID             `json:"file_id"`
+	Name             string                `json:"name"`
+	Tags             []string              `json:"tags"`
+	Metadata         pqtype.NullRawMessage `json:"metadata"`
+	Status           string                `json:"status"`
+	ProcessedAt      sql.NullTime          `json:"processed_at"`
+	CreatedAt        time.Time             `json:"created_at"`
+	UpdatedAt        time.Time             `json:"updated_at"`
+	DeletedAt        sql.NullTime          `json:"deleted_at"`
+	Progress         pqtype.NullRawMessage `json:"progress"`
+	ErrorMessage     sql.NullString        `json:"error_message"`
+	CurrentVersionID uuid.NullUUID         `json:"current_version_id"`
 }
 
 type DocumentChunk struct {
@@ -119,6 +123,36 @@ type DocumentChunk struct {
 	Metadata      pqtype.NullRawMessage `json:"metadata"`
 	CreatedAt     time.Time             `json:"created_at"`
 	PageNumber    int32                 `json:"page_number"`
+	ContentHash   sql.NullString        `json:"content_hash"`
+	VersionID     uuid.UUID             `json:"version_id"`
+	IsParent      bool                  `json:"is_parent"`
+	ParentID      uuid.NullUUID         `json:"parent_id"`
+}
+
+type DocumentJob struct {
+	ID          uuid.UUID             `json:"id"`
+	WorkspaceID uuid.UUID             `json:"workspace_id"`
+	DocumentID  uuid.UUID             `json:"document_id"`
+	Status      string                `json:"status"`
+	Options     pqtype.NullRawMessage `json:"options"`
+	Attempts    int32                 `json:"attempts"`
+	MaxAttempts int32                 `json:"max_attempts"`
+	LastError   sql.NullString        `json:"last_error"`
+	RunAt       time.Time             `json:"run_at"`
+	LockedAt    sql.NullTime          `json:"locked_at"`
+	LockedBy    sql.NullString        `json:"locked_by"`
+	FinishedAt  sql.NullTime          `json:"finished_at"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+}
+
+type DocumentVersion struct {
+	ID            uuid.UUID `json:"id"`
+	DocumentID    uuid.UUID `json:"document_id"`
+	VersionNumber int32     `json:"version_number"`
+	FileID        uuid.UUID `json:"file_id"`
+	FileName      string    `json:"file_name"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
 type File struct {
@@ -200,6 +234,27 @@ type GraphRelation struct {
 	UpdatedAt      time.Time       `json:"updated_at"`
 }
 
+type UploadSession struct {
+	ID               uuid.UUID      `json:"id"`
+	WorkspaceID      uuid.UUID      `json:"workspace_id"`
+	DirectoryID      uuid.NullUUID  `json:"directory_id"`
+	FileName         string         `json:"file_name"`
+	DeclaredMimeType sql.NullString `json:"declared_mime_type"`
+	MimeType         sql.NullString `json:"mime_type"`
+	Tags             []string       `json:"tags"`
+	SizeBytes        int64          `json:"size_bytes"`
+	UploadOffset     int64          `json:"upload_offset"`
+	PartKeys         []string       `json:"part_keys"`
+	HashState        []byte         `json:"hash_state"`
+	Status           string         `json:"status"`
+	DocumentID       uuid.NullUUID  `json:"document_id"`
+	ExpiresAt        time.Time      `json:"expires_at"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	UploadMethod     string         `json:"upload_method"`
+	ExpectedSha256   sql.NullString `json:"expected_sha256"`
+}
+
 type Workspace struct {
 	ID          uuid.UUID             `json:"id"`
 	Name        string                `json:"name"`
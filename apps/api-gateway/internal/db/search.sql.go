@@ -11,8 +11,75 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"github.com/sqlc-dev/pqtype"
 )
 
+const getChunkWindowsByPointIDs = `-- name: GetChunkWindowsByPointIDs :many
+SELECT
+    COALESCE(c.qdrant_point_id, c.id)::uuid AS point_id,
+    n.id,
+    n.version_id,
+    n.chunk_index,
+    n.page_number,
+    n.content,
+    n.id = c.id AS matched
+FROM document_chunks c
+INNER JOIN document_chunks n
+    ON n.version_id = c.version_id
+   AND NOT n.is_parent
+   AND n.chunk_index BETWEEN c.chunk_index - $1::int AND c.chunk_index + $1::int
+WHERE COALESCE(c.qdrant_point_id, c.id) = ANY($2::uuid[])
+  AND NOT c.is_parent
+ORDER BY point_id, n.chunk_index
+`
+
+type GetChunkWindowsByPointIDsParams struct {
+	WindowSize int32       `json:"window_size"`
+	PointIds   []uuid.UUID `json:"point_ids"`
+}
+
+type GetChunkWindowsByPointIDsRow struct {
+	PointID    uuid.UUID `json:"point_id"`
+	ID         uuid.UUID `json:"id"`
+	VersionID  uuid.UUID `json:"version_id"`
+	ChunkIndex int32     `json:"chunk_index"`
+	PageNumber int32     `json:"page_number"`
+	Content    string    `json:"content"`
+	Matched    bool      `json:"matched"`
+}
+
+// 検索でヒットした子チャンク（VectorStore のポイントID）ごとに、同じバージョンの前後 @window_size 件のチャンクを取得する
+func (q *Queries) GetChunkWindowsByPointIDs(ctx context.Context, arg GetChunkWindowsByPointIDsParams) ([]GetChunkWindowsByPointIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChunkWindowsByPointIDs, arg.WindowSize, pq.Array(arg.PointIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChunkWindowsByPointIDsRow
+	for rows.Next() {
+		var i GetChunkWindowsByPointIDsRow
+		if err := rows.Scan(
+			&i.PointID,
+			&i.ID,
+			&i.VersionID,
+			&i.ChunkIndex,
+			&i.PageNumber,
+			&i.Content,
+			&i.Matched,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getChunksByIDs = `-- name: GetChunksByIDs :many
 
 SELECT 
@@ -105,3 +172,143 @@ func (q *Queries) GetDocumentsByChunkIDs(ctx context.Context, chunkIds []uuid.UU
 	}
 	return items, nil
 }
+
+const getParentChunksByPointIDs = `-- name: GetParentChunksByPointIDs :many
+SELECT
+    COALESCE(c.qdrant_point_id, c.id)::uuid AS point_id,
+    p.id AS parent_id,
+    p.page_number,
+    p.content
+FROM document_chunks c
+INNER JOIN document_chunks p ON p.id = c.parent_id
+WHERE COALESCE(c.qdrant_point_id, c.id) = ANY($1::uuid[])
+  AND NOT c.is_parent
+`
+
+type GetParentChunksByPointIDsRow struct {
+	PointID    uuid.UUID `json:"point_id"`
+	ParentID   uuid.UUID `json:"parent_id"`
+	PageNumber int32     `json:"page_number"`
+	Content    string    `json:"content"`
+}
+
+// 検索でヒットした子チャンク（VectorStore のポイントID）の親チャンクを取得する（親のない子チャンクは含まない）
+func (q *Queries) GetParentChunksByPointIDs(ctx context.Context, pointIds []uuid.UUID) ([]GetParentChunksByPointIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getParentChunksByPointIDs, pq.Array(pointIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetParentChunksByPointIDsRow
+	for rows.Next() {
+		var i GetParentChunksByPointIDsRow
+		if err := rows.Scan(
+			&i.PointID,
+			&i.ParentID,
+			&i.PageNumber,
+			&i.Content,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchChunksLexical = `-- name: SearchChunksLexical :many
+SELECT
+    dc.id,
+    COALESCE(dc.qdrant_point_id, dc.id)::uuid AS point_id,
+    dc.document_id,
+    dc.version_id,
+    dc.chunk_index,
+    dc.page_number,
+    dc.content,
+    dc.metadata,
+    word_similarity($1::text, dc.content)::float8 AS score
+FROM document_chunks dc
+INNER JOIN documents d ON d.id = dc.document_id
+WHERE d.workspace_id = $2
+  AND d.deleted_at IS NULL
+  AND dc.version_id = d.current_version_id
+  AND NOT dc.is_parent
+  AND $1::text <% dc.content
+  AND (cardinality($3::text[]) = 0 OR d.tags && $3::text[])
+  AND (
+      (cardinality($4::uuid[]) = 0 AND cardinality($5::uuid[]) = 0)
+      OR d.id = ANY($4::uuid[])
+      OR d.directory_id = ANY($5::uuid[])
+  )
+ORDER BY score DESC, dc.id
+LIMIT $6
+`
+
+type SearchChunksLexicalParams struct {
+	Query        string      `json:"query"`
+	WorkspaceID  uuid.UUID   `json:"workspace_id"`
+	Tags         []string    `json:"tags"`
+	DocumentIds  []uuid.UUID `json:"document_ids"`
+	DirectoryIds []uuid.UUID `json:"directory_ids"`
+	ResultLimit  int32       `json:"result_limit"`
+}
+
+type SearchChunksLexicalRow struct {
+	ID         uuid.UUID             `json:"id"`
+	PointID    uuid.UUID             `json:"point_id"`
+	DocumentID uuid.UUID             `json:"document_id"`
+	VersionID  uuid.UUID             `json:"version_id"`
+	ChunkIndex int32                 `json:"chunk_index"`
+	PageNumber int32                 `json:"page_number"`
+	Content    string                `json:"content"`
+	Metadata   pqtype.NullRawMessage `json:"metadata"`
+	Score      float64               `json:"score"`
+}
+
+// pg_trgm の word_similarity で、クエリを語句として含むチャンクを検索する（ハイブリッド検索の lexical 側）
+// 絞り込み条件は Qdrant の filter と同じ意味：tags は must、document_ids / directory_ids は should（空配列は制限なし）
+// 現在のバージョンのチャンクだけを対象にする
+func (q *Queries) SearchChunksLexical(ctx context.Context, arg SearchChunksLexicalParams) ([]SearchChunksLexicalRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChunksLexical,
+		arg.Query,
+		arg.WorkspaceID,
+		pq.Array(arg.Tags),
+		pq.Array(arg.DocumentIds),
+		pq.Array(arg.DirectoryIds),
+		arg.ResultLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChunksLexicalRow
+	for rows.Next() {
+		var i SearchChunksLexicalRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PointID,
+			&i.DocumentID,
+			&i.VersionID,
+			&i.ChunkIndex,
+			&i.PageNumber,
+			&i.Content,
+			&i.Metadata,
+			&i.Score,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -113,17 +113,24 @@ UPDATE workspaces
 SET 
     name = $1, 
     description = $2,
+    settings = $3,
     updated_at = now() 
-WHERE id = $3 AND deleted_at IS NULl
+WHERE id = $4 AND deleted_at IS NULl
 `
 
 type UpdateWorkspaceParams struct {
-	Name        string         `json:"name"`
-	Description sql.NullString `json:"description"`
-	ID          uuid.UUID      `json:"id"`
+	Name        string                `json:"name"`
+	Description sql.NullString        `json:"description"`
+	Settings    pqtype.NullRawMessage `json:"settings"`
+	ID          uuid.UUID             `json:"id"`
 }
 
 func (q *Queries) UpdateWorkspace(ctx context.Context, arg UpdateWorkspaceParams) error {
-	_, err := q.db.ExecContext(ctx, updateWorkspace, arg.Name, arg.Description, arg.ID)
+	_, err := q.db.ExecContext(ctx, updateWorkspace,
+		arg.Name,
+		arg.Description,
+		arg.Settings,
+		arg.ID,
+	)
 	return err
 }
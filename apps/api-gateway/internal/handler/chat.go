@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"log"
@@ -51,10 +52,31 @@ func (h *Handler) CreateChat(
 		}
 	}
 
+	settings := pqtype.NullRawMessage{
+		Valid: false,
+	}
+
+	if reqBody.Settings != nil {
+		if msg := validateChatSettings(reqBody.Settings); msg != "" {
+			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", msg)
+			return
+		}
+		settingsJSON, err := json.Marshal(reqBody.Settings)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid settings")
+			return
+		}
+		settings = pqtype.NullRawMessage{
+			RawMessage: settingsJSON,
+			Valid:      true,
+		}
+	}
+
 	chat, err := h.queries.CreateChat(ctx, db.CreateChatParams{
 		WorkspaceID:  workspaceId,
 		Title:        reqBody.Title,
 		FilterConfig: filterConfig,
+		Settings:     settings,
 	})
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "DB_ERROR", "Failed to create chat")
@@ -248,6 +270,12 @@ func (h *Handler) SendMessage(
 		return
 	}
 
+	// Accept: text/event-stream の場合は SSE でストリーミング応答する
+	if acceptsEventStream(r) {
+		h.sendMessageStream(w, r, workspaceId, chatId, reqBody.Content)
+		return
+	}
+
 	tx, err := h.db.BeginTx(ctx, nil)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "DB_ERROR", "Failed to start transaction")
@@ -275,7 +303,7 @@ func (h *Handler) SendMessage(
 		return
 	}
 
-	assistantContent, documentRefs, err := h.chatService.GenerateResponse(ctx, workspaceId, chatId, reqBody.Content)
+	assistantContent, documentRefs, err := h.chatService.GenerateResponse(ctx, workspaceId, chatId, userMessage.ID, reqBody.Content)
 	log.Printf("🧪 [Handler] documentRefs len=%d value=%+v", len(documentRefs), documentRefs)
 	if err != nil {
 		log.Printf("Failed to generate response: %v", err)
@@ -328,6 +356,174 @@ func (h *Handler) SendMessage(
 	})
 }
 
+// ========================================
+// SendMessage (SSE streaming)
+// ========================================
+
+// sendMessageStream は応答を Server-Sent Events で逐次返す。
+// イベント順: user_message → sources → token（複数） → done（失敗時は error）
+// LLM の生成中はトランザクションを保持せず、ユーザーメッセージと
+// アシスタントメッセージをそれぞれ別トランザクションで保存する。
+func (h *Handler) sendMessageStream(
+	w http.ResponseWriter,
+	r *http.Request,
+	workspaceId openapi_types.UUID,
+	chatId openapi_types.UUID,
+	content string,
+) {
+	ctx := r.Context()
+
+	sse, ok := newSSEWriter(w)
+	if !ok {
+		respondError(w, http.StatusInternalServerError, "STREAMING_UNSUPPORTED", "Streaming is not supported")
+		return
+	}
+
+	userMessage, err := h.saveChatMessage(ctx, chatId, "user", content, nil)
+	if err != nil {
+		log.Printf("Failed to save user message: %v", err)
+		respondError(w, http.StatusInternalServerError, "DB_ERROR", "Failed to save user message")
+		return
+	}
+
+	sse.start()
+	if err := sse.send("user_message", messageToAPI(userMessage)); err != nil {
+		log.Printf("Failed to send user_message event: %v", err)
+	}
+
+	streamAssistantResponse(
+		ctx,
+		sse,
+		func(onSources func([]api.DocumentReference) error, onToken func(string) error) (string, []api.DocumentReference, error) {
+			return h.chatService.GenerateResponseStream(ctx, workspaceId, chatId, userMessage.ID, content, onSources, onToken)
+		},
+		func(ctx context.Context, content string, documentRefs []api.DocumentReference) (db.ChatMessage, error) {
+			return h.saveChatMessage(ctx, chatId, "assistant", content, documentRefs)
+		},
+	)
+}
+
+// streamAssistantResponse は generate の sources / token を SSE で送り、
+// 生成が終わったら（クライアントが切断していても）応答を save で保存して done または error で終える。
+// トークンを送った後に LLM 側のエラーで生成が途切れた場合は、途中までの応答を通常の回答として保存せず
+// フォールバックメッセージを保存し、GENERATION_INTERRUPTED の error で終える
+func streamAssistantResponse(
+	ctx context.Context,
+	sse *sseWriter,
+	generate func(onSources func([]api.DocumentReference) error, onToken func(string) error) (string, []api.DocumentReference, error),
+	save func(ctx context.Context, content string, documentRefs []api.DocumentReference) (db.ChatMessage, error),
+) {
+	assistantContent, documentRefs, err := generate(
+		func(refs []api.DocumentReference) error {
+			return sse.send("sources", map[string]interface{}{
+				"document_refs": refs,
+			})
+		},
+		func(token string) error {
+			return sse.send("token", map[string]string{
+				"delta": token,
+			})
+		},
+	)
+	interrupted := false
+	if err != nil {
+		log.Printf("Failed to stream response: %v", err)
+		// クライアント切断以外のエラーはフォールバックメッセージに置き換える
+		// （途中まで送ったトークンは、クライアントに error で破棄してもらう）
+		if ctx.Err() == nil {
+			interrupted = assistantContent != ""
+			assistantContent = "申し訳ございません。応答の生成中にエラーが発生しました。システム管理者に連絡してください。"
+		}
+	}
+
+	// クライアントが切断してもそこまでの応答は保存する
+	saveCtx := context.WithoutCancel(ctx)
+	assistantMessage, err := save(saveCtx, assistantContent, documentRefs)
+	if err != nil {
+		log.Printf("Failed to save assistant message: %v", err)
+		_ = sse.send("error", map[string]string{
+			"code":    "DB_ERROR",
+			"message": "Failed to save assistant message",
+		})
+		return
+	}
+
+	if ctx.Err() != nil {
+		log.Printf("Client disconnected; saved partial assistant message %s", assistantMessage.ID)
+		return
+	}
+
+	if interrupted {
+		_ = sse.send("error", map[string]interface{}{
+			"code":              "GENERATION_INTERRUPTED",
+			"message":           "Response generation was interrupted",
+			"assistant_message": messageToAPI(assistantMessage),
+		})
+		return
+	}
+
+	if err := sse.send("done", map[string]interface{}{
+		"assistant_message": messageToAPI(assistantMessage),
+	}); err != nil {
+		log.Printf("Failed to send done event: %v", err)
+	}
+}
+
+// saveChatMessage はメッセージを末尾のインデックスで保存し、チャットの更新日時を更新する
+func (h *Handler) saveChatMessage(
+	ctx context.Context,
+	chatId openapi_types.UUID,
+	role string,
+	content string,
+	documentRefs []api.DocumentReference,
+) (db.ChatMessage, error) {
+	docRefs := pqtype.NullRawMessage{Valid: false}
+	if len(documentRefs) > 0 {
+		b, err := json.Marshal(documentRefs)
+		if err != nil {
+			return db.ChatMessage{}, err
+		}
+		docRefs = pqtype.NullRawMessage{
+			RawMessage: b,
+			Valid:      true,
+		}
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return db.ChatMessage{}, err
+	}
+	defer tx.Rollback()
+
+	qtx := h.queries.WithTx(tx)
+
+	maxIndex, err := qtx.GetMaxMessageIndex(ctx, chatId)
+	if err != nil {
+		return db.ChatMessage{}, err
+	}
+
+	message, err := qtx.CreateChatMessage(ctx, db.CreateChatMessageParams{
+		ChatID:       chatId,
+		Role:         role,
+		Content:      content,
+		MessageIndex: maxIndex + 1,
+		DocumentRefs: docRefs,
+	})
+	if err != nil {
+		return db.ChatMessage{}, err
+	}
+
+	if err := qtx.UpdateChatTimestamp(ctx, chatId); err != nil {
+		return db.ChatMessage{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return db.ChatMessage{}, err
+	}
+
+	return message, nil
+}
+
 // ========================================
 // Helper Functions
 // ========================================
@@ -341,6 +537,8 @@ func chatToAPI(chat db.Chat, messageCount int64, lastMessage *db.ChatMessage) ap
 		}
 	}
 
+	settings := chatSettingsToAPI(chat.Settings)
+
 	var msgCount *int
 	if messageCount > 0 {
 		count := int(messageCount)
@@ -357,6 +555,7 @@ func chatToAPI(chat db.Chat, messageCount int64, lastMessage *db.ChatMessage) ap
 		WorkspaceId:   chat.WorkspaceID,
 		Title:         chat.Title,
 		FilterConfig:  filterConfig,
+		Settings:      settings,
 		MessageCount:  msgCount,
 		LastMessageAt: lastMessageAt,
 		CreatedAt:     chat.CreatedAt,
@@ -372,6 +571,8 @@ func listChatRowToAPI(chat db.ListChatsRow, messageCount int, lastMessageAt *tim
 		filterConfig = &config
 	}
 
+	settings := chatSettingsToAPI(chat.Settings)
+
 	var msgCount *int
 	if messageCount > 0 {
 		msgCount = &messageCount
@@ -382,6 +583,7 @@ func listChatRowToAPI(chat db.ListChatsRow, messageCount int, lastMessageAt *tim
 		WorkspaceId:   chat.WorkspaceID,
 		Title:         chat.Title,
 		FilterConfig:  filterConfig,
+		Settings:      settings,
 		MessageCount:  msgCount,
 		LastMessageAt: lastMessageAt,
 		CreatedAt:     chat.CreatedAt,
@@ -389,6 +591,39 @@ func listChatRowToAPI(chat db.ListChatsRow, messageCount int, lastMessageAt *tim
 	}
 }
 
+// chatSettingsToAPI は chats.settings (JSONB) を API 型に変換する
+func chatSettingsToAPI(raw pqtype.NullRawMessage) *api.ChatSettings {
+	if !raw.Valid || len(raw.RawMessage) == 0 {
+		return nil
+	}
+	var settings api.ChatSettings
+	if err := json.Unmarshal(raw.RawMessage, &settings); err != nil {
+		return nil
+	}
+	return &settings
+}
+
+// validateChatSettings は設定値の範囲を検証し、問題があればエラーメッセージを返す
+func validateChatSettings(settings *api.ChatSettings) string {
+	if settings.HistoryTurns != nil && (*settings.HistoryTurns < 0 || *settings.HistoryTurns > 20) {
+		return "settings.history_turns must be between 0 and 20"
+	}
+	if settings.HistoryTokenBudget != nil && *settings.HistoryTokenBudget < 0 {
+		return "settings.history_token_budget must be 0 or greater"
+	}
+	if settings.RetrievalMode != nil {
+		switch *settings.RetrievalMode {
+		case api.Dense, api.Lexical, api.Hybrid:
+		default:
+			return "settings.retrieval_mode must be one of dense, lexical or hybrid"
+		}
+	}
+	if (settings.DenseWeight != nil && *settings.DenseWeight < 0) || (settings.LexicalWeight != nil && *settings.LexicalWeight < 0) {
+		return "settings.dense_weight and settings.lexical_weight must be 0 or greater"
+	}
+	return ""
+}
+
 func messageToAPI(msg db.ChatMessage) api.ChatMessage {
 	var docRefs *[]api.DocumentReference
 	if msg.DocumentRefs.Valid {
@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/api"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/google/uuid"
+)
+
+type generateFunc = func(onSources func([]api.DocumentReference) error, onToken func(string) error) (string, []api.DocumentReference, error)
+type saveFunc = func(ctx context.Context, content string, documentRefs []api.DocumentReference) (db.ChatMessage, error)
+
+// newStreamServer は streamAssistantResponse だけを返すテスト用サーバーを起動します。
+// finished は応答を返し終えたときに close される
+func newStreamServer(t *testing.T, generate func(ctx context.Context) generateFunc, save saveFunc) (*httptest.Server, chan struct{}) {
+	t.Helper()
+	finished := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(finished)
+		sse, ok := newSSEWriter(w)
+		if !ok {
+			t.Error("Expected the ResponseWriter to support flushing")
+			return
+		}
+		sse.start()
+		streamAssistantResponse(r.Context(), sse, generate(r.Context()), save)
+	}))
+	t.Cleanup(server.Close)
+	return server, finished
+}
+
+func openStream(t *testing.T, ctx context.Context, url string) (*http.Response, *bufio.Reader) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := (&http.Client{Timeout: 5 * time.Second}).Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected Content-Type text/event-stream, got %q", ct)
+	}
+	return resp, bufio.NewReader(resp.Body)
+}
+
+// readEvent は "event: <name>\ndata: <json>\n\n" の1イベントを読みます
+func readEvent(t *testing.T, br *bufio.Reader) (string, map[string]interface{}) {
+	t.Helper()
+	var lines []string
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read event (got %q so far): %v", lines, err)
+		}
+		if line == "\n" {
+			break
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "event: ") || !strings.HasPrefix(lines[1], "data: ") {
+		t.Fatalf("Expected an event line and a data line, got %q", lines)
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &data); err != nil {
+		t.Fatalf("Expected JSON data, got %q: %v", lines[1], err)
+	}
+	return strings.TrimPrefix(lines[0], "event: "), data
+}
+
+func expectEOF(t *testing.T, br *bufio.Reader) {
+	t.Helper()
+	if rest, err := br.ReadString('\n'); err != io.EOF {
+		t.Fatalf("Expected the stream to end, got %q (%v)", rest, err)
+	}
+}
+
+func savedMessage(content string) db.ChatMessage {
+	return db.ChatMessage{ID: uuid.New(), ChatID: uuid.New(), Role: "assistant", Content: content}
+}
+
+func TestStreamAssistantResponse_FlushesEachTokenAndEndsWithDone(t *testing.T) {
+	tokens := []string{"Hello", " ", "world"}
+	next := make(chan struct{})
+
+	generate := func(ctx context.Context) generateFunc {
+		return func(onSources func([]api.DocumentReference) error, onToken func(string) error) (string, []api.DocumentReference, error) {
+			refs := []api.DocumentReference{{DocumentId: uuid.New(), Score: 0.9}}
+			if err := onSources(refs); err != nil {
+				return "", nil, err
+			}
+			for _, token := range tokens {
+				if err := onToken(token); err != nil {
+					return "", nil, err
+				}
+				// クライアントがこのトークンを受け取るまで次を生成しない（Flush されていなければここで止まる）
+				select {
+				case <-next:
+				case <-time.After(5 * time.Second):
+					return "", nil, errors.New("token was not flushed to the client")
+				}
+			}
+			return strings.Join(tokens, ""), refs, nil
+		}
+	}
+	var savedContent string
+	save := func(ctx context.Context, content string, documentRefs []api.DocumentReference) (db.ChatMessage, error) {
+		savedContent = content
+		if len(documentRefs) != 1 {
+			t.Errorf("Expected the document refs to be saved, got %+v", documentRefs)
+		}
+		return savedMessage(content), nil
+	}
+
+	server, _ := newStreamServer(t, generate, save)
+	_, br := openStream(t, context.Background(), server.URL)
+
+	if event, data := readEvent(t, br); event != "sources" || len(data["document_refs"].([]interface{})) != 1 {
+		t.Fatalf("Expected a sources event first, got %s %v", event, data)
+	}
+	for _, token := range tokens {
+		event, data := readEvent(t, br)
+		if event != "token" || data["delta"] != token {
+			t.Fatalf("Expected token %q, got %s %v", token, event, data)
+		}
+		next <- struct{}{}
+	}
+
+	event, data := readEvent(t, br)
+	if event != "done" {
+		t.Fatalf("Expected a done event, got %s %v", event, data)
+	}
+	if msg := data["assistant_message"].(map[string]interface{}); msg["content"] != "Hello world" {
+		t.Errorf("Expected the saved assistant message in done, got %v", msg)
+	}
+	if savedContent != "Hello world" {
+		t.Errorf("Expected the full response to be saved, got %q", savedContent)
+	}
+	expectEOF(t, br)
+}
+
+func TestStreamAssistantResponse_EndsWithErrorWhenSaveFails(t *testing.T) {
+	generate := func(ctx context.Context) generateFunc {
+		return func(onSources func([]api.DocumentReference) error, onToken func(string) error) (string, []api.DocumentReference, error) {
+			return "", nil, errors.New("ollama is down")
+		}
+	}
+	var savedContent string
+	save := func(ctx context.Context, content string, documentRefs []api.DocumentReference) (db.ChatMessage, error) {
+		savedContent = content
+		return db.ChatMessage{}, errors.New("connection reset")
+	}
+
+	server, _ := newStreamServer(t, generate, save)
+	_, br := openStream(t, context.Background(), server.URL)
+
+	event, data := readEvent(t, br)
+	if event != "error" || data["code"] != "DB_ERROR" {
+		t.Fatalf("Expected an error event, got %s %v", event, data)
+	}
+	// 生成に失敗して何も無ければフォールバックメッセージを保存しようとする
+	if savedContent == "" {
+		t.Error("Expected a fallback message to be saved when generation fails")
+	}
+	expectEOF(t, br)
+}
+
+func TestStreamAssistantResponse_SavesPartialResponseOnDisconnect(t *testing.T) {
+	generate := func(ctx context.Context) generateFunc {
+		return func(onSources func([]api.DocumentReference) error, onToken func(string) error) (string, []api.DocumentReference, error) {
+			if err := onToken("partial"); err != nil {
+				return "", nil, err
+			}
+			// クライアントが切断するまで生成を続けているつもりで待つ
+			<-ctx.Done()
+			return "partial", nil, ctx.Err()
+		}
+	}
+	type saved struct {
+		content string
+		ctxErr  error
+	}
+	savedCh := make(chan saved, 1)
+	save := func(ctx context.Context, content string, documentRefs []api.DocumentReference) (db.ChatMessage, error) {
+		savedCh <- saved{content: content, ctxErr: ctx.Err()}
+		return savedMessage(content), nil
+	}
+
+	server, finished := newStreamServer(t, generate, save)
+	ctx, disconnect := context.WithCancel(context.Background())
+	resp, br := openStream(t, ctx, server.URL)
+
+	if event, data := readEvent(t, br); event != "token" || data["delta"] != "partial" {
+		t.Fatalf("Expected the first token, got %s %v", event, data)
+	}
+	disconnect()
+	resp.Body.Close()
+
+	select {
+	case s := <-savedCh:
+		if s.content != "partial" {
+			t.Errorf("Expected the partial response to be saved, got %q", s.content)
+		}
+		// 保存はリクエストのキャンセルに巻き込まれない
+		if s.ctxErr != nil {
+			t.Errorf("Expected the save context to outlive the request, got %v", s.ctxErr)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the response to be saved after the client disconnected")
+	}
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the handler to return after the client disconnected")
+	}
+}
+
+func TestStreamAssistantResponse_EndsWithErrorWhenGenerationIsInterrupted(t *testing.T) {
+	generate := func(ctx context.Context) generateFunc {
+		return func(onSources func([]api.DocumentReference) error, onToken func(string) error) (string, []api.DocumentReference, error) {
+			if err := onToken("途中まで"); err != nil {
+				return "", nil, err
+			}
+			return "途中まで", nil, errors.New("ollama stream error: model crashed")
+		}
+	}
+	var savedContent string
+	save := func(ctx context.Context, content string, documentRefs []api.DocumentReference) (db.ChatMessage, error) {
+		savedContent = content
+		return savedMessage(content), nil
+	}
+
+	server, _ := newStreamServer(t, generate, save)
+	_, br := openStream(t, context.Background(), server.URL)
+
+	if event, data := readEvent(t, br); event != "token" || data["delta"] != "途中まで" {
+		t.Fatalf("Expected the first token, got %s %v", event, data)
+	}
+	// done ではなく error で終え、クライアントに受信済みのトークンを破棄させる
+	event, data := readEvent(t, br)
+	if event != "error" || data["code"] != "GENERATION_INTERRUPTED" {
+		t.Fatalf("Expected a GENERATION_INTERRUPTED error event, got %s %v", event, data)
+	}
+	// 途中までの応答を通常の回答として保存しない
+	if savedContent == "" || strings.Contains(savedContent, "途中まで") {
+		t.Errorf("Expected the fallback message to be saved instead of the truncated response, got %q", savedContent)
+	}
+	if msg, ok := data["assistant_message"].(map[string]interface{}); !ok || msg["content"] != savedContent {
+		t.Errorf("Expected the saved fallback message in the error event, got %v", data["assistant_message"])
+	}
+	expectEOF(t, br)
+}
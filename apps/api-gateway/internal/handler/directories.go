@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/api"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/service"
+	"github.com/google/uuid"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+// ListDirectories implements GET /workspaces/{workspaceId}/directories
+func (h *Handler) ListDirectories(
+	w http.ResponseWriter,
+	r *http.Request,
+	workspaceId openapi_types.UUID,
+	params api.ListDirectoriesParams,
+) {
+	tree, err := h.directoryService.ListDirectoryTree(r.Context(), uuid.UUID(workspaceId), (*uuid.UUID)(params.RootId))
+	if err != nil {
+		writeDirectoryError(w, "failed to list directories", err)
+		return
+	}
+
+	directories := make([]api.Directory, len(tree))
+	for i, node := range tree {
+		directories[i] = toAPIDirectory(node)
+	}
+	respondJSON(w, http.StatusOK, api.DirectoryTree{Directories: directories})
+}
+
+// CreateDirectory implements POST /workspaces/{workspaceId}/directories
+func (h *Handler) CreateDirectory(
+	w http.ResponseWriter,
+	r *http.Request,
+	workspaceId openapi_types.UUID,
+) {
+	var req api.CreateDirectoryJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	dir, err := h.directoryService.CreateDirectory(r.Context(), uuid.UUID(workspaceId), req.Name, (*uuid.UUID)(req.ParentId))
+	if err != nil {
+		writeDirectoryError(w, "failed to create directory", err)
+		return
+	}
+
+	w.Header().Set("Location", r.URL.Path+"/"+dir.ID.String())
+	respondJSON(w, http.StatusCreated, toAPIDirectory(dir))
+}
+
+// GetDirectory implements GET /workspaces/{workspaceId}/directories/{directoryId}
+func (h *Handler) GetDirectory(
+	w http.ResponseWriter,
+	r *http.Request,
+	workspaceId openapi_types.UUID,
+	directoryId openapi_types.UUID,
+) {
+	dir, err := h.directoryService.GetDirectory(r.Context(), uuid.UUID(workspaceId), uuid.UUID(directoryId))
+	if err != nil {
+		writeDirectoryError(w, "failed to get directory", err)
+		return
+	}
+	respondJSON(w, http.StatusOK, toAPIDirectory(dir))
+}
+
+// RenameDirectory implements PATCH /workspaces/{workspaceId}/directories/{directoryId}
+func (h *Handler) RenameDirectory(
+	w http.ResponseWriter,
+	r *http.Request,
+	workspaceId openapi_types.UUID,
+	directoryId openapi_types.UUID,
+) {
+	var req api.RenameDirectoryJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	dir, err := h.directoryService.RenameDirectory(r.Context(), uuid.UUID(workspaceId), uuid.UUID(directoryId), req.Name)
+	if err != nil {
+		writeDirectoryError(w, "failed to rename directory", err)
+		return
+	}
+	respondJSON(w, http.StatusOK, toAPIDirectory(dir))
+}
+
+// MoveDirectory implements POST /workspaces/{workspaceId}/directories/{directoryId}/move
+func (h *Handler) MoveDirectory(
+	w http.ResponseWriter,
+	r *http.Request,
+	workspaceId openapi_types.UUID,
+	directoryId openapi_types.UUID,
+) {
+	var req api.MoveDirectoryJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	dir, err := h.directoryService.MoveDirectory(r.Context(), uuid.UUID(workspaceId), uuid.UUID(directoryId), (*uuid.UUID)(req.ParentId))
+	if err != nil {
+		writeDirectoryError(w, "failed to move directory", err)
+		return
+	}
+	respondJSON(w, http.StatusOK, toAPIDirectory(dir))
+}
+
+// DeleteDirectory implements DELETE /workspaces/{workspaceId}/directories/{directoryId}
+func (h *Handler) DeleteDirectory(
+	w http.ResponseWriter,
+	r *http.Request,
+	workspaceId openapi_types.UUID,
+	directoryId openapi_types.UUID,
+) {
+	result, err := h.directoryService.DeleteDirectory(r.Context(), uuid.UUID(workspaceId), uuid.UUID(directoryId))
+	if err != nil {
+		writeDirectoryError(w, "failed to delete directory", err)
+		return
+	}
+	respondJSON(w, http.StatusOK, api.DirectoryDeleteResult{
+		DeletedDirectories: result.DeletedDirectories,
+		DeletedDocuments:   result.DeletedDocuments,
+	})
+}
+
+// MoveDocuments implements POST /workspaces/{workspaceId}/documents/move
+func (h *Handler) MoveDocuments(
+	w http.ResponseWriter,
+	r *http.Request,
+	workspaceId openapi_types.UUID,
+) {
+	var req api.MoveDocumentsJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+	if len(req.DocumentIds) == 0 {
+		writeError(w, http.StatusBadRequest, "documentIds is required", nil)
+		return
+	}
+
+	documentIDs := make([]uuid.UUID, len(req.DocumentIds))
+	for i, id := range req.DocumentIds {
+		documentIDs[i] = uuid.UUID(id)
+	}
+
+	moved, err := h.directoryService.MoveDocuments(r.Context(), uuid.UUID(workspaceId), documentIDs, (*uuid.UUID)(req.DirectoryId))
+	if err != nil {
+		writeDirectoryError(w, "failed to move documents", err)
+		return
+	}
+
+	movedIDs := make([]openapi_types.UUID, len(moved))
+	for i, id := range moved {
+		movedIDs[i] = openapi_types.UUID(id)
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{"movedDocumentIds": movedIDs})
+}
+
+// writeDirectoryError はサービスのエラーを HTTP ステータスに変換して返します
+func writeDirectoryError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrDirectoryNotFound):
+		writeError(w, http.StatusNotFound, message, err)
+	case errors.Is(err, service.ErrDirectoryNameConflict):
+		writeError(w, http.StatusConflict, message, err)
+	case errors.Is(err, service.ErrDirectoryCycle), errors.Is(err, service.ErrInvalidDirectoryName):
+		writeError(w, http.StatusBadRequest, message, err)
+	default:
+		writeError(w, http.StatusInternalServerError, message, err)
+	}
+}
+
+func toAPIDirectory(node *service.DirectoryNode) api.Directory {
+	dir := api.Directory{
+		Id:        openapi_types.UUID(node.ID),
+		ParentId:  (*openapi_types.UUID)(node.ParentID),
+		Name:      node.Name,
+		CreatedAt: node.CreatedAt,
+		UpdatedAt: node.UpdatedAt,
+	}
+	if node.Children != nil {
+		children := make([]api.Directory, len(node.Children))
+		for i, child := range node.Children {
+			children[i] = toAPIDirectory(child)
+		}
+		dir.Children = &children
+		count := node.DocumentCount
+		dir.DocumentCount = &count
+	}
+	return dir
+}
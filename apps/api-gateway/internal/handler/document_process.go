@@ -10,7 +10,7 @@ import (
 	openapi_types "github.com/oapi-codegen/runtime/types"
 )
 
-// ProcessDocument は、ドキュメントの処理を開始します
+// ProcessDocument は、ドキュメントの処理ジョブを登録します（処理自体はワーカーが非同期に実行）
 func (h *Handler) ProcessDocument(
 	w http.ResponseWriter,
 	r *http.Request,
@@ -21,9 +21,11 @@ func (h *Handler) ProcessDocument(
 
 	// Step 1: リクエストボディを読み込む（オプション）
 	var opts struct {
-		ChunkSize      *int  `json:"chunk_size"`
-		ChunkOverlap   *int  `json:"chunk_overlap"`
-		ForceReprocess *bool `json:"force_reprocess"`
+		ChunkSize       *int    `json:"chunk_size"`
+		ChunkOverlap    *int    `json:"chunk_overlap"`
+		ChunkStrategy   *string `json:"chunk_strategy"`
+		ParentChunkSize *int    `json:"parent_chunk_size"`
+		ForceReprocess  *bool   `json:"force_reprocess"`
 	}
 
 	// リクエストボディがあれば読み込む
@@ -35,47 +37,68 @@ func (h *Handler) ProcessDocument(
 	}
 
 	// デフォルト値を設定
-	chunkSize := 500
+	chunkSize := service.DefaultChunkSize
 	if opts.ChunkSize != nil {
 		chunkSize = *opts.ChunkSize
 	}
 
-	chunkOverlap := 50
+	chunkOverlap := service.DefaultChunkOverlap
 	if opts.ChunkOverlap != nil {
 		chunkOverlap = *opts.ChunkOverlap
 	}
 
+	chunkStrategy := service.DefaultChunkStrategy
+	if opts.ChunkStrategy != nil {
+		chunkStrategy = service.ChunkStrategy(*opts.ChunkStrategy)
+		if !chunkStrategy.Valid() {
+			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Unknown chunk_strategy")
+			return
+		}
+	}
+
+	// 0 なら親チャンクを作らない（検索でヒットした子チャンクをそのまま LLM に渡す）
+	parentChunkSize := service.DefaultParentChunkSize
+	if opts.ParentChunkSize != nil {
+		parentChunkSize = *opts.ParentChunkSize
+		if parentChunkSize < 0 {
+			parentChunkSize = 0
+		}
+	}
+
 	forceReprocess := false
 	if opts.ForceReprocess != nil {
 		forceReprocess = *opts.ForceReprocess
 	}
 
-	// Step 2: Service層を呼ぶ
+	// Step 2: ジョブキューに登録
 	processOpts := service.ProcessOptions{
-		ChunkSize:      chunkSize,
-		ChunkOverlap:   chunkOverlap,
-		ForceReprocess: forceReprocess,
+		ChunkSize:       chunkSize,
+		ChunkOverlap:    chunkOverlap,
+		ChunkStrategy:   chunkStrategy,
+		ParentChunkSize: parentChunkSize,
+		ForceReprocess:  forceReprocess,
 	}
 
-	err := h.documentProcessor.ProcessDocument(ctx, workspaceId, documentId, processOpts)
+	job, err := h.jobQueue.Enqueue(ctx, workspaceId, documentId, processOpts)
 	if err != nil {
-		log.Printf("Failed to process document: %v", err)
+		log.Printf("Failed to enqueue document processing: %v", err)
 
 		switch err {
 		case service.ErrDocumentNotFound:
 			respondError(w, http.StatusNotFound, "DOCUMENT_NOT_FOUND", "Document not found")
 		case service.ErrAlreadyProcessing:
-			respondError(w, http.StatusConflict, "ALREADY_PROCESSING", "Document is already being processed")
+			respondError(w, http.StatusConflict, "ALREADY_PROCESSING", "Document is already queued or being processed")
 		default:
-			respondError(w, http.StatusInternalServerError, "PROCESSING_ERROR", "Failed to process document")
+			respondError(w, http.StatusInternalServerError, "PROCESSING_ERROR", "Failed to enqueue document processing")
 		}
 		return
 	}
 
-	// Step 3: レスポンスを返す（202 Accepted）
-	respondJSON(w, http.StatusAccepted, map[string]string{
-		"status":  "processing",
-		"message": "Document processing started successfully",
+	// Step 3: レスポンスを返す（202 Accepted：処理は GET /status で追跡）
+	respondJSON(w, http.StatusAccepted, map[string]interface{}{
+		"status":  "queued",
+		"job_id":  job.ID,
+		"message": "Document processing queued",
 	})
 }
 
@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/api"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/service"
+	"github.com/google/uuid"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+// ListFileVersions implements GET /workspaces/{workspaceId}/files/{fileId}/versions
+func (h *Handler) ListFileVersions(
+	w http.ResponseWriter,
+	r *http.Request,
+	workspaceId openapi_types.UUID,
+	fileId openapi_types.UUID,
+) {
+	versions, err := h.versionService.ListVersions(r.Context(), uuid.UUID(workspaceId), uuid.UUID(fileId))
+	if err != nil {
+		writeVersionError(w, "failed to list versions", err)
+		return
+	}
+
+	resp := api.FileVersionListResponse{Versions: make([]api.FileVersion, len(versions))}
+	for i := range versions {
+		resp.Versions[i] = toAPIFileVersion(&versions[i])
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// UploadFileVersion implements POST /workspaces/{workspaceId}/files/{fileId}/versions
+// The file part is streamed to storage like UploadFile; the new version becomes current once processed
+func (h *Handler) UploadFileVersion(
+	w http.ResponseWriter,
+	r *http.Request,
+	workspaceId openapi_types.UUID,
+	fileId openapi_types.UUID,
+) {
+	ctx := r.Context()
+
+	reader, ok := h.uploadMultipartReader(w, r, uuid.UUID(workspaceId))
+	if !ok {
+		return
+	}
+
+	var staged *service.StagedUpload
+	completed := false
+	defer func() {
+		if staged != nil && !completed {
+			_ = h.fileService.AbortUpload(ctx, staged)
+		}
+	}()
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeUploadError(w, http.StatusBadRequest, "failed to parse form", err)
+			return
+		}
+
+		if part.FormName() == "file" {
+			if staged != nil {
+				writeError(w, http.StatusBadRequest, "only one file can be uploaded per request", nil)
+				return
+			}
+			staged, err = h.fileService.StageUpload(
+				ctx,
+				uuid.UUID(workspaceId),
+				part.FileName(),
+				part.Header.Get("Content-Type"),
+				part,
+			)
+			if err != nil {
+				writeUploadError(w, http.StatusInternalServerError, "upload failed", err)
+				return
+			}
+		}
+		part.Close()
+	}
+
+	if staged == nil {
+		writeError(w, http.StatusBadRequest, "file field required", nil)
+		return
+	}
+
+	version, err := h.fileService.CompleteVersionUpload(ctx, staged, uuid.UUID(fileId))
+	if err != nil {
+		writeVersionError(w, "upload failed", err)
+		return
+	}
+	completed = true
+
+	respondJSON(w, http.StatusCreated, toAPIFileVersion(version))
+}
+
+// DiffFileVersions implements GET /workspaces/{workspaceId}/files/{fileId}/versions/diff
+func (h *Handler) DiffFileVersions(
+	w http.ResponseWriter,
+	r *http.Request,
+	workspaceId openapi_types.UUID,
+	fileId openapi_types.UUID,
+	params api.DiffFileVersionsParams,
+) {
+	if params.From < 1 || params.To < 1 {
+		writeError(w, http.StatusBadRequest, "from and to must be version numbers (>= 1)", nil)
+		return
+	}
+
+	diff, err := h.versionService.DiffVersions(
+		r.Context(),
+		uuid.UUID(workspaceId),
+		uuid.UUID(fileId),
+		params.From,
+		params.To,
+	)
+	if err != nil {
+		writeVersionError(w, "failed to diff versions", err)
+		return
+	}
+
+	resp := api.FileVersionDiff{
+		FromVersion:    diff.FromVersion,
+		ToVersion:      diff.ToVersion,
+		Added:          toAPIFileVersionChunks(diff.Added),
+		Removed:        toAPIFileVersionChunks(diff.Removed),
+		UnchangedCount: diff.Unchanged,
+	}
+	if diff.Truncated {
+		resp.Truncated = &diff.Truncated
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// RollbackFileVersion implements POST /workspaces/{workspaceId}/files/{fileId}/versions/{versionNumber}/rollback
+// Returns 200 when the version was activated right away, 202 when it has to be processed first
+func (h *Handler) RollbackFileVersion(
+	w http.ResponseWriter,
+	r *http.Request,
+	workspaceId openapi_types.UUID,
+	fileId openapi_types.UUID,
+	versionNumber int,
+) {
+	result, err := h.versionService.RollbackVersion(
+		r.Context(),
+		uuid.UUID(workspaceId),
+		uuid.UUID(fileId),
+		versionNumber,
+	)
+	if err != nil {
+		writeVersionError(w, "failed to roll back", err)
+		return
+	}
+
+	resp := api.FileVersionRollbackResult{Version: toAPIFileVersion(result.Version)}
+	if result.Job != nil {
+		jobID := openapi_types.UUID(result.Job.ID)
+		resp.JobId = &jobID
+		respondJSON(w, http.StatusAccepted, resp)
+		return
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// writeVersionError maps version service errors to HTTP status codes
+func writeVersionError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrVersionNotFound),
+		errors.Is(err, service.ErrFileNotFound),
+		errors.Is(err, service.ErrDocumentNotFound):
+		writeError(w, http.StatusNotFound, message, err)
+	case errors.Is(err, service.ErrVersionNotProcessed), errors.Is(err, service.ErrAlreadyProcessing):
+		writeError(w, http.StatusConflict, message, err)
+	default:
+		writeError(w, http.StatusInternalServerError, message, err)
+	}
+}
+
+func toAPIFileVersion(v *service.DocumentVersionInfo) api.FileVersion {
+	version := api.FileVersion{
+		Id:            openapi_types.UUID(v.ID),
+		VersionNumber: v.VersionNumber,
+		FileName:      v.FileName,
+		MimeType:      v.MimeType,
+		SizeBytes:     v.SizeBytes,
+		IsCurrent:     v.IsCurrent,
+		ChunkCount:    v.ChunkCount,
+		CreatedAt:     v.CreatedAt,
+	}
+	if v.SHA256Hash != "" {
+		sha := v.SHA256Hash
+		version.Sha256Hash = &sha
+	}
+	return version
+}
+
+func toAPIFileVersionChunks(chunks []service.VersionChunk) []api.FileVersionChunk {
+	result := make([]api.FileVersionChunk, len(chunks))
+	for i, c := range chunks {
+		result[i] = api.FileVersionChunk{
+			ChunkIndex: c.ChunkIndex,
+			PageNumber: c.PageNumber,
+			Content:    c.Content,
+		}
+	}
+	return result
+}
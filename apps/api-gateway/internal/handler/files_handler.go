@@ -1,11 +1,13 @@
 package handler
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
-	"log"
+	"mime/multipart"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/Martin4208/Nexus/apps/api-gateway/internal/api"
@@ -37,93 +39,171 @@ func (h *Handler) ListFiles(
 		directoryID = (*uuid.UUID)(params.DirectoryId)
 	}
 
-	// Call service
-	result, err := h.fileService.ListFiles(r.Context(), uuid.UUID(workspaceId), service.FileListFilter{
+	filter := service.FileListFilter{
 		DirectoryID: directoryID,
 		Limit:       limit,
 		Offset:      offset,
-	})
+	}
+	if params.Tag != nil {
+		filter.Tags = *params.Tag
+	}
+	if params.Metadata != nil {
+		filter.MetadataKeys, filter.Metadata = parseMetadataFilter(*params.Metadata)
+	}
+
+	// Call service
+	result, err := h.fileService.ListFiles(r.Context(), uuid.UUID(workspaceId), filter)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to list files", err)
+		writeFileError(w, "failed to list files", err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(result)
+	files := make([]api.FileMetadata, len(result.Files))
+	for i := range result.Files {
+		files[i] = toAPIFileMetadata(&result.Files[i])
+	}
+	respondJSON(w, http.StatusOK, api.FileListResponse{
+		Files: files,
+		Total: int(result.Total),
+	})
 }
 
+// parseMetadataFilter splits "key" and "key=value" filters into required keys and key-value pairs
+func parseMetadataFilter(values []string) ([]string, map[string]string) {
+	var keys []string
+	pairs := map[string]string{}
+	for _, v := range values {
+		if key, value, ok := strings.Cut(v, "="); ok {
+			pairs[key] = value
+		} else if v != "" {
+			keys = append(keys, v)
+		}
+	}
+	return keys, pairs
+}
+
+// uploadFormOverhead is the allowance for multipart boundaries and form fields on top of the file size limit
+const uploadFormOverhead = 1 << 20
+
+// maxUploadFieldBytes limits non-file form fields (directoryId, tags)
+const maxUploadFieldBytes = 64 << 10
+
 // UploadFile implements POST /workspaces/{workspaceId}/files/upload
+// The file part is streamed straight to storage without buffering it in memory or on disk
 func (h *Handler) UploadFile(
 	w http.ResponseWriter,
 	r *http.Request,
 	workspaceId openapi_types.UUID,
 ) {
-	// Parse multipart form (100MB max)
-	err := r.ParseMultipartForm(100 * 1024 * 1024)
-	if err != nil {
-		writeError(w, http.StatusBadRequest, "failed to parse form", err)
-		return
-	}
+	ctx := r.Context()
 
-	// Get file from form
-	file, header, err := r.FormFile("file")
-	if err != nil {
-		writeError(w, http.StatusBadRequest, "file field required", err)
+	reader, ok := h.uploadMultipartReader(w, r, uuid.UUID(workspaceId))
+	if !ok {
 		return
 	}
-	defer file.Close()
 
-	// Parse optional directoryId
-	var directoryID *uuid.UUID
-	if dirIDStr := r.FormValue("directoryId"); dirIDStr != "" {
-		if parsed, err := uuid.Parse(dirIDStr); err == nil {
-			directoryID = &parsed
+	// Read parts in order; directoryId and tags may come before or after the file
+	var (
+		staged      *service.StagedUpload
+		directoryID *uuid.UUID
+		tags        []string
+	)
+	completed := false
+	defer func() {
+		if staged != nil && !completed {
+			_ = h.fileService.AbortUpload(ctx, staged)
+		}
+	}()
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
 		}
+		if err != nil {
+			writeUploadError(w, http.StatusBadRequest, "failed to parse form", err)
+			return
+		}
+
+		switch part.FormName() {
+		case "file":
+			if staged != nil {
+				writeError(w, http.StatusBadRequest, "only one file can be uploaded per request", nil)
+				return
+			}
+			staged, err = h.fileService.StageUpload(
+				ctx,
+				uuid.UUID(workspaceId),
+				part.FileName(),
+				part.Header.Get("Content-Type"),
+				part,
+			)
+			if err != nil {
+				writeUploadError(w, http.StatusInternalServerError, "upload failed", err)
+				return
+			}
+		case "directoryId":
+			// Parse optional directoryId
+			if value := readFormValue(part); value != "" {
+				if parsed, err := uuid.Parse(value); err == nil {
+					directoryID = &parsed
+				}
+			}
+		case "tags":
+			// Parse optional tags
+			if value := readFormValue(part); value != "" {
+				json.Unmarshal([]byte(value), &tags)
+			}
+		}
+		part.Close()
 	}
 
-	// Parse optional tags
-	var tags []string
-	if tagsStr := r.FormValue("tags"); tagsStr != "" {
-		json.Unmarshal([]byte(tagsStr), &tags)
+	if staged == nil {
+		writeError(w, http.StatusBadRequest, "file field required", nil)
+		return
 	}
 
 	// Call service
-	result, err := h.fileService.UploadFile(
-		r.Context(),
-		uuid.UUID(workspaceId),
-		file,
-		header,
-		directoryID,
-		tags,
-	)
+	result, err := h.fileService.CompleteUpload(ctx, staged, directoryID, tags)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "upload failed", err)
 		return
 	}
+	completed = true
 
-	go func() {
-		processCtx := context.Background() // 別のcontextで非同期実行
+	writeFileUploadResponse(w, result)
+}
 
-		opts := service.ProcessOptions{
-			ChunkSize:      500,
-			ChunkOverlap:   50,
-			ForceReprocess: false,
-		}
+// uploadMultipartReader rejects uploads over the workspace size limit before reading the body,
+// caps the body at the limit and returns its multipart reader. On failure the error response
+// has been written and ok is false
+func (h *Handler) uploadMultipartReader(
+	w http.ResponseWriter,
+	r *http.Request,
+	workspaceID uuid.UUID,
+) (reader *multipart.Reader, ok bool) {
+	limit, err := h.fileService.UploadSizeLimit(r.Context(), workspaceID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "upload failed", err)
+		return nil, false
+	}
+	if r.ContentLength > limit+uploadFormOverhead {
+		writeError(w, http.StatusRequestEntityTooLarge, "file too large",
+			fmt.Errorf("%w: limit is %d bytes", service.ErrUploadTooLarge, limit))
+		return nil, false
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, limit+uploadFormOverhead)
 
-		err := h.documentProcessor.ProcessDocument(
-			processCtx,
-			uuid.UUID(workspaceId),
-			result.ID,
-			opts,
-		)
-		if err != nil {
-			log.Printf("Failed to process document %s: %v", result.ID, err)
-		} else {
-			log.Printf("Successfully processed document %s", result.ID)
-		}
-	}()
+	reader, err = r.MultipartReader()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to parse form", err)
+		return nil, false
+	}
+	return reader, true
+}
 
+// writeFileUploadResponse writes 201 with the uploaded file
+func writeFileUploadResponse(w http.ResponseWriter, result *service.FileUploadResponse) {
 	createdAt, err := time.Parse(time.RFC3339, result.CreatedAt)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "invalid createdAt", err)
@@ -146,6 +226,25 @@ func (h *Handler) UploadFile(
 	json.NewEncoder(w).Encode(resp)
 }
 
+// writeUploadError maps upload errors to 413 (too large) and 415 (rejected type), otherwise uses status
+func writeUploadError(w http.ResponseWriter, status int, message string, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, service.ErrUploadTooLarge) || errors.As(err, &maxBytesErr):
+		writeError(w, http.StatusRequestEntityTooLarge, "file too large", err)
+	case errors.Is(err, service.ErrMIMETypeMismatch) || errors.Is(err, service.ErrMIMETypeNotAllowed):
+		writeError(w, http.StatusUnsupportedMediaType, "unsupported file type", err)
+	default:
+		writeError(w, status, message, err)
+	}
+}
+
+// readFormValue reads a small non-file form field
+func readFormValue(part io.Reader) string {
+	value, _ := io.ReadAll(io.LimitReader(part, maxUploadFieldBytes))
+	return string(value)
+}
+
 // GetFile implements GET /workspaces/{workspaceId}/files/{fileId}
 func (h *Handler) GetFile(
 	w http.ResponseWriter,
@@ -159,9 +258,94 @@ func (h *Handler) GetFile(
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(result)
+	respondJSON(w, http.StatusOK, toAPIFileMetadata(result))
+}
+
+// UpdateFile implements PATCH /workspaces/{workspaceId}/files/{fileId}
+func (h *Handler) UpdateFile(
+	w http.ResponseWriter,
+	r *http.Request,
+	workspaceId openapi_types.UUID,
+	fileId openapi_types.UUID,
+) {
+	var req api.UpdateFileJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	input := service.UpdateFileRequest{
+		Name: req.Name,
+		Tags: req.Tags,
+	}
+	if req.Metadata != nil {
+		input.Metadata = *req.Metadata
+	}
+
+	result, err := h.fileService.UpdateFile(r.Context(), uuid.UUID(workspaceId), uuid.UUID(fileId), input)
+	if err != nil {
+		writeFileError(w, "failed to update file", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, toAPIFileMetadata(result))
+}
+
+// ListTags implements GET /workspaces/{workspaceId}/tags
+func (h *Handler) ListTags(
+	w http.ResponseWriter,
+	r *http.Request,
+	workspaceId openapi_types.UUID,
+) {
+	tags, err := h.fileService.ListTags(r.Context(), uuid.UUID(workspaceId))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list tags", err)
+		return
+	}
+
+	resp := api.TagListResponse{Tags: make([]api.TagCount, len(tags))}
+	for i, t := range tags {
+		resp.Tags[i] = api.TagCount{Tag: t.Tag, FileCount: t.FileCount}
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// writeFileError maps file errors to 404 (not found) and 400 (invalid attributes)
+func writeFileError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrFileNotFound):
+		writeError(w, http.StatusNotFound, message, err)
+	case errors.Is(err, service.ErrInvalidFileAttributes):
+		writeError(w, http.StatusBadRequest, message, err)
+	default:
+		writeError(w, http.StatusInternalServerError, message, err)
+	}
+}
+
+// toAPIFileMetadata converts the service response to the API schema
+func toAPIFileMetadata(f *service.FileMetadataResponse) api.FileMetadata {
+	file := api.FileMetadata{
+		Id:        f.ID,
+		FileName:  f.FileName,
+		MimeType:  f.MimeType,
+		SizeBytes: f.SizeBytes,
+		Tags:      &f.Tags,
+	}
+	if createdAt, err := time.Parse(time.RFC3339, f.CreatedAt); err == nil {
+		file.CreatedAt = createdAt
+	}
+	if f.SHA256Hash != "" {
+		sha := f.SHA256Hash
+		file.Sha256Hash = &sha
+	}
+	if f.Status != "" {
+		status := api.FileMetadataStatus(f.Status)
+		file.Status = &status
+	}
+	if f.Metadata != nil {
+		file.Metadata = &f.Metadata
+	}
+	return file
 }
 
 // DeleteFile implements DELETE /workspaces/{workspaceId}/files/{fileId}
@@ -198,3 +382,23 @@ func (h *Handler) DownloadFile(
 	w.WriteHeader(http.StatusOK)
 	io.Copy(w, reader)
 }
+
+// GetFileDownloadUrl implements GET /workspaces/{workspaceId}/files/{fileId}/download-url
+func (h *Handler) GetFileDownloadUrl(
+	w http.ResponseWriter,
+	r *http.Request,
+	workspaceId openapi_types.UUID,
+	fileId openapi_types.UUID,
+) {
+	download, err := h.fileService.PresignDownload(r.Context(), uuid.UUID(workspaceId), uuid.UUID(fileId))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "file not found", err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	respondJSON(w, http.StatusOK, api.PresignedDownload{
+		Url:       download.URL,
+		ExpiresAt: download.ExpiresAt,
+	})
+}
@@ -13,30 +13,42 @@ type Handler struct {
 	queries           *db.Queries
 	fileService       service.FileService
 	documentProcessor *service.DocumentProcessor
+	jobQueue          *service.DocumentJobQueue
 	searchService     *service.SearchService
 	chatService       *service.ChatService
 	analysisService   *service.AnalysisService
 	sourceService     *service.SourceService
+	blobGC            *service.BlobGC
+	directoryService  *service.DirectoryService
+	versionService    *service.DocumentVersionService
 }
 
 func NewHandler(
 	database *sql.DB,
 	fileService service.FileService,
 	documentProcessor *service.DocumentProcessor,
+	jobQueue *service.DocumentJobQueue,
 	searchService *service.SearchService,
 	chatService *service.ChatService,
 	analysisService *service.AnalysisService,
 	sourceService *service.SourceService,
+	blobGC *service.BlobGC,
+	directoryService *service.DirectoryService,
+	versionService *service.DocumentVersionService,
 ) *Handler {
 	return &Handler{
 		db:                database,
 		queries:           db.New(database),
 		fileService:       fileService,
 		documentProcessor: documentProcessor,
+		jobQueue:          jobQueue,
 		searchService:     searchService,
 		chatService:       chatService,
 		analysisService:   analysisService,
 		sourceService:     sourceService,
+		blobGC:            blobGC,
+		directoryService:  directoryService,
+		versionService:    versionService,
 	}
 }
 
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/api"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/service"
+)
+
+// GetBlobGcReport は GC の削除対象を返します（dry-run、何も削除しない）
+func (h *Handler) GetBlobGcReport(w http.ResponseWriter, r *http.Request) {
+	report, err := h.blobGC.Run(r.Context(), true)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to build blob GC report: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, toAPIBlobGcReport(report))
+}
+
+// RunBlobGc は GC をすぐに実行します
+func (h *Handler) RunBlobGc(w http.ResponseWriter, r *http.Request) {
+	report, err := h.blobGC.Run(r.Context(), false)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "blob GC failed: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, toAPIBlobGcReport(report))
+}
+
+func toAPIBlobGcReport(report *service.BlobGCReport) api.BlobGcReport {
+	files := make([]api.BlobGcFile, len(report.Files))
+	for i, f := range report.Files {
+		files[i] = api.BlobGcFile{
+			FileId:     f.FileID,
+			Sha256Hash: f.SHA256Hash,
+			SizeBytes:  f.SizeBytes,
+			ObjectKey:  f.MinioKey,
+			LastUsedAt: f.LastUsedAt,
+		}
+	}
+	return api.BlobGcReport{
+		DryRun:                report.DryRun,
+		Files:                 files,
+		ReclaimedBytes:        report.ReclaimedBytes,
+		FailedFiles:           report.FailedFiles,
+		ExpiredUploadSessions: report.ExpiredUploadSessions,
+		StartedAt:             report.StartedAt,
+		FinishedAt:            report.FinishedAt,
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 
 	"github.com/Martin4208/Nexus/apps/api-gateway/internal/api"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/service"
 	openapi_types "github.com/oapi-codegen/runtime/types"
 )
 
@@ -38,6 +39,28 @@ func (h *Handler) SearchWorkspace(
 		topK = *reqBody.TopK
 	}
 
+	// 検索方式（未指定は hybrid）
+	retrievalOpts := service.RetrievalOptions{}
+	if reqBody.Mode != nil {
+		retrievalOpts.Mode = service.RetrievalMode(*reqBody.Mode)
+	}
+	if reqBody.DenseWeight != nil {
+		retrievalOpts.DenseWeight = float64(*reqBody.DenseWeight)
+	}
+	if reqBody.LexicalWeight != nil {
+		retrievalOpts.LexicalWeight = float64(*reqBody.LexicalWeight)
+	}
+	if reqBody.ContextExpansion != nil {
+		retrievalOpts.Expansion = service.ContextExpansion(*reqBody.ContextExpansion)
+	}
+	if reqBody.ContextWindow != nil {
+		retrievalOpts.Window = *reqBody.ContextWindow
+	}
+	if err := retrievalOpts.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
 	// Workspace の存在確認
 	_, err := h.queries.GetWorkspace(ctx, workspaceId)
 	if err != nil {
@@ -47,7 +70,7 @@ func (h *Handler) SearchWorkspace(
 
 	// 検索実行
 	log.Printf("Executing search: workspace=%s, query=%s", workspaceId, reqBody.Query)
-	result, err := h.searchService.Search(ctx, workspaceId, reqBody.Query, topK)
+	result, err := h.searchService.Search(ctx, workspaceId, reqBody.Query, topK, retrievalOpts)
 	if err != nil {
 		log.Printf("Search failed: %v", err)
 		respondError(w, http.StatusInternalServerError, "SEARCH_ERROR", "Failed to execute search")
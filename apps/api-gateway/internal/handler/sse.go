@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// sseWriter は Server-Sent Events 形式でイベントを書き出すヘルパー
+type sseWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// newSSEWriter は ResponseWriter が Flush に対応している場合のみ sseWriter を返す
+func newSSEWriter(w http.ResponseWriter) (*sseWriter, bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, false
+	}
+	return &sseWriter{w: w, flusher: flusher}, true
+}
+
+// start は SSE 用のヘッダーを書き込み、ストリームを開始する
+func (s *sseWriter) start() {
+	s.w.Header().Set("Content-Type", "text/event-stream")
+	s.w.Header().Set("Cache-Control", "no-cache")
+	s.w.Header().Set("Connection", "keep-alive")
+	s.w.Header().Set("X-Accel-Buffering", "no") // リバースプロキシでのバッファリングを無効化
+	s.w.WriteHeader(http.StatusOK)
+	s.flusher.Flush()
+}
+
+// send は data を JSON にエンコードして1イベントとして送信する
+func (s *sseWriter) send(event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", event, err)
+	}
+
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// acceptsEventStream はクライアントが SSE でのレスポンスを要求しているか判定する
+func acceptsEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
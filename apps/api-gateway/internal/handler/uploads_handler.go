@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/api"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/service"
+	"github.com/google/uuid"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+// CreateUploadSession implements POST /workspaces/{workspaceId}/uploads
+func (h *Handler) CreateUploadSession(
+	w http.ResponseWriter,
+	r *http.Request,
+	workspaceId openapi_types.UUID,
+) {
+	var req api.CreateUploadSessionJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+	if req.FileName == "" {
+		writeError(w, http.StatusBadRequest, "fileName is required", nil)
+		return
+	}
+	if req.SizeBytes <= 0 {
+		writeError(w, http.StatusBadRequest, "sizeBytes must be positive", nil)
+		return
+	}
+
+	input := service.CreateUploadSessionRequest{
+		FileName:    req.FileName,
+		SizeBytes:   req.SizeBytes,
+		DirectoryID: (*uuid.UUID)(req.DirectoryId),
+	}
+	if req.MimeType != nil {
+		input.MimeType = *req.MimeType
+	}
+	if req.Tags != nil {
+		input.Tags = *req.Tags
+	}
+
+	session, err := h.fileService.CreateUploadSession(r.Context(), uuid.UUID(workspaceId), input)
+	if err != nil {
+		writeUploadSessionError(w, "failed to create upload session", err)
+		return
+	}
+
+	w.Header().Set("Location", r.URL.Path+"/"+session.ID.String())
+	writeUploadSession(w, http.StatusCreated, session)
+}
+
+// CreatePresignedUpload implements POST /workspaces/{workspaceId}/uploads/presigned
+func (h *Handler) CreatePresignedUpload(
+	w http.ResponseWriter,
+	r *http.Request,
+	workspaceId openapi_types.UUID,
+) {
+	var req api.CreatePresignedUploadJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+	if req.FileName == "" {
+		writeError(w, http.StatusBadRequest, "fileName is required", nil)
+		return
+	}
+	if req.SizeBytes <= 0 {
+		writeError(w, http.StatusBadRequest, "sizeBytes must be positive", nil)
+		return
+	}
+	if b, err := hex.DecodeString(req.Sha256Hash); err != nil || len(b) != 32 {
+		writeError(w, http.StatusBadRequest, "sha256Hash must be a hex SHA256", nil)
+		return
+	}
+
+	input := service.CreatePresignedUploadRequest{
+		FileName:    req.FileName,
+		SizeBytes:   req.SizeBytes,
+		SHA256Hash:  req.Sha256Hash,
+		DirectoryID: (*uuid.UUID)(req.DirectoryId),
+	}
+	if req.MimeType != nil {
+		input.MimeType = *req.MimeType
+	}
+	if req.Tags != nil {
+		input.Tags = *req.Tags
+	}
+
+	upload, err := h.fileService.CreatePresignedUpload(r.Context(), uuid.UUID(workspaceId), input)
+	if err != nil {
+		writeUploadSessionError(w, "failed to create presigned upload", err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, api.PresignedUpload{
+		Upload:    toAPIUploadSession(upload.Session),
+		Url:       upload.URL,
+		ExpiresAt: upload.ExpiresAt,
+	})
+}
+
+// GetUploadSession implements GET /workspaces/{workspaceId}/uploads/{uploadId}
+func (h *Handler) GetUploadSession(
+	w http.ResponseWriter,
+	r *http.Request,
+	workspaceId openapi_types.UUID,
+	uploadId openapi_types.UUID,
+) {
+	session, err := h.fileService.GetUploadSession(r.Context(), uuid.UUID(workspaceId), uuid.UUID(uploadId))
+	if err != nil {
+		writeUploadSessionError(w, "failed to get upload session", err)
+		return
+	}
+
+	// 再開位置（Upload-Offset）をキャッシュさせない
+	w.Header().Set("Cache-Control", "no-store")
+	writeUploadSession(w, http.StatusOK, session)
+}
+
+// UploadChunk implements PATCH /workspaces/{workspaceId}/uploads/{uploadId}
+func (h *Handler) UploadChunk(
+	w http.ResponseWriter,
+	r *http.Request,
+	workspaceId openapi_types.UUID,
+	uploadId openapi_types.UUID,
+	params api.UploadChunkParams,
+) {
+	session, err := h.fileService.AppendUploadChunk(
+		r.Context(),
+		uuid.UUID(workspaceId),
+		uuid.UUID(uploadId),
+		params.UploadOffset,
+		r.Body,
+	)
+	if err != nil {
+		writeUploadSessionError(w, "failed to store chunk", err)
+		return
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AbortUploadSession implements DELETE /workspaces/{workspaceId}/uploads/{uploadId}
+func (h *Handler) AbortUploadSession(
+	w http.ResponseWriter,
+	r *http.Request,
+	workspaceId openapi_types.UUID,
+	uploadId openapi_types.UUID,
+) {
+	if err := h.fileService.AbortUploadSession(r.Context(), uuid.UUID(workspaceId), uuid.UUID(uploadId)); err != nil {
+		writeUploadSessionError(w, "failed to abort upload", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CompleteUploadSession implements POST /workspaces/{workspaceId}/uploads/{uploadId}/complete
+func (h *Handler) CompleteUploadSession(
+	w http.ResponseWriter,
+	r *http.Request,
+	workspaceId openapi_types.UUID,
+	uploadId openapi_types.UUID,
+) {
+	result, err := h.fileService.CompleteUploadSession(r.Context(), uuid.UUID(workspaceId), uuid.UUID(uploadId))
+	if err != nil {
+		writeUploadSessionError(w, "upload failed", err)
+		return
+	}
+
+	writeFileUploadResponse(w, result)
+}
+
+// writeUploadSession writes the session as JSON with the current offset in the Upload-Offset header
+func writeUploadSession(w http.ResponseWriter, status int, s *service.UploadSessionInfo) {
+	w.Header().Set("Upload-Offset", strconv.FormatInt(s.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(s.SizeBytes, 10))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(toAPIUploadSession(s))
+}
+
+func toAPIUploadSession(s *service.UploadSessionInfo) api.UploadSession {
+	return api.UploadSession{
+		Id:           s.ID,
+		FileName:     s.FileName,
+		SizeBytes:    s.SizeBytes,
+		Offset:       s.Offset,
+		MimeType:     s.MimeType,
+		Status:       api.UploadSessionStatus(s.Status),
+		MinChunkSize: service.MinUploadChunkSize,
+		DocumentId:   (*openapi_types.UUID)(s.DocumentID),
+		ExpiresAt:    s.ExpiresAt,
+		CreatedAt:    s.CreatedAt,
+	}
+}
+
+// writeUploadSessionError maps resumable upload errors to status codes
+func writeUploadSessionError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrUploadSessionNotFound):
+		writeError(w, http.StatusNotFound, message, err)
+	case errors.Is(err, service.ErrUploadSessionConflict):
+		writeError(w, http.StatusConflict, message, err)
+	case errors.Is(err, service.ErrUploadSessionExpired):
+		writeError(w, http.StatusGone, message, err)
+	case errors.Is(err, service.ErrUploadChunkInvalid):
+		writeError(w, http.StatusBadRequest, message, err)
+	case errors.Is(err, service.ErrUploadVerificationFailed):
+		writeError(w, http.StatusUnprocessableEntity, message, err)
+	default:
+		writeUploadError(w, http.StatusInternalServerError, message, err)
+	}
+}
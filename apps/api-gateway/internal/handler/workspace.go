@@ -7,6 +7,7 @@ import (
 
 	"github.com/Martin4208/Nexus/apps/api-gateway/internal/api"
 	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/service"
 	openapi_types "github.com/oapi-codegen/runtime/types"
 	"github.com/sqlc-dev/pqtype"
 )
@@ -74,10 +75,21 @@ func (h *Handler) CreateWorkspace(w http.ResponseWriter, r *http.Request) {
 		desc = sql.NullString{Valid: false}
 	}
 
+	// settings を検証（allowed_mime_types など）
+	settings, err := mergeWorkspaceSettings(pqtype.NullRawMessage{}, reqBody.Settings)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
 	params := db.CreateWorkspaceParams{
 		Name:        reqBody.Name,
 		Description: desc,
-		Settings:    pqtype.NullRawMessage{Valid: false}, // 今は空
+		Settings:    settings,
 	}
 
 	workspace, err := h.queries.CreateWorkspace(ctx, params)
@@ -174,10 +186,22 @@ func (h *Handler) UpdateWorkspace(w http.ResponseWriter, r *http.Request, worksp
 		desc = existing.Description
 	}
 
+	// settings は指定されたキーだけ上書きする（null のキーは削除）
+	settings, err := mergeWorkspaceSettings(existing.Settings, reqBody.Settings)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
 	params := db.UpdateWorkspaceParams{
 		ID:          workspaceId,
 		Name:        name,
 		Description: desc,
+		Settings:    settings,
 	}
 
 	err = h.queries.UpdateWorkspace(ctx, params)
@@ -225,6 +249,36 @@ func (h *Handler) DeleteWorkspace(w http.ResponseWriter, r *http.Request, worksp
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// mergeWorkspaceSettings は既存の settings に patch のキーを上書きして検証します（patch が nil なら既存のまま）
+func mergeWorkspaceSettings(existing pqtype.NullRawMessage, patch *map[string]interface{}) (pqtype.NullRawMessage, error) {
+	if patch == nil {
+		return existing, nil
+	}
+
+	merged := map[string]interface{}{}
+	if existing.Valid && len(existing.RawMessage) > 0 {
+		if err := json.Unmarshal(existing.RawMessage, &merged); err != nil || merged == nil {
+			merged = map[string]interface{}{}
+		}
+	}
+	for k, v := range *patch {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+		merged[k] = v
+	}
+
+	raw, err := json.Marshal(merged)
+	if err != nil {
+		return existing, err
+	}
+	if _, err := service.ParseWorkspaceSettings(raw); err != nil {
+		return existing, err
+	}
+	return pqtype.NullRawMessage{RawMessage: raw, Valid: true}, nil
+}
+
 func convertToAPIWorkspace(w db.Workspace) api.Workspace {
 	var desc *string
 	if w.Description.Valid {
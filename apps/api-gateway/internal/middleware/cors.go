@@ -16,7 +16,10 @@ func CORS(next http.Handler) http.Handler {
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 
 		// Allow common headers
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, Upload-Offset")
+
+		// Let browsers read the resumable upload headers
+		w.Header().Set("Access-Control-Expose-Headers", "Location, Upload-Offset, Upload-Length")
 
 		// Allow credentials (cookies, authorization headers)
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
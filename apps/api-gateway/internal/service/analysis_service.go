@@ -10,6 +10,7 @@ import (
 
 	"github.com/Martin4208/Nexus/apps/api-gateway/internal/client"
 	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/storage"
 	"github.com/google/uuid"
 	"github.com/sqlc-dev/pqtype"
 )
@@ -18,7 +19,7 @@ import (
 type AnalysisService struct {
 	queries      *db.Queries
 	aiClient     client.AIWorkerClient
-	qdrantClient client.QdrantClient
+	vectorStore  storage.VectorStore
 	ollamaClient client.OllamaClient
 }
 
@@ -26,13 +27,13 @@ type AnalysisService struct {
 func NewAnalysisService(
 	queries *db.Queries,
 	aiClient client.AIWorkerClient,
-	qdrantClient client.QdrantClient,
+	vectorStore storage.VectorStore,
 	ollamaClient client.OllamaClient,
 ) *AnalysisService {
 	return &AnalysisService{
 		queries:      queries,
 		aiClient:     aiClient,
-		qdrantClient: qdrantClient,
+		vectorStore:  vectorStore,
 		ollamaClient: ollamaClient,
 	}
 }
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"expvar"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/storage"
+	"github.com/google/uuid"
+)
+
+// blobGCMetrics は GC の累計値（管理用リスナーの /debug/vars の "blob_gc" で公開）
+var blobGCMetrics = expvar.NewMap("blob_gc")
+
+// BlobGCOptions はファイル実体（files + MinIO オブジェクト）の GC 設定
+type BlobGCOptions struct {
+	Interval    time.Duration // バックグラウンド実行の間隔
+	GracePeriod time.Duration // 最後に使われてからこの期間が過ぎたファイルだけを削除する
+	BatchSize   int           // 1回の実行で処理するファイル / 期限切れセッションの最大数
+}
+
+// withDefaults は未設定の項目をデフォルト値で埋めます
+func (o BlobGCOptions) withDefaults() BlobGCOptions {
+	if o.Interval <= 0 {
+		o.Interval = 6 * time.Hour
+	}
+	if o.GracePeriod <= 0 {
+		o.GracePeriod = 24 * time.Hour
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 500
+	}
+	return o
+}
+
+// BlobGCCandidate は GC の対象になるファイル
+type BlobGCCandidate struct {
+	FileID     uuid.UUID
+	SHA256Hash string
+	SizeBytes  int64
+	MinioKey   string
+	LastUsedAt time.Time
+}
+
+// BlobGCReport は GC 1回分の結果（dry-run の場合は削除対象の一覧）
+type BlobGCReport struct {
+	DryRun                bool
+	Files                 []BlobGCCandidate // 削除した（dry-run では削除対象の）ファイル
+	ReclaimedBytes        int64             // Files のサイズ合計
+	FailedFiles           int               // 削除に失敗した、または判定後に参照が増えて残したファイル数
+	ExpiredUploadSessions int               // 中止した期限切れのアップロードセッション数
+	StartedAt             time.Time
+	FinishedAt            time.Time
+}
+
+// BlobGC は SHA256 で重複排除されたファイル実体の参照カウント GC。
+// DeleteFile は他の document と共有されている可能性があるため files / MinIO オブジェクトを消さない。
+// BlobGC は生きている document から参照されなくなって猶予期間を過ぎたファイルについて、
+// 論理削除済みの document、files 行、MinIO オブジェクトの順に削除する。
+// 期限切れの再開可能アップロードのチャンク（tmp/uploads/）も合わせて掃除する。
+type BlobGC struct {
+	db            *sql.DB
+	queries       *db.Queries
+	storageClient storage.ObjectStorageClient
+	storageBucket string // アップロード中のチャンクを置くバケット
+	vectorStore   storage.VectorStore
+	opts          BlobGCOptions
+	mu            sync.Mutex // 同時に1回だけ実行する（バックグラウンドと手動実行の重複防止）
+}
+
+// NewBlobGC は新しい BlobGC を作成
+func NewBlobGC(
+	database *sql.DB,
+	queries *db.Queries,
+	storageClient storage.ObjectStorageClient,
+	storageBucket string,
+	vectorStore storage.VectorStore,
+	opts BlobGCOptions,
+) *BlobGC {
+	return &BlobGC{
+		db:            database,
+		queries:       queries,
+		storageClient: storageClient,
+		storageBucket: storageBucket,
+		vectorStore:   vectorStore,
+		opts:          opts.withDefaults(),
+	}
+}
+
+// Start は Interval ごとに GC を実行するループをバックグラウンドで起動します（ctx のキャンセルで停止）
+func (g *BlobGC) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(g.opts.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := g.Run(ctx, false); err != nil && ctx.Err() == nil {
+					log.Printf("⚠️ Blob GC failed: %v", err)
+				}
+			}
+		}
+	}()
+
+	log.Printf("🧹 Blob GC started (interval=%s, grace=%s)", g.opts.Interval, g.opts.GracePeriod)
+}
+
+// Run は GC を1回実行します。dryRun の場合は削除対象を返すだけで何も削除しません。
+func (g *BlobGC) Run(ctx context.Context, dryRun bool) (*BlobGCReport, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	report := &BlobGCReport{DryRun: dryRun, StartedAt: time.Now()}
+
+	// Step 1: 参照されていないファイルを取得
+	unusedSince := report.StartedAt.Add(-g.opts.GracePeriod)
+	files, err := g.queries.ListUnreferencedFiles(ctx, db.ListUnreferencedFilesParams{
+		UnusedSince: unusedSince,
+		MaxFiles:    int32(g.opts.BatchSize),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unreferenced files: %w", err)
+	}
+
+	// Step 2: ファイルごとに削除（dry-run では一覧だけ）
+	for _, f := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		candidate := BlobGCCandidate{
+			FileID:     f.ID,
+			SHA256Hash: f.Sha256Hash,
+			SizeBytes:  f.SizeBytes,
+			MinioKey:   f.MinioKey,
+			LastUsedAt: f.LastUsedAt,
+		}
+		if !dryRun {
+			if err := g.deleteFile(ctx, f); err != nil {
+				log.Printf("⚠️ Blob GC skipped file %s: %v", f.ID, err)
+				report.FailedFiles++
+				continue
+			}
+		}
+		report.Files = append(report.Files, candidate)
+		report.ReclaimedBytes += f.SizeBytes
+	}
+
+	// Step 3: 期限切れのアップロードセッションのチャンクを削除
+	sessions, err := g.queries.ListExpiredUploadSessions(ctx, db.ListExpiredUploadSessionsParams{
+		ExpiredBefore: unusedSince,
+		MaxSessions:   int32(g.opts.BatchSize),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired upload sessions: %w", err)
+	}
+	for _, s := range sessions {
+		if !dryRun {
+			if err := g.abortUploadSession(ctx, s); err != nil {
+				log.Printf("⚠️ Blob GC failed to clean up upload session %s: %v", s.ID, err)
+				continue
+			}
+		}
+		report.ExpiredUploadSessions++
+	}
+
+	report.FinishedAt = time.Now()
+
+	if !dryRun {
+		blobGCMetrics.Add("runs", 1)
+		blobGCMetrics.Add("files_reclaimed", int64(len(report.Files)))
+		blobGCMetrics.Add("bytes_reclaimed", report.ReclaimedBytes)
+		blobGCMetrics.Add("files_failed", int64(report.FailedFiles))
+		blobGCMetrics.Add("upload_sessions_expired", int64(report.ExpiredUploadSessions))
+		lastRun := new(expvar.Int)
+		lastRun.Set(report.FinishedAt.Unix())
+		blobGCMetrics.Set("last_run_unix", lastRun)
+
+		log.Printf("🧹 Blob GC finished: files=%d, bytes=%d, failed=%d, expired_uploads=%d (%s)",
+			len(report.Files), report.ReclaimedBytes, report.FailedFiles, report.ExpiredUploadSessions,
+			report.FinishedAt.Sub(report.StartedAt))
+	}
+
+	return report, nil
+}
+
+// deleteFile は論理削除済みの documents と files 行をトランザクションで削除してから MinIO オブジェクトを削除します。
+// 判定後にアップロードの重複排除で参照が増えた場合は、行が削除されないか（0行）外部キー制約でエラーになり、何も消さない。
+func (g *BlobGC) deleteFile(ctx context.Context, f db.ListUnreferencedFilesRow) error {
+	// Step 1: DB から削除（documents.file_id は ON DELETE RESTRICT のため、論理削除済みの documents から消す）
+	tx, err := g.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := g.queries.WithTx(tx)
+	purged, err := qtx.PurgeDeletedDocumentsByFileID(ctx, f.ID)
+	if err != nil {
+		return fmt.Errorf("failed to purge deleted documents: %w", err)
+	}
+	rows, err := qtx.DeleteUnreferencedFile(ctx, f.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete file record: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("file is referenced again")
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+
+	// Step 2: 物理削除した documents のベクトルを削除（失敗はログだけ）
+	for _, doc := range purged {
+		collectionName := fmt.Sprintf("workspace_%s", doc.WorkspaceID.String())
+		err := g.vectorStore.DeleteByFilter(
+			ctx,
+			collectionName,
+			&storage.Filter{Must: []storage.Condition{storage.MatchValue("document_id", doc.ID.String())}},
+		)
+		if err != nil {
+			log.Printf("⚠️ Failed to delete vectors of document %s: %v", doc.ID, err)
+		}
+	}
+
+	// Step 3: MinIO オブジェクトを削除（行は既に無いので、失敗してもオブジェクトが残るだけ）
+	if err := g.storageClient.RemoveObject(ctx, f.MinioBucket, f.MinioKey); err != nil {
+		log.Printf("⚠️ Failed to remove object %s/%s of file %s: %v", f.MinioBucket, f.MinioKey, f.ID, err)
+	}
+
+	log.Printf("🗑️ Blob GC deleted file %s (%d bytes, %d deleted documents)", f.ID, f.SizeBytes, len(purged))
+	return nil
+}
+
+// abortUploadSession は期限切れのアップロードセッションのチャンクを削除して aborted にします
+func (g *BlobGC) abortUploadSession(ctx context.Context, s db.UploadSession) error {
+	// 先に aborted にして、同時に完了処理が始まっていないことを確かめる（0行なら別のリクエストが先に状態を変えた）
+	if s.Status != UploadSessionStatusAborted {
+		rows, err := g.queries.TransitionUploadSession(ctx, db.TransitionUploadSessionParams{
+			ToStatus:   UploadSessionStatusAborted,
+			ID:         s.ID,
+			FromStatus: s.Status,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to abort upload session: %w", err)
+		}
+		if rows == 0 {
+			return fmt.Errorf("upload session %s changed concurrently", s.ID)
+		}
+	}
+	for _, key := range s.PartKeys {
+		if err := g.storageClient.RemoveObject(ctx, g.storageBucket, key); err != nil {
+			return fmt.Errorf("failed to remove chunk %s: %w", key, err)
+		}
+	}
+	return g.queries.AbortUploadSession(ctx, s.ID)
+}
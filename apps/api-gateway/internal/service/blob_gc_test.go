@@ -0,0 +1,322 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/storage"
+	"github.com/google/uuid"
+)
+
+type gcFile struct {
+	id             uuid.UUID
+	sizeBytes      int64
+	createdAt      time.Time
+	lastAccessedAt *time.Time
+}
+
+type gcDocument struct {
+	id          uuid.UUID
+	workspaceID uuid.UUID
+	fileID      uuid.UUID
+	deletedAt   *time.Time
+}
+
+type gcVersion struct {
+	documentID uuid.UUID
+	fileID     uuid.UUID
+}
+
+// gcFakeDB は BlobGC が使うクエリだけを、queries/*.sql と同じ意味でメモリ上のテーブルに対して実行する database/sql ドライバ。
+// トランザクションは Begin 時のスナップショットに Rollback で戻す。
+type gcFakeDB struct {
+	files     map[uuid.UUID]gcFile
+	documents map[uuid.UUID]gcDocument
+	versions  []gcVersion
+	snapshot  map[uuid.UUID]gcDocument
+
+	events       []string // purge / delete / commit / rollback / remove:<bucket>/<key> の実行順
+	beforeDelete func()   // DeleteUnreferencedFile の直前に呼ぶ（判定後に参照が増えるケースの再現用）
+}
+
+func newGCFakeDB() *gcFakeDB {
+	return &gcFakeDB{files: map[uuid.UUID]gcFile{}, documents: map[uuid.UUID]gcDocument{}}
+}
+
+func (f *gcFakeDB) addFile(createdAt time.Time, lastAccessedAt *time.Time) uuid.UUID {
+	id := uuid.New()
+	f.files[id] = gcFile{id: id, sizeBytes: 100, createdAt: createdAt, lastAccessedAt: lastAccessedAt}
+	return id
+}
+
+func (f *gcFakeDB) addDocument(fileID uuid.UUID, deletedAt *time.Time) uuid.UUID {
+	id := uuid.New()
+	f.documents[id] = gcDocument{id: id, workspaceID: uuid.New(), fileID: fileID, deletedAt: deletedAt}
+	return id
+}
+
+func (f *gcFakeDB) record(event string) {
+	f.events = append(f.events, event)
+}
+
+// references は fileID を現在または過去のバージョンとして参照している documents を返します
+func (f *gcFakeDB) references(fileID uuid.UUID) []gcDocument {
+	var docs []gcDocument
+	for _, d := range f.documents {
+		referenced := d.fileID == fileID
+		for _, v := range f.versions {
+			if v.documentID == d.id && v.fileID == fileID {
+				referenced = true
+			}
+		}
+		if referenced {
+			docs = append(docs, d)
+		}
+	}
+	return docs
+}
+
+func (f *gcFakeDB) Connect(ctx context.Context) (driver.Conn, error) { return &gcFakeConn{db: f}, nil }
+func (f *gcFakeDB) Driver() driver.Driver                            { return gcFakeDriver{db: f} }
+
+type gcFakeDriver struct{ db *gcFakeDB }
+
+func (d gcFakeDriver) Open(name string) (driver.Conn, error) { return &gcFakeConn{db: d.db}, nil }
+
+type gcFakeConn struct{ db *gcFakeDB }
+
+func (c *gcFakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepare is not supported")
+}
+
+func (c *gcFakeConn) Close() error { return nil }
+
+func (c *gcFakeConn) Begin() (driver.Tx, error) {
+	c.db.snapshot = make(map[uuid.UUID]gcDocument, len(c.db.documents))
+	for id, d := range c.db.documents {
+		c.db.snapshot[id] = d
+	}
+	return c, nil
+}
+
+func (c *gcFakeConn) Commit() error {
+	c.db.record("commit")
+	return nil
+}
+
+func (c *gcFakeConn) Rollback() error {
+	c.db.documents = c.db.snapshot
+	c.db.record("rollback")
+	return nil
+}
+
+func (c *gcFakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	f := c.db
+	switch queryName(query) {
+	case "ListUnreferencedFiles":
+		unusedSince := args[0].Value.(time.Time)
+		rows := &fakeRows{columns: []string{"id", "sha256_hash", "size_bytes", "minio_bucket", "minio_key", "last_used_at"}}
+		for _, file := range f.files {
+			lastUsedAt := file.createdAt
+			if file.lastAccessedAt != nil && file.lastAccessedAt.After(lastUsedAt) {
+				lastUsedAt = *file.lastAccessedAt
+			}
+			live := false
+			for _, d := range f.references(file.id) {
+				if d.deletedAt == nil {
+					live = true
+				} else if d.deletedAt.After(lastUsedAt) {
+					lastUsedAt = *d.deletedAt
+				}
+			}
+			if live || !lastUsedAt.Before(unusedSince) {
+				continue
+			}
+			rows.values = append(rows.values, []driver.Value{
+				file.id.String(), "sha-" + file.id.String(), file.sizeBytes, "files", "objects/" + file.id.String(), lastUsedAt,
+			})
+		}
+		sort.Slice(rows.values, func(i, j int) bool {
+			return rows.values[i][5].(time.Time).Before(rows.values[j][5].(time.Time))
+		})
+		if limit := int(args[1].Value.(int64)); len(rows.values) > limit {
+			rows.values = rows.values[:limit]
+		}
+		return rows, nil
+
+	case "PurgeDeletedDocumentsByFileID":
+		fileID := uuid.MustParse(args[0].Value.(string))
+		rows := &fakeRows{columns: []string{"id", "workspace_id"}}
+		for _, d := range f.references(fileID) {
+			if d.deletedAt != nil {
+				delete(f.documents, d.id)
+				rows.values = append(rows.values, []driver.Value{d.id.String(), d.workspaceID.String()})
+			}
+		}
+		f.record("purge")
+		return rows, nil
+
+	case "ListExpiredUploadSessions":
+		return &fakeRows{}, nil
+	}
+	return nil, fmt.Errorf("unexpected query: %s", queryName(query))
+}
+
+func (c *gcFakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	f := c.db
+	if queryName(query) != "DeleteUnreferencedFile" {
+		return nil, fmt.Errorf("unexpected query: %s", queryName(query))
+	}
+	if f.beforeDelete != nil {
+		f.beforeDelete()
+	}
+	f.record("delete")
+
+	fileID := uuid.MustParse(args[0].Value.(string))
+	if len(f.references(fileID)) > 0 {
+		return driver.RowsAffected(0), nil
+	}
+	delete(f.files, fileID)
+	return driver.RowsAffected(1), nil
+}
+
+// gcFakeStorage は RemoveObject の呼び出しを gcFakeDB のイベントとして記録する
+type gcFakeStorage struct {
+	storage.ObjectStorageClient
+	db *gcFakeDB
+}
+
+func (s *gcFakeStorage) RemoveObject(ctx context.Context, bucket, objectName string) error {
+	s.db.record("remove:" + bucket + "/" + objectName)
+	return nil
+}
+
+func newTestBlobGC(f *gcFakeDB) *BlobGC {
+	database := sql.OpenDB(f)
+	return NewBlobGC(
+		database,
+		db.New(database),
+		&gcFakeStorage{db: f},
+		"uploads",
+		storage.NewMemoryVectorStore(),
+		BlobGCOptions{GracePeriod: 24 * time.Hour},
+	)
+}
+
+func TestBlobGC_SelectsFilesPastGracePeriod(t *testing.T) {
+	now := time.Now()
+	ago := func(d time.Duration) *time.Time { t := now.Add(-d); return &t }
+	f := newGCFakeDB()
+
+	// 参照が無く、作成から猶予期間を過ぎている
+	orphan := f.addFile(*ago(72 * time.Hour), nil)
+	// 作成されたばかり
+	f.addFile(*ago(time.Hour), nil)
+	// 削除済みの document が2つ。古い方は猶予期間を過ぎているが、最後の削除（MAX）がまだ猶予期間内
+	recent := f.addFile(*ago(72 * time.Hour), nil)
+	f.addDocument(recent, ago(120*time.Hour))
+	f.addDocument(recent, ago(time.Hour))
+	// 生きている document の過去のバージョンとして参照されている
+	versioned := f.addFile(*ago(72 * time.Hour), nil)
+	liveDoc := f.addDocument(f.addFile(*ago(72 * time.Hour), nil), nil)
+	f.versions = append(f.versions, gcVersion{documentID: liveDoc, fileID: versioned})
+	// 削除済みの document の現在のファイルと過去のバージョン。過去のバージョンは最後に使われたのがアクセス時刻
+	purged := f.addFile(*ago(240 * time.Hour), nil)
+	deletedDoc := f.addDocument(purged, ago(96*time.Hour))
+	accessed := f.addFile(*ago(240 * time.Hour), ago(48*time.Hour))
+	f.versions = append(f.versions, gcVersion{documentID: deletedDoc, fileID: accessed})
+
+	report, err := newTestBlobGC(f).Run(context.Background(), true)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	var got []uuid.UUID
+	for _, c := range report.Files {
+		got = append(got, c.FileID)
+	}
+	// 最後に使われたのが古い順
+	want := []uuid.UUID{purged, orphan, accessed}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected candidates %v, got %v", want, got)
+	}
+	if !report.Files[0].LastUsedAt.Equal(*ago(96 * time.Hour)) {
+		t.Errorf("Expected last_used_at to be the deletion time, got %v", report.Files[0].LastUsedAt)
+	}
+	if !report.Files[2].LastUsedAt.Equal(*ago(48 * time.Hour)) {
+		t.Errorf("Expected last_used_at to be the access time, got %v", report.Files[2].LastUsedAt)
+	}
+	if report.ReclaimedBytes != 300 {
+		t.Errorf("Expected 300 reclaimable bytes, got %d", report.ReclaimedBytes)
+	}
+	// dry-run では何も削除しない
+	if len(f.events) != 0 || len(f.files) != 7 {
+		t.Errorf("Expected a dry run to leave everything in place, got events %v and %d files", f.events, len(f.files))
+	}
+}
+
+func TestBlobGC_RemovesObjectAfterCommit(t *testing.T) {
+	old := time.Now().Add(-72 * time.Hour)
+	f := newGCFakeDB()
+	fileID := f.addFile(old, nil)
+	docID := f.addDocument(fileID, &old)
+
+	report, err := newTestBlobGC(f).Run(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(report.Files) != 1 || report.FailedFiles != 0 {
+		t.Fatalf("Expected one reclaimed file, got %+v", report)
+	}
+	// 行を消してコミットしてから MinIO オブジェクトを消す（逆だと失敗時に行だけが残り、ダウンロードが壊れる）
+	want := []string{"purge", "delete", "commit", "remove:files/objects/" + fileID.String()}
+	if !reflect.DeepEqual(f.events, want) {
+		t.Errorf("Expected events %v, got %v", want, f.events)
+	}
+	if _, ok := f.documents[docID]; ok {
+		t.Error("Expected the deleted document to be purged")
+	}
+	if _, ok := f.files[fileID]; ok {
+		t.Error("Expected the file row to be deleted")
+	}
+}
+
+func TestBlobGC_KeepsFileReferencedAgain(t *testing.T) {
+	old := time.Now().Add(-72 * time.Hour)
+	f := newGCFakeDB()
+	fileID := f.addFile(old, nil)
+	deletedDoc := f.addDocument(fileID, &old)
+	// 一覧を取ってから削除するまでの間に、同じ内容のファイルがアップロードされて重複排除で再利用された
+	// （別のトランザクションでコミット済みなので、ロールバックしても残る）
+	f.beforeDelete = func() {
+		id := f.addDocument(fileID, nil)
+		f.snapshot[id] = f.documents[id]
+	}
+
+	report, err := newTestBlobGC(f).Run(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(report.Files) != 0 || report.FailedFiles != 1 || report.ReclaimedBytes != 0 {
+		t.Fatalf("Expected the file to be skipped, got %+v", report)
+	}
+	want := []string{"purge", "delete", "rollback"}
+	if !reflect.DeepEqual(f.events, want) {
+		t.Errorf("Expected the transaction to roll back without touching MinIO, got %v", f.events)
+	}
+	if _, ok := f.files[fileID]; !ok {
+		t.Error("Expected the file row to be kept")
+	}
+	if _, ok := f.documents[deletedDoc]; !ok {
+		t.Error("Expected the purge of the deleted document to be rolled back")
+	}
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultHistoryTurns はプロンプトに含める過去のターン数（user + assistant で1ターン）
+	defaultHistoryTurns = 3
+	// defaultHistoryTokenBudget は会話履歴に割り当てるおおよそのトークン数
+	defaultHistoryTokenBudget = 1000
+	// maxRewrittenQueryRunes を超える書き換え結果は LLM の暴走とみなして使わない
+	maxRewrittenQueryRunes = 500
+)
+
+// chatMemorySettings はチャットごとの会話メモリ設定（chats.settings）
+type chatMemorySettings struct {
+	HistoryTurns       int
+	HistoryTokenBudget int
+	RewriteQuery       bool
+}
+
+// parseChatMemorySettings は chats.settings を読み込み、未指定の項目にはデフォルト値を入れます
+func parseChatMemorySettings(chat db.Chat) (chatMemorySettings, error) {
+	settings := chatMemorySettings{
+		HistoryTurns:       defaultHistoryTurns,
+		HistoryTokenBudget: defaultHistoryTokenBudget,
+		RewriteQuery:       true,
+	}
+
+	if !chat.Settings.Valid || len(chat.Settings.RawMessage) == 0 {
+		return settings, nil
+	}
+
+	var raw struct {
+		HistoryTurns       *int  `json:"history_turns"`
+		HistoryTokenBudget *int  `json:"history_token_budget"`
+		RewriteQuery       *bool `json:"rewrite_query"`
+	}
+	if err := json.Unmarshal(chat.Settings.RawMessage, &raw); err != nil {
+		return settings, fmt.Errorf("failed to parse chat settings: %w", err)
+	}
+
+	if raw.HistoryTurns != nil && *raw.HistoryTurns >= 0 {
+		settings.HistoryTurns = *raw.HistoryTurns
+	}
+	if raw.HistoryTokenBudget != nil && *raw.HistoryTokenBudget >= 0 {
+		settings.HistoryTokenBudget = *raw.HistoryTokenBudget
+	}
+	if raw.RewriteQuery != nil {
+		settings.RewriteQuery = *raw.RewriteQuery
+	}
+
+	return settings, nil
+}
+
+// loadHistory は直近の会話履歴を古い順で返します。
+// 今回のユーザーメッセージ（userMessageID）が既に見えている場合（ストリーミング時）は履歴から除外し、
+// トークン予算を超える古いメッセージは切り捨てます。
+func (s *ChatService) loadHistory(
+	ctx context.Context,
+	chatID uuid.UUID,
+	userMessageID uuid.UUID,
+	settings chatMemorySettings,
+) ([]db.ChatMessage, error) {
+	if settings.HistoryTurns == 0 || settings.HistoryTokenBudget == 0 {
+		return nil, nil
+	}
+
+	// 今回のユーザーメッセージが含まれる可能性があるので1件多く取得
+	recent, err := s.queries.GetRecentChatMessages(ctx, db.GetRecentChatMessagesParams{
+		ChatID: chatID,
+		Limit:  int32(settings.HistoryTurns*2 + 1),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat history: %w", err)
+	}
+
+	return trimHistory(recent, userMessageID, settings), nil
+}
+
+// trimHistory は新しい順の recent から今回のユーザーメッセージ（userMessageID）を除き、
+// ターン数とトークン予算に収まる新しいメッセージだけを古い順で返します（古いものから切り捨てる）。
+// 内容ではなく ID で除くので、前回と同じ質問を繰り返しても前回の質問は履歴に残ります
+func trimHistory(recent []db.ChatMessage, userMessageID uuid.UUID, settings chatMemorySettings) []db.ChatMessage {
+	for i, msg := range recent {
+		if msg.ID == userMessageID {
+			recent = append(recent[:i:i], recent[i+1:]...)
+			break
+		}
+	}
+	if len(recent) > settings.HistoryTurns*2 {
+		recent = recent[:settings.HistoryTurns*2]
+	}
+
+	// recent は新しい順なので、新しいものから予算に収まる分だけ採用する
+	used := 0
+	count := 0
+	for _, msg := range recent {
+		tokens := estimateTokens(msg.Content)
+		if used+tokens > settings.HistoryTokenBudget {
+			break
+		}
+		used += tokens
+		count++
+	}
+
+	history := make([]db.ChatMessage, count)
+	for i := 0; i < count; i++ {
+		history[count-1-i] = recent[i]
+	}
+
+	return history
+}
+
+// estimateTokens はテキストのおおよそのトークン数を見積もります。
+// 英数字は約4文字で1トークン、日本語などのマルチバイト文字は1文字1トークンとして数えます。
+func estimateTokens(text string) int {
+	ascii := 0
+	other := 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
+
+// formatHistory は会話履歴をプロンプト用のテキストに整形します
+func formatHistory(history []db.ChatMessage) string {
+	var lines []string
+	for _, msg := range history {
+		speaker := "ユーザー"
+		if msg.Role == "assistant" {
+			speaker = "アシスタント"
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", speaker, msg.Content))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// rewriteQuery は会話履歴を踏まえて、フォローアップの質問を単独で意味が通る検索クエリに書き換えます。
+// 履歴がない場合や書き換えに失敗した場合は元のメッセージをそのまま返します。
+func (s *ChatService) rewriteQuery(
+	ctx context.Context,
+	history []db.ChatMessage,
+	userMessage string,
+) string {
+	if len(history) == 0 {
+		return userMessage
+	}
+
+	prompt := fmt.Sprintf(`以下の会話履歴を踏まえて、最後の質問を資料検索用の単独の質問文に書き換えてください。
+代名詞や省略された語は、会話履歴から具体的な語に置き換えてください。
+書き換えた質問文のみを1行で出力し、説明や回答は書かないでください。
+
+会話履歴:
+%s
+
+最後の質問: %s
+
+書き換えた質問:`, formatHistory(history), userMessage)
+
+	rewritten, err := s.ollamaClient.Generate(ctx, "phi3:mini", prompt)
+	if err != nil {
+		log.Printf("⚠️ [RAG] Query rewrite failed, using original message: %v", err)
+		return userMessage
+	}
+
+	rewritten = strings.TrimSpace(rewritten)
+	if i := strings.IndexByte(rewritten, '\n'); i >= 0 {
+		rewritten = strings.TrimSpace(rewritten[:i])
+	}
+	rewritten = strings.Trim(rewritten, "\"'「」")
+
+	if rewritten == "" || utf8.RuneCountInString(rewritten) > maxRewrittenQueryRunes {
+		return userMessage
+	}
+
+	log.Printf("✏️ [RAG] Rewrote query: %q -> %q", userMessage, rewritten)
+	return rewritten
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/google/uuid"
+)
+
+type stubOllamaClient struct {
+	response string
+	err      error
+	calls    int
+}
+
+func (c *stubOllamaClient) Generate(ctx context.Context, model string, prompt string) (string, error) {
+	c.calls++
+	return c.response, c.err
+}
+
+func (c *stubOllamaClient) GenerateStream(ctx context.Context, model string, prompt string, onToken func(token string) error) (string, error) {
+	return c.Generate(ctx, model, prompt)
+}
+
+func (c *stubOllamaClient) WarmUp(ctx context.Context, model string) error { return nil }
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"abcd", 1},
+		{"abcde", 2},
+		{"日本語", 3},
+		{"ab日本", 3},
+	}
+	for _, tt := range tests {
+		if got := estimateTokens(tt.text); got != tt.want {
+			t.Errorf("estimateTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTrimHistory(t *testing.T) {
+	msg := func(role, content string) db.ChatMessage {
+		return db.ChatMessage{ID: uuid.New(), Role: role, Content: content}
+	}
+	contents := func(history []db.ChatMessage) []string {
+		var result []string
+		for _, m := range history {
+			result = append(result, m.Content)
+		}
+		return result
+	}
+
+	// 新しい順（GetRecentChatMessages の並び）。履歴のメッセージはどれも6トークン
+	current := msg("user", "今回の質問です")
+	recent := []db.ChatMessage{
+		current,
+		msg("assistant", "回答その二。"),
+		msg("user", "質問その二。"),
+		msg("assistant", "回答その一。"),
+		msg("user", "質問その一。"),
+	}
+	// 前回と同じ質問を繰り返した場合
+	repeated := []db.ChatMessage{
+		current,
+		msg("assistant", "回答です。"),
+		msg("user", "今回の質問です"),
+	}
+	tests := []struct {
+		name     string
+		recent   []db.ChatMessage
+		settings chatMemorySettings
+		want     []string
+	}{
+		{
+			name:     "drops the saved user message and returns oldest first",
+			recent:   recent,
+			settings: chatMemorySettings{HistoryTurns: 3, HistoryTokenBudget: 1000},
+			want:     []string{"質問その一。", "回答その一。", "質問その二。", "回答その二。"},
+		},
+		{
+			// 同じ内容でも、今回のメッセージ以外は履歴に残す
+			name:     "keeps an earlier message with the same text",
+			recent:   repeated,
+			settings: chatMemorySettings{HistoryTurns: 3, HistoryTokenBudget: 1000},
+			want:     []string{"今回の質問です", "回答です。"},
+		},
+		{
+			// 非ストリーミングではユーザーメッセージがまだコミットされておらず、履歴に含まれない
+			name:     "current message not visible yet",
+			recent:   recent[1:],
+			settings: chatMemorySettings{HistoryTurns: 3, HistoryTokenBudget: 1000},
+			want:     []string{"質問その一。", "回答その一。", "質問その二。", "回答その二。"},
+		},
+		{
+			name:     "keeps only the latest turns",
+			recent:   recent,
+			settings: chatMemorySettings{HistoryTurns: 1, HistoryTokenBudget: 1000},
+			want:     []string{"質問その二。", "回答その二。"},
+		},
+		{
+			name:     "drops the oldest messages over the budget",
+			recent:   recent,
+			settings: chatMemorySettings{HistoryTurns: 3, HistoryTokenBudget: 20},
+			want:     []string{"回答その一。", "質問その二。", "回答その二。"},
+		},
+		{
+			// 古いメッセージが予算に収まっても、間を飛ばして含めない
+			name: "does not skip over a message that does not fit",
+			recent: []db.ChatMessage{
+				msg("assistant", "short"),
+				msg("user", strings.Repeat("長", 50)),
+				msg("assistant", "ok"),
+			},
+			settings: chatMemorySettings{HistoryTurns: 3, HistoryTokenBudget: 10},
+			want:     []string{"short"},
+		},
+		{
+			name:     "latest message alone is over the budget",
+			recent:   recent[1:],
+			settings: chatMemorySettings{HistoryTurns: 3, HistoryTokenBudget: 5},
+			want:     nil,
+		},
+	}
+	for _, tt := range tests {
+		history := trimHistory(tt.recent, current.ID, tt.settings)
+		if got := contents(history); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: trimHistory() = %q, want %q", tt.name, got, tt.want)
+		}
+
+		used := 0
+		for _, m := range history {
+			used += estimateTokens(m.Content)
+		}
+		if used > tt.settings.HistoryTokenBudget {
+			t.Errorf("%s: used %d tokens, over the budget of %d", tt.name, used, tt.settings.HistoryTokenBudget)
+		}
+	}
+}
+
+func TestRewriteQuery(t *testing.T) {
+	history := []db.ChatMessage{
+		{Role: "user", Content: "2024年度の売上は？"},
+		{Role: "assistant", Content: "120億円です。"},
+	}
+
+	tests := []struct {
+		name     string
+		history  []db.ChatMessage
+		response string
+		err      error
+		want     string
+	}{
+		{"rewrites with history", history, "「2024年度の営業利益は？」\n説明: 代名詞を置き換えました", nil, "2024年度の営業利益は？"},
+		{"falls back on error", history, "", errors.New("ollama is down"), "利益は？"},
+		{"falls back on empty output", history, "  \n", nil, "利益は？"},
+		{"falls back on runaway output", history, strings.Repeat("あ", maxRewrittenQueryRunes+1), nil, "利益は？"},
+		{"no history", nil, "使われない", nil, "利益は？"},
+	}
+	for _, tt := range tests {
+		llm := &stubOllamaClient{response: tt.response, err: tt.err}
+		s := &ChatService{ollamaClient: llm}
+
+		if got := s.rewriteQuery(context.Background(), tt.history, "利益は？"); got != tt.want {
+			t.Errorf("%s: rewriteQuery() = %q, want %q", tt.name, got, tt.want)
+		}
+		if len(tt.history) == 0 && llm.calls != 0 {
+			t.Errorf("%s: expected no LLM call without history", tt.name)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"strings"
@@ -9,6 +10,7 @@ import (
 	"github.com/Martin4208/Nexus/apps/api-gateway/internal/api"
 	"github.com/Martin4208/Nexus/apps/api-gateway/internal/client"
 	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/storage"
 	"github.com/google/uuid"
 )
 
@@ -16,76 +18,177 @@ import (
 type ChatService struct {
 	queries        *db.Queries
 	aiWorkerClient client.AIWorkerClient
-	qdrantClient   client.QdrantClient
+	vectorStore    storage.VectorStore
 	ollamaClient   client.OllamaClient
+	retriever      *hybridRetriever
 }
 
 // NewChatService は新しいChatServiceを作成
 func NewChatService(
 	queries *db.Queries,
 	aiWorkerClient client.AIWorkerClient,
-	qdrantClient client.QdrantClient,
+	vectorStore storage.VectorStore,
 	ollamaClient client.OllamaClient,
+	reranker Reranker,
+	rerankCandidates int,
 ) *ChatService {
 	return &ChatService{
 		queries:        queries,
 		aiWorkerClient: aiWorkerClient,
-		qdrantClient:   qdrantClient,
+		vectorStore:    vectorStore,
 		ollamaClient:   ollamaClient,
+		retriever:      newHybridRetriever(queries, vectorStore, reranker, rerankCandidates),
 	}
 }
 
-// GenerateResponse はRAGを使ってLLMの応答を生成します
+// GenerateResponse はRAGを使ってLLMの応答を生成します。
+// userMessageID は保存済みの今回のユーザーメッセージの ID で、会話履歴からはこの ID のメッセージを除きます
 func (s *ChatService) GenerateResponse(
 	ctx context.Context,
 	workspaceID uuid.UUID,
 	chatID uuid.UUID,
+	userMessageID uuid.UUID,
 	userMessage string,
 ) (string, []api.DocumentReference, error) {
 	log.Printf("🔍 [RAG] Starting response generation for message: %s", userMessage)
 
-	// Step 1: ユーザーメッセージをEmbedding化
-	embedResp, err := s.aiWorkerClient.EmbedDocuments(ctx, []string{userMessage})
+	prompt, documentRefs, err := s.preparePrompt(ctx, workspaceID, chatID, userMessageID, userMessage)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to embed user message: %w", err)
+		return "", nil, err
 	}
 
-	if len(embedResp.Embeddings) == 0 {
-		return "", nil, fmt.Errorf("no embeddings returned from AI worker")
+	// Step 5: Ollamaで生成
+	llmResponse, err := s.ollamaClient.Generate(ctx, "phi3:mini", prompt)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate response from LLM: %w", err)
+	}
+
+	log.Printf("✅ [RAG] Response generated: %d characters", len(llmResponse))
+
+	return llmResponse, documentRefs, nil
+}
+
+// GenerateResponseStream は GenerateResponse のストリーミング版です。
+// 検索で得た引用情報を onSources で先に通知し、その後 LLM のトークンを onToken で逐次通知します。
+// 生成が途中でキャンセル・失敗した場合も、それまでに生成されたテキストと引用情報を返すため、
+// 呼び出し側は部分的な応答を保存できます。
+func (s *ChatService) GenerateResponseStream(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	chatID uuid.UUID,
+	userMessageID uuid.UUID,
+	userMessage string,
+	onSources func(refs []api.DocumentReference) error,
+	onToken func(token string) error,
+) (string, []api.DocumentReference, error) {
+	log.Printf("🔍 [RAG] Starting streaming response generation for message: %s", userMessage)
+
+	prompt, documentRefs, err := s.preparePrompt(ctx, workspaceID, chatID, userMessageID, userMessage)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := onSources(documentRefs); err != nil {
+		return "", documentRefs, err
 	}
 
-	queryVector := embedResp.Embeddings[0]
+	// Step 5: Ollamaでストリーミング生成
+	llmResponse, err := s.ollamaClient.GenerateStream(ctx, "phi3:mini", prompt, onToken)
+	if err != nil {
+		return llmResponse, documentRefs, fmt.Errorf("failed to stream response from LLM: %w", err)
+	}
+
+	log.Printf("✅ [RAG] Streamed response generated: %d characters", len(llmResponse))
+
+	return llmResponse, documentRefs, nil
+}
 
-	// Step 2: Qdrantで類似チャンクを検索
-	collectionName := fmt.Sprintf("workspace_%s", workspaceID.String())
-	searchResp, err := s.qdrantClient.Search(ctx, collectionName, queryVector, 5)
+// preparePrompt はユーザーメッセージで類似チャンクを検索し、プロンプトと引用情報を構築します
+// 検索範囲はチャットの filter_config で絞り込み、会話履歴は settings に従ってプロンプトに含めます
+func (s *ChatService) preparePrompt(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	chatID uuid.UUID,
+	userMessageID uuid.UUID,
+	userMessage string,
+) (string, []api.DocumentReference, error) {
+	chat, err := s.queries.GetChat(ctx, db.GetChatParams{
+		ID:          chatID,
+		WorkspaceID: workspaceID,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get chat: %w", err)
+	}
+
+	// Step 0: チャットの filter_config から検索範囲を構築
+	filterConfig, err := parseChatFilterConfig(chat)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to search Qdrant: %w", err)
+		return "", nil, err
 	}
-	log.Printf("✅ [RAG] Found %d results from Qdrant", len(searchResp.Result))
+
+	scope, err := resolveRetrievalScope(ctx, s.queries, workspaceID, filterConfig)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Step 0.5: 会話履歴を取得し、必要なら検索クエリを書き換える
+	settings, err := parseChatMemorySettings(chat)
+	if err != nil {
+		return "", nil, err
+	}
+
+	retrievalOpts, err := parseChatRetrievalOptions(chat)
+	if err != nil {
+		return "", nil, err
+	}
+
+	history, err := s.loadHistory(ctx, chatID, userMessageID, settings)
+	if err != nil {
+		return "", nil, err
+	}
+	log.Printf("🧠 [RAG] Using %d history messages", len(history))
+
+	searchQuery := userMessage
+	if settings.RewriteQuery {
+		searchQuery = s.rewriteQuery(ctx, history, userMessage)
+	}
+
+	// Step 1-2: 検索クエリで類似チャンクを検索（dense / lexical / hybrid は settings で選択）し、リランキングで上位5件に絞る
+	results, err := s.retriever.retrieve(ctx, workspaceID, searchQuery, s.embedQuery, scope, 5, retrievalOpts)
+	if err != nil {
+		return "", nil, err
+	}
+	log.Printf("✅ [RAG] Found %d results", len(results))
 
 	// Step 3: 検索結果からDocumentReferenceを生成（page_number付き）
-	documentRefs := s.extractDocumentRefs(searchResp.Result)
+	documentRefs := s.extractDocumentRefs(results)
 
-	// Step 4: コンテキストを構築
-	context := s.buildContext(searchResp.Result)
+	// Step 4: ヒットしたチャンクを親チャンク（または前後のチャンク）に広げてコンテキストを構築し、プロンプトを作成
+	// 引用（documentRefs）はヒットしたチャンクとそのページを指したままにする
+	context := s.buildContext(s.retriever.expand(ctx, results, retrievalOpts))
 
-	// Step 5: プロンプトを作成してOllamaで生成
-	prompt := s.buildPrompt(context, userMessage)
-	llmResponse, err := s.ollamaClient.Generate(ctx, "phi3:mini", prompt)
+	return s.buildPrompt(context, history, userMessage), documentRefs, nil
+}
+
+// embedQuery は検索クエリを Embedding 化します
+func (s *ChatService) embedQuery(ctx context.Context, text string) ([]float64, error) {
+	embedResp, err := s.aiWorkerClient.EmbedDocuments(ctx, []string{text})
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to generate response from LLM: %w", err)
+		return nil, fmt.Errorf("failed to embed user message: %w", err)
 	}
 
-	log.Printf("✅ [RAG] Response generated: %d characters", len(llmResponse))
+	if len(embedResp.Embeddings) == 0 {
+		return nil, fmt.Errorf("no embeddings returned from AI worker")
+	}
 
-	return llmResponse, documentRefs, nil
+	return embedResp.Embeddings[0], nil
 }
 
 // extractDocumentRefs は Qdrant の検索結果から DocumentReference スライスを生成する。
 // payload に page_number が含まれるようになったので、それを取り出してセットする。
+// score は最終スコア、original_score / rerank_score はリランキング前後のスコア。
 func (s *ChatService) extractDocumentRefs(
-	results []client.SearchResult,
+	results []rankedResult,
 ) []api.DocumentReference {
 	refs := make([]api.DocumentReference, 0, len(results))
 
@@ -115,6 +218,24 @@ func (s *ChatService) extractDocumentRefs(
 			pageNumber = &pn
 		}
 
+		// version_id（バージョン管理より前のポイントにはない）
+		var versionID *uuid.UUID
+		if v, ok := payload["version_id"].(string); ok {
+			if id, err := uuid.Parse(v); err == nil {
+				versionID = &id
+			}
+		}
+
+		// regions（PDF のみ。引用箇所のハイライトに使う）
+		regions := payloadRegions(payload["regions"])
+
+		// ocr_confidence（OCR で認識したページのチャンクのみ）
+		var ocrConfidence *float32
+		if v, ok := payload["ocr_confidence"].(float64); ok {
+			c := float32(v)
+			ocrConfidence = &c
+		}
+
 		// content_preview（最初の200文字）
 		contentPreview := ""
 		if v, ok := payload["text"].(string); ok {
@@ -127,12 +248,23 @@ func (s *ChatService) extractDocumentRefs(
 		}
 
 		score := float32(r.Score)
+		originalScore := float32(r.OriginalScore)
+		var rerankScore *float32
+		if r.RerankScore != nil {
+			rs := float32(*r.RerankScore)
+			rerankScore = &rs
+		}
 		ref := api.DocumentReference{
 			DocumentId:     docUUID,
-			ChunkIndex:     int32(chunkIndex),
+			ChunkIndex:     chunkIndex,
 			PageNumber:     pageNumber, // ← 追加（openapi再生成後に型が確定する）
 			Score:          score,
+			OriginalScore:  &originalScore,
+			RerankScore:    rerankScore,
 			ContentPreview: &contentPreview,
+			VersionId:      versionID,
+			Regions:        regions,
+			OcrConfidence:  ocrConfidence,
 		}
 
 		refs = append(refs, ref)
@@ -141,25 +273,41 @@ func (s *ChatService) extractDocumentRefs(
 	return refs
 }
 
-// buildContext は検索結果からコンテキストテキストを構築
-func (s *ChatService) buildContext(results []client.SearchResult) string {
+// payloadRegions は payload の regions を API の型に変換します（ない場合・空の場合は nil）。
+// Qdrant からは JSON をデコードした値、インメモリの VectorStore からは []ChunkRegion が返るため JSON を経由する
+func payloadRegions(value interface{}) *[]api.ChunkRegion {
+	if value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var regions []api.ChunkRegion
+	if err := json.Unmarshal(data, &regions); err != nil || len(regions) == 0 {
+		return nil
+	}
+	return &regions
+}
+
+// buildContext は広げた検索結果からコンテキストテキストを構築
+func (s *ChatService) buildContext(contexts []retrievedContext) string {
 	var contextParts []string
 
-	for i, result := range results {
-		content, ok := result.Payload["text"].(string)
-		if !ok {
+	for i, c := range contexts {
+		if c.text == "" {
 			continue
 		}
 
 		// ページ番号が取れる場合はコンテキストにも含める（LLMへのヒントになる）
 		pageInfo := ""
-		if v, ok := result.Payload["page_number"].(float64); ok {
-			pageInfo = fmt.Sprintf(" [P.%d]", int(v))
+		if c.pageNumber != nil {
+			pageInfo = fmt.Sprintf(" [P.%d]", *c.pageNumber)
 		}
 
 		contextParts = append(contextParts, fmt.Sprintf(
 			"--- Document %d%s (Score: %.3f) ---\n%s",
-			i+1, pageInfo, result.Score, content,
+			i+1, pageInfo, c.score, c.text,
 		))
 	}
 
@@ -171,7 +319,7 @@ func (s *ChatService) buildContext(results []client.SearchResult) string {
 }
 
 // buildPrompt はLLMに送るプロンプトを構築
-func (s *ChatService) buildPrompt(context string, userMessage string) string {
+func (s *ChatService) buildPrompt(context string, history []db.ChatMessage, userMessage string) string {
 	systemPrompt := `あなたは提供された資料を基に正確に回答するAIアシスタントです。
 以下のルールに従ってください：
 1. 提供された資料の内容のみを基に回答する
@@ -179,12 +327,18 @@ func (s *ChatService) buildPrompt(context string, userMessage string) string {
 3. 推測や一般知識での回答は避ける
 4. 回答は簡潔かつ正確に`
 
+	// 会話履歴はフォローアップの質問の意図を解釈するためだけに使う
+	historySection := ""
+	if len(history) > 0 {
+		historySection = fmt.Sprintf("会話履歴:\n%s\n\n", formatHistory(history))
+	}
+
 	return fmt.Sprintf(`%s
 
 参考資料:
 %s
 
-ユーザーの質問: %s
+%sユーザーの質問: %s
 
-回答:`, systemPrompt, context, userMessage)
+回答:`, systemPrompt, context, historySection, userMessage)
 }
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/google/uuid"
+)
+
+// chunkContentHash はチャンク本文・見出しの階層・ページ上の領域・チャンク分割パラメータから content_hash を計算します。
+// chunk_size / chunk_overlap や見出し・領域が変わった場合は、本文が同じでも別のチャンクとして扱う
+// （見出しと領域は metadata と payload に保存しているため）。見出しと領域がない場合のハッシュは以前と同じ
+func chunkContentHash(text string, headings []string, regions []ChunkRegion, opts ProcessOptions) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "chunk_size=%d\nchunk_overlap=%d\n", opts.ChunkSize, opts.ChunkOverlap)
+	// fixed は以前のハッシュと一致させるため含めない
+	if opts.ChunkStrategy != "" && opts.ChunkStrategy != ChunkStrategyFixed {
+		fmt.Fprintf(h, "chunk_strategy=%s\n", opts.ChunkStrategy)
+	}
+	if len(headings) > 0 {
+		fmt.Fprintf(h, "headings=%q\n", headings)
+	}
+	if len(regions) > 0 {
+		fmt.Fprintf(h, "regions=%v\n", regions)
+	}
+	h.Write([]byte(text))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// chunkSyncPlan は新しいチャンク列と既存チャンクの差分です。
+// スライスはすべて新しいチャンクの順序（= chunk_index）に対応します。
+type chunkSyncPlan struct {
+	chunkIDs []uuid.UUID // document_chunks.id（再利用するものは既存のID、それ以外は新規採番）
+	pointIDs []uuid.UUID // Qdrant のポイントID（再利用するものは既存の qdrant_point_id を維持）
+	existing []bool      // 既存の行をそのまま使うか（false なら INSERT する）
+	moved    []bool      // 再利用するチャンクで chunk_index か page_number が変わったか（payload の更新が必要）
+
+	embed   []int       // embedding を計算するチャンクの位置
+	deleted []uuid.UUID // 消えたチャンクの document_chunks.id
+}
+
+// reusedCount は embedding を再利用するチャンク数を返します
+func (p *chunkSyncPlan) reusedCount() int {
+	return len(p.chunkIDs) - len(p.embed)
+}
+
+// planChunkSync は content_hash をもとに新旧のチャンクを対応付けます。
+// 同じハッシュのチャンクが複数ある場合は、出現順に1対1で対応させる。
+// ハッシュのない行（この機能より前に処理されたチャンク）は対応付けず、削除して作り直す。
+// forceReprocess の場合も ID の対応付けは行い、全チャンクの embedding を再計算する。
+func planChunkSync(
+	chunks []chunkWithPage,
+	existing []db.ListDocumentChunkHashesRow,
+	forceReprocess bool,
+) *chunkSyncPlan {
+	plan := &chunkSyncPlan{
+		chunkIDs: make([]uuid.UUID, len(chunks)),
+		pointIDs: make([]uuid.UUID, len(chunks)),
+		existing: make([]bool, len(chunks)),
+		moved:    make([]bool, len(chunks)),
+	}
+
+	// existing は chunk_index 順に並んでいるので、キューの先頭から使えば出現順の対応になる
+	byHash := make(map[string][]db.ListDocumentChunkHashesRow)
+	for _, row := range existing {
+		if row.ContentHash.Valid {
+			byHash[row.ContentHash.String] = append(byHash[row.ContentHash.String], row)
+		}
+	}
+
+	matched := make(map[uuid.UUID]bool, len(existing))
+	for i, c := range chunks {
+		candidates := byHash[c.hash]
+		if len(candidates) == 0 {
+			id := uuid.New()
+			plan.chunkIDs[i] = id
+			plan.pointIDs[i] = id
+			plan.embed = append(plan.embed, i)
+			continue
+		}
+
+		row := candidates[0]
+		byHash[c.hash] = candidates[1:]
+		matched[row.ID] = true
+
+		plan.chunkIDs[i] = row.ID
+		plan.pointIDs[i] = row.ID
+		if row.QdrantPointID.Valid {
+			plan.pointIDs[i] = row.QdrantPointID.UUID
+		}
+		plan.existing[i] = true
+		if forceReprocess {
+			// upsert で payload ごと置き換わるため moved は立てない
+			plan.embed = append(plan.embed, i)
+			continue
+		}
+		plan.moved[i] = int(row.ChunkIndex) != i || int(row.PageNumber) != c.pageNumber
+	}
+
+	for _, row := range existing {
+		if !matched[row.ID] {
+			plan.deleted = append(plan.deleted, row.ID)
+		}
+	}
+
+	return plan
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/google/uuid"
+)
+
+func newTestChunks(opts ProcessOptions, texts ...string) []chunkWithPage {
+	chunks := make([]chunkWithPage, len(texts))
+	for i, text := range texts {
+		chunks[i] = chunkWithPage{text: text, pageNumber: 1, hash: chunkContentHash(text, nil, nil, opts)}
+	}
+	return chunks
+}
+
+func newTestRows(chunks []chunkWithPage) []db.ListDocumentChunkHashesRow {
+	rows := make([]db.ListDocumentChunkHashesRow, len(chunks))
+	for i, c := range chunks {
+		id := uuid.New()
+		rows[i] = db.ListDocumentChunkHashesRow{
+			ID:            id,
+			ChunkIndex:    int32(i),
+			PageNumber:    int32(c.pageNumber),
+			ContentHash:   sql.NullString{String: c.hash, Valid: true},
+			QdrantPointID: uuid.NullUUID{UUID: id, Valid: true},
+		}
+	}
+	return rows
+}
+
+func TestChunkContentHash_DependsOnChunkingParams(t *testing.T) {
+	a := chunkContentHash("同じ本文", nil, nil, ProcessOptions{ChunkSize: 500, ChunkOverlap: 50})
+	b := chunkContentHash("同じ本文", nil, nil, ProcessOptions{ChunkSize: 300, ChunkOverlap: 50})
+
+	if a == b {
+		t.Errorf("Expected different hashes for different chunk sizes")
+	}
+}
+
+func TestChunkContentHash_DependsOnChunkStrategy(t *testing.T) {
+	legacy := chunkContentHash("同じ本文", nil, nil, ProcessOptions{ChunkSize: 500, ChunkOverlap: 50})
+	fixed := chunkContentHash("同じ本文", nil, nil, ProcessOptions{ChunkSize: 500, ChunkOverlap: 50, ChunkStrategy: ChunkStrategyFixed})
+	semantic := chunkContentHash("同じ本文", nil, nil, ProcessOptions{ChunkSize: 500, ChunkOverlap: 50, ChunkStrategy: ChunkStrategySemantic})
+
+	if legacy != fixed {
+		t.Errorf("Expected the fixed strategy to keep existing hashes")
+	}
+	if fixed == semantic {
+		t.Errorf("Expected different hashes for different chunk strategies")
+	}
+}
+
+func TestPlanChunkSync_ReusesUnchangedChunks(t *testing.T) {
+	opts := ProcessOptions{ChunkSize: 500, ChunkOverlap: 50}
+	rows := newTestRows(newTestChunks(opts, "A", "B", "C"))
+
+	// B が消え、先頭に X が追加された
+	plan := planChunkSync(newTestChunks(opts, "X", "A", "C"), rows, false)
+
+	if len(plan.embed) != 1 || plan.embed[0] != 0 {
+		t.Fatalf("Expected only chunk 0 to be embedded, got %v", plan.embed)
+	}
+	if plan.pointIDs[1] != rows[0].ID || plan.pointIDs[2] != rows[2].ID {
+		t.Errorf("Expected point IDs of unchanged chunks to be kept")
+	}
+	if !plan.moved[1] || plan.moved[2] {
+		t.Errorf("Expected only chunk 1 to be moved, got %v", plan.moved)
+	}
+	if len(plan.deleted) != 1 || plan.deleted[0] != rows[1].ID {
+		t.Errorf("Expected chunk B to be deleted, got %v", plan.deleted)
+	}
+}
+
+func TestPlanChunkSync_DuplicateChunks(t *testing.T) {
+	opts := ProcessOptions{ChunkSize: 500, ChunkOverlap: 50}
+	rows := newTestRows(newTestChunks(opts, "A", "A"))
+
+	plan := planChunkSync(newTestChunks(opts, "A", "A", "A"), rows, false)
+
+	if plan.chunkIDs[0] != rows[0].ID || plan.chunkIDs[1] != rows[1].ID {
+		t.Errorf("Expected duplicate chunks to be matched in order")
+	}
+	if len(plan.embed) != 1 || plan.embed[0] != 2 {
+		t.Errorf("Expected only the third chunk to be embedded, got %v", plan.embed)
+	}
+}
+
+func TestPlanChunkSync_ForceReprocessKeepsIDs(t *testing.T) {
+	opts := ProcessOptions{ChunkSize: 500, ChunkOverlap: 50}
+	rows := newTestRows(newTestChunks(opts, "A", "B"))
+
+	plan := planChunkSync(newTestChunks(opts, "A", "B"), rows, true)
+
+	if len(plan.embed) != 2 {
+		t.Errorf("Expected all chunks to be embedded, got %v", plan.embed)
+	}
+	if plan.pointIDs[0] != rows[0].ID || plan.pointIDs[1] != rows[1].ID {
+		t.Errorf("Expected point IDs to be kept on force reprocess")
+	}
+}
+
+func TestPlanChunkSync_LegacyRowsWithoutHash(t *testing.T) {
+	opts := ProcessOptions{ChunkSize: 500, ChunkOverlap: 50}
+	rows := newTestRows(newTestChunks(opts, "A"))
+	rows[0].ContentHash = sql.NullString{}
+
+	plan := planChunkSync(newTestChunks(opts, "A"), rows, false)
+
+	if len(plan.embed) != 1 || len(plan.deleted) != 1 {
+		t.Errorf("Expected legacy chunk to be rebuilt, got embed=%v deleted=%v", plan.embed, plan.deleted)
+	}
+}
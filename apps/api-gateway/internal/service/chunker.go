@@ -0,0 +1,491 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ChunkStrategy はチャンク分割の方式
+type ChunkStrategy string
+
+const (
+	ChunkStrategyFixed     ChunkStrategy = "fixed"     // 文字数で区切り、前後20文字以内の文末に合わせる（TextChunker）
+	ChunkStrategyRecursive ChunkStrategy = "recursive" // 見出し → 段落 → 行 → 文 → 単語の順に、収まるまで区切りを細かくする
+	ChunkStrategyMarkdown  ChunkStrategy = "markdown"  // Markdown の見出しごとのセクション。コードブロックと表は途中で切らない
+	ChunkStrategySemantic  ChunkStrategy = "semantic"  // 隣り合う文の embedding の類似度が下がる位置で区切る
+	ChunkStrategyToken     ChunkStrategy = "token"     // recursive と同じ区切り方で、サイズとオーバーラップをトークン数で測る
+)
+
+// DefaultChunkStrategy は chunk_strategy を指定しない場合の方式（以前に処理したチャンクの content_hash と一致する）
+const DefaultChunkStrategy = ChunkStrategyFixed
+
+// semanticBreakpointPercentile は、隣り合う文の距離（1 - コサイン類似度）がこの分位点を超える位置で区切る
+const semanticBreakpointPercentile = 0.9
+
+// ChunkStrategies は指定できる方式の一覧
+var ChunkStrategies = []ChunkStrategy{
+	ChunkStrategyFixed,
+	ChunkStrategyRecursive,
+	ChunkStrategyMarkdown,
+	ChunkStrategySemantic,
+	ChunkStrategyToken,
+}
+
+// Valid は既知の方式かどうかを返します
+func (s ChunkStrategy) Valid() bool {
+	for _, known := range ChunkStrategies {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Chunker はテキストをチャンクに分割します。
+// チャンクは元のテキストの連続した部分（前後の空白を除いたもの）で、前から順に並ぶ
+type Chunker interface {
+	Chunk(ctx context.Context, text string) ([]string, error)
+}
+
+// embedFunc はテキストの embedding を返します（semantic で文の類似度を測るのに使う）
+type embedFunc func(ctx context.Context, texts []string) ([][]float64, error)
+
+// NewChunker は strategy に応じた Chunker を作成します。
+// chunkSize / chunkOverlap は token では トークン数、それ以外では文字数。embed は semantic でのみ使う。
+// countTokens は token でトークン数を数える関数（nil なら文字種からの推定値）
+func NewChunker(
+	strategy ChunkStrategy,
+	chunkSize, chunkOverlap int,
+	embed embedFunc,
+	countTokens func(string) int,
+) (Chunker, error) {
+	// サイズのバリデーションは TextChunker と同じ
+	base := NewTextChunker(chunkSize, chunkOverlap)
+	runeLength := utf8.RuneCountInString
+	if countTokens == nil {
+		countTokens = estimateTokens
+	}
+
+	switch strategy {
+	case "", ChunkStrategyFixed:
+		return fixedChunker{base}, nil
+	case ChunkStrategyRecursive:
+		return newRecursiveChunker(base.ChunkSize, base.ChunkOverlap, runeLength), nil
+	case ChunkStrategyToken:
+		return newRecursiveChunker(base.ChunkSize, base.ChunkOverlap, countTokens), nil
+	case ChunkStrategyMarkdown:
+		return &markdownChunker{size: base.ChunkSize, length: runeLength}, nil
+	case ChunkStrategySemantic:
+		if embed == nil {
+			return nil, fmt.Errorf("semantic chunking requires an embedding function")
+		}
+		return &semanticChunker{
+			size:     base.ChunkSize,
+			embed:    embed,
+			fallback: newRecursiveChunker(base.ChunkSize, 0, runeLength),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown chunk strategy %q", strategy)
+	}
+}
+
+// fixedChunker は TextChunker を Chunker として使います
+type fixedChunker struct {
+	*TextChunker
+}
+
+func (c fixedChunker) Chunk(ctx context.Context, text string) ([]string, error) {
+	return c.TextChunker.Chunk(text), nil
+}
+
+// recursiveChunker は大きな区切りから順に試し、chunk サイズに収まらない部分だけを細かい区切りで分けます。
+// 分けた部分はサイズに収まるまで前から詰め、直前のチャンクの末尾をオーバーラップとして含める
+type recursiveChunker struct {
+	size    int
+	overlap int
+	length  func(string) int
+}
+
+func newRecursiveChunker(size, overlap int, length func(string) int) *recursiveChunker {
+	return &recursiveChunker{size: size, overlap: overlap, length: length}
+}
+
+// recursiveSplitters は区切りの候補（大きい順）。各関数は連結すると元のテキストに戻る部分に分ける
+var recursiveSplitters = []func(string) []string{
+	splitBeforeHeadings,
+	func(text string) []string { return splitAfter(text, "\n\n") },
+	func(text string) []string { return splitAfter(text, "\n") },
+	splitSentences,
+	func(text string) []string { return splitAfter(text, " ") },
+}
+
+func (c *recursiveChunker) Chunk(ctx context.Context, text string) ([]string, error) {
+	if strings.TrimSpace(text) == "" {
+		return []string{}, nil
+	}
+	return mergePieces(c.split(text, 0), c.size, c.overlap, c.length), nil
+}
+
+// split は text を level 以降の区切りで、それぞれ size に収まる部分に分けます
+func (c *recursiveChunker) split(text string, level int) []string {
+	if c.length(text) <= c.size {
+		return []string{text}
+	}
+	if level >= len(recursiveSplitters) {
+		return c.hardSplit(text)
+	}
+
+	pieces := recursiveSplitters[level](text)
+	if len(pieces) <= 1 {
+		return c.split(text, level+1)
+	}
+
+	var result []string
+	for _, p := range pieces {
+		result = append(result, c.split(p, level+1)...)
+	}
+	return result
+}
+
+// hardSplit は区切りのないテキスト（空白のない長い文字列など）を size ごとに分けます
+func (c *recursiveChunker) hardSplit(text string) []string {
+	var result []string
+	start := 0
+	for i, r := range text {
+		if i > start && c.length(text[start:i+utf8.RuneLen(r)]) > c.size {
+			result = append(result, text[start:i])
+			start = i
+		}
+	}
+	return append(result, text[start:])
+}
+
+// mergePieces は連続する部分を size に収まるまでまとめてチャンクにします。
+// 新しいチャンクの先頭には、直前のチャンクの末尾の部分を overlap に収まる分だけ含める
+func mergePieces(pieces []string, size, overlap int, length func(string) int) []string {
+	chunks := []string{}
+	var current []string
+	currentLen := 0
+
+	flush := func() {
+		if chunk := strings.TrimSpace(strings.Join(current, "")); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+	}
+
+	for _, p := range pieces {
+		l := length(p)
+		if len(current) > 0 && currentLen+l > size {
+			flush()
+
+			// 先頭の部分は必ず外す（同じ内容のチャンクが続かないように）
+			keep, kept := 0, 0
+			for i := len(current) - 1; i >= 1; i-- {
+				pl := length(current[i])
+				if kept+pl > overlap || kept+pl+l > size {
+					break
+				}
+				kept += pl
+				keep++
+			}
+			current = current[len(current)-keep:]
+			currentLen = kept
+		}
+		current = append(current, p)
+		currentLen += l
+	}
+	flush()
+
+	return chunks
+}
+
+// splitAfter は sep の直後で分けます（sep は前の部分に残る）
+func splitAfter(text, sep string) []string {
+	parts := strings.SplitAfter(text, sep)
+	if len(parts) > 0 && parts[len(parts)-1] == "" {
+		parts = parts[:len(parts)-1]
+	}
+	return parts
+}
+
+// splitBeforeHeadings は Markdown の見出し行（# で始まる行）の前で分けます
+func splitBeforeHeadings(text string) []string {
+	var parts []string
+	var current strings.Builder
+	for _, line := range splitAfter(text, "\n") {
+		if isMarkdownHeading(line) && current.Len() > 0 {
+			parts = append(parts, current.String())
+			current.Reset()
+		}
+		current.WriteString(line)
+	}
+	if current.Len() > 0 {
+		parts = append(parts, current.String())
+	}
+	return parts
+}
+
+// splitSentences は文末（句点・段落の境界）の後で分けます。文末の後ろの空白は前の文に含める
+func splitSentences(text string) []string {
+	runes := []rune(text)
+	var parts []string
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		if !isSentenceEnd(runes, i) {
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && (runes[end] == ' ' || runes[end] == '\n' || runes[end] == '\t') {
+			end++
+		}
+		parts = append(parts, string(runes[start:end]))
+		start = end
+		i = end - 1
+	}
+	if start < len(runes) {
+		parts = append(parts, string(runes[start:]))
+	}
+	return parts
+}
+
+func isMarkdownHeading(line string) bool {
+	trimmed := strings.TrimLeft(line, "#")
+	level := len(line) - len(trimmed)
+	return level >= 1 && level <= 6 && (trimmed == "" || trimmed[0] == ' ' || trimmed[0] == '\t' || trimmed[0] == '\n')
+}
+
+// markdownChunker は Markdown の見出しごとのセクションを1チャンクにします。
+// セクションが大きい場合はブロック（段落・コードブロック・表）の境界で分け、コードブロックと表は途中で切らない。
+// ブロック自体が大きすぎる場合だけ行の境界で分ける。セクションは独立しているためオーバーラップはしない
+type markdownChunker struct {
+	size   int
+	length func(string) int
+}
+
+func (c *markdownChunker) Chunk(ctx context.Context, text string) ([]string, error) {
+	chunks := []string{}
+	lines := newRecursiveChunker(c.size, 0, c.length)
+
+	for _, section := range markdownSections(text) {
+		var whole strings.Builder
+		for _, b := range section {
+			whole.WriteString(b)
+		}
+		if c.length(whole.String()) <= c.size {
+			if chunk := strings.TrimSpace(whole.String()); chunk != "" {
+				chunks = append(chunks, chunk)
+			}
+			continue
+		}
+
+		var pieces []string
+		for _, b := range section {
+			if c.length(b) <= c.size {
+				pieces = append(pieces, b)
+				continue
+			}
+			// 行の境界（レベル2）から分ける
+			pieces = append(pieces, lines.split(b, 2)...)
+		}
+		chunks = append(chunks, mergePieces(pieces, c.size, 0, c.length)...)
+	}
+	return chunks, nil
+}
+
+// markdownSections はテキストを見出しごとのセクションに分け、各セクションをブロックの列で返します。
+// ブロックは見出し行、フェンスで囲まれたコードブロック、表（| で始まる行の連続）、段落（空行まで）のいずれかで、
+// 後ろの空行を含む。ブロックを連結すると元のテキストに戻る
+func markdownSections(text string) [][]string {
+	var (
+		sections [][]string
+		blocks   []string
+		current  strings.Builder
+		fence    string // コードブロックの中ならその開始のフェンス
+		inTable  bool
+	)
+
+	endBlock := func() {
+		if current.Len() > 0 {
+			blocks = append(blocks, current.String())
+			current.Reset()
+		}
+		inTable = false
+	}
+	endSection := func() {
+		endBlock()
+		if len(blocks) > 0 {
+			sections = append(sections, blocks)
+			blocks = nil
+		}
+	}
+
+	for _, line := range splitAfter(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if fence != "" {
+			current.WriteString(line)
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+				endBlock()
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			endBlock()
+			fence = trimmed[:3]
+			current.WriteString(line)
+		case isMarkdownHeading(line):
+			endSection()
+			current.WriteString(line)
+			endBlock()
+		case trimmed == "":
+			// 空行は直前のブロックに含めて、ブロックを閉じる
+			if current.Len() == 0 && len(blocks) > 0 {
+				blocks[len(blocks)-1] += line
+				continue
+			}
+			current.WriteString(line)
+			endBlock()
+		case strings.HasPrefix(trimmed, "|"):
+			if !inTable {
+				endBlock()
+				inTable = true
+			}
+			current.WriteString(line)
+		default:
+			if inTable {
+				endBlock()
+			}
+			current.WriteString(line)
+		}
+	}
+	endSection()
+
+	return sections
+}
+
+// semanticChunker は文ごとの embedding を計算し、隣り合う文の類似度が大きく下がる位置で区切ります。
+// 区切った後も chunk サイズを超える部分は、その中で最も類似度の低い位置でさらに分ける。
+// chunk サイズに収まるテキストは分けない（embedding の計算もしない）
+type semanticChunker struct {
+	size     int
+	embed    embedFunc
+	fallback *recursiveChunker // 1文だけで chunk サイズを超える場合に使う
+}
+
+func (c *semanticChunker) Chunk(ctx context.Context, text string) ([]string, error) {
+	if strings.TrimSpace(text) == "" {
+		return []string{}, nil
+	}
+	if utf8.RuneCountInString(text) <= c.size {
+		return []string{strings.TrimSpace(text)}, nil
+	}
+
+	// Step 1: 文に分ける（長すぎる文は先に分けておく）
+	// 空白だけの部分（先頭の空行など）は隣の文に含める
+	var sentences []string
+	prefix := ""
+	for _, s := range splitSentences(text) {
+		if strings.TrimSpace(s) == "" {
+			if len(sentences) > 0 {
+				sentences[len(sentences)-1] += s
+			} else {
+				prefix += s
+			}
+			continue
+		}
+		sentences = append(sentences, c.fallback.split(prefix+s, 0)...)
+		prefix = ""
+	}
+	if len(sentences) < 2 {
+		return c.fallback.Chunk(ctx, text)
+	}
+
+	// Step 2: 隣り合う文の距離（1 - コサイン類似度）
+	inputs := make([]string, len(sentences))
+	for i, s := range sentences {
+		inputs[i] = strings.TrimSpace(s)
+	}
+	embeddings, err := c.embed(ctx, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed sentences: %w", err)
+	}
+	if len(embeddings) != len(sentences) {
+		return nil, fmt.Errorf("sentence embedding count mismatch: got %d, want %d", len(embeddings), len(sentences))
+	}
+	distances := make([]float64, len(sentences)-1)
+	for i := range distances {
+		distances[i] = 1 - cosineSimilarity(embeddings[i], embeddings[i+1])
+	}
+
+	// Step 3: 距離が分位点を超える位置（話題が変わる位置）で区切り、大きすぎる部分はさらに分ける
+	threshold := percentile(distances, semanticBreakpointPercentile)
+	chunks := []string{}
+	start := 0
+	for i := 0; i <= len(distances); i++ {
+		if i < len(distances) && distances[i] <= threshold {
+			continue
+		}
+		for _, group := range c.splitGroup(sentences, distances, start, i+1) {
+			if chunk := strings.TrimSpace(group); chunk != "" {
+				chunks = append(chunks, chunk)
+			}
+		}
+		start = i + 1
+	}
+
+	log.Printf("🧩 Semantic chunking: %d sentences -> %d chunks", len(sentences), len(chunks))
+	return chunks, nil
+}
+
+// splitGroup は sentences[start:end] が chunk サイズを超える場合、最も距離の大きい位置で再帰的に分けます
+func (c *semanticChunker) splitGroup(sentences []string, distances []float64, start, end int) []string {
+	text := strings.Join(sentences[start:end], "")
+	if end-start <= 1 || utf8.RuneCountInString(text) <= c.size {
+		return []string{text}
+	}
+
+	at := start
+	for i := start + 1; i < end-1; i++ {
+		if distances[i] > distances[at] {
+			at = i
+		}
+	}
+	return append(c.splitGroup(sentences, distances, start, at+1), c.splitGroup(sentences, distances, at+1, end)...)
+}
+
+func cosineSimilarity(a, b []float64) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		if i >= len(b) {
+			break
+		}
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// percentile は values の p 分位点（0〜1、線形補間）を返します
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	pos := p * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
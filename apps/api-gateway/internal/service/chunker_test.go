@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestNewChunker_UnknownStrategy(t *testing.T) {
+	if _, err := NewChunker("sliding", 500, 50, nil, nil); err == nil {
+		t.Errorf("Expected an error for an unknown strategy")
+	}
+	if _, err := NewChunker(ChunkStrategySemantic, 500, 50, nil, nil); err == nil {
+		t.Errorf("Expected an error for semantic chunking without an embedding function")
+	}
+}
+
+func TestRecursiveChunker_KeepsParagraphs(t *testing.T) {
+	paragraphs := []string{
+		strings.Repeat("あ", 40) + "。",
+		strings.Repeat("い", 40) + "。",
+		strings.Repeat("う", 40) + "。",
+	}
+	text := strings.Join(paragraphs, "\n\n")
+
+	chunker, err := NewChunker(ChunkStrategyRecursive, 100, 50, nil, nil)
+	if err != nil {
+		t.Fatalf("NewChunker failed: %v", err)
+	}
+	chunks, err := chunker.Chunk(context.Background(), text)
+	if err != nil {
+		t.Fatalf("Chunk failed: %v", err)
+	}
+
+	// 2段落ずつ収まり、2つ目のチャンクは直前の段落をオーバーラップとして含む
+	want := []string{
+		paragraphs[0] + "\n\n" + paragraphs[1],
+		paragraphs[1] + "\n\n" + paragraphs[2],
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("Expected %d chunks, got %q", len(want), chunks)
+	}
+	for i := range want {
+		if chunks[i] != want[i] {
+			t.Errorf("Chunk %d: expected %q, got %q", i, want[i], chunks[i])
+		}
+	}
+}
+
+func TestRecursiveChunker_SplitsLongText(t *testing.T) {
+	text := strings.Repeat("これはテストの文です。", 50) + strings.Repeat("x", 250)
+
+	chunker, _ := NewChunker(ChunkStrategyRecursive, 100, 20, nil, nil)
+	chunks, err := chunker.Chunk(context.Background(), text)
+	if err != nil {
+		t.Fatalf("Chunk failed: %v", err)
+	}
+
+	for i, chunk := range chunks {
+		if n := utf8.RuneCountInString(chunk); n > 100 {
+			t.Errorf("Chunk %d has %d runes, exceeds chunk size", i, n)
+		}
+	}
+	if !strings.HasSuffix(chunks[len(chunks)-1], "x") {
+		t.Errorf("Expected the text without separators to be kept, got %q", chunks[len(chunks)-1])
+	}
+}
+
+func TestTokenChunker_SizesByTokens(t *testing.T) {
+	// 英単語は4文字で約1トークン。文字数なら 100 を超えるが、トークン数では1チャンクに収まる
+	text := strings.Repeat("word ", 60)
+
+	chunker, _ := NewChunker(ChunkStrategyToken, 100, 10, nil, nil)
+	chunks, err := chunker.Chunk(context.Background(), text)
+	if err != nil {
+		t.Fatalf("Chunk failed: %v", err)
+	}
+	if len(chunks) != 1 {
+		t.Errorf("Expected 1 chunk, got %d", len(chunks))
+	}
+
+	chunks, _ = chunker.Chunk(context.Background(), strings.Repeat("word ", 200))
+	for i, chunk := range chunks {
+		if n := estimateTokens(chunk); n > 100 {
+			t.Errorf("Chunk %d has %d tokens, exceeds chunk size", i, n)
+		}
+	}
+	if len(chunks) < 3 {
+		t.Errorf("Expected at least 3 chunks, got %d", len(chunks))
+	}
+}
+
+func TestMarkdownChunker_KeepsCodeBlocks(t *testing.T) {
+	code := "```go\nfunc main() {\n\n\tfmt.Println(\"hello\")\n}\n```"
+	text := "# Setup\n\n" + strings.Repeat("Install the tool first. ", 3) + "\n\n" + code + "\n\n" +
+		"# Usage\n\n" + strings.Repeat("Run the command. ", 3)
+
+	chunker, _ := NewChunker(ChunkStrategyMarkdown, 100, 0, nil, nil)
+	chunks, err := chunker.Chunk(context.Background(), text)
+	if err != nil {
+		t.Fatalf("Chunk failed: %v", err)
+	}
+
+	found := false
+	for _, chunk := range chunks {
+		if strings.Contains(chunk, code) {
+			found = true
+		}
+		if strings.Contains(chunk, "# Setup") && strings.Contains(chunk, "# Usage") {
+			t.Errorf("Expected sections to be split at headings, got %q", chunk)
+		}
+	}
+	if !found {
+		t.Errorf("Expected the code block to stay in one chunk, got %q", chunks)
+	}
+	if !strings.HasPrefix(chunks[len(chunks)-1], "# Usage") {
+		t.Errorf("Expected the last chunk to start with its heading, got %q", chunks[len(chunks)-1])
+	}
+}
+
+func TestSemanticChunker_SplitsAtTopicChange(t *testing.T) {
+	sentences := []string{
+		"Cats are small pets.", "Cats like to sleep.", "Cats chase mice.",
+		"Rockets fly to space.", "Rockets burn fuel.", "Rockets carry satellites.",
+	}
+	text := strings.Join(sentences, " ")
+
+	// 猫の文と宇宙の文で直交するベクトルを返す
+	embed := func(ctx context.Context, texts []string) ([][]float64, error) {
+		vectors := make([][]float64, len(texts))
+		for i, s := range texts {
+			if strings.HasPrefix(s, "Cats") {
+				vectors[i] = []float64{1, 0.1 * float64(i)}
+			} else {
+				vectors[i] = []float64{0.1 * float64(i), 1}
+			}
+		}
+		return vectors, nil
+	}
+
+	chunker, err := NewChunker(ChunkStrategySemantic, 100, 0, embed, nil)
+	if err != nil {
+		t.Fatalf("NewChunker failed: %v", err)
+	}
+	chunks, err := chunker.Chunk(context.Background(), text)
+	if err != nil {
+		t.Fatalf("Chunk failed: %v", err)
+	}
+
+	want := []string{
+		strings.Join(sentences[:3], " "),
+		strings.Join(sentences[3:], " "),
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("Expected %d chunks, got %q", len(want), chunks)
+	}
+	for i := range want {
+		if chunks[i] != want[i] {
+			t.Errorf("Chunk %d: expected %q, got %q", i, want[i], chunks[i])
+		}
+	}
+}
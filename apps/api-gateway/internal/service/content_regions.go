@@ -0,0 +1,49 @@
+package service
+
+import "strings"
+
+// ChunkRegion はチャンクの本文が描かれているページ上の領域（引用箇所のハイライトに使う）
+type ChunkRegion struct {
+	PageNumber int `json:"page_number"`
+	BoundingBox
+}
+
+// locateChunkRegions は各チャンクがどのブロックから作られたかを本文中の位置で求め、ブロックの領域を返します。
+// Blocks を持たない区切り（PDF 以外）では nil を返す。
+// チャンクは Content の部分文字列（前後の空白を除いたもの）で、オーバーラップを除けば前から順に並んでいる前提
+func locateChunkRegions(page PageContent, chunks []string) [][]ChunkRegion {
+	if len(page.Blocks) == 0 {
+		return nil
+	}
+
+	// Content 中の各ブロックの位置（Content は Blocks の Text を "\n\n" で連結したもの）
+	starts := make([]int, len(page.Blocks))
+	ends := make([]int, len(page.Blocks))
+	pos := 0
+	for i, b := range page.Blocks {
+		starts[i], ends[i] = pos, pos+len(b.Text)
+		pos = ends[i] + len("\n\n")
+	}
+
+	regions := make([][]ChunkRegion, len(chunks))
+	cursor := 0
+	for i, chunk := range chunks {
+		idx := strings.Index(page.Content[cursor:], chunk)
+		if idx < 0 {
+			if idx = strings.Index(page.Content, chunk); idx < 0 {
+				continue
+			}
+		} else {
+			idx += cursor
+		}
+		start, end := idx, idx+len(chunk)
+
+		for j, b := range page.Blocks {
+			if starts[j] < end && start < ends[j] {
+				regions[i] = append(regions[i], ChunkRegion{PageNumber: page.PageNumber, BoundingBox: b.BBox})
+			}
+		}
+		cursor = idx + 1
+	}
+	return regions
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/google/uuid"
+)
+
+// ContextExpansion は検索でヒットした子チャンクを LLM に渡す前にどう広げるか
+type ContextExpansion string
+
+const (
+	ContextExpansionNone   ContextExpansion = "none"   // ヒットしたチャンクだけを渡す
+	ContextExpansionParent ContextExpansion = "parent" // 親チャンク（ページ内のセクション）に広げ、同じ親は1つにまとめる
+	ContextExpansionWindow ContextExpansion = "window" // 同じバージョンの前後 Window 件のチャンクをつなげる
+)
+
+const (
+	// DefaultContextWindow は window のときにヒットしたチャンクの前後それぞれ何件をつなげるか
+	DefaultContextWindow = 1
+	maxContextWindow     = 10
+	// minOverlapJoin はチャンクをつなげるときに重なりとみなす最小のバイト数（偶然の一致で本文を削らないため）
+	minOverlapJoin = 8
+)
+
+// retrievedContext は LLM に渡すコンテキストの1件です（ヒットした子チャンクを広げたもの）。
+// 引用（DocumentReference / SearchSource）はヒットした子チャンクのまま作る
+type retrievedContext struct {
+	text       string
+	pageNumber *int
+	score      float64 // まとめた子チャンクのうち最も高いスコア
+	hits       int     // まとめた子チャンクの数
+}
+
+// expand は opts.Expansion に従って、ヒットした子チャンク（スコア降順）を LLM に渡すコンテキストに広げます。
+// 親チャンクや前後のチャンクを取得できなかった場合は、ヒットしたチャンクの本文のまま続行します
+func (r *hybridRetriever) expand(ctx context.Context, results []rankedResult, opts RetrievalOptions) []retrievedContext {
+	opts = opts.withDefaults()
+	if opts.Expansion == ContextExpansionNone || len(results) == 0 {
+		return matchedContexts(results)
+	}
+
+	pointIDs := make([]uuid.UUID, 0, len(results))
+	for _, res := range results {
+		if id, err := uuid.Parse(res.ID); err == nil {
+			pointIDs = append(pointIDs, id)
+		}
+	}
+
+	var contexts []retrievedContext
+	switch opts.Expansion {
+	case ContextExpansionParent:
+		rows, err := r.queries.GetParentChunksByPointIDs(ctx, pointIDs)
+		if err != nil {
+			log.Printf("⚠️ [RAG] Failed to load parent chunks, using matched chunks: %v", err)
+			return matchedContexts(results)
+		}
+		contexts = expandToParents(results, rows)
+	case ContextExpansionWindow:
+		rows, err := r.queries.GetChunkWindowsByPointIDs(ctx, db.GetChunkWindowsByPointIDsParams{
+			WindowSize: int32(opts.Window),
+			PointIds:   pointIDs,
+		})
+		if err != nil {
+			log.Printf("⚠️ [RAG] Failed to load neighbouring chunks, using matched chunks: %v", err)
+			return matchedContexts(results)
+		}
+		contexts = expandToWindows(results, rows)
+	}
+
+	log.Printf("🧩 [RAG] Expanded %d matched chunks into %d contexts (expansion=%s)", len(results), len(contexts), opts.Expansion)
+	return contexts
+}
+
+// matchedContexts はヒットしたチャンクの本文（payload の text）をそのままコンテキストにします
+func matchedContexts(results []rankedResult) []retrievedContext {
+	contexts := make([]retrievedContext, len(results))
+	for i, res := range results {
+		contexts[i] = matchedContext(res)
+	}
+	return contexts
+}
+
+func matchedContext(res rankedResult) retrievedContext {
+	c := retrievedContext{score: res.Score, hits: 1}
+	c.text, _ = res.Payload["text"].(string)
+	if v, ok := res.Payload["page_number"].(float64); ok {
+		page := int(v)
+		c.pageNumber = &page
+	}
+	return c
+}
+
+// expandToParents はヒットした子チャンクを親チャンクに置き換えます。
+// 同じ親を持つ子チャンクは最初の（スコアの高い）位置に1つにまとめ、親のない子チャンクはそのまま使う
+func expandToParents(results []rankedResult, rows []db.GetParentChunksByPointIDsRow) []retrievedContext {
+	parents := make(map[string]db.GetParentChunksByPointIDsRow, len(rows))
+	for _, row := range rows {
+		if _, ok := parents[row.PointID.String()]; !ok {
+			parents[row.PointID.String()] = row
+		}
+	}
+
+	var contexts []retrievedContext
+	seen := make(map[uuid.UUID]int) // 親チャンクID → contexts の位置
+	for _, res := range results {
+		row, ok := parents[res.ID]
+		if !ok {
+			contexts = append(contexts, matchedContext(res))
+			continue
+		}
+		if i, ok := seen[row.ParentID]; ok {
+			contexts[i].hits++
+			continue
+		}
+		seen[row.ParentID] = len(contexts)
+		page := int(row.PageNumber)
+		contexts = append(contexts, retrievedContext{text: row.Content, pageNumber: &page, score: res.Score, hits: 1})
+	}
+	return contexts
+}
+
+// expandToWindows はヒットした子チャンクを前後のチャンクとつなげます。
+// スコアの高い順に、まだ他のコンテキストで使っていないチャンクだけをつなげ、
+// ヒットしたチャンク自体が既に使われていればそのコンテキストにまとめる
+func expandToWindows(results []rankedResult, rows []db.GetChunkWindowsByPointIDsRow) []retrievedContext {
+	windows := make(map[string][]db.GetChunkWindowsByPointIDsRow)
+	for _, row := range rows {
+		key := row.PointID.String()
+		windows[key] = append(windows[key], row)
+	}
+
+	var contexts []retrievedContext
+	used := make(map[uuid.UUID]int) // チャンクID → contexts の位置
+	for _, res := range results {
+		window := windows[res.ID]
+		hit := -1
+		for i, row := range window {
+			if row.Matched {
+				hit = i
+				break
+			}
+		}
+		if hit < 0 {
+			contexts = append(contexts, matchedContext(res))
+			continue
+		}
+		if i, ok := used[window[hit].ID]; ok {
+			contexts[i].hits++
+			continue
+		}
+
+		// ヒットしたチャンクから前後に、使われていないチャンクが続く範囲を広げる
+		start, end := hit, hit+1
+		for start > 0 && !isUsed(used, window[start-1].ID) {
+			start--
+		}
+		for end < len(window) && !isUsed(used, window[end].ID) {
+			end++
+		}
+
+		texts := make([]string, 0, end-start)
+		for _, row := range window[start:end] {
+			used[row.ID] = len(contexts)
+			texts = append(texts, row.Content)
+		}
+		// ページ番号はヒットしたチャンクのもの
+		page := int(window[hit].PageNumber)
+		contexts = append(contexts, retrievedContext{text: joinOverlapping(texts), pageNumber: &page, score: res.Score, hits: 1})
+	}
+	return contexts
+}
+
+func isUsed(used map[uuid.UUID]int, id uuid.UUID) bool {
+	_, ok := used[id]
+	return ok
+}
+
+// joinOverlapping は連続するチャンクをつなげます。
+// chunk_overlap で前のチャンクの末尾と次のチャンクの先頭が重なっている場合は、重なった部分を1回だけ含める
+func joinOverlapping(texts []string) string {
+	var b strings.Builder
+	prev := ""
+	for i, text := range texts {
+		if i == 0 {
+			b.WriteString(text)
+			prev = text
+			continue
+		}
+		overlap := longestOverlap(prev, text)
+		if overlap == 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(text[overlap:])
+		prev = text
+	}
+	return b.String()
+}
+
+// longestOverlap は a の末尾と b の先頭が一致する最長のバイト数を返します（minOverlapJoin 未満なら 0）
+func longestOverlap(a, b string) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for k := n; k >= minOverlapJoin; k-- {
+		if k < len(b) && !utf8.RuneStart(b[k]) {
+			continue
+		}
+		if strings.HasSuffix(a, b[:k]) {
+			return k
+		}
+	}
+	return 0
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/storage"
+	"github.com/google/uuid"
+)
+
+func newTestRankedResult(id uuid.UUID, score float64, text string, page int) rankedResult {
+	return rankedResult{SearchResult: storage.SearchResult{
+		ID:      id.String(),
+		Score:   score,
+		Payload: map[string]interface{}{"text": text, "page_number": float64(page)},
+	}}
+}
+
+func TestExpandToParents_DeduplicatesParents(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	parent := uuid.New()
+	results := []rankedResult{
+		newTestRankedResult(a, 0.9, "child a", 1),
+		newTestRankedResult(b, 0.8, "child b", 2),
+		newTestRankedResult(c, 0.7, "child c", 1),
+	}
+	rows := []db.GetParentChunksByPointIDsRow{
+		{PointID: a, ParentID: parent, PageNumber: 1, Content: "parent section"},
+		{PointID: c, ParentID: parent, PageNumber: 1, Content: "parent section"},
+	}
+
+	contexts := expandToParents(results, rows)
+
+	// a と c は同じ親にまとめ、親のない b は子チャンクのまま使う
+	if len(contexts) != 2 {
+		t.Fatalf("Expected 2 contexts, got %+v", contexts)
+	}
+	if contexts[0].text != "parent section" || contexts[0].hits != 2 || contexts[0].score != 0.9 {
+		t.Errorf("Unexpected parent context: %+v", contexts[0])
+	}
+	if contexts[1].text != "child b" || *contexts[1].pageNumber != 2 {
+		t.Errorf("Expected the child without a parent to be kept, got %+v", contexts[1])
+	}
+}
+
+func TestExpandToWindows_JoinsNeighboursOnce(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	c0, c1, c2, c3 := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	results := []rankedResult{
+		newTestRankedResult(a, 0.9, "second chunk text", 1),
+		newTestRankedResult(b, 0.8, "third chunk text", 2),
+	}
+	// a は chunk 1（前後の 0 と 2）、b は chunk 2（前後の 1 と 3）にヒットした
+	rows := []db.GetChunkWindowsByPointIDsRow{
+		{PointID: a, ID: c0, ChunkIndex: 0, PageNumber: 1, Content: "first chunk text"},
+		{PointID: a, ID: c1, ChunkIndex: 1, PageNumber: 1, Content: "second chunk text", Matched: true},
+		{PointID: a, ID: c2, ChunkIndex: 2, PageNumber: 2, Content: "third chunk text"},
+		{PointID: b, ID: c1, ChunkIndex: 1, PageNumber: 1, Content: "second chunk text"},
+		{PointID: b, ID: c2, ChunkIndex: 2, PageNumber: 2, Content: "third chunk text", Matched: true},
+		{PointID: b, ID: c3, ChunkIndex: 3, PageNumber: 2, Content: "fourth chunk text"},
+	}
+
+	contexts := expandToWindows(results, rows)
+
+	// b がヒットした chunk 2 は a のウィンドウに含まれているため、a のコンテキストにまとめる
+	if len(contexts) != 1 {
+		t.Fatalf("Expected 1 context, got %+v", contexts)
+	}
+	want := "first chunk text\nsecond chunk text\nthird chunk text"
+	if contexts[0].text != want || contexts[0].hits != 2 || *contexts[0].pageNumber != 1 {
+		t.Errorf("Unexpected window context: %+v", contexts[0])
+	}
+}
+
+func TestJoinOverlapping_TrimsChunkOverlap(t *testing.T) {
+	got := joinOverlapping([]string{"これは最初のチャンクです。重なる部分", "重なる部分のあとに続く本文"})
+	if got != "これは最初のチャンクです。重なる部分のあとに続く本文" {
+		t.Errorf("Expected the overlap to appear once, got %q", got)
+	}
+
+	// 短い偶然の一致は重なりとみなさない
+	if got := joinOverlapping([]string{"ends with a", "a starts"}); got != "ends with a\na starts" {
+		t.Errorf("Expected a newline join without overlap, got %q", got)
+	}
+}
+
+func TestRetrievalOptions_ValidateExpansion(t *testing.T) {
+	if err := (RetrievalOptions{Expansion: "document"}).Validate(); err == nil {
+		t.Errorf("Expected error for unknown context expansion")
+	}
+	if err := (RetrievalOptions{Expansion: ContextExpansionWindow, Window: maxContextWindow + 1}).Validate(); err == nil {
+		t.Errorf("Expected error for a context window over the limit")
+	}
+	if opts := (RetrievalOptions{}).withDefaults(); opts.Expansion != ContextExpansionParent || opts.Window != DefaultContextWindow {
+		t.Errorf("Unexpected defaults: %+v", opts)
+	}
+}
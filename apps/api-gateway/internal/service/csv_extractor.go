@@ -0,0 +1,81 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CSVExtractor は CSV / TSV を行単位で抽出します。
+// 1行目をヘッダーとして扱い、チャンク分割では行の途中で切らずに、各チャンクの先頭にヘッダー行を付けます。
+type CSVExtractor struct {
+	Comma rune // 区切り文字（CSV は ','、TSV は '\t'）
+}
+
+// Extract は表全体を1つの PageContent（Rows に各行）として返します
+func (e *CSVExtractor) Extract(ctx context.Context, reader io.Reader) ([]PageContent, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV data: %w", err)
+	}
+	// Excel が付ける UTF-8 の BOM を除く
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = e.Comma
+	if r.Comma == 0 {
+		r.Comma = ','
+	}
+	r.FieldsPerRecord = -1 // 列数が揃っていない行も読む
+	r.LazyQuotes = true
+
+	var header string
+	var rows []string
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CSV: %w", err)
+		}
+
+		line := formatCSVRecord(record)
+		if strings.TrimSpace(strings.ReplaceAll(line, "|", "")) == "" {
+			continue // 空行
+		}
+		if header == "" {
+			header = line
+			continue
+		}
+		rows = append(rows, line)
+	}
+
+	if header == "" {
+		return nil, fmt.Errorf("no rows found in CSV")
+	}
+
+	content := header
+	if len(rows) > 0 {
+		content += "\n" + strings.Join(rows, "\n")
+	}
+	return []PageContent{{
+		PageNumber: 1,
+		Content:    content,
+		Rows:       rows,
+		RowHeader:  header,
+	}}, nil
+}
+
+// formatCSVRecord はセルを " | " でつないだ1行にします（セル内の改行は空白にする）
+func formatCSVRecord(record []string) string {
+	cells := make([]string, len(record))
+	for i, c := range record {
+		cells[i] = strings.Join(strings.Fields(c), " ")
+	}
+	return strings.Join(cells, " | ")
+}
@@ -0,0 +1,427 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/storage"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrDirectoryNotFound はディレクトリ（または移動先の親）がワークスペースに存在しない場合のエラー
+	ErrDirectoryNotFound = errors.New("directory not found")
+	// ErrDirectoryNameConflict は同じ親の下に同じ名前のディレクトリが既にある場合のエラー
+	ErrDirectoryNameConflict = errors.New("directory with the same name already exists")
+	// ErrDirectoryCycle はディレクトリを自分自身または配下に移動しようとした場合のエラー
+	ErrDirectoryCycle = errors.New("cannot move a directory into itself or its subdirectory")
+	// ErrInvalidDirectoryName は空の名前や "/" を含む名前の場合のエラー
+	ErrInvalidDirectoryName = errors.New("invalid directory name")
+)
+
+// maxDirectoryNameLength はディレクトリ名の最大文字数
+const maxDirectoryNameLength = 255
+
+// DirectoryNode はディレクトリツリーの1ノード
+type DirectoryNode struct {
+	ID            uuid.UUID
+	ParentID      *uuid.UUID
+	Name          string
+	DocumentCount int64 // 直下のドキュメント数（サブディレクトリは含まない）
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Children      []*DirectoryNode // ツリー取得時のみ（単体の取得・更新では nil）
+}
+
+// DirectoryDeleteResult はディレクトリ削除の結果
+type DirectoryDeleteResult struct {
+	DeletedDirectories int
+	DeletedDocuments   int
+}
+
+// DirectoryService はディレクトリツリーの作成・名前変更・移動・削除と、ドキュメントの移動を担当
+type DirectoryService struct {
+	db          *sql.DB
+	queries     *db.Queries
+	vectorStore storage.VectorStore
+}
+
+// NewDirectoryService は新しい DirectoryService を作成
+func NewDirectoryService(
+	database *sql.DB,
+	queries *db.Queries,
+	vectorStore storage.VectorStore,
+) *DirectoryService {
+	return &DirectoryService{
+		db:          database,
+		queries:     queries,
+		vectorStore: vectorStore,
+	}
+}
+
+// CreateDirectory は parentID の下（nil ならワークスペース直下）にディレクトリを作成します
+func (s *DirectoryService) CreateDirectory(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	name string,
+	parentID *uuid.UUID,
+) (*DirectoryNode, error) {
+	name, err := normalizeDirectoryName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	parent := uuid.NullUUID{}
+	if parentID != nil {
+		if _, err := getDirectory(ctx, s.queries, workspaceID, *parentID); err != nil {
+			return nil, err
+		}
+		parent = uuid.NullUUID{UUID: *parentID, Valid: true}
+	}
+
+	dir, err := s.queries.CreateDirectory(ctx, db.CreateDirectoryParams{
+		WorkspaceID: workspaceID,
+		ParentID:    parent,
+		Name:        name,
+	})
+	if err != nil {
+		return nil, directoryWriteError("create", err)
+	}
+
+	log.Printf("📁 Directory created: id=%s, name=%s", dir.ID, dir.Name)
+	return toDirectoryNode(dir), nil
+}
+
+// GetDirectory はディレクトリを1件取得します
+func (s *DirectoryService) GetDirectory(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	directoryID uuid.UUID,
+) (*DirectoryNode, error) {
+	dir, err := getDirectory(ctx, s.queries, workspaceID, directoryID)
+	if err != nil {
+		return nil, err
+	}
+	return toDirectoryNode(dir), nil
+}
+
+// ListDirectoryTree は rootID（nil ならワークスペース直下のすべて）以下のツリーを返します。
+// rootID を指定した場合はそのディレクトリ1件を根とするツリーになります。
+func (s *DirectoryService) ListDirectoryTree(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	rootID *uuid.UUID,
+) ([]*DirectoryNode, error) {
+	root := uuid.NullUUID{}
+	if rootID != nil {
+		if _, err := getDirectory(ctx, s.queries, workspaceID, *rootID); err != nil {
+			return nil, err
+		}
+		root = uuid.NullUUID{UUID: *rootID, Valid: true}
+	}
+
+	rows, err := s.queries.ListDirectoryTree(ctx, db.ListDirectoryTreeParams{
+		WorkspaceID: workspaceID,
+		RootID:      root,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list directory tree: %w", err)
+	}
+
+	return buildDirectoryTree(rows), nil
+}
+
+// RenameDirectory はディレクトリの名前を変更します
+func (s *DirectoryService) RenameDirectory(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	directoryID uuid.UUID,
+	name string,
+) (*DirectoryNode, error) {
+	name, err := normalizeDirectoryName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := s.queries.RenameDirectory(ctx, db.RenameDirectoryParams{
+		ID:          directoryID,
+		WorkspaceID: workspaceID,
+		Name:        name,
+	})
+	if err != nil {
+		return nil, directoryWriteError("rename", err)
+	}
+	return toDirectoryNode(dir), nil
+}
+
+// MoveDirectory はディレクトリを parentID の下（nil ならワークスペース直下）に移動します。
+// 配下のドキュメントの directory_id は変わらないため、ベクトルの payload の更新は不要です。
+// 循環の確認と付け替えは、ワークスペースのツリーをロックした1トランザクションで行います
+// （A を B の下へ、B を A の下へという同時の移動が両方とも確認を通ってしまわないように）。
+func (s *DirectoryService) MoveDirectory(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	directoryID uuid.UUID,
+	parentID *uuid.UUID,
+) (*DirectoryNode, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := s.queries.WithTx(tx)
+	if err := qtx.LockWorkspaceDirectoryTree(ctx, workspaceID); err != nil {
+		return nil, fmt.Errorf("failed to lock directory tree: %w", err)
+	}
+
+	// Step 1: 移動元の存在確認
+	if _, err := getDirectory(ctx, qtx, workspaceID, directoryID); err != nil {
+		return nil, err
+	}
+
+	// Step 2: 移動先が自分自身や配下でないことを確認（循環を防ぐ）
+	parent := uuid.NullUUID{}
+	if parentID != nil {
+		if _, err := getDirectory(ctx, qtx, workspaceID, *parentID); err != nil {
+			return nil, err
+		}
+		descendants, err := qtx.GetDirectoryDescendantIDs(ctx, db.GetDirectoryDescendantIDsParams{
+			DirectoryIds: []uuid.UUID{directoryID},
+			WorkspaceID:  workspaceID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve directory tree: %w", err)
+		}
+		for _, id := range descendants {
+			if id == *parentID {
+				return nil, ErrDirectoryCycle
+			}
+		}
+		parent = uuid.NullUUID{UUID: *parentID, Valid: true}
+	}
+
+	// Step 3: 親を付け替える
+	dir, err := qtx.MoveDirectory(ctx, db.MoveDirectoryParams{
+		ParentID:    parent,
+		ID:          directoryID,
+		WorkspaceID: workspaceID,
+	})
+	if err != nil {
+		return nil, directoryWriteError("move", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit: %w", err)
+	}
+
+	log.Printf("📁 Directory moved: id=%s, parent=%v", dir.ID, parentID)
+	return toDirectoryNode(dir), nil
+}
+
+// DeleteDirectory はディレクトリとその配下のサブディレクトリ・ドキュメントを削除します。
+// ディレクトリとドキュメントは論理削除し、チャンクと VectorStore のポイントは物理削除します
+// （ファイル実体は参照がなくなった後に blob GC が回収します）。
+func (s *DirectoryService) DeleteDirectory(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	directoryID uuid.UUID,
+) (*DirectoryDeleteResult, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Step 1: ツリーをロックしてから配下のディレクトリを再帰的に取得
+	// （取得後に別のディレクトリが配下へ移動してきて、削除されずに残らないように）
+	qtx := s.queries.WithTx(tx)
+	if err := qtx.LockWorkspaceDirectoryTree(ctx, workspaceID); err != nil {
+		return nil, fmt.Errorf("failed to lock directory tree: %w", err)
+	}
+	if _, err := getDirectory(ctx, qtx, workspaceID, directoryID); err != nil {
+		return nil, err
+	}
+	directoryIDs, err := qtx.GetDirectoryDescendantIDs(ctx, db.GetDirectoryDescendantIDsParams{
+		DirectoryIds: []uuid.UUID{directoryID},
+		WorkspaceID:  workspaceID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve directory tree: %w", err)
+	}
+
+	// Step 2: ドキュメント・チャンク・ディレクトリを同じトランザクションで削除
+	documentIDs, err := qtx.SoftDeleteDocumentsInDirectories(ctx, db.SoftDeleteDocumentsInDirectoriesParams{
+		WorkspaceID:  workspaceID,
+		DirectoryIds: directoryIDs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete documents: %w", err)
+	}
+	if len(documentIDs) > 0 {
+		if err := qtx.DeleteDocumentChunksByDocumentIDs(ctx, documentIDs); err != nil {
+			return nil, fmt.Errorf("failed to delete document chunks: %w", err)
+		}
+	}
+	if err := qtx.SoftDeleteDirectories(ctx, db.SoftDeleteDirectoriesParams{
+		Ids:         directoryIDs,
+		WorkspaceID: workspaceID,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to delete directories: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit: %w", err)
+	}
+
+	// Step 3: VectorStore からポイントを削除（失敗はログだけ。ドキュメントは削除済みなので検索結果には出ない）
+	if len(documentIDs) > 0 {
+		collectionName := fmt.Sprintf("workspace_%s", workspaceID.String())
+		err := s.vectorStore.DeleteByFilter(
+			ctx,
+			collectionName,
+			&storage.Filter{Must: []storage.Condition{storage.MatchAny("document_id", uuidStrings(documentIDs))}},
+		)
+		if err != nil && !errors.Is(err, storage.ErrCollectionNotFound) {
+			log.Printf("⚠️  Failed to delete from vector store: %v", err)
+		}
+	}
+
+	log.Printf("🗑️  Directory deleted: id=%s, directories=%d, documents=%d", directoryID, len(directoryIDs), len(documentIDs))
+	return &DirectoryDeleteResult{
+		DeletedDirectories: len(directoryIDs),
+		DeletedDocuments:   len(documentIDs),
+	}, nil
+}
+
+// MoveDocuments はドキュメントを directoryID（nil ならワークスペース直下）に移動し、
+// ベクトルの payload の directory_id も合わせて更新します。移動したドキュメントのIDを返します。
+func (s *DirectoryService) MoveDocuments(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	documentIDs []uuid.UUID,
+	directoryID *uuid.UUID,
+) ([]uuid.UUID, error) {
+	// Step 1: 移動先の存在確認
+	target := uuid.NullUUID{}
+	var payloadDirectoryID interface{}
+	if directoryID != nil {
+		if _, err := getDirectory(ctx, s.queries, workspaceID, *directoryID); err != nil {
+			return nil, err
+		}
+		target = uuid.NullUUID{UUID: *directoryID, Valid: true}
+		payloadDirectoryID = directoryID.String()
+	}
+
+	// Step 2: documents を更新
+	moved, err := s.queries.MoveDocuments(ctx, db.MoveDocumentsParams{
+		DirectoryID: target,
+		WorkspaceID: workspaceID,
+		Ids:         documentIDs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to move documents: %w", err)
+	}
+
+	// Step 3: ディレクトリで絞り込むベクトル検索のため payload を更新
+	if err := updateDocumentPayloads(ctx, s.queries, s.vectorStore, workspaceID, moved, map[string]interface{}{
+		"directory_id": payloadDirectoryID,
+	}); err != nil {
+		// documents は更新済み。再処理（force_reprocess）で payload は作り直される
+		log.Printf("⚠️  Failed to update directory_id in vector store: %v", err)
+	}
+
+	log.Printf("📁 Documents moved: count=%d, directory=%v", len(moved), directoryID)
+	return moved, nil
+}
+
+// getDirectory はディレクトリを取得します（トランザクション内では qtx を渡す）
+func getDirectory(ctx context.Context, queries *db.Queries, workspaceID, directoryID uuid.UUID) (db.Directory, error) {
+	dir, err := queries.GetDirectory(ctx, db.GetDirectoryParams{
+		ID:          directoryID,
+		WorkspaceID: workspaceID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return dir, ErrDirectoryNotFound
+		}
+		return dir, fmt.Errorf("failed to get directory: %w", err)
+	}
+	return dir, nil
+}
+
+// normalizeDirectoryName は前後の空白を取り除き、名前として使えるか確認します
+func normalizeDirectoryName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "" || name == "." || name == "..":
+		return "", fmt.Errorf("%w: name is required", ErrInvalidDirectoryName)
+	case strings.ContainsAny(name, "/\\"):
+		return "", fmt.Errorf("%w: name must not contain slashes", ErrInvalidDirectoryName)
+	case len([]rune(name)) > maxDirectoryNameLength:
+		return "", fmt.Errorf("%w: name is longer than %d characters", ErrInvalidDirectoryName, maxDirectoryNameLength)
+	}
+	return name, nil
+}
+
+// directoryWriteError は INSERT / UPDATE のエラーを ErrDirectoryNotFound / ErrDirectoryNameConflict に変換します
+func directoryWriteError(op string, err error) error {
+	if err == sql.ErrNoRows {
+		return ErrDirectoryNotFound
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" { // unique_violation
+		return ErrDirectoryNameConflict
+	}
+	return fmt.Errorf("failed to %s directory: %w", op, err)
+}
+
+// buildDirectoryTree は深さ順のフラットな行を親子関係のツリーに組み立てます
+func buildDirectoryTree(rows []db.ListDirectoryTreeRow) []*DirectoryNode {
+	nodes := make(map[uuid.UUID]*DirectoryNode, len(rows))
+	roots := []*DirectoryNode{}
+
+	for _, row := range rows {
+		node := &DirectoryNode{
+			ID:            row.ID,
+			Name:          row.Name,
+			DocumentCount: row.DocumentCount,
+			CreatedAt:     row.CreatedAt,
+			UpdatedAt:     row.UpdatedAt,
+			Children:      []*DirectoryNode{},
+		}
+		if row.ParentID.Valid {
+			parentID := row.ParentID.UUID
+			node.ParentID = &parentID
+		}
+		nodes[row.ID] = node
+
+		// 深さ順に並んでいるので親は既に登録済み（深さ0の行がルート）
+		if parent, ok := nodes[row.ParentID.UUID]; ok && row.Depth > 0 {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+
+	return roots
+}
+
+func toDirectoryNode(d db.Directory) *DirectoryNode {
+	node := &DirectoryNode{
+		ID:        d.ID,
+		Name:      d.Name,
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+	}
+	if d.ParentID.Valid {
+		parentID := d.ParentID.UUID
+		node.ParentID = &parentID
+	}
+	return node
+}
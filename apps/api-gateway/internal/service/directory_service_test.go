@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/google/uuid"
+)
+
+func TestNormalizeDirectoryName(t *testing.T) {
+	got, err := normalizeDirectoryName("  議事録 2024 ")
+	if err != nil || got != "議事録 2024" {
+		t.Errorf("normalizeDirectoryName() = %q, %v; want %q", got, err, "議事録 2024")
+	}
+
+	for _, name := range []string{"", "   ", ".", "..", "a/b", `a\b`, strings.Repeat("あ", maxDirectoryNameLength+1)} {
+		if _, err := normalizeDirectoryName(name); !errors.Is(err, ErrInvalidDirectoryName) {
+			t.Errorf("normalizeDirectoryName(%q) error = %v, want ErrInvalidDirectoryName", name, err)
+		}
+	}
+}
+
+func TestBuildDirectoryTree(t *testing.T) {
+	rootA, rootB, child, grandchild := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	parent := func(id uuid.UUID) uuid.NullUUID { return uuid.NullUUID{UUID: id, Valid: true} }
+
+	// ListDirectoryTree と同じく深さ順に並んだ行
+	rows := []db.ListDirectoryTreeRow{
+		{ID: rootA, Name: "a", Depth: 0, DocumentCount: 2},
+		{ID: rootB, Name: "b", Depth: 0},
+		{ID: child, ParentID: parent(rootA), Name: "a1", Depth: 1, DocumentCount: 1},
+		{ID: grandchild, ParentID: parent(child), Name: "a1x", Depth: 2},
+	}
+
+	roots := buildDirectoryTree(rows)
+	if len(roots) != 2 || roots[0].ID != rootA || roots[1].ID != rootB {
+		t.Fatalf("roots = %+v, want [a b]", roots)
+	}
+	if roots[0].DocumentCount != 2 || len(roots[0].Children) != 1 || roots[0].Children[0].ID != child {
+		t.Fatalf("a = %+v, want one child a1", roots[0])
+	}
+	a1 := roots[0].Children[0]
+	if a1.ParentID == nil || *a1.ParentID != rootA {
+		t.Errorf("a1.ParentID = %v, want %s", a1.ParentID, rootA)
+	}
+	if len(a1.Children) != 1 || a1.Children[0].ID != grandchild {
+		t.Errorf("a1.Children = %+v, want [a1x]", a1.Children)
+	}
+	if roots[1].Children == nil || len(roots[1].Children) != 0 {
+		t.Errorf("b.Children = %#v, want empty slice", roots[1].Children)
+	}
+}
+
+func TestBuildDirectoryTreeSubtree(t *testing.T) {
+	// rootId を指定した場合、深さ0の行は親を持っていてもルートになる
+	outside, root, child := uuid.New(), uuid.New(), uuid.New()
+	rows := []db.ListDirectoryTreeRow{
+		{ID: root, ParentID: uuid.NullUUID{UUID: outside, Valid: true}, Name: "sub", Depth: 0},
+		{ID: child, ParentID: uuid.NullUUID{UUID: root, Valid: true}, Name: "leaf", Depth: 1},
+	}
+
+	roots := buildDirectoryTree(rows)
+	if len(roots) != 1 || roots[0].ID != root || len(roots[0].Children) != 1 {
+		t.Fatalf("roots = %+v, want [sub] with one child", roots)
+	}
+}
+
+// newDirectoryTestService は parents（ディレクトリ → 親、ワークスペース直下は uuid.Nil）のツリーに応答する DirectoryService を作成します
+func newDirectoryTestService(t *testing.T, workspaceID uuid.UUID, parents map[uuid.UUID]uuid.UUID) (*DirectoryService, *fakeDB) {
+	t.Helper()
+	f := newFakeDB()
+	directoryRow := func(id uuid.UUID) *fakeRows {
+		now := time.Now()
+		var parent driver.Value
+		if p := parents[id]; p != uuid.Nil {
+			parent = p.String()
+		}
+		return newFakeRow(id.String(), workspaceID.String(), parent, "dir-"+id.String()[:8], now, now, nil)
+	}
+
+	f.execs["LockWorkspaceDirectoryTree"] = func(args []driver.Value) (int64, error) { return 1, nil }
+	f.queries["GetDirectory"] = func(args []driver.Value) (*fakeRows, error) {
+		id := uuid.MustParse(args[0].(string))
+		if _, ok := parents[id]; !ok {
+			return &fakeRows{}, nil
+		}
+		return directoryRow(id), nil
+	}
+	f.queries["GetDirectoryDescendantIDs"] = func(args []driver.Value) (*fakeRows, error) {
+		rows := &fakeRows{columns: []string{"id"}}
+		queue := []uuid.UUID{}
+		for _, id := range parsePQArray(args[0]) {
+			queue = append(queue, uuid.MustParse(id))
+		}
+		for len(queue) > 0 {
+			id := queue[0]
+			queue = queue[1:]
+			rows.values = append(rows.values, []driver.Value{id.String()})
+			for child, parent := range parents {
+				if parent == id {
+					queue = append(queue, child)
+				}
+			}
+		}
+		return rows, nil
+	}
+	f.queries["MoveDirectory"] = func(args []driver.Value) (*fakeRows, error) {
+		id := uuid.MustParse(args[1].(string))
+		parents[id] = uuid.Nil
+		if args[0] != nil {
+			parents[id] = uuid.MustParse(args[0].(string))
+		}
+		return directoryRow(id), nil
+	}
+	f.queries["SoftDeleteDocumentsInDirectories"] = func(args []driver.Value) (*fakeRows, error) {
+		return &fakeRows{columns: []string{"id"}}, nil
+	}
+	f.execs["SoftDeleteDirectories"] = func(args []driver.Value) (int64, error) {
+		for _, id := range parsePQArray(args[0]) {
+			delete(parents, uuid.MustParse(id))
+		}
+		return 1, nil
+	}
+
+	database := f.open()
+	t.Cleanup(func() { database.Close() })
+	return NewDirectoryService(database, db.New(database), nil), f
+}
+
+func TestMoveDirectory_ChecksCycleInsideLockedTransaction(t *testing.T) {
+	workspaceID := uuid.New()
+	root, child, grandchild, other := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	tree := func() map[uuid.UUID]uuid.UUID {
+		return map[uuid.UUID]uuid.UUID{root: uuid.Nil, child: root, grandchild: child, other: uuid.Nil}
+	}
+
+	tests := []struct {
+		name      string
+		directory uuid.UUID
+		parent    uuid.UUID
+		wantErr   error
+		wantCalls []string
+	}{
+		{
+			name: "moves under another directory", directory: child, parent: other,
+			wantCalls: []string{"BEGIN", "LockWorkspaceDirectoryTree", "GetDirectory", "GetDirectory", "GetDirectoryDescendantIDs", "MoveDirectory", "COMMIT"},
+		},
+		{
+			name: "rejects moving under its own descendant", directory: root, parent: grandchild, wantErr: ErrDirectoryCycle,
+			wantCalls: []string{"BEGIN", "LockWorkspaceDirectoryTree", "GetDirectory", "GetDirectory", "GetDirectoryDescendantIDs", "ROLLBACK"},
+		},
+		{
+			name: "rejects moving under itself", directory: child, parent: child, wantErr: ErrDirectoryCycle,
+			wantCalls: []string{"BEGIN", "LockWorkspaceDirectoryTree", "GetDirectory", "GetDirectory", "GetDirectoryDescendantIDs", "ROLLBACK"},
+		},
+	}
+	for _, tt := range tests {
+		parents := tree()
+		s, f := newDirectoryTestService(t, workspaceID, parents)
+
+		node, err := s.MoveDirectory(context.Background(), workspaceID, tt.directory, &tt.parent)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: MoveDirectory() error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+		if err == nil && (node.ParentID == nil || *node.ParentID != tt.parent || parents[tt.directory] != tt.parent) {
+			t.Errorf("%s: expected the directory to be moved under %s, got %+v", tt.name, tt.parent, node)
+		}
+		// ロックしてから確認し、確認と書き込みを同じトランザクションで行う
+		if got := f.callLog(); !reflect.DeepEqual(got, tt.wantCalls) {
+			t.Errorf("%s: calls = %q, want %q", tt.name, got, tt.wantCalls)
+		}
+	}
+}
+
+func TestDeleteDirectory_ResolvesDescendantsInsideLockedTransaction(t *testing.T) {
+	workspaceID := uuid.New()
+	root, child, grandchild, other := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	parents := map[uuid.UUID]uuid.UUID{root: uuid.Nil, child: root, grandchild: child, other: uuid.Nil}
+	s, f := newDirectoryTestService(t, workspaceID, parents)
+
+	result, err := s.DeleteDirectory(context.Background(), workspaceID, root)
+	if err != nil {
+		t.Fatalf("DeleteDirectory failed: %v", err)
+	}
+	if result.DeletedDirectories != 3 || len(parents) != 1 {
+		t.Errorf("Expected the directory and its 2 subdirectories to be deleted, got %+v (remaining %v)", result, parents)
+	}
+
+	want := []string{
+		"BEGIN", "LockWorkspaceDirectoryTree", "GetDirectory", "GetDirectoryDescendantIDs",
+		"SoftDeleteDocumentsInDirectories", "SoftDeleteDirectories", "COMMIT",
+	}
+	if got := f.callLog(); !reflect.DeepEqual(got, want) {
+		t.Errorf("calls = %q, want %q", got, want)
+	}
+}
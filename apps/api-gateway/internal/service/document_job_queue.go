@@ -0,0 +1,407 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/google/uuid"
+	"github.com/sqlc-dev/pqtype"
+)
+
+// ドキュメント処理ジョブのステータス
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusDead      = "dead"
+)
+
+// staleJobError は回収したジョブの last_error（RequeueStaleDocumentJobs と同じ文言）
+const staleJobError = "worker lock expired"
+
+// JobQueueOptions はジョブキューとワーカーの設定
+type JobQueueOptions struct {
+	Workers      int
+	PollInterval time.Duration
+	MaxAttempts  int
+	RetryBase    time.Duration
+	RetryMax     time.Duration
+	LockTimeout  time.Duration
+}
+
+// DocumentJobQueue は Postgres の document_jobs テーブルを使ったドキュメント処理キュー。
+// ジョブは SELECT ... FOR UPDATE SKIP LOCKED で取得するため、ワーカー数やプロセス数を増やしても二重実行されない。
+type DocumentJobQueue struct {
+	queries   *db.Queries
+	processor *DocumentProcessor
+	opts      JobQueueOptions
+	workerID  string
+	wg        sync.WaitGroup
+}
+
+// NewDocumentJobQueue は新しい DocumentJobQueue を作成
+func NewDocumentJobQueue(
+	queries *db.Queries,
+	processor *DocumentProcessor,
+	opts JobQueueOptions,
+) *DocumentJobQueue {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 2 * time.Second
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.RetryBase <= 0 {
+		opts.RetryBase = 10 * time.Second
+	}
+	if opts.RetryMax < opts.RetryBase {
+		opts.RetryMax = opts.RetryBase
+	}
+	if opts.LockTimeout <= 0 {
+		opts.LockTimeout = 5 * time.Minute
+	}
+
+	hostname, _ := os.Hostname()
+
+	return &DocumentJobQueue{
+		queries:   queries,
+		processor: processor,
+		opts:      opts,
+		workerID:  fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+	}
+}
+
+// Enqueue はドキュメント処理ジョブを登録します。
+// 同じドキュメントの実行待ち・実行中ジョブが既にある場合は ErrAlreadyProcessing を返します。
+func (q *DocumentJobQueue) Enqueue(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	documentID uuid.UUID,
+	opts ProcessOptions,
+) (*db.DocumentJob, error) {
+	// Step 1: ドキュメントの存在確認
+	_, err := q.queries.GetDocument(ctx, db.GetDocumentParams{
+		ID:          documentID,
+		WorkspaceID: workspaceID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrDocumentNotFound
+		}
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	// Step 2: 処理オプションを JSONB に変換
+	optionsJSON, err := json.Marshal(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal process options: %w", err)
+	}
+
+	// Step 3: ジョブを登録（アクティブなジョブがあれば ErrNoRows）
+	job, err := q.queries.EnqueueDocumentJob(ctx, db.EnqueueDocumentJobParams{
+		WorkspaceID: workspaceID,
+		DocumentID:  documentID,
+		Options:     pqtype.NullRawMessage{RawMessage: optionsJSON, Valid: true},
+		MaxAttempts: int32(q.opts.MaxAttempts),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrAlreadyProcessing
+		}
+		return nil, fmt.Errorf("failed to enqueue document job: %w", err)
+	}
+
+	// Step 4: ドキュメントのステータスを queued に更新
+	if err := q.queries.UpdateDocumentStatus(ctx, db.UpdateDocumentStatusParams{
+		Status:       "queued",
+		ErrorMessage: sql.NullString{Valid: false},
+		ID:           documentID,
+		WorkspaceID:  workspaceID,
+	}); err != nil {
+		log.Printf("⚠️ Failed to update document status to queued: %v", err)
+	}
+
+	log.Printf("📥 Document job queued: job=%s document=%s", job.ID, documentID)
+
+	return &job, nil
+}
+
+// Start はワーカーと stale ジョブの回収ループをバックグラウンドで起動します。
+// ctx がキャンセルされると、実行中のジョブを終えてから停止します（Wait で待機できます）。
+func (q *DocumentJobQueue) Start(ctx context.Context) {
+	for i := 0; i < q.opts.Workers; i++ {
+		workerID := fmt.Sprintf("%s-w%d", q.workerID, i+1)
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			q.runWorker(ctx, workerID)
+		}()
+	}
+
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		q.runReaper(ctx)
+	}()
+
+	log.Printf("👷 Started %d document job workers", q.opts.Workers)
+}
+
+// Wait は全ワーカーの停止を待ちます
+func (q *DocumentJobQueue) Wait() {
+	q.wg.Wait()
+}
+
+// runWorker はキューからジョブを取得して処理し続けます
+func (q *DocumentJobQueue) runWorker(ctx context.Context, workerID string) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		job, err := q.queries.ClaimDocumentJob(ctx, sql.NullString{String: workerID, Valid: true})
+		if err != nil {
+			if err != sql.ErrNoRows && ctx.Err() == nil {
+				log.Printf("⚠️ [%s] Failed to claim document job: %v", workerID, err)
+			}
+			// キューが空（またはDBエラー）の場合は少し待つ
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(q.opts.PollInterval):
+			}
+			continue
+		}
+
+		q.runJob(ctx, workerID, job)
+	}
+}
+
+// runJob は1件のジョブを実行し、結果に応じて完了・リトライ・dead に更新します
+func (q *DocumentJobQueue) runJob(ctx context.Context, workerID string, job db.DocumentJob) {
+	log.Printf("🔄 [%s] Running document job %s (document=%s, attempt %d/%d)",
+		workerID, job.ID, job.DocumentID, job.Attempts, job.MaxAttempts)
+
+	// サーバーの停止（ctx のキャンセル）では実行中のジョブを中断しない。
+	// 途中でキャンセルすると試行回数を1回無駄にするため、処理を終えてから停止する（Start を参照）。
+	// ロックを失った場合（回収されて別のワーカーが実行している）だけ処理を中断する
+	jobCtx, cancelJob := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelJob()
+
+	// 実行中はロック時刻を定期的に更新し、長時間の処理が stale として回収されないようにする
+	heartbeatCtx, stopHeartbeat := context.WithCancel(jobCtx)
+	defer stopHeartbeat()
+	go q.heartbeat(heartbeatCtx, workerID, job.ID, cancelJob)
+
+	var opts ProcessOptions
+	if job.Options.Valid {
+		if err := json.Unmarshal(job.Options.RawMessage, &opts); err != nil {
+			q.deadLetter(workerID, job, fmt.Errorf("invalid job options: %w", err))
+			return
+		}
+	}
+
+	err := q.processor.ProcessDocument(jobCtx, job.WorkspaceID, job.DocumentID, opts)
+	stopHeartbeat()
+
+	// シャットダウン後・ロックを失った後もジョブの状態は必ず記録する
+	// （ロックを失っていれば更新は0件になり、結果は捨てられる）
+	updateCtx := context.WithoutCancel(ctx)
+	lockedBy := sql.NullString{String: workerID, Valid: true}
+
+	if err == nil {
+		n, err := q.queries.CompleteDocumentJob(updateCtx, db.CompleteDocumentJobParams{
+			ID:       job.ID,
+			LockedBy: lockedBy,
+		})
+		if err != nil {
+			log.Printf("⚠️ Failed to mark document job %s as succeeded: %v", job.ID, err)
+			return
+		}
+		if n == 0 {
+			logLostLease(workerID, job.ID)
+			return
+		}
+		log.Printf("✅ [%s] Document job %s succeeded", workerID, job.ID)
+		return
+	}
+
+	if isPermanentJobError(err) || job.Attempts >= job.MaxAttempts {
+		q.deadLetter(workerID, job, err)
+		return
+	}
+
+	delay := q.retryDelay(int(job.Attempts))
+	n, retryErr := q.queries.RetryDocumentJob(updateCtx, db.RetryDocumentJobParams{
+		ID:        job.ID,
+		LastError: sql.NullString{String: err.Error(), Valid: true},
+		RunAt:     time.Now().Add(delay),
+		LockedBy:  lockedBy,
+	})
+	if retryErr != nil {
+		log.Printf("⚠️ Failed to schedule retry for document job %s: %v", job.ID, retryErr)
+		return
+	}
+	if n == 0 {
+		logLostLease(workerID, job.ID)
+		return
+	}
+
+	// リトライ待ちの間は failed ではなく queued として見せる
+	if err := q.queries.UpdateDocumentStatus(updateCtx, db.UpdateDocumentStatusParams{
+		Status:       "queued",
+		ErrorMessage: sql.NullString{Valid: false},
+		ID:           job.DocumentID,
+		WorkspaceID:  job.WorkspaceID,
+	}); err != nil {
+		log.Printf("⚠️ Failed to update document status to queued: %v", err)
+	}
+
+	log.Printf("🔁 [%s] Document job %s failed (attempt %d/%d), retrying in %s: %v",
+		workerID, job.ID, job.Attempts, job.MaxAttempts, delay, err)
+}
+
+// deadLetter はジョブを dead にしてこれ以上リトライしないようにします（ロックを失っていれば何もしない）
+func (q *DocumentJobQueue) deadLetter(workerID string, job db.DocumentJob, cause error) {
+	ctx := context.Background()
+
+	n, err := q.queries.DeadLetterDocumentJob(ctx, db.DeadLetterDocumentJobParams{
+		ID:        job.ID,
+		LastError: sql.NullString{String: cause.Error(), Valid: true},
+		LockedBy:  sql.NullString{String: workerID, Valid: true},
+	})
+	if err != nil {
+		log.Printf("⚠️ Failed to mark document job %s as dead: %v", job.ID, err)
+	} else if n == 0 {
+		logLostLease(workerID, job.ID)
+		return
+	}
+
+	if err := q.queries.UpdateDocumentStatus(ctx, db.UpdateDocumentStatusParams{
+		Status:       "failed",
+		ErrorMessage: sql.NullString{String: cause.Error(), Valid: true},
+		ID:           job.DocumentID,
+		WorkspaceID:  job.WorkspaceID,
+	}); err != nil {
+		log.Printf("⚠️ Failed to update document status to failed: %v", err)
+	}
+
+	log.Printf("💀 Document job %s moved to dead letter after %d attempts: %v", job.ID, job.Attempts, cause)
+}
+
+// heartbeat は実行中のジョブのロック時刻を定期的に更新します。
+// 更新が0件ならロックを失っている（回収されて別のワーカーが再取得した可能性がある）ため、onLost で処理を中断させる
+func (q *DocumentJobQueue) heartbeat(ctx context.Context, workerID string, jobID uuid.UUID, onLost context.CancelFunc) {
+	ticker := time.NewTicker(q.opts.LockTimeout / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := q.queries.TouchDocumentJob(ctx, db.TouchDocumentJobParams{
+				ID:       jobID,
+				LockedBy: sql.NullString{String: workerID, Valid: true},
+			})
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("⚠️ Failed to extend lock for document job %s: %v", jobID, err)
+				}
+				continue
+			}
+			if n == 0 {
+				logLostLease(workerID, jobID)
+				onLost()
+				return
+			}
+		}
+	}
+}
+
+// logLostLease はロックを失ったジョブの結果を捨てたことを記録します
+func logLostLease(workerID string, jobID uuid.UUID) {
+	log.Printf("⚠️ [%s] Lost the lock on document job %s; another worker owns it now, discarding this run", workerID, jobID)
+}
+
+// runReaper はワーカー停止などで running のまま残ったジョブを定期的に回収します
+func (q *DocumentJobQueue) runReaper(ctx context.Context) {
+	ticker := time.NewTicker(q.opts.LockTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cutoff := time.Now().Add(-q.opts.LockTimeout)
+			jobs, err := q.queries.RequeueStaleDocumentJobs(ctx, sql.NullTime{Time: cutoff, Valid: true})
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("⚠️ Failed to requeue stale document jobs: %v", err)
+				}
+				continue
+			}
+			for _, job := range jobs {
+				q.updateStaleDocument(ctx, job)
+			}
+			if len(jobs) > 0 {
+				log.Printf("♻️ Requeued %d stale document jobs", len(jobs))
+			}
+		}
+	}
+}
+
+// updateStaleDocument は回収したジョブのドキュメントのステータスを、runJob と同じ規則で更新します。
+// 試行回数を使い切って dead になったジョブは failed、待機状態に戻したジョブは queued にする
+func (q *DocumentJobQueue) updateStaleDocument(ctx context.Context, job db.RequeueStaleDocumentJobsRow) {
+	params := db.UpdateDocumentStatusParams{
+		Status:       "queued",
+		ErrorMessage: sql.NullString{Valid: false},
+		ID:           job.DocumentID,
+		WorkspaceID:  job.WorkspaceID,
+	}
+	if job.Status == JobStatusDead {
+		params.Status = "failed"
+		params.ErrorMessage = sql.NullString{String: staleJobError, Valid: true}
+		log.Printf("💀 Document job %s moved to dead letter: %s", job.ID, staleJobError)
+	}
+
+	if err := q.queries.UpdateDocumentStatus(ctx, params); err != nil {
+		log.Printf("⚠️ Failed to update status of document %s to %s: %v", job.DocumentID, params.Status, err)
+	}
+}
+
+// retryDelay は試行回数に応じた待機時間を返します（指数バックオフ + 最大20%のジッター）
+func (q *DocumentJobQueue) retryDelay(attempt int) time.Duration {
+	delay := q.opts.RetryBase
+	for i := 1; i < attempt && delay < q.opts.RetryMax; i++ {
+		delay *= 2
+	}
+	if delay > q.opts.RetryMax {
+		delay = q.opts.RetryMax
+	}
+
+	jitter := time.Duration(rand.Int63n(int64(delay)/5 + 1))
+	return delay + jitter
+}
+
+// isPermanentJobError はリトライしても成功しないエラーかどうかを判定します
+func isPermanentJobError(err error) bool {
+	return errors.Is(err, ErrDocumentNotFound) ||
+		errors.Is(err, ErrVersionNotFound) ||
+		errors.Is(err, ErrUnsupportedFileType) ||
+		errors.Is(err, ErrChunkTooLong)
+}
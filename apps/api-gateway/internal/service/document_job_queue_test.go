@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDocumentJobQueue_RetryDelay(t *testing.T) {
+	q := NewDocumentJobQueue(nil, nil, JobQueueOptions{
+		RetryBase: 10 * time.Second,
+		RetryMax:  80 * time.Second,
+	})
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, 10 * time.Second},
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{4, 80 * time.Second},
+		// 上限を超えたら RetryMax で頭打ち
+		{5, 80 * time.Second},
+		{20, 80 * time.Second},
+	}
+	for _, tt := range tests {
+		// ジッターは最大 20% 上乗せされるだけで、基準の遅延より短くはならない
+		for i := 0; i < 50; i++ {
+			got := q.retryDelay(tt.attempt)
+			if got < tt.want || got > tt.want+tt.want/5 {
+				t.Fatalf("retryDelay(%d) = %v, want within [%v, %v]", tt.attempt, got, tt.want, tt.want+tt.want/5)
+			}
+		}
+	}
+}
+
+func TestIsPermanentJobError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"document not found", ErrDocumentNotFound, true},
+		{"version not found", ErrVersionNotFound, true},
+		{"unsupported file type", fmt.Errorf("failed to extract text: %w", ErrUnsupportedFileType), true},
+		{"wrapped chunk too long", fmt.Errorf("failed to embed chunk 3: %w", fmt.Errorf("chunk 3: %w", ErrChunkTooLong)), true},
+		{"generic error", errors.New("connection refused"), false},
+		// シャットダウンなどによる中断はリトライ対象
+		{"context canceled", fmt.Errorf("failed to generate embeddings: %w", context.Canceled), false},
+		{"deadline exceeded", context.DeadlineExceeded, false},
+	}
+	for _, tt := range tests {
+		if got := isPermanentJobError(tt.err); got != tt.want {
+			t.Errorf("%s: isPermanentJobError(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/storage"
+	"github.com/google/uuid"
+)
+
+// payloadUpdateBatchSize は UpdatePayloads 1回あたりのポイント数
+const payloadUpdateBatchSize = 256
+
+// updateDocumentPayloads はドキュメントのチャンクのベクトル payload の一部（directory_id など）を上書きします。
+// ベクトル検索のフィルタは payload を見るため、documents の値を変えたら合わせて呼ぶ必要があります。
+// コレクションがまだ無い（未処理のドキュメントしかない）場合は何もしません。
+func updateDocumentPayloads(
+	ctx context.Context,
+	queries *db.Queries,
+	vectorStore storage.VectorStore,
+	workspaceID uuid.UUID,
+	documentIDs []uuid.UUID,
+	payload map[string]interface{},
+) error {
+	if len(documentIDs) == 0 {
+		return nil
+	}
+
+	pointIDs, err := queries.ListChunkPointIDsByDocumentIDs(ctx, documentIDs)
+	if err != nil {
+		return fmt.Errorf("failed to list chunk point IDs: %w", err)
+	}
+
+	updates := make([]storage.PayloadUpdate, len(pointIDs))
+	for i, id := range pointIDs {
+		updates[i] = storage.PayloadUpdate{PointID: id.String(), Payload: payload}
+	}
+
+	collectionName := fmt.Sprintf("workspace_%s", workspaceID.String())
+	for _, batch := range splitBatches(len(updates), payloadUpdateBatchSize) {
+		err := vectorStore.UpdatePayloads(ctx, collectionName, updates[batch.start:batch.end])
+		if err != nil {
+			if errors.Is(err, storage.ErrCollectionNotFound) {
+				return nil
+			}
+			return fmt.Errorf("failed to update payloads in vector store: %w", err)
+		}
+	}
+	return nil
+}
+
+// backfillPageSize は BackfillDocumentPayloads で1回に読むドキュメント数
+const backfillPageSize = 100
+
+// BackfillDocumentPayloads はワークスペースの全ドキュメントについて、チャンクのベクトル payload の
+// directory_id / tags / metadata を documents の現在の値で上書きします。
+// これらのキーが無かった頃にインデックスしたポイントは、再処理しなくてもディレクトリやタグの絞り込みに掛かるようになります。
+// 同じ値を書き込むだけなので何度実行してもかまいません。戻り値は payload を書き込んだドキュメント数です
+func BackfillDocumentPayloads(
+	ctx context.Context,
+	queries *db.Queries,
+	vectorStore storage.VectorStore,
+	workspaceID uuid.UUID,
+) (int, error) {
+	updated := 0
+	for offset := 0; ; offset += backfillPageSize {
+		docs, err := queries.ListDocuments(ctx, db.ListDocumentsParams{
+			WorkspaceID:  workspaceID,
+			Limit:        backfillPageSize,
+			Offset:       int32(offset),
+			Tags:         []string{},
+			MetadataKeys: []string{},
+		})
+		if err != nil {
+			return updated, fmt.Errorf("failed to list documents: %w", err)
+		}
+
+		for _, doc := range docs {
+			// document_processor がインデックス時に書き込むのと同じ形にする
+			var directoryID interface{}
+			if doc.DirectoryID.Valid {
+				directoryID = doc.DirectoryID.UUID.String()
+			}
+			payload := map[string]interface{}{
+				"directory_id": directoryID,
+				"tags":         tagsOrEmpty(doc.Tags),
+				"metadata":     decodeMetadata(doc.Metadata),
+			}
+			if err := updateDocumentPayloads(ctx, queries, vectorStore, workspaceID, []uuid.UUID{doc.ID}, payload); err != nil {
+				return updated, fmt.Errorf("document %s: %w", doc.ID, err)
+			}
+			updated++
+		}
+
+		if len(docs) < backfillPageSize {
+			return updated, nil
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/storage"
+	"github.com/google/uuid"
+)
+
+func TestBackfillDocumentPayloads(t *testing.T) {
+	ctx := context.Background()
+	workspaceID := uuid.New()
+	directoryID := uuid.New()
+
+	// 1ページに収まらない件数にして、ページングも確かめる。チャンクがあるのは先頭の2件だけ
+	filed, root := uuid.New(), uuid.New()
+	docIDs := []uuid.UUID{filed, root}
+	for len(docIDs) <= backfillPageSize {
+		docIDs = append(docIDs, uuid.New())
+	}
+	chunks := map[string][]string{
+		filed.String(): {uuid.New().String(), uuid.New().String()},
+		root.String():  {uuid.New().String()},
+	}
+
+	f := newFakeDB()
+	f.queries["ListDocuments"] = func(args []driver.Value) (*fakeRows, error) {
+		limit, offset := int(args[1].(int64)), int(args[2].(int64))
+		rows := &fakeRows{columns: make([]string, 12)}
+		now := time.Now()
+		for _, id := range docIDs[min(offset, len(docIDs)):min(offset+limit, len(docIDs))] {
+			var dir, tags, metadata driver.Value = nil, "{}", nil
+			if id == filed {
+				dir, tags, metadata = directoryID.String(), pqTextArray([]string{"finance"}), []byte(`{"year":2024}`)
+			}
+			rows.values = append(rows.values, []driver.Value{
+				id.String(), workspaceID.String(), dir, uuid.New().String(), "doc", tags, metadata,
+				"completed", now, now, int64(1), "text/plain",
+			})
+		}
+		return rows, nil
+	}
+	f.queries["ListChunkPointIDsByDocumentIDs"] = func(args []driver.Value) (*fakeRows, error) {
+		rows := &fakeRows{columns: make([]string, 1)}
+		for _, docID := range parsePQArray(args[0]) {
+			for _, pointID := range chunks[docID] {
+				rows.values = append(rows.values, []driver.Value{pointID})
+			}
+		}
+		return rows, nil
+	}
+	database := f.open()
+	t.Cleanup(func() { database.Close() })
+
+	// payload に document_id しか無かった頃にインデックスしたポイント
+	vectorStore := storage.NewMemoryVectorStore()
+	collection := "workspace_" + workspaceID.String()
+	if err := vectorStore.EnsureCollection(ctx, collection, storage.CollectionConfig{Size: 1, Distance: storage.DistanceCosine}); err != nil {
+		t.Fatal(err)
+	}
+	var points []storage.Point
+	for docID, pointIDs := range chunks {
+		for _, id := range pointIDs {
+			points = append(points, storage.Point{ID: id, Vector: []float64{1}, Payload: map[string]interface{}{"document_id": docID}})
+		}
+	}
+	if err := vectorStore.Upsert(ctx, collection, points); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := BackfillDocumentPayloads(ctx, db.New(database), vectorStore, workspaceID)
+	if err != nil {
+		t.Fatalf("BackfillDocumentPayloads failed: %v", err)
+	}
+	if n != len(docIDs) {
+		t.Errorf("Expected %d documents to be updated, got %d", len(docIDs), n)
+	}
+	if got := f.called("ListDocuments"); got != 2 {
+		t.Errorf("Expected 2 pages of documents, got %d", got)
+	}
+
+	// 再処理しなくても、ディレクトリ・タグ・メタデータで絞り込めるようになる
+	tests := []struct {
+		name   string
+		filter *storage.Filter
+		want   int
+	}{
+		{"directory", &storage.Filter{Must: []storage.Condition{storage.MatchValue("directory_id", directoryID.String())}}, 2},
+		{"tags", &storage.Filter{Must: []storage.Condition{storage.MatchAny("tags", []string{"finance"})}}, 2},
+		{"document still set", &storage.Filter{Must: []storage.Condition{storage.MatchValue("document_id", root.String())}}, 1},
+	}
+	for _, tt := range tests {
+		got, err := vectorStore.Count(ctx, collection, tt.filter)
+		if err != nil {
+			t.Fatalf("%s: Count failed: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %d matching points, got %d", tt.name, tt.want, got)
+		}
+	}
+
+	page, err := vectorStore.Scroll(ctx, collection, storage.ScrollRequest{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range page.Points {
+		metadata, _ := p.Payload["metadata"].(map[string]interface{})
+		if p.Payload["document_id"] == filed.String() && metadata["year"] != float64(2024) {
+			t.Errorf("Expected point %s to carry the document metadata, got %v", p.ID, p.Payload["metadata"])
+		}
+	}
+}
@@ -2,65 +2,80 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"log"
+	"unicode/utf8"
 
 	"github.com/Martin4208/Nexus/apps/api-gateway/internal/client"
 	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/storage"
 	"github.com/google/uuid"
 )
 
 var (
-	ErrDocumentNotFound  = errors.New("document not found")
-	ErrAlreadyProcessing = errors.New("document is already being processed")
+	ErrDocumentNotFound    = errors.New("document not found")
+	ErrAlreadyProcessing   = errors.New("document is already being processed")
+	ErrUnsupportedFileType = errors.New("unsupported file type")
+)
+
+// チャンク分割のデフォルト値
+const (
+	DefaultChunkSize    = 500
+	DefaultChunkOverlap = 50
+	// DefaultParentChunkSize は親チャンク（検索でヒットした子チャンクの代わりに LLM に渡すセクション）の文字数
+	DefaultParentChunkSize = 2000
 )
 
 // ProcessOptions はドキュメント処理のオプション
+// ジョブキューに JSONB として保存されるため json タグを付けている
 type ProcessOptions struct {
-	ChunkSize      int
-	ChunkOverlap   int
-	ForceReprocess bool
+	ChunkSize    int `json:"chunk_size"`
+	ChunkOverlap int `json:"chunk_overlap"`
+	// ChunkStrategy はチャンク分割の方式（空なら DefaultChunkStrategy）。token では ChunkSize / ChunkOverlap はトークン数
+	ChunkStrategy ChunkStrategy `json:"chunk_strategy,omitempty"`
+	// ParentChunkSize は親チャンクの文字数（0 なら親チャンクを作らない）。
+	// 子チャンクは親セクションの中で分割し、検索でヒットした子チャンクの代わりに親セクションを LLM に渡す
+	ParentChunkSize int  `json:"parent_chunk_size,omitempty"`
+	ForceReprocess  bool `json:"force_reprocess"`
+	// VersionID は処理するバージョン（nil の場合は現在のバージョン）
+	// 現在のバージョン以外を処理した場合は、成功後にそのバージョンへ切り替える
+	VersionID *uuid.UUID `json:"version_id,omitempty"`
 }
 
 // DocumentProcessor はドキュメント処理のビジネスロジックを担当
 type DocumentProcessor struct {
-	queries      *db.Queries
-	minioClient  *MinIOClient
-	aiClient     client.AIWorkerClient
-	qdrantClient client.QdrantClient
+	db            *sql.DB // チャンク保存のトランザクション用
+	queries       *db.Queries
+	storageClient storage.ObjectStorageClient
+	aiClient      client.AIWorkerClient
+	vectorStore   storage.VectorStore
+	opts          ProcessorOptions
 }
 
 // NewDocumentProcessor は新しい DocumentProcessor を作成
 func NewDocumentProcessor(
+	database *sql.DB,
 	queries *db.Queries,
 	aiClient client.AIWorkerClient,
-	qdrantClient client.QdrantClient,
+	vectorStore storage.VectorStore,
+	storageClient storage.ObjectStorageClient,
+	opts ProcessorOptions,
 ) *DocumentProcessor {
-	minioClient, err := NewMinIOClient(
-		"localhost:9000",
-		"admin",
-		"password123",
-		false,
-	)
-	if err != nil {
-		log.Printf("Failed to create MinIO client: %v", err)
-		return &DocumentProcessor{
-			queries:     queries,
-			minioClient: nil,
-		}
-	}
-
 	return &DocumentProcessor{
-		queries:      queries,
-		minioClient:  minioClient,
-		aiClient:     aiClient,
-		qdrantClient: qdrantClient,
+		db:            database,
+		queries:       queries,
+		storageClient: storageClient,
+		aiClient:      aiClient,
+		vectorStore:   vectorStore,
+		opts:          opts.withDefaults(),
 	}
 }
 
-// ProcessDocument はドキュメントを処理します
+// ProcessDocument はドキュメントを同期的に処理します。
+// 通常は DocumentJobQueue のワーカーから呼ばれ、同時実行の制御はキュー側で行います。
 func (p *DocumentProcessor) ProcessDocument(
 	ctx context.Context,
 	workspaceID uuid.UUID,
@@ -72,28 +87,68 @@ func (p *DocumentProcessor) ProcessDocument(
 		WorkspaceID: workspaceID,
 	})
 	if err != nil {
-		return ErrDocumentNotFound
+		if err == sql.ErrNoRows {
+			return ErrDocumentNotFound
+		}
+		return fmt.Errorf("failed to get document: %w", err)
+	}
+
+	// 処理するバージョンのファイルを解決する
+	if opts.VersionID == nil && !doc.CurrentVersionID.Valid {
+		return fmt.Errorf("%w: document has no current version", ErrVersionNotFound)
+	}
+	versionID := doc.CurrentVersionID.UUID
+	if opts.VersionID != nil {
+		versionID = *opts.VersionID
+	}
+	version, err := p.queries.GetDocumentVersionFile(ctx, db.GetDocumentVersionFileParams{
+		ID:         versionID,
+		DocumentID: documentID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrVersionNotFound
+		}
+		return fmt.Errorf("failed to get document version: %w", err)
 	}
 
-	if doc.Status == "processing" && !opts.ForceReprocess {
-		return ErrAlreadyProcessing
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = DefaultChunkSize
+	}
+	if opts.ChunkOverlap < 0 {
+		opts.ChunkOverlap = DefaultChunkOverlap
+	}
+	if opts.ChunkStrategy == "" {
+		opts.ChunkStrategy = DefaultChunkStrategy
+	}
+	if opts.ParentChunkSize < 0 {
+		opts.ParentChunkSize = 0
 	}
 
 	err = p.queries.UpdateDocumentStatus(ctx, db.UpdateDocumentStatusParams{
-		Status:      "processing",
-		ID:          documentID,
-		WorkspaceID: workspaceID,
+		Status:       "processing",
+		ErrorMessage: sql.NullString{Valid: false},
+		ID:           documentID,
+		WorkspaceID:  workspaceID,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to update document status: %w", err)
 	}
 
-	err = p.processDocumentInternal(ctx, doc, opts)
+	tracker := newProgressTracker(p.queries, documentID, parseProcessingProgress(doc.Progress))
+	tracker.save(ctx)
+
+	err = p.processDocumentInternal(ctx, doc, version, opts, tracker)
 	if err != nil {
-		updateErr := p.queries.UpdateDocumentStatus(ctx, db.UpdateDocumentStatusParams{
-			Status:      "failed",
-			ID:          documentID,
-			WorkspaceID: workspaceID,
+		// キャンセルされた場合でも失敗の記録は残す
+		failCtx := context.WithoutCancel(ctx)
+		tracker.fail(failCtx, err)
+
+		updateErr := p.queries.UpdateDocumentStatus(failCtx, db.UpdateDocumentStatusParams{
+			Status:       "failed",
+			ErrorMessage: sql.NullString{String: err.Error(), Valid: true},
+			ID:           documentID,
+			WorkspaceID:  workspaceID,
 		})
 		if updateErr != nil {
 			log.Printf("Failed to update status to failed: %v", updateErr)
@@ -101,10 +156,13 @@ func (p *DocumentProcessor) ProcessDocument(
 		return err
 	}
 
+	tracker.complete(ctx)
+
 	return p.queries.UpdateDocumentStatus(ctx, db.UpdateDocumentStatusParams{
-		Status:      "processed",
-		ID:          documentID,
-		WorkspaceID: workspaceID,
+		Status:       "processed",
+		ErrorMessage: sql.NullString{Valid: false},
+		ID:           documentID,
+		WorkspaceID:  workspaceID,
 	})
 }
 
@@ -112,153 +170,518 @@ func (p *DocumentProcessor) ProcessDocument(
 type chunkWithPage struct {
 	text       string
 	pageNumber int
+	headings   []string      // チャンクが属する見出しの階層（Markdown / HTML / DOCX / PDF）
+	regions    []ChunkRegion // チャンクの本文が描かれているページ上の領域（PDF のみ）
+	// ocrConfidence は OCR で認識したページの信頼度（テキストレイヤーから抽出した場合は nil）
+	ocrConfidence *float64
+	strategy      ChunkStrategy // チャンク分割の方式（document_chunks.metadata に記録する）
+	parent        *parentChunk  // 親チャンク（親を持たない場合は nil）
+	hash          string        // content_hash（本文 + 見出し + 領域 + チャンク分割パラメータ）
 }
 
 // processDocumentInternal は実際の処理を行います（内部用）
+// 各ステージの開始時と、embedding / 保存 / upsert のバッチごとに進捗を記録します
+// チャンクは version のものとして保存し、version が現在のバージョンでなければ最後に切り替えます
 func (p *DocumentProcessor) processDocumentInternal(
 	ctx context.Context,
 	doc db.GetDocumentRow,
+	version db.GetDocumentVersionFileRow,
 	opts ProcessOptions,
+	tracker *progressTracker,
 ) error {
-	log.Printf("Processing document: %s (type: %s)", doc.Name, doc.MimeType)
-
-	if p.minioClient == nil {
-		return fmt.Errorf("MinIO client not initialized")
-	}
+	isCurrent := doc.CurrentVersionID.Valid && doc.CurrentVersionID.UUID == version.ID
+	log.Printf("Processing document: %s v%d (type: %s, current: %v)", doc.Name, version.VersionNumber, version.MimeType, isCurrent)
 
 	// Step 1: MinIOからファイルをダウンロード
-	reader, err := p.minioClient.DownloadFile(ctx, doc.MinioBucket, doc.MinioKey)
+	tracker.update(ctx, func(pr *ProcessingProgress) { pr.Stage = StageDownloading })
+
+	reader, err := p.storageClient.GetObject(ctx, version.MinioBucket, version.MinioKey)
 	if err != nil {
 		return fmt.Errorf("failed to download file from MinIO: %w", err)
 	}
 	defer reader.Close()
 
-	// Step 2: ファイルタイプに応じてチャンクを生成
+	// Step 2: MIME タイプと拡張子から Extractor を選び、ページ・セクション単位のテキストを抽出
 	// ポイント：PDFはページ単位で処理し、page_numberを保持する
-	var chunksWithPage []chunkWithPage
-	chunker := NewTextChunker(opts.ChunkSize, opts.ChunkOverlap)
+	// Markdown / HTML / DOCX は見出しごと、CSV / TSV は行のまとまりとして抽出される
+	tracker.update(ctx, func(pr *ProcessingProgress) { pr.Stage = StageExtracting })
+
+	extractor, ok := p.opts.Extractors.Lookup(version.MimeType, version.FileName)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnsupportedFileType, version.MimeType)
+	}
+
+	pages, err := extractor.Extract(ctx, reader)
+	if err != nil {
+		return fmt.Errorf("failed to extract text: %w", err)
+	}
+
+	log.Printf("Extracted %d pages/sections from %s", len(pages), doc.Name)
+
+	ocrPages := countOCRPages(pages)
+	tracker.update(ctx, func(pr *ProcessingProgress) {
+		pr.PagesExtracted = len(pages)
+		pr.PagesOCR = ocrPages
+		pr.Stage = StageChunking
+	})
+
+	// Step 2.5: ページごとに chunk_strategy の方式でチャンクに分割
+	// token はモデルのトークナイザを Go で使えればそれで数え、なければ推定値で数える
+	countTokens := estimateTokens
+	if local, ok := p.opts.Tokenizer.(LocalTokenizer); ok {
+		countTokens = local.Count
+	}
+	chunker, err := NewChunker(opts.ChunkStrategy, opts.ChunkSize, opts.ChunkOverlap, p.embedSentences, countTokens)
+	if err != nil {
+		return err
+	}
+	rowChunker := NewTextChunker(opts.ChunkSize, opts.ChunkOverlap)
+
+	// parent_chunk_size が指定されていれば、ページを親セクションに分けてからセクションごとに子チャンクに分割する
+	var parentChunker Chunker
+	if opts.ParentChunkSize > 0 {
+		parentChunker = newRecursiveChunker(opts.ParentChunkSize, 0, utf8.RuneCountInString)
+	}
 
-	switch doc.MimeType {
-	case "application/pdf":
-		// PDFはページ単位で抽出 → ページごとにチャンク化
-		// これによりチャンクは必ず単一ページに属するようになる
-		pages, err := NewPDFExtractor().ExtractPages(reader)
+	var groups []chunkGroup
+	for i, page := range pages {
+		// 表の行は方式によらず途中で切らない（親チャンクも作らない）
+		if len(page.Rows) > 0 {
+			groups = append(groups, chunkGroup{page: i, children: rowChunker.ChunkRows(page.RowHeader, page.Rows)})
+			continue
+		}
+		pageGroups, err := splitChunkGroups(ctx, chunker, parentChunker, i, page.Content)
 		if err != nil {
-			return fmt.Errorf("failed to extract PDF pages: %w", err)
+			return fmt.Errorf("failed to chunk page %d: %w", page.PageNumber, err)
 		}
+		groups = append(groups, pageGroups...)
+	}
 
-		log.Printf("Extracted %d pages from PDF", len(pages))
+	// モデルの最大シーケンス長を超えるチャンクは embedding 時に切り捨てられるため、分け直す（または失敗させる）
+	adjusted := 0
+	if p.opts.Tokenizer != nil {
+		pageNumbers := make([]int, len(groups))
+		groupChunks := make([][]string, len(groups))
+		for i, g := range groups {
+			pageNumbers[i] = pages[g.page].PageNumber
+			groupChunks[i] = g.children
+		}
+		adjusted, err = fitTokenLimit(ctx, p.opts.Tokenizer, pageNumbers, groupChunks, p.opts.OversizeChunks)
+		if err != nil {
+			return err
+		}
+		for i := range groups {
+			groups[i].children = groupChunks[i]
+		}
+	}
 
-		for _, page := range pages {
-			// 1ページのテキストをチャンクに分割
-			pageChunks := chunker.Chunk(page.Content)
-			for _, chunk := range pageChunks {
-				chunksWithPage = append(chunksWithPage, chunkWithPage{
-					text:       chunk,
+	var chunksWithPage []chunkWithPage
+	var parents []*parentChunk
+	for gi := 0; gi < len(groups); {
+		pi := groups[gi].page
+		page := pages[pi]
+
+		// 同じページのセクションをまとめ、子チャンクごとに親チャンクを対応付ける
+		var chunks []string
+		var chunkParents []*parentChunk
+		for ; gi < len(groups) && groups[gi].page == pi; gi++ {
+			var parent *parentChunk
+			if groups[gi].parent != "" {
+				parent = &parentChunk{
+					id:         uuid.New(),
+					index:      len(parents),
+					text:       groups[gi].parent,
 					pageNumber: page.PageNumber,
-				})
+					headings:   page.Headings,
+				}
+				parents = append(parents, parent)
+			}
+			for _, child := range groups[gi].children {
+				chunks = append(chunks, child)
+				chunkParents = append(chunkParents, parent)
 			}
 		}
 
-	case "text/plain":
-		// テキストファイルはページ概念がないのでpage_number=1固定
-		data, err := io.ReadAll(reader)
-		if err != nil {
-			return fmt.Errorf("failed to read text file: %w", err)
-		}
-		textChunks := chunker.Chunk(string(data))
-		for _, chunk := range textChunks {
-			chunksWithPage = append(chunksWithPage, chunkWithPage{
-				text:       chunk,
-				pageNumber: 1,
-			})
+		// PDF はチャンクの元になったブロックの座標を引用用に保持する
+		regions := locateChunkRegions(page, chunks)
+
+		for i, chunk := range chunks {
+			c := chunkWithPage{
+				text:          chunk,
+				pageNumber:    page.PageNumber,
+				headings:      page.Headings,
+				ocrConfidence: page.OCRConfidence,
+				strategy:      opts.ChunkStrategy,
+				parent:        chunkParents[i],
+			}
+			if regions != nil {
+				c.regions = regions[i]
+			}
+			c.hash = chunkContentHash(chunk, c.headings, c.regions, opts)
+			chunksWithPage = append(chunksWithPage, c)
 		}
+	}
 
-	default:
-		return fmt.Errorf("unsupported file type: %s", doc.MimeType)
+	log.Printf("Split into %d chunks total (strategy: %s, adjusted: %d, parents: %d)",
+		len(chunksWithPage), opts.ChunkStrategy, adjusted, len(parents))
+
+	// Step 2.6: このバージョンの既存チャンクと content_hash で突き合わせ、embedding が必要なチャンクだけを選ぶ
+	existingChunks, err := p.queries.ListDocumentChunkHashes(ctx, version.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list existing chunks: %w", err)
 	}
+	plan := planChunkSync(chunksWithPage, existingChunks, opts.ForceReprocess)
+
+	log.Printf("Chunk diff: %d to embed, %d reused, %d deleted",
+		len(plan.embed), plan.reusedCount(), len(plan.deleted))
+
+	embedBatches := splitBatches(len(plan.embed), p.opts.EmbedBatchSize)
+	tracker.update(ctx, func(pr *ProcessingProgress) {
+		pr.ChunksTotal = len(chunksWithPage)
+		pr.ChunksAdjusted = adjusted
+		pr.ParentChunks = len(parents)
+		pr.ChunksReused = plan.reusedCount()
+		pr.ChunksDeleted = len(plan.deleted)
+		pr.EmbedBatches = len(embedBatches)
+	})
 
-	log.Printf("Split into %d chunks total", len(chunksWithPage))
+	// Step 3: Embeddingをバッチごとに並列生成（テキストのみを渡す）
+	// 既存のチャンクはここではまだ消さない（失敗しても前回の結果で検索できるように）
+	tracker.update(ctx, func(pr *ProcessingProgress) { pr.Stage = StageEmbedding })
 
-	// Step 3: 既存チャンクを削除（再処理の場合）
-	if opts.ForceReprocess {
-		if err := p.queries.DeleteDocumentChunks(ctx, doc.ID); err != nil {
-			log.Printf("Failed to delete existing chunks: %v", err)
-		}
+	texts := make([]string, len(plan.embed))
+	for i, idx := range plan.embed {
+		texts[i] = chunksWithPage[idx].text
 	}
 
-	// Step 4: Embeddingを生成（テキストのみを渡す）
-	texts := make([]string, len(chunksWithPage))
-	for i, c := range chunksWithPage {
-		texts[i] = c.text
+	log.Printf("Generating embeddings for %d chunks in %d batches (parallelism: %d)...",
+		len(texts), len(embedBatches), p.opts.EmbedParallelism)
+	embeddings, vectorDim, err := p.embedTexts(ctx, texts, tracker)
+	if err != nil {
+		return err
 	}
 
-	log.Printf("Generating embeddings for %d chunks...", len(texts))
-	embeddingResp, err := p.aiClient.EmbedDocuments(ctx, texts)
+	log.Printf("✅ Generated %d embeddings (dimension: %d)", len(embeddings), vectorDim)
+
+	// Step 4: チャンクをDBに保存（page_number・content_hash付き）
+	// 削除・位置の更新・INSERT と VectorStore への反映が終わってからコミットする
+	tracker.update(ctx, func(pr *ProcessingProgress) { pr.Stage = StagePersisting })
+
+	tx, err := p.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to generate embeddings: %w", err)
+		return fmt.Errorf("failed to start transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	if len(embeddingResp.Embeddings) != len(chunksWithPage) {
-		return fmt.Errorf("embedding count mismatch: got %d, expected %d",
-			len(embeddingResp.Embeddings), len(chunksWithPage))
+	if err := p.persistChunks(ctx, p.queries.WithTx(tx), doc.ID, version.ID, chunksWithPage, parents, plan, tracker); err != nil {
+		return err
 	}
 
-	vectorDim := 1024
-	if embeddingResp.Dim != nil {
-		vectorDim = *embeddingResp.Dim
+	// Step 5: VectorStoreにEmbeddingを保存（payloadにpage_numberを追加）
+	tracker.update(ctx, func(pr *ProcessingProgress) { pr.Stage = StageIndexing })
+
+	log.Printf("Saving embeddings to vector store...")
+	collectionName := fmt.Sprintf("workspace_%s", doc.WorkspaceID.String())
+
+	if len(plan.embed) > 0 {
+		if err := p.vectorStore.EnsureCollection(ctx, collectionName, storage.CollectionConfig{Size: vectorDim}); err != nil {
+			return fmt.Errorf("failed to ensure vector collection: %w", err)
+		}
 	}
 
-	log.Printf("✅ Generated %d embeddings (dimension: %d)", embeddingResp.Count, vectorDim)
+	if err := p.indexChunks(ctx, collectionName, doc, version.ID, isCurrent, chunksWithPage, plan, embeddings, tracker); err != nil {
+		return err
+	}
 
-	// Step 5: チャンクをDBに保存（page_number付き）
-	chunkIDs := make([]uuid.UUID, len(chunksWithPage))
-	for i, c := range chunksWithPage {
-		dbChunk, err := p.queries.CreateDocumentChunk(ctx, db.CreateDocumentChunkParams{
-			DocumentID: doc.ID,
-			ChunkIndex: int32(i),
-			Content:    c.text,
-			PageNumber: int32(c.pageNumber), // ← 追加：sqlcの再生成が必要
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit chunks: %w", err)
+	}
+
+	// Step 6: このドキュメントのポイントのうち、どのバージョンのチャンクにも対応しないものを削除する
+	// 消えたチャンクに加えて、以前の失敗で残ったポイントもここで掃除される（他のバージョンのポイントは残す）
+	if len(existingChunks) > 0 || len(plan.embed) > 0 {
+		chunkPointIDs, err := p.queries.ListChunkPointIDsByDocumentIDs(ctx, []uuid.UUID{doc.ID})
+		if err != nil {
+			return fmt.Errorf("failed to list chunk point IDs: %w", err)
+		}
+		pointIDs := uuidStrings(chunkPointIDs)
+		filter := &storage.Filter{
+			Must:    []storage.Condition{storage.MatchValue("document_id", doc.ID.String())},
+			MustNot: []storage.Condition{storage.HasID(pointIDs)},
+		}
+		if len(pointIDs) == 0 {
+			filter.MustNot = nil
+		}
+		err = retryWithBackoff(ctx, p.opts.BatchRetries, p.opts.RetryDelay, func() error {
+			return p.vectorStore.DeleteByFilter(ctx, collectionName, filter)
 		})
 		if err != nil {
-			return fmt.Errorf("failed to save chunk %d: %w", i, err)
+			return fmt.Errorf("failed to delete stale points from vector store: %w", err)
 		}
-		chunkIDs[i] = dbChunk.ID
 	}
 
-	// Step 6: QdrantにEmbeddingを保存（payloadにpage_numberを追加）
-	log.Printf("Saving embeddings to Qdrant...")
-	collectionName := fmt.Sprintf("workspace_%s", doc.WorkspaceID.String())
+	log.Printf("✅ Indexed %d chunks in vector collection '%s' (%d upserted, %d reused, %d deleted)",
+		len(chunksWithPage), collectionName, len(plan.embed), plan.reusedCount(), len(plan.deleted))
 
-	if err := p.qdrantClient.EnsureCollection(ctx, collectionName, vectorDim); err != nil {
-		return fmt.Errorf("failed to ensure Qdrant collection: %w", err)
+	// Step 7: 新しくアップロードされたバージョン（または未処理だった過去のバージョン）なら検索対象を切り替える
+	if !isCurrent {
+		if err := activateDocumentVersion(ctx, p.queries, p.vectorStore, doc.WorkspaceID, doc.ID, version.ID); err != nil {
+			return err
+		}
+		log.Printf("🔀 Document %s switched to version %d", doc.ID, version.VersionNumber)
 	}
+	log.Printf("Successfully processed document: %s (%d chunks)", doc.Name, len(chunksWithPage))
 
-	points := make([]client.Point, len(chunksWithPage))
-	for i, c := range chunksWithPage {
-		points[i] = client.Point{
-			ID:     chunkIDs[i].String(),
-			Vector: embeddingResp.Embeddings[i],
+	return nil
+}
+
+// persistChunks は差分に従って document_chunks を更新します。
+// 親チャンクを作り直し、消えたチャンクを削除し、再利用するチャンクの位置（と親）を更新してから、
+// 新しいチャンクを IndexBatchSize 件ずつ INSERT します。
+func (p *DocumentProcessor) persistChunks(
+	ctx context.Context,
+	qtx *db.Queries,
+	documentID uuid.UUID,
+	versionID uuid.UUID,
+	chunks []chunkWithPage,
+	parents []*parentChunk,
+	plan *chunkSyncPlan,
+	tracker *progressTracker,
+) error {
+	// 親チャンクは embedding しないため差分を取らずに毎回作り直す（子チャンクの parent_id は下で付け直す）
+	if err := qtx.DeleteParentChunks(ctx, versionID); err != nil {
+		return fmt.Errorf("failed to delete parent chunks: %w", err)
+	}
+	for _, batch := range splitBatches(len(parents), p.opts.IndexBatchSize) {
+		params := db.CreateParentChunksParams{
+			DocumentID:   documentID,
+			VersionID:    versionID,
+			Ids:          make([]uuid.UUID, 0, batch.end-batch.start),
+			ChunkIndexes: make([]int32, 0, batch.end-batch.start),
+			Contents:     make([]string, 0, batch.end-batch.start),
+			PageNumbers:  make([]int32, 0, batch.end-batch.start),
+			Metadatas:    make([]string, 0, batch.end-batch.start),
+		}
+		for _, parent := range parents[batch.start:batch.end] {
+			params.Ids = append(params.Ids, parent.id)
+			params.ChunkIndexes = append(params.ChunkIndexes, int32(parent.index))
+			params.Contents = append(params.Contents, parent.text)
+			params.PageNumbers = append(params.PageNumbers, int32(parent.pageNumber))
+			params.Metadatas = append(params.Metadatas, chunkMetadata(chunkWithPage{headings: parent.headings}))
+		}
+		if err := qtx.CreateParentChunks(ctx, params); err != nil {
+			return fmt.Errorf("failed to save parent chunks %d-%d: %w", batch.start, batch.end-1, err)
+		}
+	}
+
+	if len(plan.deleted) > 0 {
+		err := qtx.DeleteDocumentChunksByIDs(ctx, db.DeleteDocumentChunksByIDsParams{
+			VersionID: versionID,
+			Ids:       plan.deleted,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete stale chunks: %w", err)
+		}
+	}
+
+	// chunk_index を振り直すため、残ったチャンクを一旦負の番号に退避する
+	if err := qtx.ShiftDocumentChunkIndexes(ctx, versionID); err != nil {
+		return fmt.Errorf("failed to shift chunk indexes: %w", err)
+	}
+
+	positions := db.UpdateDocumentChunkPositionsParams{VersionID: versionID}
+	var inserts []int
+	for i, c := range chunks {
+		if !plan.existing[i] {
+			inserts = append(inserts, i)
+			continue
+		}
+		positions.Ids = append(positions.Ids, plan.chunkIDs[i])
+		positions.ChunkIndexes = append(positions.ChunkIndexes, int32(i))
+		positions.PageNumbers = append(positions.PageNumbers, int32(c.pageNumber))
+		positions.ParentIds = append(positions.ParentIds, parentIDOf(c))
+	}
+	if len(positions.Ids) > 0 {
+		if err := qtx.UpdateDocumentChunkPositions(ctx, positions); err != nil {
+			return fmt.Errorf("failed to update chunk positions: %w", err)
+		}
+	}
+	tracker.update(ctx, func(pr *ProcessingProgress) { pr.ChunksPersisted = len(positions.Ids) })
+
+	for _, batch := range splitBatches(len(inserts), p.opts.IndexBatchSize) {
+		params := db.CreateDocumentChunksParams{
+			DocumentID:    documentID,
+			VersionID:     versionID,
+			Ids:           make([]uuid.UUID, 0, batch.end-batch.start),
+			ChunkIndexes:  make([]int32, 0, batch.end-batch.start),
+			Contents:      make([]string, 0, batch.end-batch.start),
+			PageNumbers:   make([]int32, 0, batch.end-batch.start),
+			ContentHashes: make([]string, 0, batch.end-batch.start),
+			Metadatas:     make([]string, 0, batch.end-batch.start),
+			ParentIds:     make([]uuid.UUID, 0, batch.end-batch.start),
+		}
+		for _, i := range inserts[batch.start:batch.end] {
+			params.Ids = append(params.Ids, plan.chunkIDs[i])
+			params.ChunkIndexes = append(params.ChunkIndexes, int32(i))
+			params.Contents = append(params.Contents, chunks[i].text)
+			params.PageNumbers = append(params.PageNumbers, int32(chunks[i].pageNumber))
+			params.ContentHashes = append(params.ContentHashes, chunks[i].hash)
+			params.Metadatas = append(params.Metadatas, chunkMetadata(chunks[i]))
+			params.ParentIds = append(params.ParentIds, parentIDOf(chunks[i]))
+		}
+
+		if _, err := qtx.CreateDocumentChunks(ctx, params); err != nil {
+			return fmt.Errorf("failed to save chunks %d-%d: %w", batch.start, batch.end-1, err)
+		}
+
+		tracker.update(ctx, func(pr *ProcessingProgress) { pr.ChunksPersisted = len(positions.Ids) + batch.end })
+	}
+
+	return nil
+}
+
+// indexChunks は embedding したチャンクを VectorStore に upsert し、
+// 位置だけが変わった再利用チャンクは payload の chunk_index / page_number を更新します。
+// ポイントIDは document_chunks の qdrant_point_id と一致させる（再利用するチャンクは ID が変わらない）。
+// is_current が false のポイントは検索から除外される（バージョンを切り替えるときに付け直す）。
+func (p *DocumentProcessor) indexChunks(
+	ctx context.Context,
+	collectionName string,
+	doc db.GetDocumentRow,
+	versionID uuid.UUID,
+	isCurrent bool,
+	chunks []chunkWithPage,
+	plan *chunkSyncPlan,
+	embeddings [][]float64,
+	tracker *progressTracker,
+) error {
+	// tags / directory_id / metadata はチャットの filter_config による絞り込みに使う
+	tags := tagsOrEmpty(doc.Tags)
+	metadata := decodeMetadata(doc.Metadata)
+	var directoryID interface{}
+	if doc.DirectoryID.Valid {
+		directoryID = doc.DirectoryID.UUID.String()
+	}
+
+	points := make([]storage.Point, len(plan.embed))
+	for i, idx := range plan.embed {
+		c := chunks[idx]
+		points[i] = storage.Point{
+			ID:     plan.pointIDs[idx].String(),
+			Vector: embeddings[i],
 			Payload: map[string]interface{}{
-				"document_id":  doc.ID.String(),
-				"workspace_id": doc.WorkspaceID.String(),
-				"chunk_index":  i,
-				"page_number":  c.pageNumber, // ← 追加：検索結果からページ番号を取得できるようになる
-				"text":         c.text,
+				"document_id":    doc.ID.String(),
+				"version_id":     versionID.String(),
+				"is_current":     isCurrent,
+				"workspace_id":   doc.WorkspaceID.String(),
+				"directory_id":   directoryID,
+				"tags":           tags,
+				"metadata":       metadata,
+				"chunk_index":    idx,
+				"page_number":    c.pageNumber, // ← 追加：検索結果からページ番号を取得できるようになる
+				"headings":       headingsOrEmpty(c.headings),
+				"regions":        regionsOrEmpty(c.regions), // 引用箇所のハイライト用（PDF のみ）
+				"ocr_confidence": ocrConfidenceOrNil(c.ocrConfidence),
+				"text":           c.text,
 			},
 		}
 	}
 
-	if err := p.qdrantClient.UpsertPoints(ctx, collectionName, points); err != nil {
-		return fmt.Errorf("failed to save embeddings to Qdrant: %w", err)
+	for _, batch := range splitBatches(len(points), p.opts.IndexBatchSize) {
+		err := retryWithBackoff(ctx, p.opts.BatchRetries, p.opts.RetryDelay, func() error {
+			return p.vectorStore.Upsert(ctx, collectionName, points[batch.start:batch.end])
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save embeddings to vector store (points %d-%d): %w", batch.start, batch.end-1, err)
+		}
+		tracker.update(ctx, func(pr *ProcessingProgress) { pr.PointsUpserted = batch.end })
 	}
 
-	log.Printf("✅ Saved %d embeddings to Qdrant collection '%s'", len(points), collectionName)
-	log.Printf("Successfully processed document: %s (%d chunks)", doc.Name, len(chunksWithPage))
+	var updates []storage.PayloadUpdate
+	for i, c := range chunks {
+		if !plan.moved[i] {
+			continue
+		}
+		updates = append(updates, storage.PayloadUpdate{
+			PointID: plan.pointIDs[i].String(),
+			Payload: map[string]interface{}{
+				"chunk_index": i,
+				"page_number": c.pageNumber,
+			},
+		})
+	}
+
+	for _, batch := range splitBatches(len(updates), p.opts.IndexBatchSize) {
+		err := retryWithBackoff(ctx, p.opts.BatchRetries, p.opts.RetryDelay, func() error {
+			return p.vectorStore.UpdatePayloads(ctx, collectionName, updates[batch.start:batch.end])
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update payloads in vector store (points %d-%d): %w", batch.start, batch.end-1, err)
+		}
+	}
 
 	return nil
 }
 
+// chunkMetadataJSON は document_chunks.metadata に保存する内容
+type chunkMetadataJSON struct {
+	Headings      []string      `json:"headings,omitempty"`
+	Regions       []ChunkRegion `json:"regions,omitempty"`
+	OCRConfidence *float64      `json:"ocr_confidence,omitempty"`
+	ChunkStrategy ChunkStrategy `json:"chunk_strategy,omitempty"`
+}
+
+// chunkMetadata はチャンクの metadata を JSON 文字列にします（保存するものがなければ空文字 = NULL）
+func chunkMetadata(c chunkWithPage) string {
+	if len(c.headings) == 0 && len(c.regions) == 0 && c.ocrConfidence == nil && c.strategy == "" {
+		return ""
+	}
+	data, err := json.Marshal(chunkMetadataJSON{
+		Headings:      c.headings,
+		Regions:       c.regions,
+		OCRConfidence: c.ocrConfidence,
+		ChunkStrategy: c.strategy,
+	})
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func headingsOrEmpty(headings []string) []string {
+	if headings == nil {
+		return []string{}
+	}
+	return headings
+}
+
+func regionsOrEmpty(regions []ChunkRegion) []ChunkRegion {
+	if regions == nil {
+		return []ChunkRegion{}
+	}
+	return regions
+}
+
+// ocrConfidenceOrNil は payload 用に *float64 を float64 か nil にします
+// （payload の数値は float64 として読むため、ポインタのままにしない）
+func ocrConfidenceOrNil(confidence *float64) interface{} {
+	if confidence == nil {
+		return nil
+	}
+	return *confidence
+}
+
+// countOCRPages は OCR で認識したページ数を返します（1ページが複数の区切りになる場合も1と数える）
+func countOCRPages(pages []PageContent) int {
+	seen := make(map[int]bool)
+	for _, p := range pages {
+		if p.OCRConfidence != nil {
+			seen[p.PageNumber] = true
+		}
+	}
+	return len(seen)
+}
+
 // GetDocumentStatus はドキュメントの処理状況を取得します
 func (p *DocumentProcessor) GetDocumentStatus(
 	ctx context.Context,
@@ -270,7 +693,10 @@ func (p *DocumentProcessor) GetDocumentStatus(
 		WorkspaceID: workspaceID,
 	})
 	if err != nil {
-		return nil, ErrDocumentNotFound
+		if err == sql.ErrNoRows {
+			return nil, ErrDocumentNotFound
+		}
+		return nil, fmt.Errorf("failed to get document: %w", err)
 	}
 
 	var processedAt interface{} = nil
@@ -290,30 +716,92 @@ func (p *DocumentProcessor) GetDocumentStatus(
 		"processed_at": processedAt,
 	}
 
-	if doc.Status == "processing" {
-		status["progress"] = map[string]interface{}{
-			"current_step":   "processing",
-			"percentage":     50,
-			"chunks_created": chunkCount,
+	// 最新のジョブ（試行回数・最後のエラー・次回実行時刻）
+	job, err := p.queries.GetLatestDocumentJob(ctx, documentID)
+	if err == nil {
+		jobInfo := map[string]interface{}{
+			"id":           job.ID,
+			"status":       job.Status,
+			"attempts":     job.Attempts,
+			"max_attempts": job.MaxAttempts,
+			"last_error":   nil,
+			"next_run_at":  nil,
+		}
+		if job.LastError.Valid {
+			jobInfo["last_error"] = job.LastError.String
+		}
+		if job.Status == JobStatusQueued {
+			jobInfo["next_run_at"] = job.RunAt
 		}
+		status["job"] = jobInfo
+	} else if err != sql.ErrNoRows {
+		log.Printf("⚠️ Failed to get latest document job: %v", err)
 	}
 
-	if doc.Status == "processed" {
+	// ワーカーが記録した進捗（ステージ・件数・最後のエラー）
+	progress := parseProcessingProgress(doc.Progress)
+	if progress != nil {
+		var lastError interface{}
+		if progress.LastError != "" {
+			lastError = progress.LastError
+		}
+		status["progress"] = map[string]interface{}{
+			"current_step":     progress.Stage,
+			"percentage":       progress.Percentage,
+			"chunks_created":   chunkCount,
+			"pages_extracted":  progress.PagesExtracted,
+			"pages_ocr":        progress.PagesOCR,
+			"chunks_total":     progress.ChunksTotal,
+			"chunks_adjusted":  progress.ChunksAdjusted,
+			"parent_chunks":    progress.ParentChunks,
+			"chunks_embedded":  progress.ChunksEmbedded,
+			"chunks_reused":    progress.ChunksReused,
+			"chunks_deleted":   progress.ChunksDeleted,
+			"embed_batches":    progress.EmbedBatches,
+			"batches_embedded": progress.BatchesEmbedded,
+			"chunks_persisted": progress.ChunksPersisted,
+			"points_upserted":  progress.PointsUpserted,
+			"last_error":       lastError,
+			"updated_at":       progress.UpdatedAt,
+		}
+	} else if doc.Status == "processed" {
+		// 進捗の記録がない（この機能より前に処理された）ドキュメント
 		status["progress"] = map[string]interface{}{
+			"current_step":   StageCompleted,
+			"percentage":     100,
 			"chunks_created": chunkCount,
 		}
 	}
 
+	if doc.Status == "failed" {
+		message := doc.ErrorMessage.String
+		if message == "" && progress != nil {
+			message = progress.LastError
+		}
+		status["error"] = map[string]interface{}{
+			"code":    "PROCESSING_FAILED",
+			"message": message,
+		}
+	}
+
 	return status, nil
 }
 
 // ChunkInfo はチャンク情報
 type ChunkInfo struct {
-	ID         uuid.UUID `json:"id"`
-	ChunkIndex int       `json:"chunk_index"`
-	PageNumber int       `json:"page_number"` // 追加
-	Content    string    `json:"content"`
-	CreatedAt  string    `json:"created_at"`
+	ID         uuid.UUID     `json:"id"`
+	ChunkIndex int           `json:"chunk_index"`
+	PageNumber int           `json:"page_number"` // 追加
+	Headings   []string      `json:"headings,omitempty"`
+	Regions    []ChunkRegion `json:"regions,omitempty"` // 本文が描かれているページ上の領域（PDF のみ）
+	// OCRConfidence は OCR で認識したページの信頼度（0〜1、テキストレイヤーから抽出した場合は nil）
+	OCRConfidence *float64 `json:"ocr_confidence,omitempty"`
+	// ChunkStrategy はチャンク分割の方式（この機能より前に処理したチャンクは空）
+	ChunkStrategy ChunkStrategy `json:"chunk_strategy,omitempty"`
+	// ParentID は検索でヒットしたときに LLM に渡す親チャンク（親を持たない場合は nil）
+	ParentID  *uuid.UUID `json:"parent_id,omitempty"`
+	Content   string     `json:"content"`
+	CreatedAt string     `json:"created_at"`
 }
 
 // GetDocumentChunks はドキュメントのチャンク一覧を取得します
@@ -356,6 +844,19 @@ func (p *DocumentProcessor) GetDocumentChunks(
 			Content:    dbChunk.Content,
 			CreatedAt:  dbChunk.CreatedAt.Format("2006-01-02T15:04:05Z"),
 		}
+		if dbChunk.ParentID.Valid {
+			parentID := dbChunk.ParentID.UUID
+			chunks[i].ParentID = &parentID
+		}
+		if dbChunk.Metadata.Valid {
+			var meta chunkMetadataJSON
+			if err := json.Unmarshal(dbChunk.Metadata.RawMessage, &meta); err == nil {
+				chunks[i].Headings = meta.Headings
+				chunks[i].Regions = meta.Regions
+				chunks[i].OCRConfidence = meta.OCRConfidence
+				chunks[i].ChunkStrategy = meta.ChunkStrategy
+			}
+		}
 	}
 
 	return chunks, int(total), nil
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/google/uuid"
+	"github.com/sqlc-dev/pqtype"
+)
+
+// ドキュメント処理のステージ（処理順）
+const (
+	StageDownloading = "downloading"
+	StageExtracting  = "extracting"
+	StageChunking    = "chunking"
+	StageEmbedding   = "embedding"
+	StagePersisting  = "persisting"
+	StageIndexing    = "indexing"
+	StageCompleted   = "completed"
+)
+
+// stageRanges は各ステージが全体の進捗率のどの範囲を占めるか（開始%, 終了%）
+// embedding が最も時間がかかるため大きく割り当てている
+var stageRanges = map[string][2]int{
+	StageDownloading: {0, 5},
+	StageExtracting:  {5, 20},
+	StageChunking:    {20, 25},
+	StageEmbedding:   {25, 75},
+	StagePersisting:  {75, 90},
+	StageIndexing:    {90, 100},
+	StageCompleted:   {100, 100},
+}
+
+// ProcessingProgress は documents.progress (JSONB) に保存される処理の進捗
+type ProcessingProgress struct {
+	Stage           string    `json:"stage"`
+	Percentage      int       `json:"percentage"`
+	PagesExtracted  int       `json:"pages_extracted"`
+	PagesOCR        int       `json:"pages_ocr"` // OCR で認識した PDF のページ数
+	ChunksTotal     int       `json:"chunks_total"`
+	ChunksAdjusted  int       `json:"chunks_adjusted"` // モデルの最大シーケンス長を超えたため分け直したチャンク数
+	ParentChunks    int       `json:"parent_chunks"`   // 子チャンクをまとめる親チャンク（セクション）の数
+	ChunksEmbedded  int       `json:"chunks_embedded"`
+	ChunksReused    int       `json:"chunks_reused"`  // 前回から変わらず、embedding を再利用したチャンク数
+	ChunksDeleted   int       `json:"chunks_deleted"` // 前回から消えたチャンク数
+	EmbedBatches    int       `json:"embed_batches"`
+	BatchesEmbedded int       `json:"batches_embedded"`
+	ChunksPersisted int       `json:"chunks_persisted"`
+	PointsUpserted  int       `json:"points_upserted"`
+	LastError       string    `json:"last_error,omitempty"`
+	StartedAt       time.Time `json:"started_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// parseProcessingProgress は documents.progress を読み込みます（未設定・不正な場合は nil）
+func parseProcessingProgress(raw pqtype.NullRawMessage) *ProcessingProgress {
+	if !raw.Valid || len(raw.RawMessage) == 0 {
+		return nil
+	}
+	var progress ProcessingProgress
+	if err := json.Unmarshal(raw.RawMessage, &progress); err != nil {
+		return nil
+	}
+	return &progress
+}
+
+// progressTracker は処理中の進捗を更新し、その都度 DB に保存します
+// 並列の embedding バッチから同時に更新されるため mu で保護する
+type progressTracker struct {
+	mu         sync.Mutex
+	queries    *db.Queries
+	documentID uuid.UUID
+	progress   ProcessingProgress
+}
+
+// newProgressTracker は新しい進捗トラッカーを作成します。
+// 前回の試行で記録されたエラーは、成功するまで last_error として引き継ぎます。
+func newProgressTracker(queries *db.Queries, documentID uuid.UUID, previous *ProcessingProgress) *progressTracker {
+	now := time.Now()
+	t := &progressTracker{
+		queries:    queries,
+		documentID: documentID,
+		progress: ProcessingProgress{
+			Stage:     StageDownloading,
+			StartedAt: now,
+			UpdatedAt: now,
+		},
+	}
+	if previous != nil {
+		t.progress.LastError = previous.LastError
+	}
+	return t
+}
+
+// update は進捗を変更して保存します。
+// 保存に失敗しても処理自体は続行する（進捗はあくまで表示用のため）
+func (t *progressTracker) update(ctx context.Context, fn func(p *ProcessingProgress)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fn(&t.progress)
+	t.progress.Percentage = t.percentage()
+	t.progress.UpdatedAt = time.Now()
+	t.save(ctx)
+}
+
+// fail は失敗時のエラーを記録します（ステージは失敗した時点のまま残す）
+func (t *progressTracker) fail(ctx context.Context, err error) {
+	t.update(ctx, func(p *ProcessingProgress) {
+		p.LastError = err.Error()
+	})
+}
+
+// complete は処理完了を記録します
+func (t *progressTracker) complete(ctx context.Context) {
+	t.update(ctx, func(p *ProcessingProgress) {
+		p.Stage = StageCompleted
+		p.LastError = ""
+	})
+}
+
+// save は現在の進捗を保存します（mu を保持した状態で呼ぶか、並列更新が始まる前に呼ぶこと）
+func (t *progressTracker) save(ctx context.Context) {
+	data, err := json.Marshal(t.progress)
+	if err != nil {
+		log.Printf("⚠️ Failed to marshal progress: %v", err)
+		return
+	}
+	err = t.queries.UpdateDocumentProgress(ctx, db.UpdateDocumentProgressParams{
+		ID:       t.documentID,
+		Progress: pqtype.NullRawMessage{RawMessage: data, Valid: true},
+	})
+	if err != nil {
+		log.Printf("⚠️ Failed to save progress for document %s: %v", t.documentID, err)
+	}
+}
+
+// percentage は現在のステージと件数から全体の進捗率を計算します
+func (t *progressTracker) percentage() int {
+	p := t.progress
+	r, ok := stageRanges[p.Stage]
+	if !ok {
+		return 0
+	}
+
+	// 再利用するチャンクは embedding / upsert が不要なため完了済みとして数える
+	var done, total int
+	switch p.Stage {
+	case StageEmbedding:
+		done, total = p.ChunksEmbedded+p.ChunksReused, p.ChunksTotal
+	case StagePersisting:
+		done, total = p.ChunksPersisted, p.ChunksTotal
+	case StageIndexing:
+		done, total = p.PointsUpserted+p.ChunksReused, p.ChunksTotal
+	}
+
+	if total <= 0 {
+		return r[0]
+	}
+	if done > total {
+		done = total
+	}
+	return r[0] + (r[1]-r[0])*done/total
+}
@@ -0,0 +1,343 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/storage"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrVersionNotFound はドキュメントに指定のバージョンが存在しない場合のエラー
+	ErrVersionNotFound = errors.New("document version not found")
+	// ErrVersionNotProcessed はチャンクがまだ無い（未処理の）バージョンを差分の対象にした場合のエラー
+	ErrVersionNotProcessed = errors.New("document version has not been processed")
+)
+
+// maxDiffCells は差分の LCS の表の最大サイズ（共通の先頭・末尾を除いたチャンク数の積）
+// 超える場合は整列せず、残りのチャンクをすべて削除・追加として返す
+const maxDiffCells = 4_000_000
+
+// DocumentVersionInfo はドキュメントの1バージョン
+type DocumentVersionInfo struct {
+	ID            uuid.UUID
+	DocumentID    uuid.UUID
+	VersionNumber int
+	FileID        uuid.UUID
+	FileName      string
+	MimeType      string
+	SizeBytes     int64
+	SHA256Hash    string
+	IsCurrent     bool  // 検索・チャットの対象になっているバージョンか
+	ChunkCount    int64 // 0 の場合は未処理
+	CreatedAt     time.Time
+}
+
+// VersionChunk は差分に含まれるチャンク
+type VersionChunk struct {
+	ChunkIndex int
+	PageNumber int
+	Content    string
+}
+
+// VersionDiff は2つのバージョンのチャンク本文の差分
+type VersionDiff struct {
+	FromVersion int
+	ToVersion   int
+	Added       []VersionChunk // to にだけあるチャンク
+	Removed     []VersionChunk // from にだけあるチャンク
+	Unchanged   int
+	Truncated   bool // 大きすぎて整列しなかった
+}
+
+// VersionRollbackResult はロールバックの結果
+type VersionRollbackResult struct {
+	Version *DocumentVersionInfo
+	Job     *db.DocumentJob // チャンクが無く、処理ジョブを登録した場合のみ（処理後に現在のバージョンになる）
+}
+
+// DocumentVersionService はドキュメントのバージョン履歴・差分・ロールバックを担当
+type DocumentVersionService struct {
+	queries     *db.Queries
+	vectorStore storage.VectorStore
+	jobQueue    *DocumentJobQueue
+}
+
+// NewDocumentVersionService は新しい DocumentVersionService を作成
+func NewDocumentVersionService(
+	queries *db.Queries,
+	vectorStore storage.VectorStore,
+	jobQueue *DocumentJobQueue,
+) *DocumentVersionService {
+	return &DocumentVersionService{
+		queries:     queries,
+		vectorStore: vectorStore,
+		jobQueue:    jobQueue,
+	}
+}
+
+// ListVersions はドキュメントのバージョンを新しい順に返します
+func (s *DocumentVersionService) ListVersions(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	documentID uuid.UUID,
+) ([]DocumentVersionInfo, error) {
+	rows, err := s.queries.ListDocumentVersions(ctx, db.ListDocumentVersionsParams{
+		DocumentID:  documentID,
+		WorkspaceID: workspaceID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list document versions: %w", err)
+	}
+	// どのドキュメントにも最低1つのバージョンがあるため、0件はドキュメントが存在しない
+	if len(rows) == 0 {
+		return nil, ErrDocumentNotFound
+	}
+
+	versions := make([]DocumentVersionInfo, len(rows))
+	for i, row := range rows {
+		versions[i] = DocumentVersionInfo{
+			ID:            row.ID,
+			DocumentID:    row.DocumentID,
+			VersionNumber: int(row.VersionNumber),
+			FileID:        row.FileID,
+			FileName:      row.FileName,
+			MimeType:      row.MimeType,
+			SizeBytes:     row.SizeBytes,
+			SHA256Hash:    row.Sha256Hash,
+			IsCurrent:     row.IsCurrent,
+			ChunkCount:    row.ChunkCount,
+			CreatedAt:     row.CreatedAt,
+		}
+	}
+	return versions, nil
+}
+
+// DiffVersions は2つのバージョンのチャンク本文を比較します。
+// 本文が同じチャンクを順番を保って対応付け（LCS）、残りを削除・追加として返します。
+func (s *DocumentVersionService) DiffVersions(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	documentID uuid.UUID,
+	fromVersion int,
+	toVersion int,
+) (*VersionDiff, error) {
+	var chunks [2][]db.ListVersionChunkContentsRow
+	for i, number := range []int{fromVersion, toVersion} {
+		version, err := s.getVersion(ctx, workspaceID, documentID, number)
+		if err != nil {
+			return nil, err
+		}
+		if version.ChunkCount == 0 {
+			return nil, fmt.Errorf("%w: version %d", ErrVersionNotProcessed, number)
+		}
+		chunks[i], err = s.queries.ListVersionChunkContents(ctx, version.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list chunks of version %d: %w", number, err)
+		}
+	}
+
+	diff := diffVersionChunks(chunks[0], chunks[1])
+	diff.FromVersion = fromVersion
+	diff.ToVersion = toVersion
+	return diff, nil
+}
+
+// RollbackVersion は指定のバージョンを現在のバージョンに戻します。
+// チャンクが残っていればすぐに切り替え、無ければ処理ジョブを登録します（処理の完了時に切り替わる）。
+func (s *DocumentVersionService) RollbackVersion(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	documentID uuid.UUID,
+	versionNumber int,
+) (*VersionRollbackResult, error) {
+	version, err := s.getVersion(ctx, workspaceID, documentID, versionNumber)
+	if err != nil {
+		return nil, err
+	}
+	if version.IsCurrent {
+		return &VersionRollbackResult{Version: version}, nil
+	}
+
+	if version.ChunkCount == 0 {
+		job, err := s.jobQueue.Enqueue(ctx, workspaceID, documentID, ProcessOptions{
+			ChunkSize:       DefaultChunkSize,
+			ChunkOverlap:    DefaultChunkOverlap,
+			ParentChunkSize: DefaultParentChunkSize,
+			VersionID:       &version.ID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("⏪ Rollback of document %s to version %d queued: job=%s", documentID, versionNumber, job.ID)
+		return &VersionRollbackResult{Version: version, Job: job}, nil
+	}
+
+	if err := activateDocumentVersion(ctx, s.queries, s.vectorStore, workspaceID, documentID, version.ID); err != nil {
+		return nil, err
+	}
+	version.IsCurrent = true
+
+	log.Printf("⏪ Document %s rolled back to version %d", documentID, versionNumber)
+	return &VersionRollbackResult{Version: version}, nil
+}
+
+// getVersion はバージョン番号でバージョンを1件取得します
+func (s *DocumentVersionService) getVersion(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	documentID uuid.UUID,
+	versionNumber int,
+) (*DocumentVersionInfo, error) {
+	row, err := s.queries.GetDocumentVersionByNumber(ctx, db.GetDocumentVersionByNumberParams{
+		DocumentID:    documentID,
+		WorkspaceID:   workspaceID,
+		VersionNumber: int32(versionNumber),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: version %d", ErrVersionNotFound, versionNumber)
+		}
+		return nil, fmt.Errorf("failed to get document version: %w", err)
+	}
+	return &DocumentVersionInfo{
+		ID:            row.ID,
+		DocumentID:    row.DocumentID,
+		VersionNumber: int(row.VersionNumber),
+		FileID:        row.FileID,
+		FileName:      row.FileName,
+		MimeType:      row.MimeType,
+		SizeBytes:     row.SizeBytes,
+		SHA256Hash:    row.Sha256Hash,
+		IsCurrent:     row.IsCurrent,
+		ChunkCount:    row.ChunkCount,
+		CreatedAt:     row.CreatedAt,
+	}, nil
+}
+
+// activateDocumentVersion は検索対象のバージョンを切り替えます。
+// 先に全バージョンのポイントの is_current / version_id を付け直し、そのあと documents.current_version_id を更新する
+// （途中で失敗しても、もう一度呼べば同じ状態になる）。
+func activateDocumentVersion(
+	ctx context.Context,
+	queries *db.Queries,
+	vectorStore storage.VectorStore,
+	workspaceID uuid.UUID,
+	documentID uuid.UUID,
+	versionID uuid.UUID,
+) error {
+	// Step 1: VectorStore の payload を付け直す（バージョン管理より前のポイントには version_id も付く）
+	points, err := queries.ListChunkPointVersionsByDocumentID(ctx, documentID)
+	if err != nil {
+		return fmt.Errorf("failed to list chunk point IDs: %w", err)
+	}
+
+	updates := make([]storage.PayloadUpdate, len(points))
+	for i, point := range points {
+		updates[i] = storage.PayloadUpdate{
+			PointID: point.PointID.String(),
+			Payload: map[string]interface{}{
+				"version_id": point.VersionID.String(),
+				"is_current": point.VersionID == versionID,
+			},
+		}
+	}
+
+	collectionName := fmt.Sprintf("workspace_%s", workspaceID.String())
+	for _, batch := range splitBatches(len(updates), payloadUpdateBatchSize) {
+		err := vectorStore.UpdatePayloads(ctx, collectionName, updates[batch.start:batch.end])
+		if err != nil {
+			if errors.Is(err, storage.ErrCollectionNotFound) {
+				break
+			}
+			return fmt.Errorf("failed to update payloads in vector store: %w", err)
+		}
+	}
+
+	// Step 2: documents の current_version_id / file_id を切り替える
+	rows, err := queries.SetDocumentCurrentVersion(ctx, versionID)
+	if err != nil {
+		return fmt.Errorf("failed to set current version: %w", err)
+	}
+	if rows == 0 {
+		return ErrDocumentNotFound
+	}
+	return nil
+}
+
+// diffVersionChunks はチャンク本文の LCS で2つのバージョンを対応付けます。
+// 共通の先頭・末尾は表を作らずに数え、中間が maxDiffCells を超える場合は整列しません。
+func diffVersionChunks(from, to []db.ListVersionChunkContentsRow) *VersionDiff {
+	diff := &VersionDiff{Added: []VersionChunk{}, Removed: []VersionChunk{}}
+
+	// 共通の先頭と末尾
+	prefix := 0
+	for prefix < len(from) && prefix < len(to) && from[prefix].Content == to[prefix].Content {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(from)-prefix && suffix < len(to)-prefix &&
+		from[len(from)-1-suffix].Content == to[len(to)-1-suffix].Content {
+		suffix++
+	}
+	diff.Unchanged = prefix + suffix
+	a := from[prefix : len(from)-suffix]
+	b := to[prefix : len(to)-suffix]
+
+	if len(a)*len(b) > maxDiffCells {
+		diff.Truncated = true
+		for _, c := range a {
+			diff.Removed = append(diff.Removed, toVersionChunk(c))
+		}
+		for _, c := range b {
+			diff.Added = append(diff.Added, toVersionChunk(c))
+		}
+		return diff
+	}
+
+	// lcs[i][j] = a[i:] と b[j:] の最長共通部分列の長さ
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i].Content == b[j].Content {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i].Content == b[j].Content:
+			diff.Unchanged++
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			diff.Removed = append(diff.Removed, toVersionChunk(a[i]))
+			i++
+		default:
+			diff.Added = append(diff.Added, toVersionChunk(b[j]))
+			j++
+		}
+	}
+	return diff
+}
+
+func toVersionChunk(c db.ListVersionChunkContentsRow) VersionChunk {
+	return VersionChunk{
+		ChunkIndex: int(c.ChunkIndex),
+		PageNumber: int(c.PageNumber),
+		Content:    c.Content,
+	}
+}
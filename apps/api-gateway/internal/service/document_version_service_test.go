@@ -0,0 +1,75 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+)
+
+func newTestVersionChunks(texts ...string) []db.ListVersionChunkContentsRow {
+	rows := make([]db.ListVersionChunkContentsRow, len(texts))
+	for i, text := range texts {
+		rows[i] = db.ListVersionChunkContentsRow{ChunkIndex: int32(i), PageNumber: 1, Content: text}
+	}
+	return rows
+}
+
+func versionChunkContents(chunks []VersionChunk) []string {
+	contents := make([]string, len(chunks))
+	for i, c := range chunks {
+		contents[i] = c.Content
+	}
+	return contents
+}
+
+func TestDiffVersionChunks_AlignsUnchangedChunks(t *testing.T) {
+	// B が削除され、C と D の間に X、末尾に E が追加された
+	diff := diffVersionChunks(
+		newTestVersionChunks("A", "B", "C", "D"),
+		newTestVersionChunks("A", "C", "X", "D", "E"),
+	)
+
+	if diff.Unchanged != 3 {
+		t.Errorf("Expected 3 unchanged chunks, got %d", diff.Unchanged)
+	}
+	if got := versionChunkContents(diff.Removed); len(got) != 1 || got[0] != "B" {
+		t.Errorf("Expected B removed, got %v", got)
+	}
+	if got := versionChunkContents(diff.Added); len(got) != 2 || got[0] != "X" || got[1] != "E" {
+		t.Errorf("Expected X and E added, got %v", got)
+	}
+	if diff.Added[1].ChunkIndex != 4 {
+		t.Errorf("Expected added chunk index from the new version, got %d", diff.Added[1].ChunkIndex)
+	}
+	if diff.Truncated {
+		t.Errorf("Expected an aligned diff")
+	}
+}
+
+func TestDiffVersionChunks_IdenticalVersions(t *testing.T) {
+	diff := diffVersionChunks(newTestVersionChunks("A", "B"), newTestVersionChunks("A", "B"))
+
+	if diff.Unchanged != 2 || len(diff.Added) != 0 || len(diff.Removed) != 0 {
+		t.Errorf("Expected no changes, got %+v", diff)
+	}
+}
+
+func TestDiffVersionChunks_TruncatesLargeDiffs(t *testing.T) {
+	from := make([]string, 2002)
+	to := make([]string, 2002)
+	for i := range from {
+		from[i] = "old"
+		to[i] = "new"
+	}
+	from[0], to[0] = "same", "same"
+
+	diff := diffVersionChunks(newTestVersionChunks(from...), newTestVersionChunks(to...))
+
+	if !diff.Truncated {
+		t.Errorf("Expected the diff to be truncated")
+	}
+	if diff.Unchanged != 1 || len(diff.Removed) != 2001 || len(diff.Added) != 2001 {
+		t.Errorf("Expected 1 unchanged, 2001 removed and added, got %d, %d, %d",
+			diff.Unchanged, len(diff.Removed), len(diff.Added))
+	}
+}
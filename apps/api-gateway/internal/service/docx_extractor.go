@@ -0,0 +1,229 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DOCXExtractor は Word（.docx）の本文を見出しごとのセクションに分割します。
+// 見出しは段落スタイルのアウトラインレベル（または "heading N" という名前のスタイル）から判定し、
+// 明示的な改ページ（Ctrl+Enter）でページ番号を進めます。表はセルを " | " でつないだ行にします。
+type DOCXExtractor struct{}
+
+// docxHeadingStyleName は英語名の見出しスタイル（styleId が "1" などに変わる日本語版の Word でも name は共通）
+var docxHeadingStyleName = regexp.MustCompile(`(?i)^heading\s*([1-9])$`)
+
+// Extract は DOCX を見出し単位の PageContent に変換します
+func (e *DOCXExtractor) Extract(ctx context.Context, reader io.Reader) ([]PageContent, error) {
+	// Step 1: zip として開く（zip.Reader はランダムアクセスが必要なため全て読み込む）
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DOCX data: %w", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open DOCX: %w", err)
+	}
+
+	// Step 2: スタイル定義から見出しレベルを取得（styles.xml がなくても本文は読める）
+	headingStyles := map[string]int{}
+	if f := findZipFile(zr, "word/styles.xml"); f != nil {
+		headingStyles, err = readDOCXHeadingStyles(f)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Step 3: 本文を段落ごとに読む
+	f := findZipFile(zr, "word/document.xml")
+	if f == nil {
+		return nil, fmt.Errorf("invalid DOCX: word/document.xml not found")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open word/document.xml: %w", err)
+	}
+	defer rc.Close()
+
+	sections, err := readDOCXBody(rc, headingStyles)
+	if err != nil {
+		return nil, err
+	}
+	if len(sections) == 0 {
+		return nil, fmt.Errorf("no text found in DOCX")
+	}
+	return sections, nil
+}
+
+func findZipFile(zr *zip.Reader, name string) *zip.File {
+	for _, f := range zr.File {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// readDOCXHeadingStyles は段落スタイルの styleId → 見出しレベル（1〜9）を返します
+func readDOCXHeadingStyles(f *zip.File) (map[string]int, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open word/styles.xml: %w", err)
+	}
+	defer rc.Close()
+
+	var styles struct {
+		Styles []struct {
+			Type    string `xml:"type,attr"`
+			StyleID string `xml:"styleId,attr"`
+			Name    struct {
+				Val string `xml:"val,attr"`
+			} `xml:"name"`
+			PPr struct {
+				OutlineLvl *struct {
+					Val string `xml:"val,attr"`
+				} `xml:"outlineLvl"`
+			} `xml:"pPr"`
+		} `xml:"style"`
+	}
+	if err := xml.NewDecoder(rc).Decode(&styles); err != nil {
+		return nil, fmt.Errorf("failed to parse word/styles.xml: %w", err)
+	}
+
+	levels := make(map[string]int)
+	for _, s := range styles.Styles {
+		if s.Type != "" && s.Type != "paragraph" {
+			continue
+		}
+		if s.PPr.OutlineLvl != nil {
+			// outlineLvl は 0 始まり、9 は本文
+			if lvl, err := strconv.Atoi(s.PPr.OutlineLvl.Val); err == nil && lvl < 9 {
+				levels[s.StyleID] = lvl + 1
+				continue
+			}
+		}
+		if m := docxHeadingStyleName.FindStringSubmatch(s.Name.Val); m != nil {
+			levels[s.StyleID], _ = strconv.Atoi(m[1])
+		} else if strings.EqualFold(s.Name.Val, "title") {
+			levels[s.StyleID] = 1
+		}
+	}
+	return levels, nil
+}
+
+// readDOCXBody は document.xml を順に読み、段落・表・改ページを sectionBuilder に書き込みます
+func readDOCXBody(r io.Reader, headingStyles map[string]int) ([]PageContent, error) {
+	b := newSectionBuilder()
+	dec := xml.NewDecoder(r)
+
+	var (
+		para      strings.Builder
+		level     int  // 現在の段落の見出しレベル（0 は本文）
+		inText    bool // <w:t> の中
+		cellDepth int  // 表のセルの入れ子
+		cellSeen  bool // 表の行で最初のセルを書いたか
+		cellText  bool // 現在のセルに文字列を書いたか
+	)
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse word/document.xml: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				para.Reset()
+				level = 0
+			case "pStyle":
+				level = headingStyles[xmlAttr(t, "val")]
+			case "outlineLvl":
+				if lvl, err := strconv.Atoi(xmlAttr(t, "val")); err == nil && lvl < 9 {
+					level = lvl + 1
+				}
+			case "t", "delText":
+				inText = t.Name.Local == "t"
+			case "tab":
+				para.WriteString("\t")
+			case "br", "cr":
+				if xmlAttr(t, "type") == "page" && cellDepth == 0 {
+					// 改ページ前の文字列を確定させてからページを進める
+					b.write(para.String())
+					para.Reset()
+					b.pageBreak()
+				} else {
+					para.WriteString("\n")
+				}
+			case "tr":
+				cellSeen = false
+			case "tc":
+				if cellSeen {
+					b.write(" | ")
+				}
+				cellSeen = true
+				cellText = false
+				cellDepth++
+			}
+
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t", "delText":
+				inText = false
+			case "p":
+				text := para.String()
+				switch {
+				case cellDepth > 0:
+					// セル内の段落は改行せずに空白でつなぐ
+					if text = strings.TrimSpace(text); text != "" {
+						if cellText {
+							b.write(" ")
+						}
+						b.write(text)
+						cellText = true
+					}
+				case level > 0 && strings.TrimSpace(text) != "":
+					b.heading(level, text)
+				default:
+					b.write(text + "\n\n")
+				}
+				para.Reset()
+				level = 0
+			case "tc":
+				cellDepth--
+			case "tr":
+				b.write("\n")
+			case "tbl":
+				b.write("\n")
+			}
+
+		case xml.CharData:
+			if inText {
+				para.Write(t)
+			}
+		}
+	}
+
+	return b.result(), nil
+}
+
+// xmlAttr は名前空間を無視して属性の値を返します
+func xmlAttr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
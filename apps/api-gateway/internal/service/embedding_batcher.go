@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ProcessorOptions はドキュメント処理時のバッチサイズ・並列度の設定
+type ProcessorOptions struct {
+	EmbedBatchSize   int           // 1回の Embedding リクエストで送るチャンク数
+	EmbedParallelism int           // 同時に送る Embedding リクエスト数
+	BatchRetries     int           // 失敗したバッチ（embedding / upsert）の再試行回数
+	RetryDelay       time.Duration // 再試行の初期待機時間（試行ごとに倍増）
+	IndexBatchSize   int           // Postgres INSERT / Qdrant upsert の1回あたりの件数
+
+	Extractors *ExtractorRegistry // ファイル形式ごとのテキスト抽出（nil なら DefaultExtractorRegistry）
+
+	// Tokenizer は embedding モデルのトークナイザ（nil ならチャンクの長さを検査しない）
+	Tokenizer      Tokenizer
+	OversizeChunks OversizeChunkPolicy // 最大シーケンス長を超えるチャンクの扱い（デフォルトは split）
+}
+
+// withDefaults は未設定の項目にデフォルト値を入れた ProcessorOptions を返します
+func (o ProcessorOptions) withDefaults() ProcessorOptions {
+	if o.EmbedBatchSize <= 0 {
+		o.EmbedBatchSize = 32
+	}
+	if o.EmbedParallelism <= 0 {
+		o.EmbedParallelism = 1
+	}
+	if o.BatchRetries < 0 {
+		o.BatchRetries = 0
+	}
+	if o.RetryDelay <= 0 {
+		o.RetryDelay = time.Second
+	}
+	if o.IndexBatchSize <= 0 {
+		o.IndexBatchSize = 256
+	}
+	if o.Extractors == nil {
+		o.Extractors = DefaultExtractorRegistry()
+	}
+	if o.OversizeChunks == "" {
+		o.OversizeChunks = OversizeChunkSplit
+	}
+	return o
+}
+
+// batchRange はスライス上の [start, end) の範囲
+type batchRange struct {
+	start int
+	end   int
+}
+
+// splitBatches は total 件を size 件ずつの範囲に分割します
+func splitBatches(total, size int) []batchRange {
+	batches := make([]batchRange, 0, (total+size-1)/size)
+	for start := 0; start < total; start += size {
+		batches = append(batches, batchRange{start: start, end: min(start+size, total)})
+	}
+	return batches
+}
+
+// embedTexts はテキストをバッチに分けて並列に Embedding し、入力と同じ順序で返します。
+// 各バッチは失敗しても BatchRetries 回まで単独で再試行されるため、成功済みのバッチを再計算することはありません。
+// いずれかのバッチが再試行を使い切った場合は、残りのバッチをキャンセルしてエラーを返します。
+func (p *DocumentProcessor) embedTexts(
+	ctx context.Context,
+	texts []string,
+	tracker *progressTracker,
+) ([][]float64, int, error) {
+	batches := splitBatches(len(texts), p.opts.EmbedBatchSize)
+	embeddings := make([][]float64, len(texts))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		dim      int
+	)
+	sem := make(chan struct{}, p.opts.EmbedParallelism)
+
+	for i, batch := range batches {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, batch batchRange) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			var result [][]float64
+			var batchDim *int
+			err := retryWithBackoff(ctx, p.opts.BatchRetries, p.opts.RetryDelay, func() error {
+				resp, err := p.aiClient.EmbedDocuments(ctx, texts[batch.start:batch.end])
+				if err != nil {
+					return err
+				}
+				if len(resp.Embeddings) != batch.end-batch.start {
+					return fmt.Errorf("embedding count mismatch: got %d, expected %d",
+						len(resp.Embeddings), batch.end-batch.start)
+				}
+				result = resp.Embeddings
+				batchDim = resp.Dim
+				return nil
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to generate embeddings for batch %d/%d (chunks %d-%d): %w",
+						i+1, len(batches), batch.start, batch.end-1, err)
+					cancel()
+				}
+				return
+			}
+
+			copy(embeddings[batch.start:batch.end], result)
+			if batchDim != nil {
+				dim = *batchDim
+			}
+
+			if tracker != nil {
+				tracker.update(ctx, func(pr *ProcessingProgress) {
+					pr.ChunksEmbedded += batch.end - batch.start
+					pr.BatchesEmbedded++
+				})
+			}
+		}(i, batch)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, 0, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	// AI Worker が dim を返さない場合はベクトルの長さから判断する
+	if dim == 0 && len(embeddings) > 0 {
+		dim = len(embeddings[0])
+	}
+
+	return embeddings, dim, nil
+}
+
+// embedSentences は semantic のチャンク分割で使う文の embedding を計算します（進捗には数えない）
+func (p *DocumentProcessor) embedSentences(ctx context.Context, sentences []string) ([][]float64, error) {
+	embeddings, _, err := p.embedTexts(ctx, sentences, nil)
+	return embeddings, err
+}
+
+// retryWithBackoff は fn を最大 retries 回まで再試行します（待機時間は試行ごとに倍増）
+func retryWithBackoff(ctx context.Context, retries int, delay time.Duration, fn func() error) error {
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			log.Printf("🔁 Retrying batch (attempt %d/%d) after %s: %v", attempt+1, retries+1, delay, err)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+
+		if err = fn(); err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return err
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/client"
+)
+
+// fakeEmbedder は "text-<n>" を [n] に埋め込む AIWorkerClient。
+// 先頭のバッチほど遅く返すので、並列実行ではバッチの完了順が入力順と逆になる
+type fakeEmbedder struct {
+	client.AIWorkerClient
+
+	batchSize int
+	failOnce  map[string]bool // このテキストで始まるバッチは最初の1回だけ失敗する
+
+	mu          sync.Mutex
+	calls       map[string]int
+	inFlight    int
+	maxInFlight int
+}
+
+func (e *fakeEmbedder) EmbedDocuments(ctx context.Context, texts []string) (*client.EmbedDocumentsResponse, error) {
+	e.mu.Lock()
+	e.calls[texts[0]]++
+	calls := e.calls[texts[0]]
+	e.inFlight++
+	e.maxInFlight = max(e.maxInFlight, e.inFlight)
+	e.mu.Unlock()
+
+	defer func() {
+		e.mu.Lock()
+		e.inFlight--
+		e.mu.Unlock()
+	}()
+
+	first, _ := strconv.Atoi(strings.TrimPrefix(texts[0], "text-"))
+	select {
+	case <-time.After(time.Duration(20-first/e.batchSize) * time.Millisecond):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if e.failOnce[texts[0]] && calls == 1 {
+		return nil, errors.New("ai worker returned 503")
+	}
+
+	embeddings := make([][]float64, len(texts))
+	for i, text := range texts {
+		n, _ := strconv.Atoi(strings.TrimPrefix(text, "text-"))
+		embeddings[i] = []float64{float64(n)}
+	}
+	return &client.EmbedDocumentsResponse{Embeddings: embeddings, Count: len(texts)}, nil
+}
+
+func embedderTexts(n int) []string {
+	texts := make([]string, n)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("text-%d", i)
+	}
+	return texts
+}
+
+func TestEmbedTexts_KeepsInputOrderAcrossParallelBatches(t *testing.T) {
+	embedder := &fakeEmbedder{
+		batchSize: 3,
+		failOnce:  map[string]bool{"text-3": true},
+		calls:     map[string]int{},
+	}
+	p := &DocumentProcessor{
+		aiClient: embedder,
+		opts: ProcessorOptions{
+			EmbedBatchSize:   3,
+			EmbedParallelism: 4,
+			BatchRetries:     2,
+			RetryDelay:       time.Millisecond,
+		}.withDefaults(),
+	}
+
+	embeddings, dim, err := p.embedTexts(context.Background(), embedderTexts(10), nil)
+	if err != nil {
+		t.Fatalf("embedTexts failed: %v", err)
+	}
+
+	if len(embeddings) != 10 || dim != 1 {
+		t.Fatalf("Expected 10 embeddings of dim 1, got %d (dim %d)", len(embeddings), dim)
+	}
+	for i, e := range embeddings {
+		if len(e) != 1 || e[0] != float64(i) {
+			t.Errorf("embeddings[%d] = %v, want [%d]", i, e, i)
+		}
+	}
+	if embedder.maxInFlight < 2 || embedder.maxInFlight > 4 {
+		t.Errorf("Expected 2-4 concurrent requests, got %d", embedder.maxInFlight)
+	}
+	// 失敗したバッチだけを再試行し、成功したバッチは再計算しない
+	for first, calls := range embedder.calls {
+		want := 1
+		if first == "text-3" {
+			want = 2
+		}
+		if calls != want {
+			t.Errorf("Batch starting at %s was sent %d times, want %d", first, calls, want)
+		}
+	}
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name      string
+		retries   int
+		failures  int // 最初の何回を失敗させるか
+		wantCalls int
+		wantErr   error
+	}{
+		{"succeeds first time", 3, 0, 1, nil},
+		{"succeeds after retries", 3, 2, 3, nil},
+		{"gives up after retries", 2, 10, 3, errFailed},
+		{"no retries", 0, 10, 1, errFailed},
+	}
+	for _, tt := range tests {
+		calls := 0
+		err := retryWithBackoff(context.Background(), tt.retries, time.Millisecond, func() error {
+			calls++
+			if calls <= tt.failures {
+				return errFailed
+			}
+			return nil
+		})
+		if !errors.Is(err, tt.wantErr) || calls != tt.wantCalls {
+			t.Errorf("%s: got %v after %d calls, want %v after %d calls", tt.name, err, calls, tt.wantErr, tt.wantCalls)
+		}
+	}
+}
+
+func TestRetryWithBackoff_StopsOnContextCancel(t *testing.T) {
+	t.Run("while waiting", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+		}()
+
+		start := time.Now()
+		err := retryWithBackoff(ctx, 5, time.Hour, func() error {
+			calls++
+			return errors.New("failed")
+		})
+		if !errors.Is(err, context.Canceled) || calls != 1 {
+			t.Errorf("Expected context.Canceled after 1 call, got %v after %d calls", err, calls)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected to stop waiting on cancel, took %s", elapsed)
+		}
+	})
+
+	t.Run("during the call", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+		err := retryWithBackoff(ctx, 5, time.Millisecond, func() error {
+			calls++
+			cancel()
+			return ctx.Err()
+		})
+		if !errors.Is(err, context.Canceled) || calls != 1 {
+			t.Errorf("Expected context.Canceled after 1 call, got %v after %d calls", err, calls)
+		}
+	})
+}
+
+func TestEmbedTexts_CancelsRemainingBatchesOnFailure(t *testing.T) {
+	embedder := &fakeEmbedder{
+		batchSize: 2,
+		failOnce:  map[string]bool{"text-0": true},
+		calls:     map[string]int{},
+	}
+	p := &DocumentProcessor{
+		aiClient: embedder,
+		opts: ProcessorOptions{
+			EmbedBatchSize:   2,
+			EmbedParallelism: 2,
+			BatchRetries:     0,
+		}.withDefaults(),
+	}
+
+	_, _, err := p.embedTexts(context.Background(), embedderTexts(20), nil)
+	if err == nil || !strings.Contains(err.Error(), "batch 1/10") {
+		t.Fatalf("Expected the first batch's error, got %v", err)
+	}
+	// 失敗した時点で残りのバッチは送らない
+	if len(embedder.calls) == 10 {
+		t.Errorf("Expected the remaining batches to be cancelled, but all %d were sent", len(embedder.calls))
+	}
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"strings"
+)
+
+// PageContent は抽出したテキストの1区切り（PDFの1ページ、見出しで区切ったセクション、表の行のまとまりなど）。
+// ここをstringの代わりに使うことで、チャンク生成時にページ情報や見出しが失われない。
+type PageContent struct {
+	PageNumber int      // 1-based（PDFの自然な表現に合わせる。ページ概念のない形式は1）
+	Content    string   // その区切りから抽出したテキスト
+	Headings   []string // この区切りが属する見出しの階層（例: ["導入", "背景"]）。Markdown / HTML / DOCX のみ
+	Rows       []string // 表形式（CSV / TSV）の行。設定されている場合、チャンクは行の途中で分割しない
+	RowHeader  string   // Rows の各チャンクの先頭に付けるヘッダー行
+	// Blocks はレイアウト解析した段落・見出し・表（PDF のみ）。Content は Blocks の Text を "\n\n" で連結したもの
+	Blocks []ContentBlock
+	// OCRConfidence は OCR で認識したページの信頼度（0〜1）。テキストレイヤーから抽出した場合は nil
+	OCRConfidence *float64
+}
+
+// BlockType はレイアウト解析したブロックの種類
+type BlockType string
+
+const (
+	BlockTypeHeading   BlockType = "heading"
+	BlockTypeParagraph BlockType = "paragraph"
+	BlockTypeTable     BlockType = "table"
+)
+
+// BoundingBox はページ上の矩形（ポイント単位、ページ左上が原点で Y は下向き）
+type BoundingBox struct {
+	X0 float64 `json:"x0"`
+	Y0 float64 `json:"y0"`
+	X1 float64 `json:"x1"`
+	Y1 float64 `json:"y1"`
+}
+
+// ContentBlock はページ上の1つのまとまり（段落・見出し・表）
+type ContentBlock struct {
+	Type  BlockType
+	Text  string // 表は行ごとに改行し、セルを " | " で区切る
+	BBox  BoundingBox
+	Level int        // 見出しのレベル（1 が最上位）。見出し以外は0
+	Cells [][]string // 表のセル（行 × 列）。表以外は nil
+}
+
+// Extractor はファイルの内容を PageContent の列に変換します
+type Extractor interface {
+	Extract(ctx context.Context, reader io.Reader) ([]PageContent, error)
+}
+
+// ExtractorRegistry は MIME タイプと拡張子から Extractor を選びます。
+// 新しい形式は Register で追加でき、DocumentProcessor の変更は不要です。
+type ExtractorRegistry struct {
+	byMIME      map[string]Extractor
+	byExtension map[string]Extractor
+}
+
+// NewExtractorRegistry は空の ExtractorRegistry を作成
+func NewExtractorRegistry() *ExtractorRegistry {
+	return &ExtractorRegistry{
+		byMIME:      make(map[string]Extractor),
+		byExtension: make(map[string]Extractor),
+	}
+}
+
+// DefaultExtractorRegistry は PDF / テキスト / Markdown / HTML / DOCX / CSV / TSV を登録した ExtractorRegistry を返します
+func DefaultExtractorRegistry() *ExtractorRegistry {
+	r := NewExtractorRegistry()
+	r.Register(NewPDFExtractor(), []string{"application/pdf"}, []string{".pdf"})
+	r.Register(&PlainTextExtractor{}, []string{"text/plain"}, []string{".txt", ".text", ".log"})
+	r.Register(&MarkdownExtractor{}, []string{"text/markdown", "text/x-markdown"}, []string{".md", ".markdown"})
+	r.Register(&HTMLExtractor{}, []string{"text/html", "application/xhtml+xml"}, []string{".html", ".htm", ".xhtml"})
+	r.Register(&DOCXExtractor{}, []string{"application/vnd.openxmlformats-officedocument.wordprocessingml.document"}, []string{".docx"})
+	r.Register(&CSVExtractor{Comma: ','}, []string{"text/csv", "application/csv"}, []string{".csv"})
+	r.Register(&CSVExtractor{Comma: '\t'}, []string{"text/tab-separated-values"}, []string{".tsv", ".tab"})
+	return r
+}
+
+// Register は Extractor を MIME タイプと拡張子（".md" の形式）に登録します。同じキーは後から登録したものが優先されます
+func (r *ExtractorRegistry) Register(extractor Extractor, mimeTypes []string, extensions []string) {
+	for _, m := range mimeTypes {
+		r.byMIME[normalizeMIMEType(m)] = extractor
+	}
+	for _, ext := range extensions {
+		r.byExtension[strings.ToLower(ext)] = extractor
+	}
+}
+
+// genericMIMETypes はブラウザやクライアントが形式を判別できなかった場合に付く MIME タイプ。
+// これらは拡張子の方が信頼できるため、先に拡張子で探す（例: text/plain で送られた .md）
+var genericMIMETypes = map[string]bool{
+	"":                         true,
+	"application/octet-stream": true,
+	"binary/octet-stream":      true,
+	"application/zip":          true,
+	"text/plain":               true,
+}
+
+// Lookup は MIME タイプとファイル名から Extractor を返します（見つからない場合は false）
+func (r *ExtractorRegistry) Lookup(mimeType, filename string) (Extractor, bool) {
+	m := normalizeMIMEType(mimeType)
+	ext := strings.ToLower(filepath.Ext(filename))
+
+	if !genericMIMETypes[m] {
+		if e, ok := r.byMIME[m]; ok {
+			return e, true
+		}
+	}
+	if e, ok := r.byExtension[ext]; ok {
+		return e, true
+	}
+	e, ok := r.byMIME[m]
+	return e, ok
+}
+
+// normalizeMIMEType は "text/plain; charset=utf-8" のようなパラメータを除いて小文字にします
+func normalizeMIMEType(mimeType string) string {
+	if m, _, err := mime.ParseMediaType(mimeType); err == nil {
+		return m
+	}
+	return strings.ToLower(strings.TrimSpace(mimeType))
+}
+
+// PlainTextExtractor はテキストファイルをそのまま1つの区切りとして返します
+type PlainTextExtractor struct{}
+
+// Extract はテキスト全体を page_number=1 の区切りとして返します（テキストファイルにはページ概念がない）
+func (e *PlainTextExtractor) Extract(ctx context.Context, reader io.Reader) ([]PageContent, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read text file: %w", err)
+	}
+	return []PageContent{{PageNumber: 1, Content: string(data)}}, nil
+}
+
+// headingStack は見出しの階層を保持します（Markdown / HTML / DOCX で共通）
+type headingStack struct {
+	levels []int
+	titles []string
+}
+
+// push はレベル level の見出しを追加し、同じレベル以下の見出しを取り除きます
+func (s *headingStack) push(level int, title string) {
+	for len(s.levels) > 0 && s.levels[len(s.levels)-1] >= level {
+		s.levels = s.levels[:len(s.levels)-1]
+		s.titles = s.titles[:len(s.titles)-1]
+	}
+	s.levels = append(s.levels, level)
+	s.titles = append(s.titles, title)
+}
+
+// path は現在の見出しの階層のコピーを返します（見出しがなければ nil）
+func (s *headingStack) path() []string {
+	if len(s.titles) == 0 {
+		return nil
+	}
+	return append([]string(nil), s.titles...)
+}
+
+// sectionBuilder は見出しごとに PageContent を組み立てます
+type sectionBuilder struct {
+	headings headingStack
+	page     int
+	current  strings.Builder
+	hasBody  bool // 見出し以外の本文が書かれたか
+	sections []PageContent
+}
+
+func newSectionBuilder() *sectionBuilder {
+	return &sectionBuilder{page: 1}
+}
+
+// write は本文を追加します
+func (b *sectionBuilder) write(text string) {
+	if strings.TrimSpace(text) != "" {
+		b.hasBody = true
+	}
+	b.current.WriteString(text)
+}
+
+// flush は書きかけのセクションを確定します（見出しだけのセクションは捨てる。見出しは下位のセクションの階層に残る）
+func (b *sectionBuilder) flush() {
+	text := cleanExtractedText(b.current.String())
+	hasBody := b.hasBody
+	b.current.Reset()
+	b.hasBody = false
+	if !hasBody || text == "" {
+		return
+	}
+	b.sections = append(b.sections, PageContent{
+		PageNumber: b.page,
+		Content:    text,
+		Headings:   b.headings.path(),
+	})
+}
+
+// heading は新しいセクションを始め、見出しの文字列を本文の先頭にも入れます
+func (b *sectionBuilder) heading(level int, title string) {
+	title = strings.Join(strings.Fields(title), " ")
+	if title == "" {
+		return
+	}
+	b.flush()
+	b.headings.push(level, title)
+	b.current.WriteString(title)
+	b.current.WriteString("\n\n")
+}
+
+// pageBreak は改ページでセクションを区切ります（見出しの階層は引き継ぐ）
+func (b *sectionBuilder) pageBreak() {
+	b.flush()
+	b.page++
+}
+
+func (b *sectionBuilder) result() []PageContent {
+	b.flush()
+	return b.sections
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractorRegistry_Lookup(t *testing.T) {
+	r := DefaultExtractorRegistry()
+
+	tests := []struct {
+		mimeType string
+		filename string
+		want     Extractor
+	}{
+		{"application/pdf", "report.pdf", &PDFExtractor{}},
+		{"text/markdown; charset=utf-8", "README", &MarkdownExtractor{}},
+		// text/plain や octet-stream は拡張子を優先する
+		{"text/plain", "notes.md", &MarkdownExtractor{}},
+		{"application/octet-stream", "table.TSV", &CSVExtractor{Comma: '\t'}},
+		{"text/plain", "memo.txt", &PlainTextExtractor{}},
+		{"text/html", "page.txt", &HTMLExtractor{}},
+	}
+	for _, tt := range tests {
+		got, ok := r.Lookup(tt.mimeType, tt.filename)
+		if !ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Lookup(%q, %q) = %T %+v, want %T %+v", tt.mimeType, tt.filename, got, got, tt.want, tt.want)
+		}
+	}
+
+	if _, ok := r.Lookup("image/png", "photo.png"); ok {
+		t.Errorf("Expected image/png to be unsupported")
+	}
+}
+
+func TestMarkdownExtractor_KeepsHeadingHierarchy(t *testing.T) {
+	md := "前書き\n\n# インストール\n\n## Linux\n\napt で入れる。\n\n```sh\n# これはコメント\n```\n\n## macOS\nbrew で入れる。\n\n使い方\n======\n\n起動する。\n"
+
+	sections, err := (&MarkdownExtractor{}).Extract(context.Background(), strings.NewReader(md))
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+
+	want := [][]string{nil, {"インストール", "Linux"}, {"インストール", "macOS"}, {"使い方"}}
+	if len(sections) != len(want) {
+		t.Fatalf("Expected %d sections, got %d: %+v", len(want), len(sections), sections)
+	}
+	for i, s := range sections {
+		if !reflect.DeepEqual(s.Headings, want[i]) {
+			t.Errorf("Section %d: expected headings %v, got %v", i, want[i], s.Headings)
+		}
+	}
+	if !strings.Contains(sections[1].Content, "# これはコメント") {
+		t.Errorf("Expected code block to stay in the Linux section, got %q", sections[1].Content)
+	}
+}
+
+func TestHTMLExtractor_SectionsAndTables(t *testing.T) {
+	page := `<html><head><title>t</title><style>p{}</style></head><body>
+<h1>製品</h1><p>概要の  説明。</p>
+<h2>価格</h2><table><tr><th>プラン</th><th>月額</th></tr><tr><td>Free</td><td>0</td></tr></table>
+<script>alert(1)</script>
+<h2>FAQ</h2><ul><li>質問1</li><li>質問2</li></ul>
+</body></html>`
+
+	sections, err := (&HTMLExtractor{}).Extract(context.Background(), strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if len(sections) != 3 {
+		t.Fatalf("Expected 3 sections, got %d: %+v", len(sections), sections)
+	}
+
+	if !reflect.DeepEqual(sections[1].Headings, []string{"製品", "価格"}) {
+		t.Errorf("Unexpected headings: %v", sections[1].Headings)
+	}
+	if !strings.Contains(sections[1].Content, "プラン | 月額\nFree | 0") {
+		t.Errorf("Expected table rows, got %q", sections[1].Content)
+	}
+	if !strings.Contains(sections[0].Content, "概要の 説明。") {
+		t.Errorf("Expected collapsed whitespace, got %q", sections[0].Content)
+	}
+	for _, s := range sections {
+		if strings.Contains(s.Content, "alert") || strings.Contains(s.Content, "p{}") {
+			t.Errorf("Expected script and style to be skipped, got %q", s.Content)
+		}
+	}
+}
+
+func TestDOCXExtractor_HeadingsTablesAndPageBreaks(t *testing.T) {
+	const w = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"`
+	styles := `<w:styles ` + w + `>
+<w:style w:type="paragraph" w:styleId="1"><w:name w:val="heading 1"/></w:style>
+<w:style w:type="paragraph" w:styleId="Sub"><w:name w:val="Custom"/><w:pPr><w:outlineLvl w:val="1"/></w:pPr></w:style>
+</w:styles>`
+	document := `<w:document ` + w + `><w:body>
+<w:p><w:pPr><w:pStyle w:val="1"/></w:pPr><w:r><w:t>概要</w:t></w:r></w:p>
+<w:p><w:r><w:t xml:space="preserve">本文の </w:t></w:r><w:r><w:t>テキスト</w:t></w:r></w:p>
+<w:tbl><w:tr><w:tc><w:p><w:r><w:t>A</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>B</w:t></w:r></w:p></w:tc></w:tr></w:tbl>
+<w:p><w:r><w:br w:type="page"/></w:r></w:p>
+<w:p><w:pPr><w:pStyle w:val="Sub"/></w:pPr><w:r><w:t>詳細</w:t></w:r></w:p>
+<w:p><w:r><w:t>2ページ目</w:t></w:r></w:p>
+</w:body></w:document>`
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{"word/styles.xml": styles, "word/document.xml": document} {
+		f, _ := zw.Create(name)
+		f.Write([]byte(content))
+	}
+	zw.Close()
+
+	sections, err := (&DOCXExtractor{}).Extract(context.Background(), &buf)
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if len(sections) != 2 {
+		t.Fatalf("Expected 2 sections, got %d: %+v", len(sections), sections)
+	}
+
+	if sections[0].PageNumber != 1 || !reflect.DeepEqual(sections[0].Headings, []string{"概要"}) {
+		t.Errorf("Unexpected first section: %+v", sections[0])
+	}
+	if !strings.Contains(sections[0].Content, "本文の テキスト") || !strings.Contains(sections[0].Content, "A | B") {
+		t.Errorf("Expected paragraph and table text, got %q", sections[0].Content)
+	}
+	if sections[1].PageNumber != 2 || !reflect.DeepEqual(sections[1].Headings, []string{"概要", "詳細"}) {
+		t.Errorf("Unexpected second section: %+v", sections[1])
+	}
+}
+
+func TestCSVExtractor_KeepsRowsTogether(t *testing.T) {
+	data := "\xef\xbb\xbfname,comment\nalice,\"hello, world\"\nbob,\"multi\nline\"\ncarol,short\n"
+
+	sections, err := (&CSVExtractor{Comma: ','}).Extract(context.Background(), strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if len(sections) != 1 || sections[0].RowHeader != "name | comment" || len(sections[0].Rows) != 3 {
+		t.Fatalf("Unexpected sections: %+v", sections)
+	}
+	if sections[0].Rows[1] != "bob | multi line" {
+		t.Errorf("Expected quoted newline to stay in the row, got %q", sections[0].Rows[1])
+	}
+
+	// 2行ずつ入る大きさ: 各チャンクはヘッダー + 完全な行
+	chunks := NewTextChunker(55, 0).ChunkRows(sections[0].RowHeader, sections[0].Rows)
+	want := []string{
+		"name | comment\nalice | hello, world\nbob | multi line",
+		"name | comment\ncarol | short",
+	}
+	if !reflect.DeepEqual(chunks, want) {
+		t.Errorf("Expected %q, got %q", want, chunks)
+	}
+}
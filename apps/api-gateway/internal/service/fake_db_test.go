@@ -0,0 +1,306 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/storage"
+)
+
+// queryName は sqlc が生成したクエリの先頭コメント（-- name: X :kind）からクエリ名を返します
+func queryName(query string) string {
+	name := strings.TrimPrefix(query, "-- name: ")
+	return name[:strings.IndexByte(name, ' ')]
+}
+
+// fakeRows は driver.Rows のメモリ上の実装
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// newFakeRow は1行だけの fakeRows を作成します（:one のクエリ用、列名は使われないので値の数だけ揃える）
+func newFakeRow(values ...driver.Value) *fakeRows {
+	return &fakeRows{columns: make([]string, len(values)), values: [][]driver.Value{values}}
+}
+
+// fakeDB はクエリ名ごとに登録したハンドラで応答する database/sql ドライバ。
+// ハンドラは1つずつ順番に呼ばれるので、ハンドラ内で状態を読んで書き換えれば1文がアトミックになる。
+// トランザクションは calls に BEGIN / COMMIT / ROLLBACK として記録するだけで、ロールバックしても書き換えは戻らない
+type fakeDB struct {
+	mu      sync.Mutex
+	queries map[string]func(args []driver.Value) (*fakeRows, error)
+	execs   map[string]func(args []driver.Value) (int64, error)
+	calls   []string
+
+	// before は各クエリの前にロックの外で呼ばれる（同時実行のテストで足並みを揃えるため）
+	before func(name string)
+}
+
+func newFakeDB() *fakeDB {
+	return &fakeDB{
+		queries: map[string]func(args []driver.Value) (*fakeRows, error){},
+		execs:   map[string]func(args []driver.Value) (int64, error){},
+	}
+}
+
+func (f *fakeDB) open() *sql.DB { return sql.OpenDB(f) }
+
+// called は name のクエリが実行された回数を返します
+func (f *fakeDB) called(name string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, c := range f.calls {
+		if c == name {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *fakeDB) Connect(ctx context.Context) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+func (f *fakeDB) Driver() driver.Driver                            { return fakeDriver{db: f} }
+
+type fakeDriver struct{ db *fakeDB }
+
+func (d fakeDriver) Open(name string) (driver.Conn, error) { return &fakeConn{db: d.db}, nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepare is not supported")
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.db.record("BEGIN")
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.db.record("COMMIT")
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.db.record("ROLLBACK")
+	return nil
+}
+
+func (f *fakeDB) record(call string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+}
+
+// callLog は実行されたクエリとトランザクションの記録のコピーを返します
+func (f *fakeDB) callLog() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+func namedValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, a := range args {
+		values[i] = a.Value
+	}
+	return values
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	f := c.db
+	name := queryName(query)
+	if f.before != nil {
+		f.before(name)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, name)
+	handler, ok := f.queries[name]
+	if !ok {
+		return nil, fmt.Errorf("unexpected query: %s", name)
+	}
+	return handler(namedValues(args))
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	f := c.db
+	name := queryName(query)
+	if f.before != nil {
+		f.before(name)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, name)
+	handler, ok := f.execs[name]
+	if !ok {
+		return nil, fmt.Errorf("unexpected query: %s", name)
+	}
+	n, err := handler(namedValues(args))
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(n), nil
+}
+
+// parsePQArray は pq.Array で渡された配列リテラルを要素に分解します
+func parsePQArray(value driver.Value) []string {
+	var literal string
+	switch v := value.(type) {
+	case string:
+		literal = v
+	case []byte:
+		literal = string(v)
+	}
+	literal = strings.Trim(literal, "{}")
+	if literal == "" {
+		return nil
+	}
+	elems := strings.Split(literal, ",")
+	for i, e := range elems {
+		elems[i] = strings.Trim(e, `"`)
+	}
+	return elems
+}
+
+// pqTextArray は []string を Postgres の配列リテラルにします（pq.Array で読み込む列用）
+func pqTextArray(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = `"` + v + `"`
+	}
+	return "{" + strings.Join(quoted, ",") + "}"
+}
+
+// memoryObjectStorage は storage.ObjectStorageClient のメモリ上の実装
+type memoryObjectStorage struct {
+	mu      sync.Mutex
+	objects map[string][]byte // bucket/key → 内容
+}
+
+func newMemoryObjectStorage() *memoryObjectStorage {
+	return &memoryObjectStorage{objects: map[string][]byte{}}
+}
+
+func (s *memoryObjectStorage) get(bucket, key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[bucket+"/"+key]
+	return data, ok
+}
+
+func (s *memoryObjectStorage) put(bucket, key string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[bucket+"/"+key] = data
+}
+
+// keys は bucket 内のオブジェクトのキーをソートして返します
+func (s *memoryObjectStorage) keys(bucket string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for k := range s.objects {
+		if strings.HasPrefix(k, bucket+"/") {
+			keys = append(keys, strings.TrimPrefix(k, bucket+"/"))
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *memoryObjectStorage) PutObject(ctx context.Context, bucket, objectName string, reader io.Reader, size int64, opts storage.PutObjectOptions) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	s.put(bucket, objectName, data)
+	return nil
+}
+
+func (s *memoryObjectStorage) GetObject(ctx context.Context, bucket, objectName string) (io.ReadCloser, error) {
+	data, ok := s.get(bucket, objectName)
+	if !ok {
+		return nil, storage.ErrObjectNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memoryObjectStorage) ComposeObject(ctx context.Context, bucket, dstObjectName string, srcObjectNames []string) error {
+	var buf bytes.Buffer
+	for _, src := range srcObjectNames {
+		data, ok := s.get(bucket, src)
+		if !ok {
+			return fmt.Errorf("%w: %s", storage.ErrObjectNotFound, src)
+		}
+		buf.Write(data)
+	}
+	s.put(bucket, dstObjectName, buf.Bytes())
+	return nil
+}
+
+func (s *memoryObjectStorage) StatObject(ctx context.Context, bucket, objectName string) (*storage.ObjectInfo, error) {
+	data, ok := s.get(bucket, objectName)
+	if !ok {
+		return nil, storage.ErrObjectNotFound
+	}
+	return &storage.ObjectInfo{Size: int64(len(data))}, nil
+}
+
+func (s *memoryObjectStorage) PresignedPutObject(ctx context.Context, bucket, objectName string, expires time.Duration) (string, error) {
+	return "http://storage.test/" + bucket + "/" + objectName, nil
+}
+
+func (s *memoryObjectStorage) PresignedGetObject(ctx context.Context, bucket, objectName string, expires time.Duration, downloadName string) (string, error) {
+	return "http://storage.test/" + bucket + "/" + objectName, nil
+}
+
+func (s *memoryObjectStorage) RemoveObject(ctx context.Context, bucket, objectName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, bucket+"/"+objectName)
+	return nil
+}
+
+func (s *memoryObjectStorage) BucketExists(ctx context.Context, bucket string) (bool, error) {
+	return true, nil
+}
+
+func (s *memoryObjectStorage) MakeBucket(ctx context.Context, bucket string) error { return nil }
+
+// hookedStorage は memoryObjectStorage の呼び出しの前に任意の処理を挟みます（beforeStat は最初の1回だけ）
+type hookedStorage struct {
+	*memoryObjectStorage
+	beforeStat func()
+	statOnce   sync.Once
+}
+
+func (s *hookedStorage) StatObject(ctx context.Context, bucket, objectName string) (*storage.ObjectInfo, error) {
+	if s.beforeStat != nil {
+		s.statOnce.Do(s.beforeStat)
+	}
+	return s.memoryObjectStorage.StatObject(ctx, bucket, objectName)
+}
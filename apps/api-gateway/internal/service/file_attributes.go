@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/google/uuid"
+	"github.com/sqlc-dev/pqtype"
+)
+
+const (
+	maxFileNameLength = 255
+	maxTagsPerFile    = 50
+	maxTagLength      = 64
+	maxMetadataBytes  = 16 << 10
+)
+
+// UpdateFile implements FileService.UpdateFile
+func (fs *FileServiceImpl) UpdateFile(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	fileID uuid.UUID,
+	req UpdateFileRequest,
+) (*FileMetadataResponse, error) {
+	// Step 1: Validate the edited attributes
+	params := db.UpdateDocumentAttributesParams{
+		ID:          fileID,
+		WorkspaceID: workspaceID,
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || len([]rune(name)) > maxFileNameLength {
+			return nil, fmt.Errorf("%w: name must be 1-%d characters", ErrInvalidFileAttributes, maxFileNameLength)
+		}
+		params.Name = sql.NullString{String: name, Valid: true}
+	}
+	if req.Tags != nil {
+		tags, err := normalizeTags(*req.Tags)
+		if err != nil {
+			return nil, err
+		}
+		params.Tags = tags
+	}
+	if req.Metadata != nil {
+		patch, err := json.Marshal(req.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("%w: metadata: %v", ErrInvalidFileAttributes, err)
+		}
+		if len(patch) > maxMetadataBytes {
+			return nil, fmt.Errorf("%w: metadata exceeds %d bytes", ErrInvalidFileAttributes, maxMetadataBytes)
+		}
+		params.MetadataPatch = pqtype.NullRawMessage{RawMessage: patch, Valid: true}
+	}
+
+	// Step 2: Update the document
+	doc, err := fs.queries.UpdateDocumentAttributes(ctx, params)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrFileNotFound
+		}
+		return nil, fmt.Errorf("failed to update document: %w", err)
+	}
+
+	// Step 3: Sync tags / metadata into the vector payloads used by retrieval filters
+	if req.Tags != nil || req.Metadata != nil {
+		payload := map[string]interface{}{
+			"tags":     tagsOrEmpty(doc.Tags),
+			"metadata": decodeMetadata(doc.Metadata),
+		}
+		if err := updateDocumentPayloads(ctx, fs.queries, fs.vectorStore, workspaceID, []uuid.UUID{doc.ID}, payload); err != nil {
+			// The document is updated; reprocessing (force_reprocess) rebuilds the payloads
+			log.Printf("⚠️  Failed to sync tags/metadata of document %s to vector store: %v", doc.ID, err)
+		}
+	}
+
+	log.Printf("🏷️ File updated: id=%s, tags=%v", doc.ID, doc.Tags)
+	return fs.GetFile(ctx, workspaceID, fileID)
+}
+
+// ListTags implements FileService.ListTags
+func (fs *FileServiceImpl) ListTags(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+) ([]TagCount, error) {
+	rows, err := fs.queries.ListDocumentTags(ctx, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	tags := make([]TagCount, len(rows))
+	for i, row := range rows {
+		tags[i] = TagCount{Tag: row.Tag, FileCount: row.DocumentCount}
+	}
+	return tags, nil
+}
+
+// normalizeTags trims tags and drops empty and duplicate ones, keeping the order
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len([]rune(tag)) > maxTagLength {
+			return nil, fmt.Errorf("%w: tag %q is longer than %d characters", ErrInvalidFileAttributes, tag, maxTagLength)
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > maxTagsPerFile {
+		return nil, fmt.Errorf("%w: at most %d tags per file", ErrInvalidFileAttributes, maxTagsPerFile)
+	}
+	return normalized, nil
+}
+
+// tagsOrEmpty returns an empty slice for NULL tags so the payload always holds an array
+func tagsOrEmpty(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+// decodeMetadata decodes documents.metadata, returning an empty map for NULL or invalid JSON
+func decodeMetadata(raw pqtype.NullRawMessage) map[string]interface{} {
+	var metadata map[string]interface{}
+	if raw.Valid && len(raw.RawMessage) > 0 {
+		if err := json.Unmarshal(raw.RawMessage, &metadata); err != nil {
+			metadata = nil
+		}
+	}
+	if metadata == nil {
+		return map[string]interface{}{}
+	}
+	return metadata
+}
+
+// metadataFilter converts FileListFilter.Metadata into a JSONB containment value (NULL when empty)
+func metadataFilter(metadata map[string]string) (pqtype.NullRawMessage, error) {
+	if len(metadata) == 0 {
+		return pqtype.NullRawMessage{}, nil
+	}
+	raw, err := json.Marshal(metadata)
+	if err != nil {
+		return pqtype.NullRawMessage{}, fmt.Errorf("failed to encode metadata filter: %w", err)
+	}
+	return pqtype.NullRawMessage{RawMessage: raw, Valid: true}, nil
+}
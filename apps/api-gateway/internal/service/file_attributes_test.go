@@ -0,0 +1,66 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sqlc-dev/pqtype"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	got, err := normalizeTags([]string{" 議事録 ", "", "design", "議事録", "  ", "design", "2024"})
+	if err != nil {
+		t.Fatalf("normalizeTags failed: %v", err)
+	}
+	want := []string{"議事録", "design", "2024"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("normalizeTags() = %v, want %v", got, want)
+	}
+
+	// フィルタ用に nil でも空配列を返す（SQL の cardinality が NULL にならないように）
+	if got, err := normalizeTags(nil); err != nil || got == nil || len(got) != 0 {
+		t.Errorf("normalizeTags(nil) = %#v, %v; want empty slice", got, err)
+	}
+
+	if _, err := normalizeTags([]string{strings.Repeat("x", maxTagLength+1)}); !errors.Is(err, ErrInvalidFileAttributes) {
+		t.Errorf("long tag error = %v, want ErrInvalidFileAttributes", err)
+	}
+
+	many := make([]string, maxTagsPerFile+1)
+	for i := range many {
+		many[i] = strings.Repeat("t", i+1)
+	}
+	if _, err := normalizeTags(many); !errors.Is(err, ErrInvalidFileAttributes) {
+		t.Errorf("too many tags error = %v, want ErrInvalidFileAttributes", err)
+	}
+}
+
+func TestDecodeMetadata(t *testing.T) {
+	got := decodeMetadata(pqtype.NullRawMessage{RawMessage: []byte(`{"author":"alice","year":2024}`), Valid: true})
+	if got["author"] != "alice" || got["year"] != float64(2024) {
+		t.Errorf("decodeMetadata() = %v", got)
+	}
+
+	for _, raw := range []pqtype.NullRawMessage{
+		{},
+		{RawMessage: []byte(`null`), Valid: true},
+		{RawMessage: []byte(`[1,2]`), Valid: true},
+	} {
+		if got := decodeMetadata(raw); got == nil || len(got) != 0 {
+			t.Errorf("decodeMetadata(%s) = %#v, want empty map", raw.RawMessage, got)
+		}
+	}
+}
+
+func TestMetadataFilter(t *testing.T) {
+	if got, err := metadataFilter(nil); err != nil || got.Valid {
+		t.Errorf("metadataFilter(nil) = %+v, %v; want NULL", got, err)
+	}
+
+	got, err := metadataFilter(map[string]string{"project": "nexus"})
+	if err != nil || !got.Valid || string(got.RawMessage) != `{"project":"nexus"}` {
+		t.Errorf("metadataFilter() = %s (valid=%v), %v", got.RawMessage, got.Valid, err)
+	}
+}
@@ -2,8 +2,8 @@ package service
 
 import (
 	"context"
+	"errors"
 	"io"
-	"mime/multipart"
 
 	"github.com/google/uuid"
 )
@@ -17,6 +17,7 @@ type FileMetadataResponse struct {
 	SHA256Hash string
 	CreatedAt  string // RFC3339 format
 	Tags       []string
+	Metadata   map[string]interface{}
 	Status     string
 }
 
@@ -30,6 +31,22 @@ type FileUploadResponse struct {
 	CreatedAt  string
 }
 
+// StagedUpload is an upload that has been streamed to a temporary object and hashed,
+// but not yet registered as a file
+type StagedUpload struct {
+	WorkspaceID uuid.UUID
+	FileName    string
+	MimeType    string // detected from content
+	SizeBytes   int64
+	SHA256Hash  string
+	// TempKeys are the temporary objects holding the content, in order
+	// (one for a single-request upload, one per chunk for a resumable upload)
+	TempKeys []string
+}
+
+// ErrUploadTooLarge is returned when an upload exceeds the global or workspace size limit
+var ErrUploadTooLarge = errors.New("upload exceeds the size limit")
+
 // FileListResponse represents the response for file listing
 type FileListResponse struct {
 	Files []FileMetadataResponse
@@ -38,24 +55,128 @@ type FileListResponse struct {
 
 // FileListFilter represents filter options for file listing
 type FileListFilter struct {
-	DirectoryID *uuid.UUID
-	Limit       int
-	Offset      int
+	DirectoryID  *uuid.UUID
+	Tags         []string          // files must have all of these tags
+	MetadataKeys []string          // files must have all of these metadata keys
+	Metadata     map[string]string // files must have these metadata key-value pairs (string values)
+	Limit        int
+	Offset       int
+}
+
+// UpdateFileRequest holds the editable attributes of a file; nil fields are left unchanged
+type UpdateFileRequest struct {
+	Name *string
+	Tags *[]string // replaces all tags
+	// Metadata is merged into the current metadata; keys with a nil value are removed
+	Metadata map[string]interface{}
+}
+
+// TagCount is a tag used in a workspace and the number of files that have it
+type TagCount struct {
+	Tag       string
+	FileCount int64
 }
 
+var (
+	// ErrFileNotFound is returned when the file does not exist in the workspace
+	ErrFileNotFound = errors.New("file not found")
+	// ErrInvalidFileAttributes is returned when an edited name, tag or metadata is not acceptable
+	ErrInvalidFileAttributes = errors.New("invalid file attributes")
+)
+
 // FileService defines the business logic for file operations
 type FileService interface {
-	// UploadFile handles file upload with deduplication by SHA256
-	// Returns FileUploadResponse on success
-	UploadFile(
+	// UploadSizeLimit returns the maximum upload size in bytes for the workspace
+	// (the smaller of the global limit and the workspace's max_upload_bytes setting)
+	UploadSizeLimit(
+		ctx context.Context,
+		workspaceID uuid.UUID,
+	) (int64, error)
+
+	// StageUpload streams content to a temporary object while hashing it, without buffering it in memory.
+	// The MIME type is detected from the first bytes and checked before anything is stored.
+	// Returns ErrUploadTooLarge as soon as the size limit is exceeded
+	StageUpload(
 		ctx context.Context,
 		workspaceID uuid.UUID,
-		file multipart.File,
-		header *multipart.FileHeader,
+		fileName string,
+		contentType string,
+		content io.Reader,
+	) (*StagedUpload, error)
+
+	// CompleteUpload deduplicates a staged upload by SHA256, moves it to its final key
+	// and creates the document. Returns FileUploadResponse on success.
+	// The temporary objects are removed on success only, so a failed call can be retried
+	CompleteUpload(
+		ctx context.Context,
+		staged *StagedUpload,
 		directoryID *uuid.UUID,
 		tags []string,
 	) (*FileUploadResponse, error)
 
+	// CompleteVersionUpload stores a staged upload like CompleteUpload, but adds it as the
+	// next version of an existing document instead of creating a new one. The new version
+	// becomes current once it has been processed. Returns ErrFileNotFound if the document does not exist
+	CompleteVersionUpload(
+		ctx context.Context,
+		staged *StagedUpload,
+		documentID uuid.UUID,
+	) (*DocumentVersionInfo, error)
+
+	// AbortUpload removes the temporary objects of a staged upload
+	AbortUpload(
+		ctx context.Context,
+		staged *StagedUpload,
+	) error
+
+	// CreateUploadSession starts a resumable upload of SizeBytes bytes.
+	// Returns ErrUploadTooLarge if the size exceeds the workspace limit
+	CreateUploadSession(
+		ctx context.Context,
+		workspaceID uuid.UUID,
+		req CreateUploadSessionRequest,
+	) (*UploadSessionInfo, error)
+
+	// GetUploadSession returns the session including the current offset
+	GetUploadSession(
+		ctx context.Context,
+		workspaceID uuid.UUID,
+		uploadID uuid.UUID,
+	) (*UploadSessionInfo, error)
+
+	// AppendUploadChunk stores the bytes at offset (which must equal the current offset)
+	// and advances the session. A chunk that is not fully received is discarded
+	AppendUploadChunk(
+		ctx context.Context,
+		workspaceID uuid.UUID,
+		uploadID uuid.UUID,
+		offset int64,
+		content io.Reader,
+	) (*UploadSessionInfo, error)
+
+	// CreatePresignedUpload starts an upload that the client PUTs directly to object storage.
+	// Confirm it with CompleteUploadSession, which verifies the size and SHA256 of the object
+	CreatePresignedUpload(
+		ctx context.Context,
+		workspaceID uuid.UUID,
+		req CreatePresignedUploadRequest,
+	) (*PresignedUpload, error)
+
+	// CompleteUploadSession composes the chunks (or verifies a presigned upload)
+	// and registers the file like a single-request upload
+	CompleteUploadSession(
+		ctx context.Context,
+		workspaceID uuid.UUID,
+		uploadID uuid.UUID,
+	) (*FileUploadResponse, error)
+
+	// AbortUploadSession removes the stored chunks and marks the session aborted
+	AbortUploadSession(
+		ctx context.Context,
+		workspaceID uuid.UUID,
+		uploadID uuid.UUID,
+	) error
+
 	// ListFiles retrieves files in a workspace with optional filtering
 	ListFiles(
 		ctx context.Context,
@@ -70,6 +191,21 @@ type FileService interface {
 		fileID uuid.UUID,
 	) (*FileMetadataResponse, error)
 
+	// UpdateFile edits the name, tags and metadata of a file.
+	// Tags and metadata are also written to the vector payloads so retrieval filters see the change
+	UpdateFile(
+		ctx context.Context,
+		workspaceID uuid.UUID,
+		fileID uuid.UUID,
+		req UpdateFileRequest,
+	) (*FileMetadataResponse, error)
+
+	// ListTags returns the tags used in a workspace with the number of files per tag
+	ListTags(
+		ctx context.Context,
+		workspaceID uuid.UUID,
+	) ([]TagCount, error)
+
 	// DeleteFile performs soft delete on a file
 	DeleteFile(
 		ctx context.Context,
@@ -84,4 +220,11 @@ type FileService interface {
 		workspaceID uuid.UUID,
 		fileID uuid.UUID,
 	) (io.ReadCloser, error)
+
+	// PresignDownload returns a presigned GET URL so the client downloads directly from object storage
+	PresignDownload(
+		ctx context.Context,
+		workspaceID uuid.UUID,
+		fileID uuid.UUID,
+	) (*PresignedDownload, error)
 }
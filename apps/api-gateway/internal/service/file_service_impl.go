@@ -1,7 +1,7 @@
 package service
 
 import (
-	"bytes"
+	"bufio"
 	"context"
 	"crypto/sha256"
 	"database/sql"
@@ -9,11 +9,9 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"mime/multipart"
 	"path/filepath"
 	"time"
 
-	"github.com/Martin4208/Nexus/apps/api-gateway/internal/client"
 	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
 	"github.com/Martin4208/Nexus/apps/api-gateway/internal/storage"
 	"github.com/google/uuid"
@@ -24,90 +22,214 @@ type FileServiceImpl struct {
 	queries       *db.Queries
 	storageClient storage.ObjectStorageClient
 	storageBucket string // MinIO bucket name for files
-	qdrantClient  client.QdrantClient
+	vectorStore   storage.VectorStore
+	jobQueue      *DocumentJobQueue // enqueues ingestion after upload
+	opts          FileServiceOptions
 }
 
+// FileServiceOptions configures upload handling
+type FileServiceOptions struct {
+	MaxUploadBytes   int64         // global upload size limit; workspaces can only lower it
+	UploadSessionTTL time.Duration // how long a resumable upload session accepts chunks
+	PresignExpiry    time.Duration // lifetime of presigned upload / download URLs
+}
+
+// withDefaults fills unset options with default values
+func (o FileServiceOptions) withDefaults() FileServiceOptions {
+	if o.MaxUploadBytes <= 0 {
+		o.MaxUploadBytes = DefaultMaxUploadBytes
+	}
+	if o.UploadSessionTTL <= 0 {
+		o.UploadSessionTTL = 24 * time.Hour
+	}
+	if o.PresignExpiry <= 0 {
+		o.PresignExpiry = 15 * time.Minute
+	}
+	return o
+}
+
+// DefaultMaxUploadBytes is the global upload size limit when none is configured (2GB)
+const DefaultMaxUploadBytes int64 = 2 << 30
+
+// uploadTempPrefix is where uploads are streamed before their hash is known
+const uploadTempPrefix = "tmp/uploads/"
+
 // NewFileService creates a new FileService instance
 func NewFileService(
 	queries *db.Queries,
 	storageClient storage.ObjectStorageClient,
 	storageBucket string,
-	qdrantClient client.QdrantClient,
+	vectorStore storage.VectorStore,
+	jobQueue *DocumentJobQueue,
+	opts FileServiceOptions,
 ) FileService {
 	return &FileServiceImpl{
 		queries:       queries,
 		storageClient: storageClient,
 		storageBucket: storageBucket,
+		vectorStore:   vectorStore,
+		jobQueue:      jobQueue,
+		opts:          opts.withDefaults(),
+	}
+}
+
+// UploadSizeLimit implements FileService.UploadSizeLimit
+func (fs *FileServiceImpl) UploadSizeLimit(ctx context.Context, workspaceID uuid.UUID) (int64, error) {
+	settings, err := fs.workspaceSettings(ctx, workspaceID)
+	if err != nil {
+		return 0, err
 	}
+	return fs.uploadSizeLimit(settings), nil
 }
 
-// UploadFile implements FileService.UploadFile
-func (fs *FileServiceImpl) UploadFile(
+// uploadSizeLimit returns the smaller of the global limit and the workspace's max_upload_bytes
+func (fs *FileServiceImpl) uploadSizeLimit(settings WorkspaceSettings) int64 {
+	limit := fs.opts.MaxUploadBytes
+	if settings.MaxUploadBytes > 0 && settings.MaxUploadBytes < limit {
+		limit = settings.MaxUploadBytes
+	}
+	return limit
+}
+
+// workspaceSettings loads and parses workspaces.settings
+func (fs *FileServiceImpl) workspaceSettings(ctx context.Context, workspaceID uuid.UUID) (WorkspaceSettings, error) {
+	workspace, err := fs.queries.GetWorkspace(ctx, workspaceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return WorkspaceSettings{}, errors.New("workspace not found")
+		}
+		return WorkspaceSettings{}, fmt.Errorf("failed to get workspace: %w", err)
+	}
+	return ParseWorkspaceSettings(workspace.Settings.RawMessage)
+}
+
+// StageUpload implements FileService.StageUpload
+func (fs *FileServiceImpl) StageUpload(
 	ctx context.Context,
 	workspaceID uuid.UUID,
-	file multipart.File,
-	header *multipart.FileHeader,
-	directoryID *uuid.UUID,
-	tags []string,
-) (*FileUploadResponse, error) {
-	// Step 1: Calculate SHA256 hash while reading file
-	hasher := sha256.New()
-	fileContent := io.TeeReader(file, hasher)
-	fileBytes, err := io.ReadAll(fileContent)
+	fileName string,
+	contentType string,
+	content io.Reader,
+) (*StagedUpload, error) {
+	settings, err := fs.workspaceSettings(ctx, workspaceID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
+		return nil, err
 	}
-	sha256Hash := fmt.Sprintf("%x", hasher.Sum(nil))
+	limit := fs.uploadSizeLimit(settings)
 
-	log.Printf("📁 File upload started: name=%s, hash=%s", header.Filename, sha256Hash)
+	log.Printf("📁 File upload started: name=%s, limit=%d bytes", fileName, limit)
 
-	// Step 2: Check if file with same hash already exists
-	existingFile, err := fs.queries.GetFileByHash(ctx, sha256Hash)
-	if err == nil {
-		log.Printf("🔄 File with same hash exists: file_id=%s, bucket=%s, key=%s",
-			existingFile.ID, existingFile.MinioBucket, existingFile.MinioKey)
-
-		// File already exists in MinIO, just create a new document reference
-		return fs.createDocumentReference(
-			ctx,
-			workspaceID,
-			existingFile.ID,
-			header.Filename,
-			directoryID,
-			tags,
-		)
+	// Step 1: Detect the MIME type from the first bytes before storing anything
+	buffered := bufio.NewReaderSize(content, MIMESniffLen)
+	head, err := buffered.Peek(MIMESniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
-	if err != sql.ErrNoRows {
-		return nil, fmt.Errorf("failed to check existing file: %w", err)
+	mimeType, err := checkUploadMIMEType(settings, head, fileName, contentType)
+	if err != nil {
+		return nil, err
 	}
 
-	// Step 3: Upload to MinIO
-	minioKey := fs.generateMinIOKey(workspaceID, header.Filename)
-	log.Printf("📤 Uploading to MinIO: bucket=%s, key=%s", fs.storageBucket, minioKey)
+	// Step 2: Stream to a temporary object, hashing and counting bytes on the way
+	hasher := sha256.New()
+	counter := &limitedCountReader{r: buffered, limit: limit}
+	tempKey := uploadTempPrefix + uuid.New().String()
 
 	err = fs.storageClient.PutObject(
 		ctx,
 		fs.storageBucket,
-		minioKey,
-		bytes.NewReader(fileBytes),
-		int64(len(fileBytes)),
+		tempKey,
+		io.TeeReader(counter, hasher),
+		-1, // size is unknown until the stream ends
 		storage.PutObjectOptions{
-			ContentType: header.Header.Get("Content-Type"),
+			ContentType: mimeType,
 		},
 	)
 	if err != nil {
+		_ = fs.storageClient.RemoveObject(context.WithoutCancel(ctx), fs.storageBucket, tempKey)
+		if counter.exceeded {
+			log.Printf("🚫 Upload too large: name=%s, limit=%d bytes", fileName, limit)
+			return nil, fmt.Errorf("%w: limit is %d bytes", ErrUploadTooLarge, limit)
+		}
 		return nil, fmt.Errorf("failed to upload to MinIO: %w", err)
 	}
 
+	staged := &StagedUpload{
+		WorkspaceID: workspaceID,
+		FileName:    fileName,
+		MimeType:    mimeType,
+		SizeBytes:   counter.n,
+		SHA256Hash:  fmt.Sprintf("%x", hasher.Sum(nil)),
+		TempKeys:    []string{tempKey},
+	}
+	log.Printf("📤 Upload staged: key=%s, size=%d, hash=%s", tempKey, staged.SizeBytes, staged.SHA256Hash)
+	return staged, nil
+}
+
+// CompleteUpload implements FileService.CompleteUpload
+func (fs *FileServiceImpl) CompleteUpload(
+	ctx context.Context,
+	staged *StagedUpload,
+	directoryID *uuid.UUID,
+	tags []string,
+) (*FileUploadResponse, error) {
+	fileID, reused, err := fs.storeStagedUpload(ctx, staged)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 4: Create document reference
+	resp, err := fs.createDocumentReference(
+		ctx,
+		staged.WorkspaceID,
+		fileID,
+		staged.FileName,
+		directoryID,
+		tags,
+	)
+	if err == nil && reused {
+		_ = fs.AbortUpload(ctx, staged)
+	}
+	return resp, err
+}
+
+// storeStagedUpload turns a staged upload into a file record, reusing an existing
+// file with the same hash. When reused is true the staged copy is still in place;
+// the caller drops it once the document referencing the file has been created.
+func (fs *FileServiceImpl) storeStagedUpload(
+	ctx context.Context,
+	staged *StagedUpload,
+) (fileID uuid.UUID, reused bool, err error) {
+	// Step 1: Check if file with same hash already exists
+	existingFile, err := fs.queries.GetFileByHash(ctx, staged.SHA256Hash)
+	if err == nil {
+		log.Printf("🔄 File with same hash exists: file_id=%s, bucket=%s, key=%s",
+			existingFile.ID, existingFile.MinioBucket, existingFile.MinioKey)
+
+		// File already exists in MinIO, just reference it.
+		// Touching last_accessed_at keeps the blob GC from collecting the file meanwhile
+		_ = fs.queries.UpdateFileLastAccessed(ctx, existingFile.ID)
+		return existingFile.ID, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return uuid.Nil, false, fmt.Errorf("failed to check existing file: %w", err)
+	}
+
+	// Step 2: Move the temporary object(s) to the final key (chunks are concatenated)
+	minioKey := fs.generateMinIOKey(staged.WorkspaceID, staged.FileName)
+	if err := fs.storageClient.ComposeObject(ctx, fs.storageBucket, minioKey, staged.TempKeys); err != nil {
+		return uuid.Nil, false, fmt.Errorf("failed to move upload to final key: %w", err)
+	}
+
 	log.Printf("✅ MinIO upload successful: key=%s", minioKey)
 
-	// Step 4: Create file record in database
+	// Step 3: Create file record in database
 	newFile, err := fs.queries.CreateFile(ctx, db.CreateFileParams{
-		Sha256Hash: sha256Hash,
-		MimeType:   header.Header.Get("Content-Type"),
-		SizeBytes:  int64(len(fileBytes)),
+		Sha256Hash: staged.SHA256Hash,
+		MimeType:   staged.MimeType,
+		SizeBytes:  staged.SizeBytes,
 		OriginalFilename: sql.NullString{
-			String: header.Filename,
+			String: staged.FileName,
 			Valid:  true,
 		},
 		MinioBucket: fs.storageBucket,
@@ -116,20 +238,61 @@ func (fs *FileServiceImpl) UploadFile(
 	if err != nil {
 		// Cleanup: remove from MinIO if DB insert fails
 		_ = fs.storageClient.RemoveObject(ctx, fs.storageBucket, minioKey)
-		return nil, fmt.Errorf("failed to create file record: %w", err)
+		return uuid.Nil, false, fmt.Errorf("failed to create file record: %w", err)
 	}
 
 	log.Printf("✅ File record created: file_id=%s", newFile.ID)
+	_ = fs.AbortUpload(ctx, staged)
+	return newFile.ID, false, nil
+}
 
-	// Step 5: Create document reference
-	return fs.createDocumentReference(
-		ctx,
-		workspaceID,
-		newFile.ID,
-		header.Filename,
-		directoryID,
-		tags,
-	)
+// AbortUpload implements FileService.AbortUpload
+func (fs *FileServiceImpl) AbortUpload(ctx context.Context, staged *StagedUpload) error {
+	// The request may already be cancelled; the cleanup should still run
+	ctx = context.WithoutCancel(ctx)
+	var firstErr error
+	for _, key := range staged.TempKeys {
+		if err := fs.storageClient.RemoveObject(ctx, fs.storageBucket, key); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to remove temporary object %s: %w", key, err)
+		}
+	}
+	return firstErr
+}
+
+// checkUploadMIMEType sniffs the MIME type from the first bytes and extension.
+// The declared Content-Type is only trusted as far as it agrees with the content,
+// and the detected type must be in the workspace's allowed_mime_types.
+func checkUploadMIMEType(settings WorkspaceSettings, head []byte, fileName, declared string) (string, error) {
+	detected := DetectMIMEType(head, fileName)
+	if err := CheckDeclaredMIMEType(declared, detected); err != nil {
+		log.Printf("🚫 MIME type mismatch: name=%s, declared=%s, detected=%s", fileName, declared, detected)
+		return "", err
+	}
+	if !settings.AllowsMIMEType(detected) {
+		log.Printf("🚫 MIME type not allowed: name=%s, detected=%s", fileName, detected)
+		return "", fmt.Errorf("%w: %s", ErrMIMETypeNotAllowed, detected)
+	}
+
+	log.Printf("🔍 MIME type detected: name=%s, declared=%s, detected=%s", fileName, declared, detected)
+	return detected, nil
+}
+
+// limitedCountReader counts the bytes read and fails once more than limit bytes have been read
+type limitedCountReader struct {
+	r        io.Reader
+	limit    int64
+	n        int64
+	exceeded bool
+}
+
+func (l *limitedCountReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n += int64(n)
+	if l.n > l.limit {
+		l.exceeded = true
+		return n, ErrUploadTooLarge
+	}
+	return n, err
 }
 
 // ListFiles implements FileService.ListFiles
@@ -164,14 +327,31 @@ func (fs *FileServiceImpl) ListFiles(
 		}
 	}
 
+	// Tag / metadata filters (empty arrays and NULL disable them)
+	tags, err := normalizeTags(filter.Tags)
+	if err != nil {
+		return nil, err
+	}
+	metadataKeys := filter.MetadataKeys
+	if metadataKeys == nil {
+		metadataKeys = []string{}
+	}
+	metadata, err := metadataFilter(filter.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
 	// Query documents
 	documents, err := fs.queries.ListDocuments(
 		ctx,
 		db.ListDocumentsParams{
-			WorkspaceID: workspaceID,
-			Offset:      int32(filter.Offset),
-			Limit:       int32(filter.Limit),
-			DirectoryID: dirID,
+			WorkspaceID:  workspaceID,
+			Offset:       int32(filter.Offset),
+			Limit:        int32(filter.Limit),
+			DirectoryID:  dirID,
+			Tags:         tags,
+			MetadataKeys: metadataKeys,
+			Metadata:     metadata,
 		},
 	)
 	if err != nil {
@@ -180,8 +360,11 @@ func (fs *FileServiceImpl) ListFiles(
 
 	// Count total
 	total, err := fs.queries.CountDocuments(ctx, db.CountDocumentsParams{
-		WorkspaceID: workspaceID,
-		DirectoryID: dirID,
+		WorkspaceID:  workspaceID,
+		DirectoryID:  dirID,
+		Tags:         tags,
+		MetadataKeys: metadataKeys,
+		Metadata:     metadata,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to count documents: %w", err)
@@ -196,6 +379,8 @@ func (fs *FileServiceImpl) ListFiles(
 			MimeType:  doc.MimeType,
 			SizeBytes: doc.SizeBytes,
 			CreatedAt: doc.CreatedAt.Format(time.RFC3339),
+			Tags:      tagsOrEmpty(doc.Tags),
+			Metadata:  decodeMetadata(doc.Metadata),
 			Status:    doc.Status,
 		})
 	}
@@ -218,18 +403,21 @@ func (fs *FileServiceImpl) GetFile(
 	})
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("file not found")
+			return nil, ErrFileNotFound
 		}
 		return nil, fmt.Errorf("failed to get document: %w", err)
 	}
 
 	return &FileMetadataResponse{
-		ID:        doc.ID,
-		FileName:  doc.Name,
-		MimeType:  doc.MimeType,
-		SizeBytes: doc.SizeBytes,
-		CreatedAt: doc.CreatedAt.Format(time.RFC3339),
-		Status:    doc.Status,
+		ID:         doc.ID,
+		FileName:   doc.Name,
+		MimeType:   doc.MimeType,
+		SizeBytes:  doc.SizeBytes,
+		SHA256Hash: doc.Sha256Hash,
+		CreatedAt:  doc.CreatedAt.Format(time.RFC3339),
+		Tags:       tagsOrEmpty(doc.Tags),
+		Metadata:   decodeMetadata(doc.Metadata),
+		Status:     doc.Status,
 	}, nil
 }
 
@@ -245,17 +433,17 @@ func (fs *FileServiceImpl) DeleteFile(
 		return fmt.Errorf("failed to get documents: %w", err)
 	}
 
-	// Step 2: 各ドキュメントのベクトルをQdrantから削除
+	// Step 2: 各ドキュメントのベクトルをVectorStoreから削除
 	collectionName := fmt.Sprintf("workspace_%s", workspaceID.String())
 	for _, doc := range documents {
-		err := fs.qdrantClient.DeletePointsByDocumentID(
+		err := fs.vectorStore.DeleteByFilter(
 			ctx,
 			collectionName,
-			doc.ID.String(),
+			&storage.Filter{Must: []storage.Condition{storage.MatchValue("document_id", doc.ID.String())}},
 		)
 		if err != nil {
-			// Qdrant削除失敗はログだけ（ベクトルが既に無い可能性もある）
-			fmt.Printf("⚠️  Failed to delete from Qdrant: %v\n", err)
+			// ベクトル削除失敗はログだけ（ベクトルが既に無い可能性もある）
+			fmt.Printf("⚠️  Failed to delete from vector store: %v\n", err)
 		}
 	}
 
@@ -337,6 +525,8 @@ func (fs *FileServiceImpl) createDocumentReference(
 		FileID:      fileID,
 		Name:        fileName,
 		Tags:        tags,
+		VersionID:   uuid.NullUUID{UUID: uuid.New(), Valid: true},
+		FileName:    fileName,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create document: %w", err)
@@ -348,6 +538,20 @@ func (fs *FileServiceImpl) createDocumentReference(
 		return nil, fmt.Errorf("failed to get file: %w", err)
 	}
 
+	// Enqueue ingestion (chunking + embedding) with default options.
+	// A failure here does not fail the upload: the document stays "uploaded"
+	// and can be processed later via POST /documents/{documentId}/process.
+	if fs.jobQueue != nil {
+		_, err := fs.jobQueue.Enqueue(ctx, workspaceID, doc.ID, ProcessOptions{
+			ChunkSize:       DefaultChunkSize,
+			ChunkOverlap:    DefaultChunkOverlap,
+			ParentChunkSize: DefaultParentChunkSize,
+		})
+		if err != nil {
+			log.Printf("⚠️ Failed to enqueue processing for document %s: %v", doc.ID, err)
+		}
+	}
+
 	return &FileUploadResponse{
 		ID:         doc.ID,
 		FileName:   doc.Name,
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLimitedCountReader(t *testing.T) {
+	r := &limitedCountReader{r: strings.NewReader("0123456789"), limit: 10}
+	if _, err := io.ReadAll(r); err != nil || r.n != 10 || r.exceeded {
+		t.Fatalf("Expected exactly the limit to pass, got n=%d exceeded=%v err=%v", r.n, r.exceeded, err)
+	}
+
+	r = &limitedCountReader{r: strings.NewReader("0123456789X"), limit: 10}
+	if _, err := io.ReadAll(r); !errors.Is(err, ErrUploadTooLarge) || !r.exceeded {
+		t.Fatalf("Expected ErrUploadTooLarge, got exceeded=%v err=%v", r.exceeded, err)
+	}
+}
+
+func TestFileService_UploadSizeLimit(t *testing.T) {
+	fs := &FileServiceImpl{opts: FileServiceOptions{MaxUploadBytes: 100}.withDefaults()}
+
+	tests := []struct {
+		workspaceLimit int64
+		want           int64
+	}{
+		{0, 100},
+		{50, 50},
+		{200, 100}, // ワークスペースの設定で全体の上限を超えることはできない
+	}
+	for _, tt := range tests {
+		if got := fs.uploadSizeLimit(WorkspaceSettings{MaxUploadBytes: tt.workspaceLimit}); got != tt.want {
+			t.Errorf("uploadSizeLimit(%d) = %d, want %d", tt.workspaceLimit, got, tt.want)
+		}
+	}
+
+	if got := (FileServiceOptions{}).withDefaults().MaxUploadBytes; got != DefaultMaxUploadBytes {
+		t.Errorf("Expected default limit %d, got %d", DefaultMaxUploadBytes, got)
+	}
+	if _, err := ParseWorkspaceSettings([]byte(`{"max_upload_bytes": -1}`)); err == nil {
+		t.Errorf("Expected error for negative max_upload_bytes")
+	}
+}
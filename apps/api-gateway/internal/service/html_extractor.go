@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTMLExtractor は HTML から本文のテキストを取り出し、h1〜h6 の見出しごとのセクションに分割します。
+// script / style などの表示されない要素は除き、表はセルを " | " でつないだ行にします。
+type HTMLExtractor struct{}
+
+// htmlSkippedElements はテキストを抽出しない要素
+var htmlSkippedElements = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+	atom.Iframe:   true,
+}
+
+// htmlBlockElements は前後で改行する要素
+var htmlBlockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.Header: true, atom.Footer: true, atom.Aside: true, atom.Nav: true,
+	atom.Ul: true, atom.Ol: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+	atom.Blockquote: true, atom.Pre: true, atom.Table: true, atom.Figure: true, atom.Figcaption: true,
+	atom.Hr: true, atom.Address: true, atom.Details: true, atom.Summary: true,
+}
+
+var htmlHeadingLevels = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+// Extract は HTML を見出し単位の PageContent に変換します
+func (e *HTMLExtractor) Extract(ctx context.Context, reader io.Reader) ([]PageContent, error) {
+	doc, err := html.Parse(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	w := &htmlTextWriter{b: newSectionBuilder()}
+	w.walk(doc)
+	return w.b.result(), nil
+}
+
+// htmlTextWriter は DOM をたどってテキストを sectionBuilder に書き込みます
+type htmlTextWriter struct {
+	b        *sectionBuilder
+	pre      int  // <pre> の中（空白をそのまま残す）
+	needSep  bool // 直前の文字列との間に空白が必要か
+	cellSeen bool // 表の行で最初のセルを書いたか
+	last     byte // 最後に書いた文字（行頭に空白を入れないため）
+}
+
+func (w *htmlTextWriter) write(s string) {
+	if s == "" {
+		return
+	}
+	w.b.write(s)
+	w.last = s[len(s)-1]
+}
+
+func (w *htmlTextWriter) newline() {
+	w.write("\n")
+	w.needSep = false
+}
+
+// lineBreak は行の途中であれば改行します（リストの項目や表の行の区切り）
+func (w *htmlTextWriter) lineBreak() {
+	if w.last != 0 && w.last != '\n' {
+		w.newline()
+	}
+}
+
+func (w *htmlTextWriter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+		if htmlSkippedElements[n.DataAtom] {
+			return
+		}
+		if level, ok := htmlHeadingLevels[n.DataAtom]; ok {
+			w.b.heading(level, htmlNodeText(n))
+			w.needSep = false
+			w.last = '\n'
+			return
+		}
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		w.newline()
+		return
+	case atom.Li:
+		w.lineBreak()
+		w.write("- ")
+	case atom.Tr:
+		w.lineBreak()
+		w.cellSeen = false
+	case atom.Td, atom.Th:
+		if w.cellSeen {
+			w.write(" | ")
+		}
+		w.cellSeen = true
+		w.needSep = false
+	case atom.Pre:
+		w.pre++
+		defer func() { w.pre-- }()
+	}
+
+	block := htmlBlockElements[n.DataAtom]
+	if block {
+		w.write("\n")
+		w.needSep = false
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.walk(c)
+	}
+
+	switch {
+	case block:
+		w.write("\n\n")
+		w.needSep = false
+	case n.DataAtom == atom.Li || n.DataAtom == atom.Tr:
+		w.lineBreak()
+	}
+}
+
+// text は空白をまとめてテキストを書き込みます（<pre> の中はそのまま）
+func (w *htmlTextWriter) text(s string) {
+	if w.pre > 0 {
+		w.write(s)
+		return
+	}
+
+	leading := s != "" && strings.TrimLeft(s, " \t\r\n\f") != s
+	trailing := s != "" && strings.TrimRight(s, " \t\r\n\f") != s
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		if leading {
+			w.needSep = true
+		}
+		return
+	}
+
+	if (w.needSep || leading) && w.last != 0 && w.last != '\n' && w.last != ' ' {
+		w.write(" ")
+	}
+	w.write(strings.Join(words, " "))
+	w.needSep = trailing
+}
+
+// htmlNodeText は要素内のテキストを空白でつないで返します（見出しの文字列用）
+func htmlNodeText(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+			sb.WriteString(" ")
+			return
+		}
+		if n.Type == html.ElementNode && htmlSkippedElements[n.DataAtom] {
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/storage"
+	"github.com/google/uuid"
+)
+
+// RetrievalMode は検索方式
+type RetrievalMode string
+
+const (
+	RetrievalModeDense   RetrievalMode = "dense"   // Qdrant のベクトル検索のみ
+	RetrievalModeLexical RetrievalMode = "lexical" // Postgres の pg_trgm による語句検索のみ
+	RetrievalModeHybrid  RetrievalMode = "hybrid"  // 両方の結果を Reciprocal Rank Fusion で統合
+)
+
+var ErrInvalidRetrievalOptions = errors.New("invalid retrieval options")
+
+const (
+	// rrfK は RRF の定数 k（上位の順位差をどれだけなだらかにするか。論文の推奨値 60）
+	rrfK = 60
+	// hybridCandidateFactor は hybrid 時に各検索から limit の何倍の候補を取るか
+	hybridCandidateFactor = 3
+)
+
+// RetrievalOptions は検索方式と、hybrid 時の RRF の重み、ヒットしたチャンクを LLM に渡す前の広げ方
+type RetrievalOptions struct {
+	Mode          RetrievalMode
+	DenseWeight   float64
+	LexicalWeight float64
+	Expansion     ContextExpansion
+	Window        int // Expansion が window のときの前後のチャンク数
+}
+
+// withDefaults は未設定の項目にデフォルト値（hybrid、重みは両方 1.0、親チャンクに広げる）を入れます
+func (o RetrievalOptions) withDefaults() RetrievalOptions {
+	if o.Mode == "" {
+		o.Mode = RetrievalModeHybrid
+	}
+	if o.DenseWeight == 0 && o.LexicalWeight == 0 {
+		o.DenseWeight = 1.0
+		o.LexicalWeight = 1.0
+	}
+	if o.Expansion == "" {
+		o.Expansion = ContextExpansionParent
+	}
+	if o.Window == 0 {
+		o.Window = DefaultContextWindow
+	}
+	return o
+}
+
+// Validate は検索方式と重みを検証します
+func (o RetrievalOptions) Validate() error {
+	switch o.Mode {
+	case "", RetrievalModeDense, RetrievalModeLexical, RetrievalModeHybrid:
+	default:
+		return fmt.Errorf("%w: unknown mode %q", ErrInvalidRetrievalOptions, o.Mode)
+	}
+	if o.DenseWeight < 0 || o.LexicalWeight < 0 {
+		return fmt.Errorf("%w: weights must be 0 or greater", ErrInvalidRetrievalOptions)
+	}
+	switch o.Expansion {
+	case "", ContextExpansionNone, ContextExpansionParent, ContextExpansionWindow:
+	default:
+		return fmt.Errorf("%w: unknown context expansion %q", ErrInvalidRetrievalOptions, o.Expansion)
+	}
+	if o.Window < 0 || o.Window > maxContextWindow {
+		return fmt.Errorf("%w: context window must be between 1 and %d", ErrInvalidRetrievalOptions, maxContextWindow)
+	}
+	return nil
+}
+
+// parseChatRetrievalOptions は chats.settings から検索方式を読み込みます（未指定はデフォルト）
+func parseChatRetrievalOptions(chat db.Chat) (RetrievalOptions, error) {
+	var opts RetrievalOptions
+
+	if chat.Settings.Valid && len(chat.Settings.RawMessage) > 0 {
+		var raw struct {
+			RetrievalMode    *string  `json:"retrieval_mode"`
+			DenseWeight      *float64 `json:"dense_weight"`
+			LexicalWeight    *float64 `json:"lexical_weight"`
+			ContextExpansion *string  `json:"context_expansion"`
+			ContextWindow    *int     `json:"context_window"`
+		}
+		if err := json.Unmarshal(chat.Settings.RawMessage, &raw); err != nil {
+			return opts, fmt.Errorf("failed to parse chat settings: %w", err)
+		}
+		if raw.RetrievalMode != nil {
+			opts.Mode = RetrievalMode(*raw.RetrievalMode)
+		}
+		if raw.DenseWeight != nil {
+			opts.DenseWeight = *raw.DenseWeight
+		}
+		if raw.LexicalWeight != nil {
+			opts.LexicalWeight = *raw.LexicalWeight
+		}
+		if raw.ContextExpansion != nil {
+			opts.Expansion = ContextExpansion(*raw.ContextExpansion)
+		}
+		if raw.ContextWindow != nil {
+			opts.Window = *raw.ContextWindow
+		}
+	}
+
+	if err := opts.Validate(); err != nil {
+		return opts, err
+	}
+	return opts.withDefaults(), nil
+}
+
+// hybridRetriever は dense / lexical / hybrid の検索を行い、
+// 結果を Qdrant の検索結果と同じ形（ID = ポイントID、payload に document_id / chunk_index / page_number / text）にそろえます。
+// reranker が設定されている場合は rerankCandidates 件を取得してからリランキングで絞り込みます
+type hybridRetriever struct {
+	queries          *db.Queries
+	vectorStore      storage.VectorStore
+	reranker         Reranker
+	rerankCandidates int
+}
+
+// newHybridRetriever は hybridRetriever を作成します（reranker が nil の場合はリランキングしない）
+func newHybridRetriever(
+	queries *db.Queries,
+	vectorStore storage.VectorStore,
+	reranker Reranker,
+	rerankCandidates int,
+) *hybridRetriever {
+	if reranker == nil {
+		reranker = noopReranker{}
+	}
+	return &hybridRetriever{
+		queries:          queries,
+		vectorStore:      vectorStore,
+		reranker:         reranker,
+		rerankCandidates: rerankCandidates,
+	}
+}
+
+// retrieve は opts.Mode に従って検索し、リランキングしたうえでスコア降順で最大 topK 件を返します。
+// embed は dense 検索が必要な場合のみ呼ばれます。
+func (r *hybridRetriever) retrieve(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	query string,
+	embed func(ctx context.Context, text string) ([]float64, error),
+	scope *retrievalScope,
+	topK int,
+	opts RetrievalOptions,
+) ([]rankedResult, error) {
+	// リランキングする場合は多めに候補を取る
+	limit := topK
+	if _, noop := r.reranker.(noopReranker); !noop && r.rerankCandidates > topK {
+		limit = r.rerankCandidates
+	}
+
+	candidates, err := r.search(ctx, workspaceID, query, embed, scope, limit, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return rerankResults(ctx, r.reranker, query, candidates, topK), nil
+}
+
+// search は opts.Mode に従って検索し、スコア降順で最大 limit 件を返します。
+// hybrid で lexical 側だけが失敗した場合は、dense の結果だけで続行します。
+func (r *hybridRetriever) search(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	query string,
+	embed func(ctx context.Context, text string) ([]float64, error),
+	scope *retrievalScope,
+	limit int,
+	opts RetrievalOptions,
+) ([]storage.SearchResult, error) {
+	opts = opts.withDefaults()
+
+	candidates := limit
+	if opts.Mode == RetrievalModeHybrid {
+		candidates = limit * hybridCandidateFactor
+	}
+
+	var dense, lexical []storage.SearchResult
+
+	if opts.Mode == RetrievalModeDense || opts.Mode == RetrievalModeHybrid {
+		queryVector, err := embed(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed query: %w", err)
+		}
+
+		collectionName := fmt.Sprintf("workspace_%s", workspaceID.String())
+		results, err := r.vectorStore.Search(ctx, collectionName, storage.SearchRequest{
+			Vector: queryVector,
+			Limit:  candidates,
+			Filter: scope.vectorFilter(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to search vector store: %w", err)
+		}
+		dense = results
+	}
+
+	if opts.Mode == RetrievalModeLexical || opts.Mode == RetrievalModeHybrid {
+		rows, err := r.queries.SearchChunksLexical(ctx, scope.lexicalParams(workspaceID, query, candidates))
+		if err != nil {
+			if opts.Mode == RetrievalModeLexical {
+				return nil, fmt.Errorf("failed to search chunks lexically: %w", err)
+			}
+			log.Printf("⚠️ [RAG] Lexical search failed, using dense results only: %v", err)
+		}
+		lexical = lexicalResults(rows)
+	}
+
+	log.Printf("🔎 [RAG] Retrieval mode=%s: %d dense, %d lexical candidates", opts.Mode, len(dense), len(lexical))
+
+	switch opts.Mode {
+	case RetrievalModeDense:
+		return dense, nil
+	case RetrievalModeLexical:
+		return lexical, nil
+	default:
+		return fuseRRF(dense, lexical, opts.DenseWeight, opts.LexicalWeight, limit), nil
+	}
+}
+
+// lexicalResults は SearchChunksLexical の結果を Qdrant の検索結果と同じ形に変換します。
+// payload の数値は JSON デコード後の Qdrant の結果に合わせて float64 にする
+// 見出しと領域は document_chunks.metadata から取り出す
+func lexicalResults(rows []db.SearchChunksLexicalRow) []storage.SearchResult {
+	results := make([]storage.SearchResult, len(rows))
+	for i, row := range rows {
+		var meta chunkMetadataJSON
+		if row.Metadata.Valid {
+			_ = json.Unmarshal(row.Metadata.RawMessage, &meta)
+		}
+		results[i] = storage.SearchResult{
+			ID:    row.PointID.String(),
+			Score: row.Score,
+			Payload: map[string]interface{}{
+				"document_id":    row.DocumentID.String(),
+				"version_id":     row.VersionID.String(),
+				"chunk_index":    float64(row.ChunkIndex),
+				"page_number":    float64(row.PageNumber),
+				"headings":       headingsOrEmpty(meta.Headings),
+				"regions":        regionsOrEmpty(meta.Regions),
+				"ocr_confidence": ocrConfidenceOrNil(meta.OCRConfidence),
+				"text":           row.Content,
+			},
+		}
+	}
+	return results
+}
+
+// fuseRRF は2つの順位付きリストを重み付き Reciprocal Rank Fusion で統合します。
+// スコアは sum(weight / (k + rank)) を、両方で1位だった場合を 1.0 とするよう正規化します。
+// 同じポイントが両方にある場合は dense 側の payload を使います。
+func fuseRRF(dense, lexical []storage.SearchResult, denseWeight, lexicalWeight float64, limit int) []storage.SearchResult {
+	type fused struct {
+		result storage.SearchResult
+		score  float64
+		order  int
+	}
+
+	byID := make(map[string]*fused)
+	var all []*fused
+
+	add := func(results []storage.SearchResult, weight float64) {
+		for rank, res := range results {
+			f, ok := byID[res.ID]
+			if !ok {
+				f = &fused{result: res, order: len(all)}
+				byID[res.ID] = f
+				all = append(all, f)
+			}
+			f.score += weight / float64(rrfK+rank+1)
+		}
+	}
+	add(dense, denseWeight)
+	add(lexical, lexicalWeight)
+
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].score != all[j].score {
+			return all[i].score > all[j].score
+		}
+		return all[i].order < all[j].order
+	})
+
+	maxScore := (denseWeight + lexicalWeight) / float64(rrfK+1)
+	if len(all) > limit {
+		all = all[:limit]
+	}
+
+	results := make([]storage.SearchResult, len(all))
+	for i, f := range all {
+		results[i] = f.result
+		if maxScore > 0 {
+			results[i].Score = f.score / maxScore
+		}
+	}
+	return results
+}
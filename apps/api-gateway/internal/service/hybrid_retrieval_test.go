@@ -0,0 +1,67 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/storage"
+)
+
+func newTestResults(ids ...string) []storage.SearchResult {
+	results := make([]storage.SearchResult, len(ids))
+	for i, id := range ids {
+		results[i] = storage.SearchResult{ID: id, Payload: map[string]interface{}{"text": id}}
+	}
+	return results
+}
+
+func TestFuseRRF_PrefersResultsInBothLists(t *testing.T) {
+	dense := newTestResults("a", "b", "c")
+	lexical := newTestResults("c", "d")
+
+	fused := fuseRRF(dense, lexical, 1.0, 1.0, 10)
+
+	if len(fused) != 4 {
+		t.Fatalf("Expected 4 fused results, got %d", len(fused))
+	}
+	if fused[0].ID != "c" {
+		t.Errorf("Expected chunk found by both searches to rank first, got %s", fused[0].ID)
+	}
+}
+
+func TestFuseRRF_Weights(t *testing.T) {
+	dense := newTestResults("a")
+	lexical := newTestResults("b")
+
+	fused := fuseRRF(dense, lexical, 0.5, 2.0, 10)
+
+	if fused[0].ID != "b" {
+		t.Errorf("Expected lexical result to rank first with higher weight, got %s", fused[0].ID)
+	}
+}
+
+func TestFuseRRF_NormalizedScoreAndLimit(t *testing.T) {
+	dense := newTestResults("a", "b", "c")
+	lexical := newTestResults("a", "b", "c")
+
+	fused := fuseRRF(dense, lexical, 1.0, 1.0, 2)
+
+	if len(fused) != 2 {
+		t.Fatalf("Expected 2 results after limit, got %d", len(fused))
+	}
+	if math.Abs(fused[0].Score-1.0) > 1e-9 {
+		t.Errorf("Expected top score 1.0 when ranked first in both lists, got %f", fused[0].Score)
+	}
+}
+
+func TestRetrievalOptions_Validate(t *testing.T) {
+	if err := (RetrievalOptions{Mode: "sparse"}).Validate(); err == nil {
+		t.Errorf("Expected error for unknown mode")
+	}
+	if err := (RetrievalOptions{Mode: RetrievalModeHybrid, DenseWeight: -1}).Validate(); err == nil {
+		t.Errorf("Expected error for negative weight")
+	}
+	if opts := (RetrievalOptions{}).withDefaults(); opts.Mode != RetrievalModeHybrid || opts.DenseWeight != 1.0 {
+		t.Errorf("Unexpected defaults: %+v", opts)
+	}
+}
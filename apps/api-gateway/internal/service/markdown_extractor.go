@@ -0,0 +1,95 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	// markdownATXHeading は "## 見出し ##" 形式の見出し
+	markdownATXHeading = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	// markdownSetextUnderline は前の行を見出しにする "===" / "---" の行
+	markdownSetextUnderline = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	// markdownFence はコードブロックの開始・終了
+	markdownFence = regexp.MustCompile("^ {0,3}(```+|~~~+)")
+	// markdownBlockStart はリスト・引用・表の行（段落ではないので Setext 見出しにならない）
+	markdownBlockStart = regexp.MustCompile(`^\s*([-*+]\s|\d+[.)]\s|>|\|)`)
+)
+
+// MarkdownExtractor は Markdown を見出しごとのセクションに分割します。
+// 各セクションには見出しの階層（例: ["インストール", "Linux"]）が付き、チャンクのメタデータとして保存されます。
+type MarkdownExtractor struct{}
+
+// Extract は Markdown を見出し単位の PageContent に変換します（本文は Markdown のまま）
+func (e *MarkdownExtractor) Extract(ctx context.Context, reader io.Reader) ([]PageContent, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	b := newSectionBuilder()
+	var fence string   // コードブロック内ならその開始記号
+	var pending string // Setext 見出しの可能性がある直前の行（まだ本文に書いていない）
+	hasPending := false
+
+	flushPending := func() {
+		if hasPending {
+			b.write(pending + "\n")
+			hasPending = false
+		}
+	}
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		// コードブロック内の "#" は見出しではない
+		if fence != "" {
+			b.write(line + "\n")
+			if strings.HasPrefix(strings.TrimSpace(line), fence) {
+				fence = ""
+			}
+			continue
+		}
+		if m := markdownFence.FindStringSubmatch(line); m != nil {
+			flushPending()
+			fence = m[1]
+			b.write(line + "\n")
+			continue
+		}
+
+		if m := markdownATXHeading.FindStringSubmatch(line); m != nil {
+			flushPending()
+			b.heading(len(m[1]), m[2])
+			continue
+		}
+
+		if hasPending {
+			if m := markdownSetextUnderline.FindStringSubmatch(line); m != nil {
+				level := 2
+				if m[1][0] == '=' {
+					level = 1
+				}
+				b.heading(level, pending)
+				hasPending = false
+				continue
+			}
+			flushPending()
+		}
+
+		// 空行でない段落の行は、次の行が "===" / "---" なら見出しになる
+		if strings.TrimSpace(line) != "" && !markdownBlockStart.MatchString(line) {
+			pending = line
+			hasPending = true
+			continue
+		}
+		b.write(line + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read markdown: %w", err)
+	}
+	flushPending()
+
+	return b.result(), nil
+}
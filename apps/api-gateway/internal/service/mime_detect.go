@@ -0,0 +1,190 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// ブラウザが送る Content-Type は拡張子から推測しただけのことが多く、偽装もできるため、
+// アップロード時はファイル先頭のバイト列（マジックバイト）と拡張子から MIME タイプを判定し直す。
+
+const (
+	MIMETypePDF      = "application/pdf"
+	MIMETypeDOCX     = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	MIMETypeZip      = "application/zip"
+	MIMETypeText     = "text/plain"
+	MIMETypeMarkdown = "text/markdown"
+	MIMETypeHTML     = "text/html"
+	MIMETypeCSV      = "text/csv"
+	MIMETypeTSV      = "text/tab-separated-values"
+	MIMETypeUnknown  = "application/octet-stream"
+)
+
+// MIMESniffLen は判定に使う先頭のバイト数
+const MIMESniffLen = 8192
+
+var (
+	// ErrMIMETypeMismatch は宣言された Content-Type と内容から判定した MIME タイプが食い違う場合のエラー
+	ErrMIMETypeMismatch = errors.New("declared content type does not match file content")
+	// ErrMIMETypeNotAllowed はワークスペースで取り込みが許可されていない MIME タイプのエラー
+	ErrMIMETypeNotAllowed = errors.New("file type is not allowed in this workspace")
+)
+
+// DefaultAllowedMIMETypes はワークスペースの設定がない場合に取り込みを許可する MIME タイプ（Extractor がある形式）
+var DefaultAllowedMIMETypes = []string{
+	MIMETypePDF,
+	MIMETypeText,
+	MIMETypeMarkdown,
+	MIMETypeHTML,
+	MIMETypeDOCX,
+	MIMETypeCSV,
+	MIMETypeTSV,
+}
+
+// textMIMETypesByExtension はテキストと判定したファイルの拡張子ごとの MIME タイプ
+var textMIMETypesByExtension = map[string]string{
+	".md":       MIMETypeMarkdown,
+	".markdown": MIMETypeMarkdown,
+	".html":     MIMETypeHTML,
+	".htm":      MIMETypeHTML,
+	".xhtml":    MIMETypeHTML,
+	".csv":      MIMETypeCSV,
+	".tsv":      MIMETypeTSV,
+	".tab":      MIMETypeTSV,
+}
+
+// mimeTypeAliases はクライアントが送ってくる別名 → 正規の MIME タイプ
+var mimeTypeAliases = map[string]string{
+	"text/x-markdown":              MIMETypeMarkdown,
+	"application/csv":              MIMETypeCSV,
+	"application/vnd.ms-excel":     MIMETypeCSV, // Windows のブラウザは .csv をこの型で送る
+	"application/xhtml+xml":        MIMETypeHTML,
+	"application/x-pdf":            MIMETypePDF,
+	"application/x-zip-compressed": MIMETypeZip,
+}
+
+// DetectMIMEType はファイル先頭のバイト列（MIMESniffLen バイトまで）とファイル名から正規の MIME タイプを返します。
+// バイナリ形式はマジックバイトで判定し、テキストは拡張子で Markdown / HTML / CSV / TSV を区別します。
+func DetectMIMEType(head []byte, filename string) string {
+	if len(head) > MIMESniffLen {
+		head = head[:MIMESniffLen]
+	}
+	ext := strings.ToLower(filepath.Ext(filename))
+
+	// Step 1: マジックバイトで判定できる形式
+	switch {
+	case bytes.HasPrefix(head, []byte("%PDF-")):
+		return MIMETypePDF
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		// DOCX は zip。先頭だけでは中身を確認できないため拡張子で区別する
+		if ext == ".docx" {
+			return MIMETypeDOCX
+		}
+		return MIMETypeZip
+	}
+
+	// Step 2: テキスト（UTF-8 として正しく、NUL を含まない）
+	if isText(head) {
+		if m, ok := textMIMETypesByExtension[ext]; ok {
+			return m
+		}
+		if normalizeMIMEType(http.DetectContentType(head)) == MIMETypeHTML {
+			return MIMETypeHTML
+		}
+		return MIMETypeText
+	}
+
+	// Step 3: その他のバイナリは標準ライブラリの判定に任せる（画像など）
+	return normalizeMIMEType(http.DetectContentType(head))
+}
+
+// isText は head が UTF-8 のテキストかどうかを返します（末尾で切れたマルチバイト文字は許容する）
+func isText(head []byte) bool {
+	if len(head) == 0 || bytes.IndexByte(head, 0) >= 0 {
+		return false
+	}
+	head = bytes.TrimPrefix(head, []byte("\xef\xbb\xbf"))
+	for i := 0; i < utf8.UTFMax-1 && len(head) > 0 && !utf8.Valid(head); i++ {
+		head = head[:len(head)-1]
+	}
+	return utf8.Valid(head)
+}
+
+// canonicalMIMEType は MIME タイプのパラメータを除き、別名を正規の名前にします
+func canonicalMIMEType(mimeType string) string {
+	m := normalizeMIMEType(mimeType)
+	if alias, ok := mimeTypeAliases[m]; ok {
+		return alias
+	}
+	return m
+}
+
+// CheckDeclaredMIMEType は宣言された Content-Type が判定結果と矛盾しないかを確認します。
+// 形式を判別できなかったクライアントが付ける汎用の型（octet-stream など）は矛盾とみなしません。
+// テキスト同士（text/plain で送られた .md など）や、zip として送られた DOCX も許容します。
+func CheckDeclaredMIMEType(declared, detected string) error {
+	d := canonicalMIMEType(declared)
+	switch {
+	case d == "" || d == MIMETypeUnknown || d == "binary/octet-stream":
+		return nil
+	case d == detected:
+		return nil
+	case strings.HasPrefix(d, "text/") && strings.HasPrefix(detected, "text/"):
+		return nil
+	case d == MIMETypeZip && detected == MIMETypeDOCX:
+		return nil
+	}
+	return fmt.Errorf("%w: declared %s, detected %s", ErrMIMETypeMismatch, d, detected)
+}
+
+// WorkspaceSettings は workspaces.settings のうちサーバーが解釈する項目
+type WorkspaceSettings struct {
+	// AllowedMIMETypes は取り込みを許可する MIME タイプ（"text/*" のようなワイルドカード可）。空なら DefaultAllowedMIMETypes
+	AllowedMIMETypes []string `json:"allowed_mime_types,omitempty"`
+	// MaxUploadBytes はアップロードできる最大サイズ（バイト）。0 ならサーバー全体の上限のみ（全体の上限より大きくはできない）
+	MaxUploadBytes int64 `json:"max_upload_bytes,omitempty"`
+}
+
+// ParseWorkspaceSettings は workspaces.settings の JSON を読みます（空なら既定値）
+func ParseWorkspaceSettings(raw []byte) (WorkspaceSettings, error) {
+	var s WorkspaceSettings
+	if len(bytes.TrimSpace(raw)) == 0 || string(bytes.TrimSpace(raw)) == "null" {
+		return s, nil
+	}
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return s, fmt.Errorf("invalid workspace settings: %w", err)
+	}
+	if s.MaxUploadBytes < 0 {
+		return s, fmt.Errorf("invalid workspace settings: max_upload_bytes must not be negative")
+	}
+	for _, m := range s.AllowedMIMETypes {
+		if !strings.Contains(normalizeMIMEType(m), "/") {
+			return s, fmt.Errorf("invalid workspace settings: allowed_mime_types contains invalid MIME type %q", m)
+		}
+	}
+	return s, nil
+}
+
+// AllowsMIMEType は detected の取り込みが許可されているかを返します
+func (s WorkspaceSettings) AllowsMIMEType(detected string) bool {
+	allowed := s.AllowedMIMETypes
+	if len(allowed) == 0 {
+		allowed = DefaultAllowedMIMETypes
+	}
+	for _, a := range allowed {
+		a = canonicalMIMEType(a)
+		if a == "*/*" || a == detected {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(a, "/*"); ok && strings.HasPrefix(detected, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDetectMIMEType(t *testing.T) {
+	tests := []struct {
+		name     string
+		head     string
+		filename string
+		want     string
+	}{
+		{"pdf", "%PDF-1.7\n%\xe2\xe3\xcf\xd3", "report.pdf", MIMETypePDF},
+		{"pdf with wrong extension", "%PDF-1.4\n", "report.txt", MIMETypePDF},
+		{"docx", "PK\x03\x04\x14\x00\x06\x00", "spec.DOCX", MIMETypeDOCX},
+		{"zip", "PK\x03\x04\x14\x00\x06\x00", "archive.zip", MIMETypeZip},
+		{"markdown", "# タイトル\n\n本文", "README.md", MIMETypeMarkdown},
+		{"csv with BOM", "\xef\xbb\xbfname,age\nalice,20\n", "people.csv", MIMETypeCSV},
+		{"tsv", "a\tb\n", "table.tsv", MIMETypeTSV},
+		{"html without extension", "<!DOCTYPE html><html><body>x</body></html>", "page", MIMETypeHTML},
+		{"plain text", "hello", "notes.txt", MIMETypeText},
+		// マルチバイト文字が先頭バイト列の末尾で切れていてもテキストとみなす
+		{"truncated utf-8", "日本語\xe8\xaa", "memo.txt", MIMETypeText},
+		{"png", "\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR", "photo.md", "image/png"},
+		{"binary", "\x00\x01\x02\x03", "data.bin", MIMETypeUnknown},
+	}
+	for _, tt := range tests {
+		if got := DetectMIMEType([]byte(tt.head), tt.filename); got != tt.want {
+			t.Errorf("%s: DetectMIMEType() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckDeclaredMIMEType(t *testing.T) {
+	ok := []struct{ declared, detected string }{
+		{"", MIMETypePDF},
+		{"application/octet-stream", MIMETypeDOCX},
+		{"application/pdf", MIMETypePDF},
+		{"text/plain; charset=utf-8", MIMETypeMarkdown},
+		{"application/vnd.ms-excel", MIMETypeCSV},
+		{"application/zip", MIMETypeDOCX},
+	}
+	for _, tt := range ok {
+		if err := CheckDeclaredMIMEType(tt.declared, tt.detected); err != nil {
+			t.Errorf("CheckDeclaredMIMEType(%q, %q) = %v, want nil", tt.declared, tt.detected, err)
+		}
+	}
+
+	mismatch := []struct{ declared, detected string }{
+		{"application/pdf", MIMETypeText},
+		{"text/plain", MIMETypePDF},
+		{"image/png", MIMETypeHTML},
+	}
+	for _, tt := range mismatch {
+		if err := CheckDeclaredMIMEType(tt.declared, tt.detected); !errors.Is(err, ErrMIMETypeMismatch) {
+			t.Errorf("CheckDeclaredMIMEType(%q, %q) = %v, want ErrMIMETypeMismatch", tt.declared, tt.detected, err)
+		}
+	}
+}
+
+func TestWorkspaceSettings_AllowsMIMEType(t *testing.T) {
+	defaults, err := ParseWorkspaceSettings(nil)
+	if err != nil {
+		t.Fatalf("ParseWorkspaceSettings failed: %v", err)
+	}
+	if !defaults.AllowsMIMEType(MIMETypeDOCX) || defaults.AllowsMIMEType("image/png") {
+		t.Errorf("Unexpected default allow-list: %v", DefaultAllowedMIMETypes)
+	}
+
+	s, err := ParseWorkspaceSettings([]byte(`{"allowed_mime_types": ["application/pdf", "text/*"], "theme": "dark"}`))
+	if err != nil {
+		t.Fatalf("ParseWorkspaceSettings failed: %v", err)
+	}
+	for m, want := range map[string]bool{
+		MIMETypePDF:      true,
+		MIMETypeMarkdown: true,
+		MIMETypeCSV:      true,
+		MIMETypeDOCX:     false,
+	} {
+		if got := s.AllowsMIMEType(m); got != want {
+			t.Errorf("AllowsMIMEType(%q) = %v, want %v", m, got, want)
+		}
+	}
+
+	if _, err := ParseWorkspaceSettings([]byte(`{"allowed_mime_types": "pdf"}`)); err == nil {
+		t.Errorf("Expected error for non-array allowed_mime_types")
+	}
+	if _, err := ParseWorkspaceSettings([]byte(`{"allowed_mime_types": ["pdf"]}`)); err == nil {
+		t.Errorf("Expected error for invalid MIME type")
+	}
+}
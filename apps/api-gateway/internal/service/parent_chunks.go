@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// parentChunk は embedding しない親チャンク（子チャンクが検索でヒットしたときに LLM に渡すセクション）
+type parentChunk struct {
+	id         uuid.UUID
+	index      int // 親チャンクの中での順番（document_chunks.chunk_index）
+	text       string
+	pageNumber int
+	headings   []string
+}
+
+// chunkGroup はページ内の1つのセクションと、それを分割した子チャンクです（parent が空なら親を持たない）
+type chunkGroup struct {
+	page     int // pages の位置
+	parent   string
+	children []string
+}
+
+// splitChunkGroups はページの本文を親セクションに分け（parentChunker が nil ならページ全体を1つのセクションとする）、
+// セクションごとに chunker で子チャンクに分割します。子チャンクが1つしかないセクションは親を持たない（親と子がほぼ同じになるため）
+func splitChunkGroups(ctx context.Context, chunker, parentChunker Chunker, page int, content string) ([]chunkGroup, error) {
+	sections := []string{content}
+	if parentChunker != nil {
+		var err error
+		if sections, err = parentChunker.Chunk(ctx, content); err != nil {
+			return nil, err
+		}
+	}
+
+	groups := make([]chunkGroup, 0, len(sections))
+	for _, section := range sections {
+		children, err := chunker.Chunk(ctx, section)
+		if err != nil {
+			return nil, err
+		}
+		g := chunkGroup{page: page, children: children}
+		if parentChunker != nil && len(children) > 1 {
+			g.parent = strings.TrimSpace(section)
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+// parentIDOf は子チャンクの parent_id を返します（親がなければ nil UUID = NULL）
+func parentIDOf(c chunkWithPage) uuid.UUID {
+	if c.parent == nil {
+		return uuid.Nil
+	}
+	return c.parent.id
+}
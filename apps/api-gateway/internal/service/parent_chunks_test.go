@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitChunkGroups_ChildrenStayInsideParents(t *testing.T) {
+	sections := []string{
+		strings.Repeat("あ", 150) + "。",
+		strings.Repeat("い", 150) + "。",
+		strings.Repeat("う", 30) + "。",
+	}
+	content := strings.Join(sections, "\n\n")
+
+	chunker, _ := NewChunker(ChunkStrategyRecursive, 100, 10, nil, nil)
+	parentChunker := newRecursiveChunker(180, 0, utf8.RuneCountInString)
+
+	groups, err := splitChunkGroups(context.Background(), chunker, parentChunker, 3, content)
+	if err != nil {
+		t.Fatalf("splitChunkGroups failed: %v", err)
+	}
+
+	if len(groups) != 3 {
+		t.Fatalf("Expected 3 parent sections, got %+v", groups)
+	}
+	for i, g := range groups[:2] {
+		if g.page != 3 || g.parent != sections[i] || len(g.children) < 2 {
+			t.Errorf("Group %d: expected a parent with several children, got %+v", i, g)
+		}
+		for _, child := range g.children {
+			if !strings.Contains(g.parent, child) {
+				t.Errorf("Group %d: child %q is not inside its parent", i, child)
+			}
+		}
+	}
+	// 子チャンクが1つしかないセクションは親を作らない
+	if groups[2].parent != "" || len(groups[2].children) != 1 {
+		t.Errorf("Expected the short section to have no parent, got %+v", groups[2])
+	}
+}
+
+func TestSplitChunkGroups_WithoutParents(t *testing.T) {
+	chunker, _ := NewChunker(ChunkStrategyFixed, 100, 10, nil, nil)
+
+	groups, err := splitChunkGroups(context.Background(), chunker, nil, 0, strings.Repeat("テキスト。", 60))
+	if err != nil {
+		t.Fatalf("splitChunkGroups failed: %v", err)
+	}
+	if len(groups) != 1 || groups[0].parent != "" || len(groups[0].children) < 2 {
+		t.Errorf("Expected one group of children without a parent, got %+v", groups)
+	}
+}
@@ -1,94 +1,235 @@
 package service
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"log"
 	"os"
 	"strings"
 
-	"github.com/pdfcpu/pdfcpu/pkg/api"
-	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/ledongthuc/pdf"
 )
 
-// PageContent はPDFの1ページ分のテキストとページ番号を保持する。
-// ここをstringの代わりに使うことで、チャンク生成時にページ情報が失われない。
-type PageContent struct {
-	PageNumber int    // 1-based（PDFの自然な表現に合わせる）
-	Content    string // そのページから抽出したテキスト
+// PDFExtractor はPDFのテキストレイヤーからテキストを抽出します。
+// フォントのエンコーディング（ToUnicode など）をデコードした文字の位置から、
+// 段組みを考慮した読み順で段落・見出し・表のブロックを組み立てます。
+// OCR を設定した場合、テキストレイヤーのないページ（スキャン・画像だけのページ）は画像にして OCR する。
+type PDFExtractor struct {
+	ocr        OCREngine // nil なら OCR しない
+	rasterizer PageRasterizer
+	dpi        int
 }
 
-// PDFExtractor はPDFからテキストを抽出します
-type PDFExtractor struct{}
+// ocrMinPageRunes より文字の少ないページはテキストレイヤーがないとみなして OCR する
+// （スキャンした画像の上にページ番号だけ文字で入っている場合など）
+const ocrMinPageRunes = 20
 
-// NewPDFExtractor は新しい PDFExtractor を作成
+// NewPDFExtractor は新しい PDFExtractor を作成（OCR しない）
 func NewPDFExtractor() *PDFExtractor {
 	return &PDFExtractor{}
 }
 
-// ExtractPages はPDFをページ単位で抽出し、[]PageContent を返す。
-// 旧: ExtractText (string) → 新: ExtractPages ([]PageContent)
-// 変更理由：ページ境界情報をchunker/processorに渡すため。
+// Extract は Extractor の実装です
+func (e *PDFExtractor) Extract(ctx context.Context, reader io.Reader) ([]PageContent, error) {
+	return e.extractPages(ctx, reader)
+}
+
+// ExtractPages はPDFをページ・見出し単位で抽出し、[]PageContent を返す。
+// 1ページの中でも見出しごとに区切り、Headings に見出しの階層、Blocks にブロックと座標を入れる。
+// 区切りがページをまたぐことはないため、PageNumber はそのまま引用先のページになる。
 func (e *PDFExtractor) ExtractPages(reader io.Reader) ([]PageContent, error) {
-	// Step 1: データを全て読み込む
+	return e.extractPages(context.Background(), reader)
+}
+
+// pdfPageLayout は1ページのレイアウト解析の結果
+type pdfPageLayout struct {
+	blocks        []*layoutBlock
+	height        float64
+	ocrConfidence *float64 // OCR で認識したページのみ
+}
+
+func (e *PDFExtractor) extractPages(ctx context.Context, reader io.Reader) ([]PageContent, error) {
+	// Step 1: データを全て読み込む（PDF の読み込みにはランダムアクセスが必要）
 	data, err := io.ReadAll(reader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read PDF data: %w", err)
 	}
 
-	// Step 2: 一時ファイルに保存（pdfcpuがファイルパスを要求するため）
-	tmpFile, err := os.CreateTemp("", "pdf-extract-*.pdf")
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create temp file: %w", err)
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	// Step 2: ページごとにテキストレイヤーの文字を取り出し、ブロックにまとめる
+	pageCount := r.NumPage()
+	layouts := make([]pdfPageLayout, pageCount)
+	var ocrPages []int
+
+	for i := 1; i <= pageCount; i++ {
+		page := r.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		layouts[i-1].height = pageHeight(page)
+
+		glyphs, err := pageGlyphs(page)
+		if err != nil {
+			// 壊れたページはスキップするが、ページ番号の連続性は維持する（OCR できる場合は OCR する）
+			log.Printf("⚠️ Failed to extract text from PDF page %d: %v", i, err)
+		} else {
+			layouts[i-1].blocks = layoutPage(glyphs, layouts[i-1].height)
+		}
+
+		if e.ocr != nil && blocksRunes(layouts[i-1].blocks) < ocrMinPageRunes {
+			ocrPages = append(ocrPages, i)
+		}
+	}
+
+	// Step 3: テキストレイヤーのないページを OCR する（一部のページだけがスキャンの PDF もある）
+	if len(ocrPages) > 0 {
+		if err := e.recognizePages(ctx, data, ocrPages, layouts); err != nil {
+			return nil, err
+		}
 	}
-	defer os.Remove(tmpFile.Name())
 
-	if _, err := tmpFile.Write(data); err != nil {
-		tmpFile.Close()
-		return nil, fmt.Errorf("failed to write PDF to temp file: %w", err)
+	// Step 4: ドキュメント全体の本文サイズから見出しを判定
+	pageBlocks := make([][]*layoutBlock, pageCount)
+	for i := range layouts {
+		pageBlocks[i] = layouts[i].blocks
 	}
-	tmpFile.Close()
+	classifyHeadings(pageBlocks)
+
+	// Step 5: 見出しごとに区切って PageContent にする
+	pages := buildPDFSections(layouts)
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("no text found in PDF (possibly image-based or scanned document)")
+	}
+
+	return pages, nil
+}
 
-	// Step 3: PDFコンテキストを作成
-	ctx, err := api.ReadContextFile(tmpFile.Name())
+// recognizePages は指定したページ（1-based）を画像にして OCR し、layouts のブロックを置き換えます。
+// テキストレイヤーの方が文字が多い場合（短いページなど）はテキストレイヤーを使う
+func (e *PDFExtractor) recognizePages(ctx context.Context, data []byte, pageNumbers []int, layouts []pdfPageLayout) error {
+	// pdftoppm はファイルから読むため一時ファイルに書き出す
+	tmp, err := os.CreateTemp("", "nexus-ocr-*.pdf")
 	if err != nil {
-		return nil, fmt.Errorf("failed to read PDF: %w", err)
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
 	}
 
-	// Step 4: ページ単位でテキストを抽出し、PageContentスライスに格納
-	pageCount := ctx.PageCount
-	pages := make([]PageContent, 0, pageCount)
+	log.Printf("🔍 Running OCR on %d PDF pages without a text layer", len(pageNumbers))
 
-	for i := 1; i <= pageCount; i++ {
-		pageReader, err := pdfcpu.ExtractPageContent(ctx, i)
+	for _, n := range pageNumbers {
+		layout := &layouts[n-1]
+
+		image, err := e.rasterizer.RasterizePage(ctx, tmp.Name(), n, e.dpi)
 		if err != nil {
-			// エラーページはスキップするが、ページ番号の連続性は維持する
-			// （空ページとして記録しない：チャンクが生成されないだけでOK）
-			continue
+			return fmt.Errorf("failed to rasterize PDF page %d: %w", n, err)
 		}
-
-		pageData, err := io.ReadAll(pageReader)
+		result, err := e.ocr.Recognize(ctx, image)
 		if err != nil {
-			continue
+			return fmt.Errorf("failed to OCR PDF page %d: %w", n, err)
 		}
 
-		// クリーンアップしてから格納
-		text := cleanExtractedText(string(pageData))
-		if len(strings.TrimSpace(text)) == 0 {
-			// 画像ページなどテキストが取れない場合はスキップ
+		blocks := ocrPageBlocks(result, e.dpi, layout.height)
+		if blocksRunes(blocks) <= blocksRunes(layout.blocks) {
 			continue
 		}
+		confidence := result.Confidence
+		layout.blocks, layout.ocrConfidence = blocks, &confidence
+		log.Printf("✅ OCR page %d: %d blocks (confidence: %.2f)", n, len(blocks), confidence)
+	}
+	return nil
+}
 
-		pages = append(pages, PageContent{
-			PageNumber: i,
-			Content:    text,
+// pageGlyphs はページのテキストレイヤーの文字を返します（不正なコンテンツストリームでは pdf ライブラリが panic する）
+func pageGlyphs(page pdf.Page) (glyphs []pdfGlyph, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed page content: %v", r)
+		}
+	}()
+
+	texts := page.Content().Text
+	glyphs = make([]pdfGlyph, len(texts))
+	for i, t := range texts {
+		glyphs[i] = pdfGlyph{X: t.X, Y: t.Y, W: t.W, Size: t.FontSize, Font: t.Font, S: t.S}
+	}
+	return glyphs, nil
+}
+
+// pageHeight は MediaBox（親のページツリーから継承される）からページの高さを返します（取得できない場合は A4 の高さ）
+func pageHeight(page pdf.Page) float64 {
+	var box pdf.Value
+	for v := page.V; !v.IsNull() && box.IsNull(); v = v.Key("Parent") {
+		box = v.Key("MediaBox")
+	}
+	if box.Len() == 4 {
+		if h := box.Index(3).Float64() - box.Index(1).Float64(); h > 0 {
+			return h
+		}
+	}
+	return 842
+}
+
+// buildPDFSections はページのブロックを見出しごとの PageContent にまとめます。
+// 見出しのブロックは新しい区切りの先頭に入れ、見出しの階層はページをまたいで引き継ぐ
+func buildPDFSections(layouts []pdfPageLayout) []PageContent {
+	var (
+		headings headingStack
+		sections []PageContent
+		current  []ContentBlock
+		hasBody  bool
+	)
+
+	flush := func(pageNumber int, ocrConfidence *float64) {
+		blocks, body := current, hasBody
+		current, hasBody = nil, false
+		if !body {
+			return
+		}
+		texts := make([]string, len(blocks))
+		for i, b := range blocks {
+			texts[i] = b.Text
+		}
+		sections = append(sections, PageContent{
+			PageNumber:    pageNumber,
+			Content:       strings.Join(texts, "\n\n"),
+			Headings:      headings.path(),
+			Blocks:        blocks,
+			OCRConfidence: ocrConfidence,
 		})
 	}
 
-	if len(pages) == 0 {
-		return nil, fmt.Errorf("no text found in PDF (possibly image-based or scanned document)")
+	for i, layout := range layouts {
+		pageNumber := i + 1
+		for _, b := range layout.blocks {
+			block := b.toContentBlock(layout.height)
+			if block.Text == "" {
+				continue
+			}
+			if block.Type == BlockTypeHeading {
+				flush(pageNumber, layout.ocrConfidence)
+				headings.push(block.Level, block.Text)
+			} else {
+				hasBody = true
+			}
+			current = append(current, block)
+		}
+		flush(pageNumber, layout.ocrConfidence)
 	}
 
-	return pages, nil
+	return sections
 }
 
 // ExtractText は後方互換性のために残す（テキストファイル処理などで引き続き使用）
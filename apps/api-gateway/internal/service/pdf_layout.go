@@ -0,0 +1,583 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PDF のレイアウト解析。
+// テキストレイヤーの文字（位置・サイズ・フォント）から行を組み立て、行を段落・表のブロックにまとめ、
+// 段組みを考慮した読み順に並べます。見出しの判定はドキュメント全体の本文サイズが必要なため classifyHeadings で行う。
+
+const (
+	// rowBaselineTolerance は同じ行とみなすベースラインのずれ（フォントサイズ比）
+	rowBaselineTolerance = 0.5
+	// wordGapFactor を超える文字間の隙間には空白を入れる（フォントサイズ比）
+	wordGapFactor = 0.2
+	// segmentGapFactor を超える隙間で行を分ける（段組みの境界や表のセル）
+	segmentGapFactor = 1.2
+	// paragraphGapFactor を超える行間で段落を分ける（フォントサイズ比）
+	paragraphGapFactor = 0.6
+	// tableMinRows 行以上、セルの位置がそろって続く場合に表とみなす
+	tableMinRows = 2
+	// tableMaxCellRunes を平均で超えるセルを持つ行は表ではなく段組みの本文とみなす
+	tableMaxCellRunes = 40
+	// headingSizeRatio 以上本文より大きい文字の短いブロックは見出し
+	headingSizeRatio = 1.15
+	// headingMaxRunes より長いブロックは見出しにしない
+	headingMaxRunes = 150
+	// fullWidthRatio 以上の幅のブロックは段組みをまたぐ（そこで段組みの区切りになる）
+	fullWidthRatio = 0.6
+	// pageMarginRatio はページ番号を取り除くページ上下の余白（ページの高さ比）
+	pageMarginRatio = 0.08
+)
+
+// pdfGlyph はテキストレイヤーの1文字（PDF のユーザー空間。原点は左下、単位はポイント）
+type pdfGlyph struct {
+	X, Y float64 // ベースラインの開始位置
+	W    float64 // 文字送り
+	Size float64
+	Font string
+	S    string
+}
+
+// pdfRect は PDF のユーザー空間の矩形（Y は上向き）
+type pdfRect struct {
+	x0, y0, x1, y1 float64
+}
+
+func (r pdfRect) union(o pdfRect) pdfRect {
+	return pdfRect{
+		x0: math.Min(r.x0, o.x0),
+		y0: math.Min(r.y0, o.y0),
+		x1: math.Max(r.x1, o.x1),
+		y1: math.Max(r.y1, o.y1),
+	}
+}
+
+func (r pdfRect) width() float64 { return r.x1 - r.x0 }
+
+// overlapsX は横方向に重なるかどうか
+func (r pdfRect) overlapsX(o pdfRect) bool {
+	return r.x0 < o.x1 && o.x0 < r.x1
+}
+
+// layoutLine は1行のうち、大きな隙間で区切られた1区間（段組みの1行、表の1セル）
+type layoutLine struct {
+	text  string
+	box   pdfRect
+	size  float64 // 最も多くの文字で使われているフォントサイズ
+	bold  bool
+	runes int
+}
+
+// layoutBlock は解析中のブロック
+type layoutBlock struct {
+	kind  BlockType
+	lines []layoutLine
+	cells [][]string
+	box   pdfRect
+	size  float64
+	bold  bool
+	level int
+}
+
+func (b *layoutBlock) text() string {
+	if b.kind == BlockTypeTable {
+		rows := make([]string, len(b.cells))
+		for i, row := range b.cells {
+			rows[i] = strings.Join(row, " | ")
+		}
+		return strings.Join(rows, "\n")
+	}
+	var buf []byte
+	for i, line := range b.lines {
+		if i > 0 {
+			last, _ := utf8.DecodeLastRune(buf)
+			first, _ := utf8.DecodeRuneInString(line.text)
+			switch {
+			case last == '-' && unicode.IsLower(first):
+				// 行末のハイフネーションをつなぐ
+				buf = buf[:len(buf)-1]
+			case isWideRune(last) || isWideRune(first):
+				// 日本語などは改行位置に空白を入れない
+			default:
+				buf = append(buf, ' ')
+			}
+		}
+		buf = append(buf, line.text...)
+	}
+	return string(buf)
+}
+
+func (b *layoutBlock) runes() int {
+	n := 0
+	for _, line := range b.lines {
+		n += line.runes
+	}
+	return n
+}
+
+// normalizeGlyphs は改行や空の文字を除き、幅の取れない文字の位置を補います。
+// CID フォントなどで文字幅が取れない場合、同じ文字列の文字が同じ位置に描かれるため、直前の文字の後ろに並べる
+func normalizeGlyphs(glyphs []pdfGlyph) []pdfGlyph {
+	result := make([]pdfGlyph, 0, len(glyphs))
+	var prevRawX, prevRawY float64
+	for _, g := range glyphs {
+		if g.S == "" || g.S == "\n" || g.Size <= 0 {
+			continue
+		}
+		rawX, rawY := g.X, g.Y
+		if g.W <= 0 {
+			g.W = estimateGlyphWidth(g.S, g.Size)
+		}
+		if n := len(result); n > 0 && math.Abs(rawX-prevRawX) < 0.01 && math.Abs(rawY-prevRawY) < 0.01 {
+			g.X = result[n-1].X + result[n-1].W
+		}
+		prevRawX, prevRawY = rawX, rawY
+		result = append(result, g)
+	}
+	return result
+}
+
+// estimateGlyphWidth は幅の情報がない文字の幅を見積もります（全角は1em、それ以外は0.5em）
+func estimateGlyphWidth(s string, size float64) float64 {
+	w := 0.0
+	for _, r := range s {
+		if isWideRune(r) {
+			w += size
+		} else {
+			w += size / 2
+		}
+	}
+	return w
+}
+
+// isWideRune は日本語・中国語・韓国語などの全角文字かどうか（単語の間に空白を入れない文字）
+func isWideRune(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		(r >= 0x3000 && r <= 0x303F) || (r >= 0xFF00 && r <= 0xFFEF)
+}
+
+func isBoldFont(font string) bool {
+	f := strings.ToLower(font)
+	return strings.Contains(f, "bold") || strings.Contains(f, "black") || strings.Contains(f, "heavy")
+}
+
+// groupRows は文字をベースラインごとの行にまとめ、上から順に返します（行内は左から右）
+func groupRows(glyphs []pdfGlyph) [][]pdfGlyph {
+	sorted := append([]pdfGlyph(nil), glyphs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Y > sorted[j].Y })
+
+	var rows [][]pdfGlyph
+	var rowY, rowSize float64
+	for _, g := range sorted {
+		n := len(rows)
+		if n > 0 && math.Abs(g.Y-rowY) <= rowBaselineTolerance*math.Min(g.Size, rowSize) {
+			rows[n-1] = append(rows[n-1], g)
+			continue
+		}
+		rows = append(rows, []pdfGlyph{g})
+		rowY, rowSize = g.Y, g.Size
+	}
+	for _, row := range rows {
+		sort.SliceStable(row, func(i, j int) bool { return row[i].X < row[j].X })
+	}
+	return rows
+}
+
+// splitSegments は1行を大きな隙間で区間に分けます（段組みの境界・表のセル）
+func splitSegments(row []pdfGlyph) []layoutLine {
+	var segments []layoutLine
+	var current []pdfGlyph
+	flush := func() {
+		if line, ok := buildLine(current); ok {
+			segments = append(segments, line)
+		}
+		current = nil
+	}
+	for _, g := range row {
+		if n := len(current); n > 0 {
+			prev := current[n-1]
+			gap := g.X - (prev.X + prev.W)
+			if gap > segmentGapFactor*math.Max(g.Size, prev.Size) {
+				flush()
+			}
+		}
+		current = append(current, g)
+	}
+	flush()
+	return segments
+}
+
+// buildLine は区間の文字から行を作ります。文字の隙間には空白を補う
+func buildLine(glyphs []pdfGlyph) (layoutLine, bool) {
+	if len(glyphs) == 0 {
+		return layoutLine{}, false
+	}
+
+	var sb strings.Builder
+	sizeRunes := make(map[float64]int)
+	boldRunes, totalRunes := 0, 0
+	box := pdfRect{x0: math.Inf(1), y0: math.Inf(1), x1: math.Inf(-1), y1: math.Inf(-1)}
+
+	for i, g := range glyphs {
+		if i > 0 {
+			prev := glyphs[i-1]
+			gap := g.X - (prev.X + prev.W)
+			last, _ := utf8.DecodeLastRuneInString(prev.S)
+			first, _ := utf8.DecodeRuneInString(g.S)
+			if gap > wordGapFactor*math.Max(g.Size, prev.Size) &&
+				!unicode.IsSpace(last) && !unicode.IsSpace(first) &&
+				!(isWideRune(last) && isWideRune(first)) {
+				sb.WriteByte(' ')
+			}
+		}
+		sb.WriteString(g.S)
+
+		n := utf8.RuneCountInString(strings.TrimSpace(g.S))
+		sizeRunes[math.Round(g.Size*2)/2] += n
+		totalRunes += n
+		if isBoldFont(g.Font) {
+			boldRunes += n
+		}
+
+		// ベースラインからアセント（0.8em）とディセント（0.2em）を見積もる
+		box = box.union(pdfRect{x0: g.X, y0: g.Y - 0.2*g.Size, x1: g.X + g.W, y1: g.Y + 0.8*g.Size})
+	}
+
+	text := strings.Join(strings.Fields(sb.String()), " ")
+	if text == "" {
+		return layoutLine{}, false
+	}
+
+	size, best := 0.0, -1
+	for s, n := range sizeRunes {
+		if n > best || (n == best && s > size) {
+			size, best = s, n
+		}
+	}
+
+	return layoutLine{
+		text:  text,
+		box:   box,
+		size:  size,
+		bold:  boldRunes*2 > totalRunes,
+		runes: utf8.RuneCountInString(text),
+	}, true
+}
+
+// layoutPage は1ページの文字をブロックにまとめ、読み順に並べて返します。
+// pageHeight はページ番号の除去に使う（0 の場合は除去しない）
+func layoutPage(glyphs []pdfGlyph, pageHeight float64) []*layoutBlock {
+	rows := groupRows(normalizeGlyphs(glyphs))
+	segmentRows := make([][]layoutLine, len(rows))
+	for i, row := range rows {
+		segmentRows[i] = splitSegments(row)
+	}
+
+	var blocks []*layoutBlock
+
+	// Step 1: セルの位置がそろった行の連続を表にする
+	inTable := make([]bool, len(segmentRows))
+	for i := 0; i < len(segmentRows); {
+		end := tableRunEnd(segmentRows, i)
+		if end-i < tableMinRows {
+			i++
+			continue
+		}
+		table := &layoutBlock{kind: BlockTypeTable}
+		for r := i; r < end; r++ {
+			inTable[r] = true
+			cells := make([]string, len(segmentRows[r]))
+			for c, cell := range segmentRows[r] {
+				cells[c] = cell.text
+				table.lines = append(table.lines, cell)
+			}
+			table.cells = append(table.cells, cells)
+		}
+		table.box, table.size = linesBox(table.lines), table.lines[0].size
+		blocks = append(blocks, table)
+		i = end
+	}
+
+	// Step 2: 残りの行を段落にまとめる（上の行の続きで、横方向に重なり、文字サイズと太さが同じ）
+	var paragraphs []*layoutBlock
+	for r, segments := range segmentRows {
+		if inTable[r] {
+			continue
+		}
+		for _, line := range segments {
+			if block := findParagraph(paragraphs, line); block != nil {
+				block.lines = append(block.lines, line)
+				block.box = block.box.union(line.box)
+				continue
+			}
+			paragraphs = append(paragraphs, &layoutBlock{
+				kind:  BlockTypeParagraph,
+				lines: []layoutLine{line},
+				box:   line.box,
+				size:  line.size,
+				bold:  line.bold,
+			})
+		}
+	}
+
+	// Step 3: ページ上下の余白にあるページ番号を除く
+	for _, p := range paragraphs {
+		if pageHeight > 0 && isPageNumber(p, pageHeight) {
+			continue
+		}
+		blocks = append(blocks, p)
+	}
+
+	return orderBlocks(blocks)
+}
+
+// tableRunEnd は行 start から続く表の行の終わり（含まない）を返します
+func tableRunEnd(rows [][]layoutLine, start int) int {
+	if !isTabularRow(rows[start]) {
+		return start
+	}
+	end := start + 1
+	for end < len(rows) && isTabularRow(rows[end]) && cellsAligned(rows[end-1], rows[end]) {
+		end++
+	}
+	return end
+}
+
+// isTabularRow は2つ以上のセルを持ち、セルが短い行かどうか（長い場合は段組みの本文）
+func isTabularRow(segments []layoutLine) bool {
+	if len(segments) < 2 {
+		return false
+	}
+	total := 0
+	for _, s := range segments {
+		total += s.runes
+	}
+	return total/len(segments) <= tableMaxCellRunes
+}
+
+// cellsAligned は上下の行のセル数が同じで、各セルが横方向に重なり、行間が空きすぎていないかどうか
+func cellsAligned(above, below []layoutLine) bool {
+	if len(above) != len(below) {
+		return false
+	}
+	if above[0].box.y0-below[0].box.y1 > 2*below[0].size {
+		return false
+	}
+	for i := range above {
+		if !above[i].box.overlapsX(below[i].box) {
+			return false
+		}
+	}
+	return true
+}
+
+// findParagraph は line が続きになる段落を探します（最も近い段落。なければ nil）
+func findParagraph(paragraphs []*layoutBlock, line layoutLine) *layoutBlock {
+	var best *layoutBlock
+	bestGap := math.Inf(1)
+	for _, p := range paragraphs {
+		last := p.lines[len(p.lines)-1]
+		gap := last.box.y0 - line.box.y1
+		if gap < -0.5*line.size || gap > paragraphGapFactor*line.size {
+			continue
+		}
+		if !p.box.overlapsX(line.box) || last.bold != line.bold {
+			continue
+		}
+		if math.Abs(last.size-line.size) > 0.15*math.Max(last.size, line.size) {
+			continue
+		}
+		if gap < bestGap {
+			best, bestGap = p, gap
+		}
+	}
+	return best
+}
+
+// isPageNumber はページ上下の余白にある数字だけのブロックかどうか
+func isPageNumber(b *layoutBlock, pageHeight float64) bool {
+	if len(b.lines) != 1 {
+		return false
+	}
+	margin := pageMarginRatio * pageHeight
+	if b.box.y0 > margin && b.box.y1 < pageHeight-margin {
+		return false
+	}
+	text := strings.Trim(b.lines[0].text, "-–— ")
+	if text == "" {
+		return false
+	}
+	for _, r := range text {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func linesBox(lines []layoutLine) pdfRect {
+	box := lines[0].box
+	for _, line := range lines[1:] {
+		box = box.union(line.box)
+	}
+	return box
+}
+
+// orderBlocks はブロックを読み順に並べます。
+// ページ幅の大部分にわたるブロック（タイトル・段組みをまたぐ表など）で上下の帯に区切り、
+// 帯の中では段（横方向に重なるブロックのまとまり）を左から、段の中は上から読む
+func orderBlocks(blocks []*layoutBlock) []*layoutBlock {
+	if len(blocks) == 0 {
+		return blocks
+	}
+
+	sort.SliceStable(blocks, func(i, j int) bool { return blocks[i].box.y1 > blocks[j].box.y1 })
+
+	page := blocks[0].box
+	for _, b := range blocks[1:] {
+		page = page.union(b.box)
+	}
+
+	ordered := make([]*layoutBlock, 0, len(blocks))
+	var band []*layoutBlock
+	for _, b := range blocks {
+		if b.box.width() >= fullWidthRatio*page.width() {
+			ordered = append(ordered, orderColumns(band)...)
+			ordered = append(ordered, b)
+			band = nil
+			continue
+		}
+		band = append(band, b)
+	}
+	return append(ordered, orderColumns(band)...)
+}
+
+// orderColumns は帯の中のブロックを段ごとにまとめ、左の段から上から順に並べます
+func orderColumns(band []*layoutBlock) []*layoutBlock {
+	type column struct {
+		box    pdfRect
+		blocks []*layoutBlock
+	}
+
+	var columns []*column
+	for _, b := range band {
+		var target *column
+		for _, c := range columns {
+			if c.box.overlapsX(b.box) {
+				target = c
+				break
+			}
+		}
+		if target == nil {
+			columns = append(columns, &column{box: b.box, blocks: []*layoutBlock{b}})
+			continue
+		}
+		target.box = target.box.union(b.box)
+		target.blocks = append(target.blocks, b)
+	}
+
+	sort.SliceStable(columns, func(i, j int) bool { return columns[i].box.x0 < columns[j].box.x0 })
+
+	ordered := make([]*layoutBlock, 0, len(band))
+	for _, c := range columns {
+		ordered = append(ordered, c.blocks...)
+	}
+	return ordered
+}
+
+// classifyHeadings はドキュメント全体の本文サイズをもとに見出しを判定し、レベルを付けます。
+// 本文より大きい文字の短いブロックは文字サイズの大きい順にレベル 1, 2, ...、
+// 本文と同じサイズの太字の短いブロックはその次のレベルにする
+func classifyHeadings(pages [][]*layoutBlock) {
+	// 本文サイズ = 最も多くの文字で使われている段落の文字サイズ
+	sizeRunes := make(map[float64]int)
+	for _, blocks := range pages {
+		for _, b := range blocks {
+			// OCR したページのブロックは文字サイズが分からない（0）
+			if b.kind == BlockTypeParagraph && b.size > 0 {
+				sizeRunes[b.size] += b.runes()
+			}
+		}
+	}
+	bodySize, best := 0.0, -1
+	for s, n := range sizeRunes {
+		if n > best || (n == best && s < bodySize) {
+			bodySize, best = s, n
+		}
+	}
+	if bodySize == 0 {
+		return
+	}
+
+	var headings []*layoutBlock
+	sizeSet := make(map[float64]bool)
+	for _, blocks := range pages {
+		for _, b := range blocks {
+			if b.kind != BlockTypeParagraph || len(b.lines) > 3 || b.runes() > headingMaxRunes {
+				continue
+			}
+			switch {
+			case b.size >= bodySize*headingSizeRatio:
+				sizeSet[b.size] = true
+			case b.bold && b.size >= bodySize*0.95 && !endsWithSentence(b.text()):
+			default:
+				continue
+			}
+			headings = append(headings, b)
+		}
+	}
+
+	sizes := make([]float64, 0, len(sizeSet))
+	for s := range sizeSet {
+		sizes = append(sizes, s)
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(sizes)))
+
+	for _, h := range headings {
+		h.kind = BlockTypeHeading
+		h.level = len(sizes) + 1 // 本文サイズの太字
+		for i, s := range sizes {
+			if h.size == s {
+				h.level = i + 1
+				break
+			}
+		}
+		if h.level > 6 {
+			h.level = 6
+		}
+	}
+}
+
+// endsWithSentence は文末の句点で終わるかどうか（太字の見出しと強調した文を区別する）
+func endsWithSentence(text string) bool {
+	last, _ := utf8.DecodeLastRuneInString(strings.TrimSpace(text))
+	return strings.ContainsRune(".。!！?？:：", last)
+}
+
+// toContentBlock はブロックを PageContent 用に変換します（座標はページ左上を原点にする）
+func (b *layoutBlock) toContentBlock(pageHeight float64) ContentBlock {
+	return ContentBlock{
+		Type:  b.kind,
+		Text:  b.text(),
+		BBox:  toTopLeftBox(b.box, pageHeight),
+		Level: b.level,
+		Cells: b.cells,
+	}
+}
+
+// toTopLeftBox は PDF の座標（原点は左下）をページ左上を原点とする座標に変換し、小数2桁に丸めます
+func toTopLeftBox(r pdfRect, pageHeight float64) BoundingBox {
+	round := func(v float64) float64 { return math.Round(v*100) / 100 }
+	return BoundingBox{
+		X0: round(r.x0),
+		Y0: round(pageHeight - r.y1),
+		X1: round(r.x1),
+		Y1: round(pageHeight - r.y0),
+	}
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// textGlyphs は1行分の文字を返します（1文字 0.5em 幅のフォントで描いたもの）
+func textGlyphs(text string, x, y, size float64, font string) []pdfGlyph {
+	var glyphs []pdfGlyph
+	for _, r := range text {
+		w := estimateGlyphWidth(string(r), size)
+		glyphs = append(glyphs, pdfGlyph{X: x, Y: y, W: w, Size: size, Font: font, S: string(r)})
+		x += w
+	}
+	return glyphs
+}
+
+func blockTexts(blocks []*layoutBlock) []string {
+	texts := make([]string, len(blocks))
+	for i, b := range blocks {
+		texts[i] = b.text()
+	}
+	return texts
+}
+
+func TestLayoutPage_ReadsColumnsInOrder(t *testing.T) {
+	const line = "the quick brown fox jumps over the lazy dog again"
+	var glyphs []pdfGlyph
+	glyphs = append(glyphs, textGlyphs("Layout Aware Extraction Of Documents", 50, 760, 18, "Helvetica")...)
+	// 左右の段を1行ずつ交互に描く（コンテンツストリームの順序は読み順と一致しない）
+	for i, y := range []float64{700, 688, 676} {
+		glyphs = append(glyphs, textGlyphs(fmt.Sprintf("L%d %s", i, line), 50, y, 10, "Helvetica")...)
+		glyphs = append(glyphs, textGlyphs(fmt.Sprintf("R%d %s", i, line), 330, y, 10, "Helvetica")...)
+	}
+	glyphs = append(glyphs, textGlyphs("7", 300, 30, 10, "Helvetica")...)
+
+	blocks := layoutPage(glyphs, 842)
+	texts := blockTexts(blocks)
+
+	if len(blocks) != 3 {
+		t.Fatalf("Expected title and two columns (page number removed), got %q", texts)
+	}
+	if texts[0] != "Layout Aware Extraction Of Documents" {
+		t.Errorf("Expected the full-width title first, got %q", texts[0])
+	}
+	if !strings.HasPrefix(texts[1], "L0 ") || !strings.Contains(texts[1], "L2 ") || strings.Contains(texts[1], "R0") {
+		t.Errorf("Expected the left column as one block, got %q", texts[1])
+	}
+	if !strings.HasPrefix(texts[2], "R0 ") {
+		t.Errorf("Expected the right column after the left column, got %q", texts[2])
+	}
+}
+
+func TestLayoutPage_DetectsTables(t *testing.T) {
+	var glyphs []pdfGlyph
+	for i, row := range [][]string{{"Name", "Qty", "Price"}, {"Apple", "3", "120"}, {"Banana", "12", "80"}} {
+		y := 700 - float64(i)*14
+		for c, cell := range row {
+			glyphs = append(glyphs, textGlyphs(cell, 50+float64(c)*100, y, 10, "Helvetica")...)
+		}
+	}
+	glyphs = append(glyphs, textGlyphs("Prices are in yen.", 50, 640, 10, "Helvetica")...)
+
+	blocks := layoutPage(glyphs, 842)
+	if len(blocks) != 2 {
+		t.Fatalf("Expected a table and a paragraph, got %q", blockTexts(blocks))
+	}
+
+	table := blocks[0]
+	if table.kind != BlockTypeTable {
+		t.Fatalf("Expected the first block to be a table, got %s", table.kind)
+	}
+	want := [][]string{{"Name", "Qty", "Price"}, {"Apple", "3", "120"}, {"Banana", "12", "80"}}
+	if !reflect.DeepEqual(table.cells, want) {
+		t.Errorf("Unexpected cells: %v", table.cells)
+	}
+	if table.text() != "Name | Qty | Price\nApple | 3 | 120\nBanana | 12 | 80" {
+		t.Errorf("Unexpected table text: %q", table.text())
+	}
+	if blocks[1].kind != BlockTypeParagraph || blocks[1].text() != "Prices are in yen." {
+		t.Errorf("Unexpected paragraph: %q", blocks[1].text())
+	}
+}
+
+func TestClassifyHeadings_AssignsLevelsBySize(t *testing.T) {
+	const body = "This paragraph is body text that sets the dominant font size."
+	var glyphs []pdfGlyph
+	glyphs = append(glyphs, textGlyphs("Annual Report", 50, 780, 20, "Helvetica")...)
+	glyphs = append(glyphs, textGlyphs("Overview", 50, 740, 14, "Helvetica")...)
+	glyphs = append(glyphs, textGlyphs(body, 50, 715, 10, "Helvetica")...)
+	glyphs = append(glyphs, textGlyphs("Details", 50, 690, 10, "Helvetica-Bold")...)
+	glyphs = append(glyphs, textGlyphs(body, 50, 670, 10, "Helvetica")...)
+	glyphs = append(glyphs, textGlyphs("Bold text that ends a sentence.", 50, 645, 10, "Helvetica-Bold")...)
+
+	pages := [][]*layoutBlock{layoutPage(glyphs, 842)}
+	classifyHeadings(pages)
+
+	type heading struct {
+		text  string
+		level int
+	}
+	var got []heading
+	for _, b := range pages[0] {
+		if b.kind == BlockTypeHeading {
+			got = append(got, heading{b.text(), b.level})
+		}
+	}
+	want := []heading{{"Annual Report", 1}, {"Overview", 2}, {"Details", 3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected headings %v, got %v", want, got)
+	}
+}
+
+func TestToContentBlock_UsesTopLeftOrigin(t *testing.T) {
+	b := &layoutBlock{
+		kind:  BlockTypeParagraph,
+		lines: []layoutLine{{text: "text"}},
+		box:   pdfRect{x0: 50, y0: 700, x1: 150.123, y1: 712},
+	}
+
+	got := b.toContentBlock(842).BBox
+	want := BoundingBox{X0: 50, Y0: 130, X1: 150.12, Y1: 142}
+	if got != want {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+}
+
+func TestLocateChunkRegions_MapsChunksToBlocks(t *testing.T) {
+	page := PageContent{
+		PageNumber: 3,
+		Blocks: []ContentBlock{
+			{Type: BlockTypeHeading, Text: "Intro", BBox: BoundingBox{X0: 1, Y0: 1, X1: 2, Y1: 2}},
+			{Type: BlockTypeParagraph, Text: "First paragraph.", BBox: BoundingBox{X0: 3, Y0: 3, X1: 4, Y1: 4}},
+			{Type: BlockTypeParagraph, Text: "Second paragraph.", BBox: BoundingBox{X0: 5, Y0: 5, X1: 6, Y1: 6}},
+		},
+	}
+	page.Content = "Intro\n\nFirst paragraph.\n\nSecond paragraph."
+
+	regions := locateChunkRegions(page, []string{"Intro\n\nFirst paragraph.", "paragraph.\n\nSecond paragraph."})
+
+	if len(regions) != 2 {
+		t.Fatalf("Expected regions for 2 chunks, got %d", len(regions))
+	}
+	if len(regions[0]) != 2 || regions[0][0].BoundingBox != page.Blocks[0].BBox || regions[0][1].PageNumber != 3 {
+		t.Errorf("Unexpected regions for the first chunk: %+v", regions[0])
+	}
+	// オーバーラップで前のチャンクと重なる部分も含めて、またがるブロックをすべて返す
+	if len(regions[1]) != 2 || regions[1][0].BoundingBox != page.Blocks[1].BBox || regions[1][1].BoundingBox != page.Blocks[2].BBox {
+		t.Errorf("Unexpected regions for the second chunk: %+v", regions[1])
+	}
+
+	if got := locateChunkRegions(PageContent{Content: "plain"}, []string{"plain"}); got != nil {
+		t.Errorf("Expected nil regions without blocks, got %+v", got)
+	}
+}
+
+// pdfTestLine は PDF に描く1行（フォントサイズ, ベースラインの位置, テキスト）
+type pdfTestLine struct {
+	size, x, y float64
+	text       string
+}
+
+// minimalPDF は各ページの行を Helvetica で描いた PDF（612x792pt）を作ります。行のないページは空のページになる
+func minimalPDF(pages ...[]pdfTestLine) []byte {
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 612 792] >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	}
+	for i, lines := range pages {
+		var content strings.Builder
+		for _, l := range lines {
+			fmt.Fprintf(&content, "BT /F1 %g Tf %g %g Td (%s) Tj ET\n", l.size, l.x, l.y, l.text)
+		}
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func TestPDFExtractor_ExtractsLayoutBlocks(t *testing.T) {
+	data := minimalPDF([]pdfTestLine{
+		{24, 72, 720, "Introduction"},
+		{12, 72, 680, "The first line of the body text."},
+		{12, 72, 666, "The second line of the body text."},
+		{18, 72, 620, "Method"},
+		{12, 72, 590, "Details of the method."},
+	})
+
+	pages, err := (&PDFExtractor{}).ExtractPages(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ExtractPages failed: %v", err)
+	}
+	if len(pages) != 2 {
+		t.Fatalf("Expected 2 sections, got %d: %+v", len(pages), pages)
+	}
+
+	first := pages[0]
+	if first.PageNumber != 1 || !reflect.DeepEqual(first.Headings, []string{"Introduction"}) {
+		t.Errorf("Unexpected first section: %+v", first)
+	}
+	if first.Content != "Introduction\n\nThe first line of the body text. The second line of the body text." {
+		t.Errorf("Unexpected content: %q", first.Content)
+	}
+	if len(first.Blocks) != 2 || first.Blocks[0].Type != BlockTypeHeading || first.Blocks[0].Level != 1 {
+		t.Fatalf("Unexpected blocks: %+v", first.Blocks)
+	}
+	// 見出しのベースラインは下から 720pt、上からは 792-720 = 72pt
+	if box := first.Blocks[0].BBox; box.X0 != 72 || box.Y1 < 72 || box.Y0 > 72 {
+		t.Errorf("Unexpected heading box: %+v", box)
+	}
+
+	if !reflect.DeepEqual(pages[1].Headings, []string{"Introduction", "Method"}) || !strings.HasSuffix(pages[1].Content, "Details of the method.") {
+		t.Errorf("Unexpected second section: %+v", pages[1])
+	}
+}
@@ -0,0 +1,364 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/client"
+)
+
+// OCREngine は画像（PNG）の文字を行ごとに認識します。
+// テキストレイヤーのない PDF のページ（スキャンした文書・画像だけのページ）に使う
+type OCREngine interface {
+	Recognize(ctx context.Context, image []byte) (*OCRResult, error)
+}
+
+// OCRResult は1枚の画像の認識結果
+type OCRResult struct {
+	Lines      []OCRLine // 読み順
+	Confidence float64   // 画像全体の信頼度（0〜1、文字数で重み付けした平均）
+}
+
+// OCRLine は認識した1行
+type OCRLine struct {
+	Text       string
+	BBox       BoundingBox // 画像のピクセル座標（左上が原点）
+	Confidence float64     // 0〜1
+	Paragraph  int         // 段落の番号（同じ番号の連続する行は1つの段落）
+}
+
+// OCRProvider は OCR の方式
+type OCRProvider string
+
+const (
+	OCRNone      OCRProvider = "none"      // OCR しない（テキストレイヤーのないページは空になる）
+	OCRTesseract OCRProvider = "tesseract" // tesseract コマンドをサブプロセスで実行
+	OCRAIWorker  OCRProvider = "ai_worker" // AI Worker の /api/v1/ocr で認識
+)
+
+// OCROptions は OCR の設定
+type OCROptions struct {
+	Provider      OCRProvider
+	Languages     string // provider=tesseract の言語（例: jpn+eng）。ai_worker は AI Worker 側の設定を使う
+	DPI           int    // ページを画像にするときの解像度
+	TesseractPath string
+	PdftoppmPath  string // ページを画像にする poppler の pdftoppm
+}
+
+// withDefaults は未設定の項目にデフォルト値を入れた OCROptions を返します
+func (o OCROptions) withDefaults() OCROptions {
+	if o.Provider == "" {
+		o.Provider = OCRNone
+	}
+	if o.Languages == "" {
+		o.Languages = "jpn+eng"
+	}
+	if o.DPI <= 0 {
+		o.DPI = 300
+	}
+	if o.TesseractPath == "" {
+		o.TesseractPath = "tesseract"
+	}
+	if o.PdftoppmPath == "" {
+		o.PdftoppmPath = "pdftoppm"
+	}
+	return o
+}
+
+// NewOCREngine は opts.Provider に応じた OCREngine を作成します（provider=none の場合は nil）
+func NewOCREngine(opts OCROptions, aiClient client.AIWorkerClient) (OCREngine, error) {
+	opts = opts.withDefaults()
+
+	switch opts.Provider {
+	case OCRNone:
+		return nil, nil
+	case OCRTesseract:
+		path, err := exec.LookPath(opts.TesseractPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find tesseract: %w", err)
+		}
+		return &tesseractOCR{path: path, languages: opts.Languages}, nil
+	case OCRAIWorker:
+		return &aiWorkerOCR{aiClient: aiClient}, nil
+	default:
+		return nil, fmt.Errorf("unknown OCR provider %q", opts.Provider)
+	}
+}
+
+// NewPDFExtractorWithOCR は、テキストレイヤーのないページを画像にして OCR する PDFExtractor を作成します
+// （provider=none の場合は NewPDFExtractor と同じ）
+func NewPDFExtractorWithOCR(opts OCROptions, aiClient client.AIWorkerClient) (*PDFExtractor, error) {
+	opts = opts.withDefaults()
+
+	engine, err := NewOCREngine(opts, aiClient)
+	if err != nil {
+		return nil, err
+	}
+	if engine == nil {
+		return NewPDFExtractor(), nil
+	}
+
+	path, err := exec.LookPath(opts.PdftoppmPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pdftoppm: %w", err)
+	}
+
+	return &PDFExtractor{
+		ocr:        engine,
+		rasterizer: &pdftoppmRasterizer{path: path},
+		dpi:        opts.DPI,
+	}, nil
+}
+
+// tesseractOCR は tesseract コマンドで認識します（TSV 出力から単語の位置と信頼度を読む）
+type tesseractOCR struct {
+	path      string
+	languages string
+}
+
+func (t *tesseractOCR) Recognize(ctx context.Context, image []byte) (*OCRResult, error) {
+	cmd := exec.CommandContext(ctx, t.path, "stdin", "stdout", "-l", t.languages, "tsv")
+	cmd.Stdin = bytes.NewReader(image)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run tesseract: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	result, err := parseTesseractTSV(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse tesseract output: %w", err)
+	}
+	return result, nil
+}
+
+// parseTesseractTSV は tesseract の TSV 出力（level 5 が単語）を行にまとめます。
+// 列: level page_num block_num par_num line_num word_num left top width height conf text
+func parseTesseractTSV(data []byte) (*OCRResult, error) {
+	type lineKey struct{ block, par, line int }
+
+	result := &OCRResult{}
+	var (
+		current                 lineKey
+		paragraphKey            [2]int
+		hasLine, hasParagraph   bool
+		paragraph               = -1
+		words                   []string
+		lineConfSum, lineWeight float64
+		confSum, confWeight     float64
+	)
+
+	flush := func() {
+		if !hasLine || len(words) == 0 {
+			return
+		}
+		line := &result.Lines[len(result.Lines)-1]
+		line.Text = joinOCRWords(words)
+		if lineWeight > 0 {
+			line.Confidence = lineConfSum / lineWeight
+		}
+		words, lineConfSum, lineWeight = nil, 0, 0
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	header := true
+	for scanner.Scan() {
+		if header {
+			header = false
+			continue
+		}
+		cols := strings.Split(scanner.Text(), "\t")
+		if len(cols) < 12 || cols[0] != "5" {
+			continue
+		}
+		text := strings.TrimSpace(cols[11])
+		if text == "" {
+			continue
+		}
+
+		nums := make([]float64, 11)
+		for i := range nums {
+			v, err := strconv.ParseFloat(cols[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid column %d %q: %w", i+1, cols[i], err)
+			}
+			nums[i] = v
+		}
+		key := lineKey{block: int(nums[2]), par: int(nums[3]), line: int(nums[4])}
+		box := BoundingBox{X0: nums[6], Y0: nums[7], X1: nums[6] + nums[8], Y1: nums[7] + nums[9]}
+		conf := nums[10] / 100
+		weight := float64(utf8.RuneCountInString(text))
+
+		if !hasLine || key != current {
+			flush()
+			if pk := [2]int{key.block, key.par}; !hasParagraph || pk != paragraphKey {
+				paragraph++
+				paragraphKey, hasParagraph = pk, true
+			}
+			result.Lines = append(result.Lines, OCRLine{BBox: box, Paragraph: paragraph})
+			current, hasLine = key, true
+		}
+
+		line := &result.Lines[len(result.Lines)-1]
+		line.BBox = unionBox(line.BBox, box)
+		words = append(words, text)
+		// 信頼度が -1 の単語（認識できなかったもの）は平均に含めない
+		if conf >= 0 {
+			lineConfSum += conf * weight
+			lineWeight += weight
+			confSum += conf * weight
+			confWeight += weight
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	if confWeight > 0 {
+		result.Confidence = confSum / confWeight
+	}
+	return result, nil
+}
+
+// joinOCRWords は単語を空白でつなぎます（日本語などの全角文字の間には空白を入れない）
+func joinOCRWords(words []string) string {
+	var sb strings.Builder
+	for i, w := range words {
+		if i > 0 {
+			last, _ := utf8.DecodeLastRuneInString(words[i-1])
+			first, _ := utf8.DecodeRuneInString(w)
+			if !isWideRune(last) || !isWideRune(first) {
+				sb.WriteByte(' ')
+			}
+		}
+		sb.WriteString(w)
+	}
+	return sb.String()
+}
+
+func unionBox(a, b BoundingBox) BoundingBox {
+	return BoundingBox{
+		X0: min(a.X0, b.X0),
+		Y0: min(a.Y0, b.Y0),
+		X1: max(a.X1, b.X1),
+		Y1: max(a.Y1, b.Y1),
+	}
+}
+
+// aiWorkerOCR は AI Worker の /api/v1/ocr で認識します
+type aiWorkerOCR struct {
+	aiClient client.AIWorkerClient
+}
+
+func (o *aiWorkerOCR) Recognize(ctx context.Context, image []byte) (*OCRResult, error) {
+	resp, err := o.aiClient.OCR(ctx, image)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recognize with AI Worker: %w", err)
+	}
+
+	result := &OCRResult{Lines: make([]OCRLine, len(resp.Lines)), Confidence: resp.Confidence}
+	for i, l := range resp.Lines {
+		result.Lines[i] = OCRLine{
+			Text:       l.Text,
+			BBox:       BoundingBox{X0: l.BBox[0], Y0: l.BBox[1], X1: l.BBox[2], Y1: l.BBox[3]},
+			Confidence: l.Confidence,
+			Paragraph:  l.Paragraph,
+		}
+	}
+	return result, nil
+}
+
+// PageRasterizer は PDF の1ページを PNG にします（page は 1-based）
+type PageRasterizer interface {
+	RasterizePage(ctx context.Context, pdfPath string, page int, dpi int) ([]byte, error)
+}
+
+// pdftoppmRasterizer は poppler の pdftoppm コマンドでページを PNG にします
+type pdftoppmRasterizer struct {
+	path string
+}
+
+func (r *pdftoppmRasterizer) RasterizePage(ctx context.Context, pdfPath string, page int, dpi int) ([]byte, error) {
+	outDir, err := os.MkdirTemp("", "nexus-ocr-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(outDir)
+
+	prefix := filepath.Join(outDir, "page")
+	p := strconv.Itoa(page)
+	cmd := exec.CommandContext(ctx, r.path, "-f", p, "-l", p, "-r", strconv.Itoa(dpi), "-png", "-singlefile", pdfPath, prefix)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to run pdftoppm: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	image, err := os.ReadFile(prefix + ".png")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rasterized page: %w", err)
+	}
+	return image, nil
+}
+
+// ocrPageBlocks は OCR の結果を段落のブロックにまとめ、読み順に並べます。
+// 画像のピクセル座標を PDF の座標（ポイント、原点は左下）に変換する。
+// 文字サイズは分からないため 0 にする（見出しの判定・本文サイズの計算には使わない）
+func ocrPageBlocks(result *OCRResult, dpi int, pageHeight float64) []*layoutBlock {
+	scale := 72 / float64(dpi)
+
+	var blocks []*layoutBlock
+	var current *layoutBlock
+	for i, l := range result.Lines {
+		text := strings.Join(strings.Fields(l.Text), " ")
+		if text == "" {
+			continue
+		}
+		line := layoutLine{
+			text: text,
+			box: pdfRect{
+				x0: l.BBox.X0 * scale,
+				y0: pageHeight - l.BBox.Y1*scale,
+				x1: l.BBox.X1 * scale,
+				y1: pageHeight - l.BBox.Y0*scale,
+			},
+			runes: utf8.RuneCountInString(text),
+		}
+
+		if current != nil && i > 0 && result.Lines[i-1].Paragraph == l.Paragraph {
+			current.lines = append(current.lines, line)
+			current.box = current.box.union(line.box)
+			continue
+		}
+		current = &layoutBlock{kind: BlockTypeParagraph, lines: []layoutLine{line}, box: line.box}
+		blocks = append(blocks, current)
+	}
+
+	kept := blocks[:0]
+	for _, b := range blocks {
+		if pageHeight > 0 && isPageNumber(b, pageHeight) {
+			continue
+		}
+		kept = append(kept, b)
+	}
+	return orderBlocks(kept)
+}
+
+// blocksRunes はブロックの文字数の合計を返します
+func blocksRunes(blocks []*layoutBlock) int {
+	n := 0
+	for _, b := range blocks {
+		n += b.runes()
+	}
+	return n
+}
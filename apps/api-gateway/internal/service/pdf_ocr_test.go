@@ -0,0 +1,122 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"reflect"
+	"testing"
+)
+
+type stubRasterizer struct {
+	pages []int
+}
+
+func (r *stubRasterizer) RasterizePage(ctx context.Context, pdfPath string, page int, dpi int) ([]byte, error) {
+	r.pages = append(r.pages, page)
+	return []byte("png"), nil
+}
+
+type stubOCREngine struct {
+	result *OCRResult
+}
+
+func (e stubOCREngine) Recognize(ctx context.Context, image []byte) (*OCRResult, error) {
+	return e.result, nil
+}
+
+func TestParseTesseractTSV_GroupsWordsIntoLines(t *testing.T) {
+	tsv := "level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n" +
+		"1\t1\t0\t0\t0\t0\t0\t0\t1000\t1400\t-1\t\n" +
+		"4\t1\t1\t1\t1\t0\t100\t100\t300\t30\t-1\t\n" +
+		"5\t1\t1\t1\t1\t1\t100\t100\t120\t30\t90\tHello\n" +
+		"5\t1\t1\t1\t1\t2\t240\t102\t160\t28\t80\tworld\n" +
+		"5\t1\t1\t1\t2\t1\t100\t140\t200\t30\t70.5\tagain\n" +
+		"5\t1\t2\t1\t1\t1\t100\t300\t40\t40\t95\t日本\n" +
+		"5\t1\t2\t1\t1\t2\t140\t300\t40\t40\t95\t語\n" +
+		"5\t1\t2\t1\t1\t3\t200\t300\t40\t40\t-1\t \n"
+
+	result, err := parseTesseractTSV([]byte(tsv))
+	if err != nil {
+		t.Fatalf("parseTesseractTSV failed: %v", err)
+	}
+
+	if len(result.Lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %+v", result.Lines)
+	}
+	first := result.Lines[0]
+	if first.Text != "Hello world" || first.Paragraph != 0 {
+		t.Errorf("Unexpected first line: %+v", first)
+	}
+	if first.BBox != (BoundingBox{X0: 100, Y0: 100, X1: 400, Y1: 130}) {
+		t.Errorf("Expected the union of the word boxes, got %+v", first.BBox)
+	}
+	if result.Lines[1].Paragraph != 0 || result.Lines[2].Paragraph != 1 {
+		t.Errorf("Expected paragraphs by block and par, got %d and %d", result.Lines[1].Paragraph, result.Lines[2].Paragraph)
+	}
+	if result.Lines[2].Text != "日本語" {
+		t.Errorf("Expected no spaces between Japanese words, got %q", result.Lines[2].Text)
+	}
+
+	// 文字数で重み付けした平均: (5*0.9 + 5*0.8 + 5*0.705 + 3*0.95) / 18（信頼度 -1 の単語は含めない）
+	want := (5*0.9 + 5*0.8 + 5*0.705 + 3*0.95) / 18
+	if math.Abs(result.Confidence-want) > 1e-9 {
+		t.Errorf("Expected confidence %f, got %f", want, result.Confidence)
+	}
+}
+
+func TestPDFExtractor_OCRsPagesWithoutText(t *testing.T) {
+	// 1ページ目はテキストレイヤーあり、2ページ目は画像だけ（テキストなし）
+	data := minimalPDF(
+		[]pdfTestLine{{12, 72, 700, "This page has a regular text layer."}},
+		nil,
+	)
+
+	rasterizer := &stubRasterizer{}
+	extractor := &PDFExtractor{
+		ocr: stubOCREngine{result: &OCRResult{
+			Lines: []OCRLine{
+				{Text: "Scanned first line", BBox: BoundingBox{X0: 144, Y0: 144, X1: 600, Y1: 168}, Paragraph: 0},
+				{Text: "and its second line", BBox: BoundingBox{X0: 144, Y0: 172, X1: 620, Y1: 196}, Paragraph: 0},
+				{Text: "Another paragraph", BBox: BoundingBox{X0: 144, Y0: 260, X1: 560, Y1: 284}, Paragraph: 1},
+			},
+			Confidence: 0.87,
+		}},
+		rasterizer: rasterizer,
+		dpi:        144,
+	}
+
+	pages, err := extractor.Extract(context.Background(), bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(rasterizer.pages, []int{2}) {
+		t.Errorf("Expected only page 2 to be rasterized, got %v", rasterizer.pages)
+	}
+	if len(pages) != 2 {
+		t.Fatalf("Expected 2 sections, got %+v", pages)
+	}
+	if pages[0].PageNumber != 1 || pages[0].OCRConfidence != nil {
+		t.Errorf("Expected the text layer for page 1, got %+v", pages[0])
+	}
+
+	scanned := pages[1]
+	if scanned.PageNumber != 2 || scanned.OCRConfidence == nil || *scanned.OCRConfidence != 0.87 {
+		t.Fatalf("Expected OCR confidence on page 2, got %+v", scanned)
+	}
+	if scanned.Content != "Scanned first line and its second line\n\nAnother paragraph" {
+		t.Errorf("Unexpected OCR content: %q", scanned.Content)
+	}
+	// 144dpi のピクセル座標はポイントの2倍
+	if box := scanned.Blocks[0].BBox; box != (BoundingBox{X0: 72, Y0: 72, X1: 310, Y1: 98}) {
+		t.Errorf("Unexpected OCR block box: %+v", box)
+	}
+}
+
+func TestPDFExtractor_ScannedPDFWithoutOCRFails(t *testing.T) {
+	_, err := NewPDFExtractor().Extract(context.Background(), bytes.NewReader(minimalPDF(nil)))
+	if err == nil {
+		t.Fatalf("Expected an error for a PDF without text")
+	}
+}
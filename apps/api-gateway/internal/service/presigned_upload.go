@@ -0,0 +1,231 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/storage"
+	"github.com/google/uuid"
+)
+
+// Presigned uploads: the client asks for a presigned PUT URL and sends the file directly
+// to object storage, so large files never pass through the gateway. The URL cannot
+// restrict what is uploaded, so on confirmation the object is copied to a key only the
+// server can write and the copy's size, SHA256 and MIME type are verified before the
+// files / documents rows are created (the URL stays valid, so the client's key is never trusted
+// after verification).
+// Confirmation reuses CompleteUploadSession (upload_method = 'presigned').
+
+// Upload methods (upload_sessions.upload_method)
+const (
+	UploadMethodChunked   = "chunked"
+	UploadMethodPresigned = "presigned"
+)
+
+// ErrUploadVerificationFailed is returned when a presigned upload does not match
+// the declared size or SHA256 (the uploaded object is discarded)
+var ErrUploadVerificationFailed = errors.New("upload verification failed")
+
+// CreatePresignedUploadRequest contains the parameters of a presigned upload
+type CreatePresignedUploadRequest struct {
+	FileName    string
+	SizeBytes   int64
+	SHA256Hash  string // hex SHA256 of the content, verified on confirmation
+	MimeType    string // declared content type (optional)
+	DirectoryID *uuid.UUID
+	Tags        []string
+}
+
+// PresignedUpload is a presigned PUT URL and the upload session to confirm it with
+type PresignedUpload struct {
+	Session   *UploadSessionInfo
+	URL       string
+	ExpiresAt time.Time // when the URL stops accepting the PUT
+}
+
+// PresignedDownload is a presigned GET URL for a file
+type PresignedDownload struct {
+	URL       string
+	ExpiresAt time.Time
+}
+
+// CreatePresignedUpload implements FileService.CreatePresignedUpload
+func (fs *FileServiceImpl) CreatePresignedUpload(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	req CreatePresignedUploadRequest,
+) (*PresignedUpload, error) {
+	// Step 1: Check the declared size (the actual size is verified on confirmation)
+	limit, err := fs.UploadSizeLimit(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if req.SizeBytes > limit {
+		return nil, fmt.Errorf("%w: limit is %d bytes", ErrUploadTooLarge, limit)
+	}
+
+	// Step 2: Create the session with the object key the client will PUT to
+	objectKey := uploadTempPrefix + "presigned/" + uuid.New().String()
+	dirID := uuid.NullUUID{}
+	if req.DirectoryID != nil {
+		dirID = uuid.NullUUID{UUID: *req.DirectoryID, Valid: true}
+	}
+	session, err := fs.queries.CreateUploadSession(ctx, db.CreateUploadSessionParams{
+		WorkspaceID:      workspaceID,
+		DirectoryID:      dirID,
+		FileName:         req.FileName,
+		DeclaredMimeType: sql.NullString{String: req.MimeType, Valid: req.MimeType != ""},
+		Tags:             req.Tags,
+		SizeBytes:        req.SizeBytes,
+		ExpiresAt:        time.Now().Add(fs.opts.UploadSessionTTL),
+		UploadMethod:     UploadMethodPresigned,
+		ExpectedSha256:   sql.NullString{String: strings.ToLower(req.SHA256Hash), Valid: true},
+		PartKeys:         []string{objectKey},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload session: %w", err)
+	}
+
+	// Step 3: Presign the PUT
+	expiresAt := time.Now().Add(fs.opts.PresignExpiry)
+	url, err := fs.storageClient.PresignedPutObject(ctx, fs.storageBucket, objectKey, fs.opts.PresignExpiry)
+	if err != nil {
+		_ = fs.queries.AbortUploadSession(ctx, session.ID)
+		return nil, fmt.Errorf("failed to presign upload: %w", err)
+	}
+
+	log.Printf("📁 Presigned upload created: id=%s, name=%s, size=%d", session.ID, session.FileName, session.SizeBytes)
+	return &PresignedUpload{
+		Session:   toUploadSessionInfo(session),
+		URL:       url,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// PresignDownload implements FileService.PresignDownload
+func (fs *FileServiceImpl) PresignDownload(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	fileID uuid.UUID,
+) (*PresignedDownload, error) {
+	doc, err := fs.queries.GetDocument(ctx, db.GetDocumentParams{
+		ID:          fileID,
+		WorkspaceID: workspaceID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("file not found")
+		}
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	expiresAt := time.Now().Add(fs.opts.PresignExpiry)
+	url, err := fs.storageClient.PresignedGetObject(ctx, doc.MinioBucket, doc.MinioKey, fs.opts.PresignExpiry, doc.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign download: %w", err)
+	}
+
+	_ = fs.queries.UpdateFileLastAccessed(ctx, doc.FileID)
+
+	return &PresignedDownload{
+		URL:       url,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// verifyPresignedUpload copies the uploaded object to a server-only key and checks the copy against the session.
+// The presigned URL is still valid at this point, so the client could PUT different bytes to its key after
+// verification; only the copy that was verified is used from here on. The client's object is kept until the
+// session completes so that a failed confirmation can be retried.
+// On a mismatch the objects are removed, the session is aborted and ErrUploadVerificationFailed is returned
+func (fs *FileServiceImpl) verifyPresignedUpload(
+	ctx context.Context,
+	session db.UploadSession,
+) (*StagedUpload, error) {
+	objectKey := session.PartKeys[0]
+
+	// Step 1: The object must exist with the declared size
+	info, err := fs.storageClient.StatObject(ctx, fs.storageBucket, objectKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return nil, fmt.Errorf("%w: the file has not been uploaded yet", ErrUploadSessionConflict)
+		}
+		return nil, fmt.Errorf("failed to stat upload: %w", err)
+	}
+	if info.Size != session.SizeBytes {
+		fs.rejectPresignedUpload(ctx, session)
+		return nil, fmt.Errorf("%w: uploaded %d bytes, declared %d", ErrUploadVerificationFailed, info.Size, session.SizeBytes)
+	}
+
+	// Step 2: Pin the upload by copying it to a key the presigned URL cannot write
+	verifiedKey := uploadTempPrefix + "verified/" + uuid.New().String()
+	if err := fs.storageClient.ComposeObject(ctx, fs.storageBucket, verifiedKey, []string{objectKey}); err != nil {
+		return nil, fmt.Errorf("failed to copy upload: %w", err)
+	}
+
+	// Step 3: Read the copy back, detecting the MIME type from the head and hashing the rest
+	reader, err := fs.storageClient.GetObject(ctx, fs.storageBucket, verifiedKey)
+	if err != nil {
+		fs.removeObjectQuietly(ctx, verifiedKey)
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	defer reader.Close()
+
+	settings, err := fs.workspaceSettings(ctx, session.WorkspaceID)
+	if err != nil {
+		fs.removeObjectQuietly(ctx, verifiedKey)
+		return nil, err
+	}
+	buffered := bufio.NewReaderSize(reader, MIMESniffLen)
+	head, err := buffered.Peek(MIMESniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		fs.removeObjectQuietly(ctx, verifiedKey)
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	mimeType, err := checkUploadMIMEType(settings, head, session.FileName, session.DeclaredMimeType.String)
+	if err != nil {
+		fs.rejectPresignedUpload(ctx, session, verifiedKey)
+		return nil, err
+	}
+
+	hasher := sha256.New()
+	n, err := io.Copy(hasher, buffered)
+	if err != nil {
+		fs.removeObjectQuietly(ctx, verifiedKey)
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+
+	// Step 4: Compare with the declared hash
+	hash := fmt.Sprintf("%x", hasher.Sum(nil))
+	if n != session.SizeBytes || hash != session.ExpectedSha256.String {
+		fs.rejectPresignedUpload(ctx, session, verifiedKey)
+		log.Printf("🚫 Presigned upload hash mismatch: id=%s, declared=%s, actual=%s", session.ID, session.ExpectedSha256.String, hash)
+		return nil, fmt.Errorf("%w: SHA256 does not match", ErrUploadVerificationFailed)
+	}
+
+	return &StagedUpload{
+		WorkspaceID: session.WorkspaceID,
+		FileName:    session.FileName,
+		MimeType:    mimeType,
+		SizeBytes:   n,
+		SHA256Hash:  hash,
+		TempKeys:    []string{verifiedKey},
+	}, nil
+}
+
+// rejectPresignedUpload discards an uploaded object (and its verified copy, if any) that failed verification
+func (fs *FileServiceImpl) rejectPresignedUpload(ctx context.Context, session db.UploadSession, copies ...string) {
+	_ = fs.AbortUpload(ctx, &StagedUpload{TempKeys: append(copies, session.PartKeys...)})
+	if err := fs.queries.AbortUploadSession(context.WithoutCancel(ctx), session.ID); err != nil {
+		log.Printf("⚠️  Failed to abort upload session: id=%s, err=%v", session.ID, err)
+	}
+}
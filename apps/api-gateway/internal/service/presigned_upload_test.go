@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/google/uuid"
+)
+
+// newPresignedTestService は GetWorkspace（設定なし）と AbortUploadSession だけに応答する FileServiceImpl を作成します
+func newPresignedTestService(t *testing.T) (*FileServiceImpl, *fakeDB, *memoryObjectStorage) {
+	t.Helper()
+	f := newFakeDB()
+	f.queries["GetWorkspace"] = func(args []driver.Value) (*fakeRows, error) {
+		now := time.Now()
+		return newFakeRow(args[0], "workspace", nil, nil, now, now, nil), nil
+	}
+	f.execs["AbortUploadSession"] = func(args []driver.Value) (int64, error) { return 1, nil }
+
+	database := f.open()
+	t.Cleanup(func() { database.Close() })
+	mem := newMemoryObjectStorage()
+	fs := &FileServiceImpl{
+		queries:       db.New(database),
+		storageClient: mem,
+		storageBucket: "files",
+		opts:          FileServiceOptions{}.withDefaults(),
+	}
+	return fs, f, mem
+}
+
+func presignedSession(objectKey string, data []byte) db.UploadSession {
+	return db.UploadSession{
+		ID:             uuid.New(),
+		WorkspaceID:    uuid.New(),
+		FileName:       "notes.txt",
+		SizeBytes:      int64(len(data)),
+		PartKeys:       []string{objectKey},
+		Status:         UploadSessionStatusUploading,
+		UploadMethod:   UploadMethodPresigned,
+		ExpectedSha256: sql.NullString{String: fmt.Sprintf("%x", sha256.Sum256(data)), Valid: true},
+	}
+}
+
+func TestVerifyPresignedUpload_UsesServerOnlyCopy(t *testing.T) {
+	fs, f, mem := newPresignedTestService(t)
+	objectKey := uploadTempPrefix + "presigned/" + uuid.New().String()
+	data := []byte("presigned upload の検証用テキストです")
+	mem.put("files", objectKey, data)
+
+	staged, err := fs.verifyPresignedUpload(context.Background(), presignedSession(objectKey, data))
+	if err != nil {
+		t.Fatalf("verifyPresignedUpload failed: %v", err)
+	}
+
+	// 検証後も presigned URL は有効なので、クライアントは同じキーに別の内容を PUT できる
+	mem.put("files", objectKey, []byte("swapped after verification"))
+
+	if len(staged.TempKeys) != 1 || staged.TempKeys[0] == objectKey || !strings.HasPrefix(staged.TempKeys[0], uploadTempPrefix) {
+		t.Fatalf("Expected a single server-only staged key, got %q", staged.TempKeys)
+	}
+	if got, _ := mem.get("files", staged.TempKeys[0]); string(got) != string(data) {
+		t.Errorf("Expected the staged copy to keep the verified bytes, got %q", got)
+	}
+	if staged.SHA256Hash != fmt.Sprintf("%x", sha256.Sum256(data)) || staged.SizeBytes != int64(len(data)) {
+		t.Errorf("Unexpected staged upload: %+v", staged)
+	}
+	if f.called("AbortUploadSession") != 0 {
+		t.Error("Expected the session to stay open after a successful verification")
+	}
+}
+
+func TestVerifyPresignedUpload_RejectsMismatch(t *testing.T) {
+	data := []byte("presigned upload の検証用テキストです")
+
+	tests := []struct {
+		name     string
+		uploaded []byte
+	}{
+		{"different bytes of the same size", []byte(strings.Repeat("x", len(data)))},
+		{"different size", []byte("short")},
+	}
+	for _, tt := range tests {
+		fs, f, mem := newPresignedTestService(t)
+		objectKey := uploadTempPrefix + "presigned/" + uuid.New().String()
+		mem.put("files", objectKey, tt.uploaded)
+
+		_, err := fs.verifyPresignedUpload(context.Background(), presignedSession(objectKey, data))
+		if !errors.Is(err, ErrUploadVerificationFailed) {
+			t.Errorf("%s: expected ErrUploadVerificationFailed, got %v", tt.name, err)
+		}
+		// クライアントのオブジェクトも検証用のコピーも残さず、セッションを中止する
+		if got := mem.keys("files"); len(got) != 0 {
+			t.Errorf("%s: expected all objects to be removed, got %q", tt.name, got)
+		}
+		if got := f.called("AbortUploadSession"); got != 1 {
+			t.Errorf("%s: expected AbortUploadSession to be called once, got %d", tt.name, got)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/client"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/storage"
+)
+
+// Reranker はベクトル検索などで得た候補を、クエリとの関連度で採点し直します。
+// 戻り値は texts と同じ順序のスコア（0〜1、大きいほど関連が高い）です
+type Reranker interface {
+	Rerank(ctx context.Context, query string, texts []string) ([]float64, error)
+}
+
+// RerankerProvider はリランキングの方式
+type RerankerProvider string
+
+const (
+	RerankerNone         RerankerProvider = "none"          // リランキングしない（検索スコアの順のまま）
+	RerankerCrossEncoder RerankerProvider = "cross_encoder" // AI Worker の Cross-Encoder で採点
+	RerankerLLM          RerankerProvider = "llm"           // Ollama の LLM で1件ずつ採点
+)
+
+// RerankOptions はリランキングの設定
+type RerankOptions struct {
+	Provider       RerankerProvider
+	LLMModel       string // RerankerLLM で使う Ollama のモデル
+	LLMParallelism int    // RerankerLLM で同時に投げる採点リクエスト数
+}
+
+// withDefaults は未設定の項目にデフォルト値を入れた RerankOptions を返します
+func (o RerankOptions) withDefaults() RerankOptions {
+	if o.Provider == "" {
+		o.Provider = RerankerNone
+	}
+	if o.LLMModel == "" {
+		o.LLMModel = "phi3:mini"
+	}
+	if o.LLMParallelism <= 0 {
+		o.LLMParallelism = 4
+	}
+	return o
+}
+
+// NewReranker は opts.Provider に応じた Reranker を作成します
+func NewReranker(
+	opts RerankOptions,
+	aiClient client.AIWorkerClient,
+	ollamaClient client.OllamaClient,
+) (Reranker, error) {
+	opts = opts.withDefaults()
+
+	switch opts.Provider {
+	case RerankerNone:
+		return noopReranker{}, nil
+	case RerankerCrossEncoder:
+		return &crossEncoderReranker{aiClient: aiClient}, nil
+	case RerankerLLM:
+		return &llmReranker{
+			ollamaClient: ollamaClient,
+			model:        opts.LLMModel,
+			parallelism:  opts.LLMParallelism,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown reranker provider %q", opts.Provider)
+	}
+}
+
+// noopReranker はリランキングを行いません（スコアを返さず、検索スコアの順を維持します）
+type noopReranker struct{}
+
+func (noopReranker) Rerank(ctx context.Context, query string, texts []string) ([]float64, error) {
+	return nil, nil
+}
+
+// crossEncoderReranker は AI Worker の Cross-Encoder（/api/v1/rerank）で採点します
+type crossEncoderReranker struct {
+	aiClient client.AIWorkerClient
+}
+
+func (r *crossEncoderReranker) Rerank(ctx context.Context, query string, texts []string) ([]float64, error) {
+	resp, err := r.aiClient.Rerank(ctx, query, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rerank with cross-encoder: %w", err)
+	}
+	return resp.Scores, nil
+}
+
+// llmReranker は Ollama の LLM に候補を1件ずつ 0〜10 で採点させ、0〜1 に正規化します（pointwise）
+type llmReranker struct {
+	ollamaClient client.OllamaClient
+	model        string
+	parallelism  int
+}
+
+// llmScorePattern は LLM の応答から最初の数値を取り出します
+var llmScorePattern = regexp.MustCompile(`\d+(?:\.\d+)?`)
+
+func (r *llmReranker) Rerank(ctx context.Context, query string, texts []string) ([]float64, error) {
+	scores := make([]float64, len(texts))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	sem := make(chan struct{}, r.parallelism)
+
+	for i, text := range texts {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, text string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			answer, err := r.ollamaClient.Generate(ctx, r.model, buildRerankPrompt(query, text))
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to score candidate %d with LLM: %w", i, err)
+					cancel()
+				}
+				mu.Unlock()
+				return
+			}
+
+			// 数値を読み取れない応答は関連なし（0）として扱う
+			score, ok := parseLLMScore(answer)
+			if !ok {
+				log.Printf("⚠️ [RAG] Could not parse LLM rerank score for candidate %d: %q", i, answer)
+			}
+			scores[i] = score
+		}(i, text)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return scores, nil
+}
+
+// buildRerankPrompt は pointwise 採点用のプロンプトを作成します
+func buildRerankPrompt(query, text string) string {
+	return fmt.Sprintf(`あなたは検索結果の関連度を評価するアシスタントです。
+以下の資料が質問に答えるためにどの程度役立つかを、0（無関係）から10（質問に直接答えている）の整数で評価してください。
+数字のみを答えてください。
+
+質問: %s
+
+資料:
+%s
+
+評価:`, query, text)
+}
+
+// parseLLMScore は LLM の応答から 0〜10 の評価を読み取り、0〜1 に正規化します
+func parseLLMScore(answer string) (float64, bool) {
+	match := llmScorePattern.FindString(answer)
+	if match == "" {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(match, 64)
+	if err != nil {
+		return 0, false
+	}
+	return min(max(value, 0), 10) / 10, true
+}
+
+// rankedResult は最終的な順位付き検索結果です。
+// Score は最終スコア（リランキングした場合はリランクスコア、しない場合は検索スコア）
+type rankedResult struct {
+	storage.SearchResult
+	OriginalScore float64  // 検索（dense / lexical / hybrid）時点のスコア
+	RerankScore   *float64 // リランキングした場合のスコア
+}
+
+// rerankResults は候補をリランキングし、スコア降順で最大 topN 件を返します。
+// リランキングに失敗した場合は、検索スコアの順のまま続行します
+func rerankResults(
+	ctx context.Context,
+	reranker Reranker,
+	query string,
+	candidates []storage.SearchResult,
+	topN int,
+) []rankedResult {
+	ranked := make([]rankedResult, len(candidates))
+	for i, c := range candidates {
+		ranked[i] = rankedResult{SearchResult: c, OriginalScore: c.Score}
+	}
+
+	if reranker != nil && len(candidates) > 0 {
+		texts := make([]string, len(candidates))
+		for i, c := range candidates {
+			texts[i], _ = c.Payload["text"].(string)
+		}
+
+		scores, err := reranker.Rerank(ctx, query, texts)
+		switch {
+		case err != nil:
+			log.Printf("⚠️ [RAG] Reranking failed, using retrieval order: %v", err)
+		case scores == nil:
+			// noopReranker
+		case len(scores) != len(candidates):
+			log.Printf("⚠️ [RAG] Reranker returned %d scores for %d candidates, using retrieval order", len(scores), len(candidates))
+		default:
+			for i := range ranked {
+				score := scores[i]
+				ranked[i].RerankScore = &score
+				ranked[i].Score = score
+			}
+			sort.SliceStable(ranked, func(i, j int) bool {
+				return ranked[i].Score > ranked[j].Score
+			})
+			log.Printf("🔁 [RAG] Reranked %d candidates", len(ranked))
+		}
+	}
+
+	if len(ranked) > topN {
+		ranked = ranked[:topN]
+	}
+	return ranked
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+// stubReranker はテキストごとに固定のスコアを返します
+type stubReranker struct {
+	scores map[string]float64
+	err    error
+}
+
+func (r stubReranker) Rerank(ctx context.Context, query string, texts []string) ([]float64, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	scores := make([]float64, len(texts))
+	for i, text := range texts {
+		scores[i] = r.scores[text]
+	}
+	return scores, nil
+}
+
+func TestRerankResults_ReordersAndKeepsTopN(t *testing.T) {
+	candidates := newTestResults("a", "b", "c")
+	candidates[0].Score = 0.9
+
+	ranked := rerankResults(context.Background(), stubReranker{scores: map[string]float64{"a": 0.1, "b": 0.5, "c": 0.8}}, "q", candidates, 2)
+
+	if len(ranked) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(ranked))
+	}
+	if ranked[0].ID != "c" || ranked[1].ID != "b" {
+		t.Errorf("Expected order [c b], got [%s %s]", ranked[0].ID, ranked[1].ID)
+	}
+	if ranked[0].RerankScore == nil || ranked[0].Score != 0.8 {
+		t.Errorf("Expected final score to be the rerank score, got %+v", ranked[0])
+	}
+}
+
+func TestRerankResults_KeepsOriginalScore(t *testing.T) {
+	candidates := newTestResults("a")
+	candidates[0].Score = 0.42
+
+	ranked := rerankResults(context.Background(), stubReranker{scores: map[string]float64{"a": 0.9}}, "q", candidates, 5)
+
+	if ranked[0].OriginalScore != 0.42 {
+		t.Errorf("Expected original score 0.42, got %f", ranked[0].OriginalScore)
+	}
+}
+
+func TestRerankResults_FallsBackOnError(t *testing.T) {
+	candidates := newTestResults("a", "b", "c")
+
+	ranked := rerankResults(context.Background(), stubReranker{err: errors.New("unavailable")}, "q", candidates, 2)
+
+	if len(ranked) != 2 || ranked[0].ID != "a" || ranked[0].RerankScore != nil {
+		t.Errorf("Expected retrieval order without rerank scores, got %+v", ranked)
+	}
+}
+
+func TestRerankResults_Noop(t *testing.T) {
+	candidates := newTestResults("a", "b")
+
+	ranked := rerankResults(context.Background(), noopReranker{}, "q", candidates, 5)
+
+	if ranked[0].ID != "a" || ranked[0].RerankScore != nil {
+		t.Errorf("Expected noop reranker to keep retrieval order, got %+v", ranked)
+	}
+}
+
+func TestParseLLMScore(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   float64
+		ok     bool
+	}{
+		{"8", 0.8, true},
+		{"評価: 7.5 点", 0.75, true},
+		{"15", 1.0, true},
+		{"関連なし", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseLLMScore(tt.answer)
+		if ok != tt.ok || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("parseLLMScore(%q) = %f, %v; want %f, %v", tt.answer, got, ok, tt.want, tt.ok)
+		}
+	}
+}
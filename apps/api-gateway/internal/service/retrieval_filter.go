@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/api"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/storage"
+	"github.com/google/uuid"
+)
+
+// parseChatFilterConfig はチャットに保存された filter_config を読み込みます（未設定の場合は nil）
+func parseChatFilterConfig(chat db.Chat) (*api.FilterConfig, error) {
+	if !chat.FilterConfig.Valid || len(chat.FilterConfig.RawMessage) == 0 {
+		return nil, nil
+	}
+
+	var cfg api.FilterConfig
+	if err := json.Unmarshal(chat.FilterConfig.RawMessage, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse filter_config: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+// retrievalScope はチャットの filter_config を解決した検索範囲です。
+// 空のスライスは「制限なし」を意味します。
+type retrievalScope struct {
+	Tags         []string
+	DocumentIDs  []uuid.UUID
+	DirectoryIDs []uuid.UUID // サブディレクトリを含めて解決済み
+}
+
+// resolveRetrievalScope は FilterConfig の directory_ids を directories ツリーをたどって展開し、
+// サブディレクトリも対象に含めた検索範囲を返します。
+// 何も指定されていなければ nil を返します。
+func resolveRetrievalScope(
+	ctx context.Context,
+	queries *db.Queries,
+	workspaceID uuid.UUID,
+	cfg *api.FilterConfig,
+) (*retrievalScope, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	scope := &retrievalScope{}
+
+	if cfg.Tags != nil {
+		scope.Tags = *cfg.Tags
+	}
+
+	if cfg.DocumentIds != nil {
+		scope.DocumentIDs = *cfg.DocumentIds
+	}
+
+	if cfg.DirectoryIds != nil && len(*cfg.DirectoryIds) > 0 {
+		directoryIDs, err := queries.GetDirectoryDescendantIDs(ctx, db.GetDirectoryDescendantIDsParams{
+			DirectoryIds: *cfg.DirectoryIds,
+			WorkspaceID:  workspaceID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve directory tree: %w", err)
+		}
+
+		// 削除済みなどで解決できなかった場合も指定IDのまま絞り込む（全件検索にはしない）
+		if len(directoryIDs) == 0 {
+			directoryIDs = *cfg.DirectoryIds
+		}
+		scope.DirectoryIDs = directoryIDs
+	}
+
+	if len(scope.Tags) == 0 && len(scope.DocumentIDs) == 0 && len(scope.DirectoryIDs) == 0 {
+		return nil, nil
+	}
+
+	return scope, nil
+}
+
+// vectorFilter は検索範囲を VectorStore の payload フィルタに変換します。
+//   - tags: must（指定タグのいずれかを持つチャンクのみ）
+//   - document_ids / directory_ids: should（どちらかに含まれていれば対象）
+//
+// 現在のバージョン以外のチャンク（is_current=false）は scope に関係なく常に除外します。
+// is_current を持たないバージョン管理より前のポイントは対象に残る。
+func (scope *retrievalScope) vectorFilter() *storage.Filter {
+	filter := &storage.Filter{
+		MustNot: []storage.Condition{storage.MatchValue("is_current", false)},
+	}
+	if scope == nil {
+		return filter
+	}
+
+	if len(scope.Tags) > 0 {
+		filter.Must = append(filter.Must, storage.MatchAny("tags", scope.Tags))
+	}
+
+	if len(scope.DocumentIDs) > 0 {
+		filter.Should = append(filter.Should, storage.MatchAny("document_id", uuidStrings(scope.DocumentIDs)))
+	}
+
+	if len(scope.DirectoryIDs) > 0 {
+		filter.Should = append(filter.Should, storage.MatchAny("directory_id", uuidStrings(scope.DirectoryIDs)))
+	}
+
+	return filter
+}
+
+// lexicalParams は検索範囲を SearchChunksLexical のパラメータに変換します（vectorFilter と同じ意味になる）
+func (scope *retrievalScope) lexicalParams(
+	workspaceID uuid.UUID,
+	query string,
+	limit int,
+) db.SearchChunksLexicalParams {
+	params := db.SearchChunksLexicalParams{
+		Query:        query,
+		WorkspaceID:  workspaceID,
+		Tags:         []string{},
+		DocumentIds:  []uuid.UUID{},
+		DirectoryIds: []uuid.UUID{},
+		ResultLimit:  int32(limit),
+	}
+	if scope != nil {
+		if len(scope.Tags) > 0 {
+			params.Tags = scope.Tags
+		}
+		if len(scope.DocumentIDs) > 0 {
+			params.DocumentIds = scope.DocumentIDs
+		}
+		if len(scope.DirectoryIDs) > 0 {
+			params.DirectoryIds = scope.DirectoryIDs
+		}
+	}
+	return params
+}
+
+// uuidStrings は UUID スライスを文字列スライスに変換します
+func uuidStrings(ids []uuid.UUID) []string {
+	result := make([]string, len(ids))
+	for i, id := range ids {
+		result[i] = id.String()
+	}
+	return result
+}
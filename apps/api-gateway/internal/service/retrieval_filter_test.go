@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/api"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/storage"
+	"github.com/google/uuid"
+	"github.com/sqlc-dev/pqtype"
+)
+
+func TestRetrievalScope_Filters(t *testing.T) {
+	workspaceID := uuid.New()
+	docID := uuid.New()
+	dirID, subDirID := uuid.New(), uuid.New()
+	notCurrent := storage.MatchValue("is_current", false)
+
+	tests := []struct {
+		name        string
+		scope       *retrievalScope
+		wantFilter  *storage.Filter
+		wantLexical db.SearchChunksLexicalParams
+	}{
+		{
+			name:       "workspace",
+			scope:      nil,
+			wantFilter: &storage.Filter{MustNot: []storage.Condition{notCurrent}},
+			// 制限なしは nil ではなく空配列（SQL 側は cardinality(...) = 0 で判定し、NULL だと何もヒットしない）
+			wantLexical: db.SearchChunksLexicalParams{
+				Tags: []string{}, DocumentIds: []uuid.UUID{}, DirectoryIds: []uuid.UUID{},
+			},
+		},
+		{
+			name:  "directory",
+			scope: &retrievalScope{DirectoryIDs: []uuid.UUID{dirID, subDirID}},
+			wantFilter: &storage.Filter{
+				Should:  []storage.Condition{storage.MatchAny("directory_id", []string{dirID.String(), subDirID.String()})},
+				MustNot: []storage.Condition{notCurrent},
+			},
+			wantLexical: db.SearchChunksLexicalParams{
+				Tags: []string{}, DocumentIds: []uuid.UUID{}, DirectoryIds: []uuid.UUID{dirID, subDirID},
+			},
+		},
+		{
+			name:  "document",
+			scope: &retrievalScope{DocumentIDs: []uuid.UUID{docID}},
+			wantFilter: &storage.Filter{
+				Should:  []storage.Condition{storage.MatchAny("document_id", []string{docID.String()})},
+				MustNot: []storage.Condition{notCurrent},
+			},
+			wantLexical: db.SearchChunksLexicalParams{
+				Tags: []string{}, DocumentIds: []uuid.UUID{docID}, DirectoryIds: []uuid.UUID{},
+			},
+		},
+		{
+			name: "tags with documents and directories",
+			scope: &retrievalScope{
+				Tags:         []string{"finance"},
+				DocumentIDs:  []uuid.UUID{docID},
+				DirectoryIDs: []uuid.UUID{dirID},
+			},
+			wantFilter: &storage.Filter{
+				Must: []storage.Condition{storage.MatchAny("tags", []string{"finance"})},
+				Should: []storage.Condition{
+					storage.MatchAny("document_id", []string{docID.String()}),
+					storage.MatchAny("directory_id", []string{dirID.String()}),
+				},
+				MustNot: []storage.Condition{notCurrent},
+			},
+			wantLexical: db.SearchChunksLexicalParams{
+				Tags: []string{"finance"}, DocumentIds: []uuid.UUID{docID}, DirectoryIds: []uuid.UUID{dirID},
+			},
+		},
+		{
+			// 空のスライスは nil と同じく制限なし（空の should で全件が落ちたりしない）
+			name:       "empty slices",
+			scope:      &retrievalScope{Tags: []string{}, DocumentIDs: []uuid.UUID{}, DirectoryIDs: []uuid.UUID{}},
+			wantFilter: &storage.Filter{MustNot: []storage.Condition{notCurrent}},
+			wantLexical: db.SearchChunksLexicalParams{
+				Tags: []string{}, DocumentIds: []uuid.UUID{}, DirectoryIds: []uuid.UUID{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.scope.vectorFilter(); !reflect.DeepEqual(got, tt.wantFilter) {
+			t.Errorf("%s: vectorFilter() = %+v, want %+v", tt.name, got, tt.wantFilter)
+		}
+
+		tt.wantLexical.Query = "売上"
+		tt.wantLexical.WorkspaceID = workspaceID
+		tt.wantLexical.ResultLimit = 20
+		got := tt.scope.lexicalParams(workspaceID, "売上", 20)
+		if !reflect.DeepEqual(got, tt.wantLexical) {
+			t.Errorf("%s: lexicalParams() = %+v, want %+v", tt.name, got, tt.wantLexical)
+		}
+		if got.Tags == nil || got.DocumentIds == nil || got.DirectoryIds == nil {
+			t.Errorf("%s: lexicalParams() must not pass nil arrays, got %+v", tt.name, got)
+		}
+	}
+}
+
+func TestResolveRetrievalScope_Unrestricted(t *testing.T) {
+	empty := []uuid.UUID{}
+	tags := []string{}
+
+	tests := []struct {
+		name string
+		cfg  *api.FilterConfig
+	}{
+		{"no filter_config", nil},
+		{"no fields", &api.FilterConfig{}},
+		{"empty fields", &api.FilterConfig{Tags: &tags, DocumentIds: &empty, DirectoryIds: &empty}},
+	}
+	for _, tt := range tests {
+		// ディレクトリの指定が無ければ DB は引かない
+		scope, err := resolveRetrievalScope(context.Background(), nil, uuid.New(), tt.cfg)
+		if err != nil || scope != nil {
+			t.Errorf("%s: resolveRetrievalScope() = %+v, %v, want nil (whole workspace)", tt.name, scope, err)
+		}
+	}
+}
+
+func TestParseChatFilterConfig(t *testing.T) {
+	docID := uuid.New()
+
+	cfg, err := parseChatFilterConfig(db.Chat{FilterConfig: pqtype.NullRawMessage{
+		RawMessage: []byte(`{"document_ids":["` + docID.String() + `"],"tags":["hr"]}`),
+		Valid:      true,
+	}})
+	if err != nil {
+		t.Fatalf("parseChatFilterConfig failed: %v", err)
+	}
+	if cfg.DocumentIds == nil || (*cfg.DocumentIds)[0] != docID || cfg.Tags == nil || (*cfg.Tags)[0] != "hr" {
+		t.Errorf("Unexpected filter_config: %+v", cfg)
+	}
+
+	if cfg, err := parseChatFilterConfig(db.Chat{}); cfg != nil || err != nil {
+		t.Errorf("Expected no filter_config for a NULL column, got %+v, %v", cfg, err)
+	}
+	if _, err := parseChatFilterConfig(db.Chat{FilterConfig: pqtype.NullRawMessage{RawMessage: []byte(`{`), Valid: true}}); err == nil {
+		t.Error("Expected an error for malformed filter_config")
+	}
+}
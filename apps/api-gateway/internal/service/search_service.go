@@ -6,29 +6,35 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
 
 	"github.com/Martin4208/Nexus/apps/api-gateway/internal/client"
 	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/storage"
 	"github.com/google/uuid"
 )
 
 // SearchService は RAG 検索のビジネスロジック
 type SearchService struct {
-	queries  *db.Queries
-	aiWorker client.AIWorkerClient
-	qdrant   client.QdrantClient
+	queries     *db.Queries
+	aiWorker    client.AIWorkerClient
+	vectorStore storage.VectorStore
+	retriever   *hybridRetriever
 }
 
 // NewSearchService は新しい SearchService を作成
 func NewSearchService(
 	queries *db.Queries,
 	aiWorker client.AIWorkerClient,
-	qdrant client.QdrantClient,
+	vectorStore storage.VectorStore,
+	reranker Reranker,
+	rerankCandidates int,
 ) *SearchService {
 	return &SearchService{
-		queries:  queries,
-		aiWorker: aiWorker,
-		qdrant:   qdrant,
+		queries:     queries,
+		aiWorker:    aiWorker,
+		vectorStore: vectorStore,
+		retriever:   newHybridRetriever(queries, vectorStore, reranker, rerankCandidates),
 	}
 }
 
@@ -39,62 +45,57 @@ type SearchResult struct {
 }
 
 // SearchSource は検索ソース情報
+// Score は最終スコア（リランキングした場合はリランクスコア）
 type SearchSource struct {
-	DocumentID uuid.UUID `json:"document_id"`
-	ChunkIndex int       `json:"chunk_index"`
-	Content    string    `json:"content"`
-	Score      float64   `json:"score"`
+	DocumentID    uuid.UUID `json:"document_id"`
+	ChunkIndex    int       `json:"chunk_index"`
+	PageNumber    int       `json:"page_number"`
+	Content       string    `json:"content"`
+	Score         float64   `json:"score"`
+	OriginalScore float64   `json:"original_score"`
+	RerankScore   *float64  `json:"rerank_score"`
 }
 
 // Search は RAG 検索を実行
+// opts.Mode で dense（ベクトル）/ lexical（語句）/ hybrid（RRF で統合）を選択できます
 func (s *SearchService) Search(
 	ctx context.Context,
 	workspaceID uuid.UUID,
 	query string,
 	topK int,
+	opts RetrievalOptions,
 ) (*SearchResult, error) {
-	log.Printf("Starting RAG search: workspace=%s, query=%s, topK=%d", workspaceID, query, topK)
+	opts = opts.withDefaults()
+	log.Printf("Starting RAG search: workspace=%s, query=%s, topK=%d, mode=%s", workspaceID, query, topK, opts.Mode)
 
-	// コレクション名を動的に生成
-	collectionName := fmt.Sprintf("workspace_%s", workspaceID)
-	log.Printf("Using Qdrant collection: %s", collectionName)
-
-	// Step 1: クエリを Embedding 化
-	log.Printf("[1/4] Embedding query...")
-	queryVector, err := s.aiWorker.EmbedQuery(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to embed query: %w", err)
-	}
-	log.Printf("Query embedded: dim=%d", len(queryVector))
-
-	// Step 2: Qdrant で類似検索
-	log.Printf("[2/4] Searching Qdrant...")
-	searchResp, err := s.qdrant.Search(ctx, collectionName, queryVector, topK)
+	// Step 1: 検索（dense の場合はクエリを Embedding 化して Qdrant で類似検索）し、リランキングで topK 件に絞る
+	log.Printf("[1/3] Retrieving chunks...")
+	results, err := s.retriever.retrieve(ctx, workspaceID, query, s.aiWorker.EmbedQuery, nil, topK, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search Qdrant: %w", err)
+		return nil, err
 	}
-	log.Printf("Found %d results from Qdrant", len(searchResp.Result))
+	log.Printf("Found %d results", len(results))
 
-	if len(searchResp.Result) == 0 {
+	if len(results) == 0 {
 		return &SearchResult{
 			Answer:  "申し訳ありません。関連する情報が見つかりませんでした。",
 			Sources: []SearchSource{},
 		}, nil
 	}
 
-	// Step 3: PostgreSQL から元テキストを取得
-	log.Printf("[3/4] Fetching chunks from PostgreSQL...")
-	chunkIDs := make([]uuid.UUID, len(searchResp.Result))
-	scoreMap := make(map[uuid.UUID]float64)
+	// Step 2: PostgreSQL から元テキストを取得
+	log.Printf("[2/3] Fetching chunks from PostgreSQL...")
+	chunkIDs := make([]uuid.UUID, 0, len(results))
+	resultMap := make(map[uuid.UUID]rankedResult)
 
-	for i, result := range searchResp.Result {
+	for _, result := range results {
 		chunkID, err := uuid.Parse(result.ID)
 		if err != nil {
 			log.Printf("Warning: invalid chunk ID: %s", result.ID)
 			continue
 		}
-		chunkIDs[i] = chunkID
-		scoreMap[chunkID] = result.Score
+		chunkIDs = append(chunkIDs, chunkID)
+		resultMap[chunkID] = result
 	}
 
 	chunks, err := s.queries.GetChunksByIDs(ctx, chunkIDs)
@@ -103,18 +104,33 @@ func (s *SearchService) Search(
 	}
 	log.Printf("Retrieved %d chunks from PostgreSQL", len(chunks))
 
-	// Step 4: コンテキストを作成して LLM に投げる
-	log.Printf("[4/4] Generating answer with LLM...")
-	contextTexts := make([]string, len(chunks))
-	sources := make([]SearchSource, len(chunks))
+	// 検索結果の順位（スコア降順）に並べ直す
+	sort.SliceStable(chunks, func(i, j int) bool {
+		return resultMap[chunks[i].ID].Score > resultMap[chunks[j].ID].Score
+	})
+
+	// Step 3: ヒットしたチャンクを親チャンク（または前後のチャンク）に広げてコンテキストを作成し、LLM に投げる
+	// sources はヒットしたチャンクとそのページのまま返す
+	log.Printf("[3/3] Generating answer with LLM...")
+	contexts := s.retriever.expand(ctx, results, opts)
+	contextTexts := make([]string, 0, len(contexts))
+	for _, c := range contexts {
+		if c.text != "" {
+			contextTexts = append(contextTexts, c.text)
+		}
+	}
 
+	sources := make([]SearchSource, len(chunks))
 	for i, chunk := range chunks {
-		contextTexts[i] = chunk.Content
+		result := resultMap[chunk.ID]
 		sources[i] = SearchSource{
-			DocumentID: chunk.DocumentID,
-			ChunkIndex: int(chunk.ChunkIndex),
-			Content:    chunk.Content,
-			Score:      scoreMap[chunk.ID],
+			DocumentID:    chunk.DocumentID,
+			ChunkIndex:    int(chunk.ChunkIndex),
+			PageNumber:    int(chunk.PageNumber),
+			Content:       chunk.Content,
+			Score:         result.Score,
+			OriginalScore: result.OriginalScore,
+			RerankScore:   result.RerankScore,
 		}
 	}
 
@@ -23,12 +23,15 @@ func NewSourceService(queries *db.Queries) *SourceService {
 // DocumentReference はJSONBから解析する内部型
 // page_number を追加（nullable: 旧データとの後方互換を保つ）
 type DocumentReference struct {
-	DocumentID     uuid.UUID `json:"document_id"`
-	DocumentName   string    `json:"document_name"`
-	ChunkIndex     int32     `json:"chunk_index"`
-	PageNumber     *int      `json:"page_number,omitempty"` // ← 追加
-	Score          float64   `json:"score"`
-	ContentPreview string    `json:"content_preview"`
+	DocumentID     uuid.UUID  `json:"document_id"`
+	DocumentName   string     `json:"document_name"`
+	ChunkIndex     int32      `json:"chunk_index"`
+	PageNumber     *int       `json:"page_number,omitempty"` // ← 追加
+	Score          float64    `json:"score"`
+	OriginalScore  *float64   `json:"original_score,omitempty"` // リランキング前のスコア（旧データにはない）
+	RerankScore    *float64   `json:"rerank_score,omitempty"`   // リランキングしていない場合は nil
+	ContentPreview string     `json:"content_preview"`
+	VersionID      *uuid.UUID `json:"version_id,omitempty"` // 引用したバージョン（旧データにはない＝バージョン1）
 }
 
 type DocumentMetadata struct {
@@ -67,12 +70,17 @@ func (s *SourceService) GetSources(
 		return nil, fmt.Errorf("failed to fetch document metadata: %w", err)
 	}
 
-	sources := s.groupByDocument(refs, metadata)
+	versions, err := s.fetchReferencedVersions(ctx, refs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch document versions: %w", err)
+	}
+
+	sources := s.groupByDocument(refs, metadata, versions)
 
 	return &api.SourcesResponse{
 		Sources:        sources,
-		TotalDocuments: int32(len(sources)),
-		TotalChunks:    int32(len(refs)),
+		TotalDocuments: len(sources),
+		TotalChunks:    len(refs),
 	}, nil
 }
 
@@ -130,79 +138,150 @@ func (s *SourceService) fetchDocumentMetadata(
 	return result, nil
 }
 
-// groupByDocument はドキュメントごとにチャンクをグルーピングし、
+// referencedVersion は document_refs が引用したバージョン
+type referencedVersion struct {
+	ID            uuid.UUID
+	VersionNumber int
+}
+
+// sourceKey はソースのグルーピング単位（同じドキュメントでもバージョンが違えば別のソース）
+type sourceKey struct {
+	DocumentID uuid.UUID
+	VersionID  uuid.UUID // version_id を持たない参照ではゼロ値
+}
+
+// fetchReferencedVersions は参照が引用したバージョンを sourceKey ごとに取得する。
+// version_id を持たない参照はバージョン管理より前のものなので、バージョン1として解決する
+func (s *SourceService) fetchReferencedVersions(
+	ctx context.Context,
+	refs []DocumentReference,
+) (map[sourceKey]referencedVersion, error) {
+	var versionIDs, legacyDocIDs []uuid.UUID
+	for _, ref := range refs {
+		if ref.VersionID != nil {
+			versionIDs = append(versionIDs, *ref.VersionID)
+		} else {
+			legacyDocIDs = append(legacyDocIDs, ref.DocumentID)
+		}
+	}
+
+	rows, err := s.queries.ListDocumentVersionsForRefs(ctx, db.ListDocumentVersionsForRefsParams{
+		VersionIds:  append([]uuid.UUID{}, versionIDs...),
+		DocumentIds: append([]uuid.UUID{}, legacyDocIDs...),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[sourceKey]referencedVersion)
+	for _, row := range rows {
+		version := referencedVersion{ID: row.ID, VersionNumber: int(row.VersionNumber)}
+		result[sourceKey{DocumentID: row.DocumentID, VersionID: row.ID}] = version
+		if row.VersionNumber == 1 {
+			result[sourceKey{DocumentID: row.DocumentID}] = version
+		}
+	}
+	return result, nil
+}
+
+// groupByDocument はドキュメント（のバージョン）ごとにチャンクをグルーピングし、
 // referenced_pages（参照されたページ番号の重複排除リスト）も構築する。
 func (s *SourceService) groupByDocument(
 	refs []DocumentReference,
 	metadata map[uuid.UUID]*DocumentMetadata,
+	versions map[sourceKey]referencedVersion,
 ) []api.SourceDocument {
 
-	grouped := make(map[uuid.UUID][]api.ChunkReference)
-	// ドキュメントごとにページ番号のセットを管理
-	referencedPages := make(map[uuid.UUID]map[int]struct{})
+	grouped := make(map[sourceKey][]api.SourceChunk)
+	// ソースごとにページ番号のセットを管理
+	referencedPages := make(map[sourceKey]map[int]struct{})
 
 	for _, ref := range refs {
+		key := sourceKey{DocumentID: ref.DocumentID}
+		if ref.VersionID != nil {
+			key.VersionID = *ref.VersionID
+		}
+
 		// page_number があればセットに追加
 		if ref.PageNumber != nil {
-			if referencedPages[ref.DocumentID] == nil {
-				referencedPages[ref.DocumentID] = make(map[int]struct{})
+			if referencedPages[key] == nil {
+				referencedPages[key] = make(map[int]struct{})
 			}
-			referencedPages[ref.DocumentID][*ref.PageNumber] = struct{}{}
+			referencedPages[key][*ref.PageNumber] = struct{}{}
 		}
 
-		chunk := api.ChunkReference{
-			ChunkIndex:     int32(ref.ChunkIndex),
+		chunk := api.SourceChunk{
+			ChunkIndex:     int(ref.ChunkIndex),
 			PageNumber:     ref.PageNumber, // ← 追加
 			ContentPreview: ref.ContentPreview,
-			RelevanceScore: &ref.Score,
+			Score:          float32(ref.Score),
 		}
-		grouped[ref.DocumentID] = append(grouped[ref.DocumentID], chunk)
+		grouped[key] = append(grouped[key], chunk)
 	}
 
 	var sources []api.SourceDocument
 
-	for docID, chunks := range grouped {
-		meta := metadata[docID]
+	for key, chunks := range grouped {
+		meta := metadata[key.DocumentID]
 		if meta == nil {
 			continue
 		}
 
 		// チャンクをスコア降順でソート
-		sort.Slice(chunks, func(i, j int) bool {
-			if chunks[i].RelevanceScore == nil {
-				return false
-			}
-			if chunks[j].RelevanceScore == nil {
-				return true
-			}
-			return *chunks[i].RelevanceScore > *chunks[j].RelevanceScore
+		sort.SliceStable(chunks, func(i, j int) bool {
+			return chunks[i].Score > chunks[j].Score
 		})
 
 		// referenced_pages をソート済みスライスに変換
-		pages := s.sortedPages(referencedPages[docID])
+		pages := s.sortedPages(referencedPages[key])
+
+		var versionID *uuid.UUID
+		var versionNumber *int
+		if version, ok := versions[key]; ok {
+			versionID = &version.ID
+			versionNumber = &version.VersionNumber
+		}
+
+		sizeBytes := meta.SizeBytes
+		createdAt := meta.CreatedAt
+		updatedAt := meta.UpdatedAt
 
 		sources = append(sources, api.SourceDocument{
-			DocumentId:      docID,
+			DocumentId:      key.DocumentID,
 			DocumentName:    meta.Name,
 			MimeType:        meta.MimeType,
-			SizeBytes:       meta.SizeBytes,
+			SizeBytes:       &sizeBytes,
 			ReferencedPages: pages, // ← 追加：フロントエンドで「P.2, P.5 を参照」と表示できる
-			ChunksUsed:      chunks,
-			CreatedAt:       meta.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:       meta.UpdatedAt.Format(time.RFC3339),
+			Chunks:          chunks,
+			CreatedAt:       &createdAt,
+			UpdatedAt:       &updatedAt,
+			VersionId:       versionID,
+			VersionNumber:   versionNumber,
 		})
 	}
 
+	// 同じドキュメントの複数バージョンは新しいバージョンを先にする
 	sort.Slice(sources, func(i, j int) bool {
-		return sources[i].DocumentName < sources[j].DocumentName
+		if sources[i].DocumentName != sources[j].DocumentName {
+			return sources[i].DocumentName < sources[j].DocumentName
+		}
+		return versionNumberOf(sources[i]) > versionNumberOf(sources[j])
 	})
 
 	return sources
 }
 
-// sortedPages はページ番号セットをソート済みスライスに変換するヘルパー
-func (s *SourceService) sortedPages(pageSet map[int]struct{}) []int {
-	if pageSet == nil {
+// versionNumberOf はソースのバージョン番号を返す（不明な場合は0）
+func versionNumberOf(source api.SourceDocument) int {
+	if source.VersionNumber == nil {
+		return 0
+	}
+	return *source.VersionNumber
+}
+
+// sortedPages はページ番号セットをソート済みスライスに変換するヘルパー（ページ番号がない場合は nil）
+func (s *SourceService) sortedPages(pageSet map[int]struct{}) *[]int {
+	if len(pageSet) == 0 {
 		return nil
 	}
 	pages := make([]int, 0, len(pageSet))
@@ -210,5 +289,5 @@ func (s *SourceService) sortedPages(pageSet map[int]struct{}) []int {
 		pages = append(pages, p)
 	}
 	sort.Ints(pages)
-	return pages
+	return &pages
 }
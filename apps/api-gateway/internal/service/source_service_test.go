@@ -0,0 +1,50 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestGroupByDocument_SplitsVersionsAndSortsChunks(t *testing.T) {
+	docID := uuid.New()
+	v1, v2 := uuid.New(), uuid.New()
+	page1, page3 := 1, 3
+
+	refs := []DocumentReference{
+		{DocumentID: docID, ChunkIndex: 0, PageNumber: &page3, Score: 0.4, VersionID: &v2},
+		{DocumentID: docID, ChunkIndex: 2, PageNumber: &page1, Score: 0.9, VersionID: &v2},
+		{DocumentID: docID, ChunkIndex: 1, Score: 0.7, VersionID: &v1},
+	}
+	metadata := map[uuid.UUID]*DocumentMetadata{
+		docID: {ID: docID, Name: "report.pdf", MimeType: "application/pdf", SizeBytes: 1024, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+	}
+	versions := map[sourceKey]referencedVersion{
+		{DocumentID: docID, VersionID: v1}: {ID: v1, VersionNumber: 1},
+		{DocumentID: docID, VersionID: v2}: {ID: v2, VersionNumber: 2},
+	}
+
+	sources := (&SourceService{}).groupByDocument(refs, metadata, versions)
+
+	// 同じドキュメントでもバージョンごとに別のソースにし、新しいバージョンを先にする
+	if len(sources) != 2 {
+		t.Fatalf("Expected 2 sources, got %+v", sources)
+	}
+	latest := sources[0]
+	if latest.VersionNumber == nil || *latest.VersionNumber != 2 {
+		t.Fatalf("Expected version 2 first, got %+v", latest)
+	}
+	if len(latest.Chunks) != 2 || latest.Chunks[0].ChunkIndex != 2 {
+		t.Errorf("Expected chunks sorted by score, got %+v", latest.Chunks)
+	}
+	if latest.ReferencedPages == nil || len(*latest.ReferencedPages) != 2 || (*latest.ReferencedPages)[0] != 1 {
+		t.Errorf("Expected sorted referenced pages [1 3], got %v", latest.ReferencedPages)
+	}
+	if latest.SizeBytes == nil || *latest.SizeBytes != 1024 {
+		t.Errorf("Expected size_bytes 1024, got %v", latest.SizeBytes)
+	}
+	if sources[1].ReferencedPages != nil {
+		t.Errorf("Expected no referenced pages without page numbers, got %v", *sources[1].ReferencedPages)
+	}
+}
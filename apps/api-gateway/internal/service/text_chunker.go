@@ -98,14 +98,14 @@ func (c *TextChunker) findSentenceBoundary(runes []rune, start, idealEnd int) in
 
 	// まず idealEnd から後ろを探す（できるだけ長くする）
 	for i := idealEnd; i < searchEnd; i++ {
-		if c.isSentenceEnd(runes, i) {
+		if isSentenceEnd(runes, i) {
 			return i + 1 // 句点の次の文字から
 		}
 	}
 
 	// 見つからなければ idealEnd から前を探す
 	for i := idealEnd - 1; i >= searchStart; i-- {
-		if c.isSentenceEnd(runes, i) {
+		if isSentenceEnd(runes, i) {
 			return i + 1
 		}
 	}
@@ -114,8 +114,8 @@ func (c *TextChunker) findSentenceBoundary(runes []rune, start, idealEnd int) in
 	return idealEnd
 }
 
-// isSentenceEnd は文末判定を行います
-func (c *TextChunker) isSentenceEnd(runes []rune, pos int) bool {
+// isSentenceEnd は文末判定を行います（Chunker の文の分割でも使う）
+func isSentenceEnd(runes []rune, pos int) bool {
 	if pos >= len(runes) {
 		return false
 	}
@@ -146,6 +146,41 @@ func (c *TextChunker) isSentenceEnd(runes []rune, pos int) bool {
 	return false
 }
 
+// ChunkRows は表の行を ChunkSize 文字以内のチャンクにまとめます（CSV / TSV 用）。
+// 行の途中では分割せず、各チャンクの先頭に header を付けます。
+// 1行だけで ChunkSize を超える場合は、その行だけで1チャンクにします。オーバーラップはしません。
+func (c *TextChunker) ChunkRows(header string, rows []string) []string {
+	chunks := []string{}
+	headerLen := len([]rune(header))
+
+	var current []string
+	size := headerLen
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		lines := current
+		if header != "" {
+			lines = append([]string{header}, current...)
+		}
+		chunks = append(chunks, strings.Join(lines, "\n"))
+		current = nil
+		size = headerLen
+	}
+
+	for _, row := range rows {
+		rowLen := len([]rune(row)) + 1 // 改行の分
+		if len(current) > 0 && size+rowLen > c.ChunkSize {
+			flush()
+		}
+		current = append(current, row)
+		size += rowLen
+	}
+	flush()
+
+	return chunks
+}
+
 // ChunkSimple はシンプルな固定文字数分割（文の境界を考慮しない）
 func (c *TextChunker) ChunkSimple(text string) []string {
 	chunks := []string{}
@@ -175,6 +210,11 @@ func (c *TextChunker) ChunkSimple(text string) []string {
 			chunks = append(chunks, chunk)
 		}
 
+		// 末尾まで分割したら終了（オーバーラップ分だけ戻ると同じ末尾を繰り返してしまう）
+		if end == textLen {
+			break
+		}
+
 		start = end - c.ChunkOverlap
 		if start <= 0 {
 			start = end
@@ -0,0 +1,407 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/client"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrChunkTooLong は、チャンクが embedding モデルの最大シーケンス長を超えていることを表します
+var ErrChunkTooLong = errors.New("chunk exceeds the embedding model's max sequence length")
+
+// Tokenizer は embedding モデルと同じ方法でトークン数を数えます。
+// CountTokens は、モデルに渡す入力（"passage: " の接頭辞と特殊トークンを含む）のトークン数を texts と同じ順序で返します
+type Tokenizer interface {
+	CountTokens(ctx context.Context, texts []string) ([]int, error)
+	// MaxTokens はモデルの最大シーケンス長（超えた分は embedding 時に切り捨てられる）
+	MaxTokens() int
+}
+
+// LocalTokenizer はプロセス内でトークン数を数えられる Tokenizer です（token のチャンク分割でサイズの計算に使う）
+type LocalTokenizer interface {
+	Tokenizer
+	// Count は text だけのトークン数（接頭辞・特殊トークンを含まない）
+	Count(text string) int
+}
+
+// TokenizerProvider はトークン数の数え方
+type TokenizerProvider string
+
+const (
+	TokenizerNone     TokenizerProvider = "none"      // 数えない（token の分割は推定値、長さも検査しない）
+	TokenizerAuto     TokenizerProvider = "auto"      // VocabPath のファイルがあれば local、なければ ai_worker
+	TokenizerLocal    TokenizerProvider = "local"     // 語彙ファイルを読み込み、Go で数える
+	TokenizerAIWorker TokenizerProvider = "ai_worker" // AI Worker のモデルのトークナイザで数える
+)
+
+// OversizeChunkPolicy は、モデルの最大シーケンス長を超えるチャンクの扱い
+type OversizeChunkPolicy string
+
+const (
+	OversizeChunkSplit  OversizeChunkPolicy = "split"  // 収まるまで分け直す
+	OversizeChunkReject OversizeChunkPolicy = "reject" // 処理を失敗させる
+)
+
+// TokenizerOptions はトークナイザの設定
+type TokenizerOptions struct {
+	Provider       TokenizerProvider
+	VocabPath      string // tokenizer.json、または sentencepiece の語彙ファイル（1行に piece と score をタブ区切り）
+	MaxTokens      int    // モデルの最大シーケンス長（multilingual-e5-large は 512）
+	DocumentPrefix string // AI Worker がドキュメントの先頭に付ける接頭辞
+}
+
+// withDefaults は未設定の項目にデフォルト値を入れた TokenizerOptions を返します
+func (o TokenizerOptions) withDefaults() TokenizerOptions {
+	if o.Provider == "" {
+		o.Provider = TokenizerAuto
+	}
+	if o.MaxTokens <= 0 {
+		o.MaxTokens = 512
+	}
+	if o.DocumentPrefix == "" {
+		o.DocumentPrefix = "passage: "
+	}
+	return o
+}
+
+// NewTokenizer は opts.Provider に応じた Tokenizer を作成します（provider=none の場合は nil）
+func NewTokenizer(opts TokenizerOptions, aiClient client.AIWorkerClient) (Tokenizer, error) {
+	opts = opts.withDefaults()
+
+	provider := opts.Provider
+	if provider == TokenizerAuto {
+		provider = TokenizerAIWorker
+		if opts.VocabPath != "" {
+			if _, err := os.Stat(opts.VocabPath); err == nil {
+				provider = TokenizerLocal
+			}
+		}
+		log.Printf("🔤 Tokenizer provider auto -> %s", provider)
+	}
+
+	switch provider {
+	case TokenizerNone:
+		return nil, nil
+	case TokenizerLocal:
+		return LoadUnigramTokenizer(opts.VocabPath, opts.MaxTokens, opts.DocumentPrefix)
+	case TokenizerAIWorker:
+		return &aiWorkerTokenizer{aiClient: aiClient, maxTokens: opts.MaxTokens}, nil
+	default:
+		return nil, fmt.Errorf("unknown tokenizer provider %q", opts.Provider)
+	}
+}
+
+// unigramSpecialPieces は本文とは照合しない特殊トークン
+var unigramSpecialPieces = map[string]bool{
+	"<s>": true, "</s>": true, "<pad>": true, "<unk>": true, "<mask>": true,
+}
+
+// unigramWordBoundary は sentencepiece が単語の先頭に付ける記号（U+2581）
+const unigramWordBoundary = "▁"
+
+// UnigramTokenizer は sentencepiece の Unigram モデル（multilingual-e5 / XLM-RoBERTa のトークナイザ）で
+// トークン数を数えます。NFKC で正規化し、空白で区切った単語ごとにスコアが最大になる分割を選ぶ
+type UnigramTokenizer struct {
+	pieces      map[string]float64
+	maxPieceLen int     // 最も長い piece のバイト数
+	unkScore    float64 // 語彙にない文字のスコア
+	overhead    int     // 接頭辞と特殊トークン（<s> と </s>）のトークン数
+	maxTokens   int
+}
+
+// LoadUnigramTokenizer は語彙ファイルから UnigramTokenizer を作成します。
+// .json は Hugging Face の tokenizer.json、それ以外は sentencepiece の語彙ファイルとして読み込みます
+func LoadUnigramTokenizer(path string, maxTokens int, prefix string) (*UnigramTokenizer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vocab file: %w", err)
+	}
+
+	var pieces map[string]float64
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		pieces, err = parseTokenizerJSON(data)
+	} else {
+		pieces, err = parseSentencePieceVocab(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse vocab file %s: %w", path, err)
+	}
+
+	t := newUnigramTokenizer(pieces, maxTokens, prefix)
+	log.Printf("🔤 Loaded unigram tokenizer: %d pieces from %s", len(pieces), path)
+	return t, nil
+}
+
+// newUnigramTokenizer は piece とスコア（対数確率）の語彙から UnigramTokenizer を作成します
+func newUnigramTokenizer(pieces map[string]float64, maxTokens int, prefix string) *UnigramTokenizer {
+	t := &UnigramTokenizer{pieces: pieces, maxTokens: maxTokens}
+
+	minScore := 0.0
+	for piece, score := range pieces {
+		t.maxPieceLen = max(t.maxPieceLen, len(piece))
+		minScore = min(minScore, score)
+	}
+	// sentencepiece と同じく、語彙で最も低いスコアよりさらに低くする
+	t.unkScore = minScore - 10
+
+	t.overhead = t.Count(prefix) + 2
+	return t
+}
+
+// parseTokenizerJSON は tokenizer.json の Unigram モデルの語彙を読み込みます
+func parseTokenizerJSON(data []byte) (map[string]float64, error) {
+	var file struct {
+		AddedTokens []struct {
+			Content string `json:"content"`
+			Special bool   `json:"special"`
+		} `json:"added_tokens"`
+		Model struct {
+			Type  string           `json:"type"`
+			Vocab [][2]interface{} `json:"vocab"`
+		} `json:"model"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Model.Type != "Unigram" {
+		return nil, fmt.Errorf("unsupported tokenizer model %q (only Unigram is supported)", file.Model.Type)
+	}
+
+	special := make(map[string]bool, len(file.AddedTokens))
+	for _, t := range file.AddedTokens {
+		if t.Special {
+			special[t.Content] = true
+		}
+	}
+
+	pieces := make(map[string]float64, len(file.Model.Vocab))
+	for i, entry := range file.Model.Vocab {
+		piece, ok1 := entry[0].(string)
+		score, ok2 := entry[1].(float64)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("invalid vocab entry %d", i)
+		}
+		if special[piece] || unigramSpecialPieces[piece] {
+			continue
+		}
+		pieces[piece] = score
+	}
+	return pieces, nil
+}
+
+// parseSentencePieceVocab は sentencepiece の語彙ファイル（spm_export_vocab の出力）を読み込みます
+func parseSentencePieceVocab(data []byte) (map[string]float64, error) {
+	pieces := make(map[string]float64)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		piece, rawScore, ok := strings.Cut(line, "\t")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected piece and score separated by a tab", i+1)
+		}
+		score, err := strconv.ParseFloat(rawScore, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid score: %w", i+1, err)
+		}
+		if unigramSpecialPieces[piece] {
+			continue
+		}
+		pieces[piece] = score
+	}
+	return pieces, nil
+}
+
+// Count は text だけのトークン数を返します（接頭辞・特殊トークンを含まない）
+func (t *UnigramTokenizer) Count(text string) int {
+	total := 0
+	for _, word := range strings.Fields(norm.NFKC.String(text)) {
+		total += t.countWord(unigramWordBoundary + word)
+	}
+	return total
+}
+
+// countWord は Viterbi で word のスコアが最大になる分割を求め、そのトークン数を返します
+func (t *UnigramTokenizer) countWord(word string) int {
+	n := len(word)
+	best := make([]float64, n+1)
+	tokens := make([]int, n+1)
+	for i := 1; i <= n; i++ {
+		best[i] = math.Inf(-1)
+	}
+
+	for start := 0; start < n; {
+		_, size := utf8.DecodeRuneInString(word[start:])
+		if !math.IsInf(best[start], -1) {
+			// 語彙にない文字は1文字 = 1トークン（<unk>）
+			if score := best[start] + t.unkScore; score > best[start+size] {
+				best[start+size] = score
+				tokens[start+size] = tokens[start] + 1
+			}
+			for end := start + size; end <= n && end-start <= t.maxPieceLen; end++ {
+				if end < n && !utf8.RuneStart(word[end]) {
+					continue
+				}
+				pieceScore, ok := t.pieces[word[start:end]]
+				if !ok {
+					continue
+				}
+				if score := best[start] + pieceScore; score > best[end] {
+					best[end] = score
+					tokens[end] = tokens[start] + 1
+				}
+			}
+		}
+		start += size
+	}
+	return tokens[n]
+}
+
+func (t *UnigramTokenizer) CountTokens(ctx context.Context, texts []string) ([]int, error) {
+	counts := make([]int, len(texts))
+	for i, text := range texts {
+		counts[i] = t.Count(text) + t.overhead
+	}
+	return counts, nil
+}
+
+func (t *UnigramTokenizer) MaxTokens() int {
+	return t.maxTokens
+}
+
+// aiWorkerTokenizer は AI Worker の embedding モデルのトークナイザで数えます
+type aiWorkerTokenizer struct {
+	aiClient  client.AIWorkerClient
+	maxTokens int
+}
+
+func (t *aiWorkerTokenizer) CountTokens(ctx context.Context, texts []string) ([]int, error) {
+	resp, err := t.aiClient.Tokenize(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tokens: %w", err)
+	}
+	return resp.Counts, nil
+}
+
+func (t *aiWorkerTokenizer) MaxTokens() int {
+	return t.maxTokens
+}
+
+// maxTokenFitRounds は、分け直してもまだ長すぎるチャンクをさらに分ける回数の上限
+const maxTokenFitRounds = 5
+
+// fitTokenLimit は、pageChunks（ページなどの区切りごとのチャンク。pageNumbers はそれぞれのページ番号）のうち
+// トークン数が tokenizer.MaxTokens() を超えるチャンクを分け直し、分け直したチャンクの数を返します。
+// policy が reject の場合は分け直さずに ErrChunkTooLong を返す
+func fitTokenLimit(
+	ctx context.Context,
+	tokenizer Tokenizer,
+	pageNumbers []int,
+	pageChunks [][]string,
+	policy OversizeChunkPolicy,
+) (int, error) {
+	// Step 1: 全チャンクをまとめて数える（最後の空文字列で接頭辞と特殊トークンの分を測る）
+	var texts []string
+	for _, chunks := range pageChunks {
+		texts = append(texts, chunks...)
+	}
+	counts, err := tokenizer.CountTokens(ctx, append(texts, ""))
+	if err != nil {
+		return 0, err
+	}
+	if len(counts) != len(texts)+1 {
+		return 0, fmt.Errorf("token count mismatch: got %d, want %d", len(counts), len(texts)+1)
+	}
+	overhead := counts[len(texts)]
+	limit := tokenizer.MaxTokens()
+	if overhead >= limit {
+		return 0, fmt.Errorf("%w: the document prefix alone has %d tokens (max %d)", ErrChunkTooLong, overhead, limit)
+	}
+
+	// Step 2: 長すぎるチャンクだけを分け直す
+	adjusted := 0
+	k := 0
+	for i, chunks := range pageChunks {
+		var fitted []string
+		for j, chunk := range chunks {
+			tokens := counts[k]
+			k++
+			if tokens <= limit {
+				fitted = append(fitted, chunk)
+				continue
+			}
+			if policy == OversizeChunkReject {
+				return 0, fmt.Errorf("%w: chunk %d on page %d has %d tokens (max %d)",
+					ErrChunkTooLong, j, pageNumbers[i], tokens, limit)
+			}
+
+			pieces, err := splitToTokenLimit(ctx, tokenizer, chunk, tokens, overhead, limit, 0)
+			if err != nil {
+				return 0, fmt.Errorf("failed to split chunk %d on page %d: %w", j, pageNumbers[i], err)
+			}
+			fitted = append(fitted, pieces...)
+			adjusted++
+		}
+		pageChunks[i] = fitted
+	}
+
+	if adjusted > 0 {
+		log.Printf("✂️ Split %d chunks exceeding the %d token limit", adjusted, limit)
+	}
+	return adjusted, nil
+}
+
+// splitToTokenLimit は tokens トークンの text を、それぞれ limit に収まる部分に分けます。
+// 文字数あたりのトークン数から目標の文字数を決めて区切りで分け、まだ長すぎる部分はさらに分ける
+func splitToTokenLimit(
+	ctx context.Context,
+	tokenizer Tokenizer,
+	text string,
+	tokens, overhead, limit, round int,
+) ([]string, error) {
+	if round >= maxTokenFitRounds {
+		return nil, fmt.Errorf("%w: still %d tokens after %d splits", ErrChunkTooLong, tokens, round)
+	}
+
+	// 数え方の誤差を見込んで 9 割を目標にする
+	runes := utf8.RuneCountInString(text)
+	size := max(1, runes*(limit-overhead)*9/(10*max(tokens-overhead, 1)))
+	pieces, err := newRecursiveChunker(size, 0, utf8.RuneCountInString).Chunk(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := tokenizer.CountTokens(ctx, pieces)
+	if err != nil {
+		return nil, err
+	}
+	if len(counts) != len(pieces) {
+		return nil, fmt.Errorf("token count mismatch: got %d, want %d", len(counts), len(pieces))
+	}
+
+	var result []string
+	for i, piece := range pieces {
+		if counts[i] <= limit {
+			result = append(result, piece)
+			continue
+		}
+		sub, err := splitToTokenLimit(ctx, tokenizer, piece, counts[i], overhead, limit, round+1)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, sub...)
+	}
+	return result, nil
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestUnigramTokenizer() *UnigramTokenizer {
+	return newUnigramTokenizer(map[string]float64{
+		"▁":         -2,
+		"▁token":    -4,
+		"▁tokenize": -5,
+		"r":         -3,
+		"s":         -3,
+		"▁日本":       -4,
+		"語":         -3,
+		"passage":   -6,
+		"▁passage":  -5,
+		":":         -2,
+	}, 16, "passage: ")
+}
+
+func TestUnigramTokenizer_Count(t *testing.T) {
+	tok := newTestUnigramTokenizer()
+
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"tokenize", 1},        // ▁tokenize
+		{"tokenizers", 3},      // ▁tokenize r s
+		{"token  tokens\n", 3}, // ▁token / ▁token s
+		{"日本語", 2},             // ▁日本 語
+		{"ｔｏｋｅｎ", 1},           // NFKC で半角になる
+		{"日本語x", 3},            // 語彙にない文字は1トークン
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := tok.Count(tt.text); got != tt.want {
+			t.Errorf("Count(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+
+	// 接頭辞（▁passage :）と <s> </s> の分が加わる
+	counts, _ := tok.CountTokens(context.Background(), []string{"tokenize", ""})
+	if counts[0] != 5 || counts[1] != 4 {
+		t.Errorf("Expected counts [5 4], got %v", counts)
+	}
+}
+
+func TestLoadUnigramTokenizer_TokenizerJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokenizer.json")
+	data := `{
+		"added_tokens": [{"id": 0, "content": "<s>", "special": true}, {"id": 1, "content": "<mask>", "special": true}],
+		"model": {"type": "Unigram", "unk_id": 2, "vocab": [["<s>", 0.0], ["<mask>", 0.0], ["<unk>", 0.0], ["▁", -2.0], ["▁ab", -1.5], ["a", -3.0], ["b", -3.0]]}
+	}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tok, err := LoadUnigramTokenizer(path, 512, "")
+	if err != nil {
+		t.Fatalf("LoadUnigramTokenizer failed: %v", err)
+	}
+	if _, ok := tok.pieces["<s>"]; ok {
+		t.Errorf("Expected special tokens to be excluded from the vocab")
+	}
+	if got := tok.Count("ab ba"); got != 4 {
+		t.Errorf("Expected 4 tokens (▁ab / ▁ b a), got %d", got)
+	}
+}
+
+func TestLoadUnigramTokenizer_RejectsBPE(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokenizer.json")
+	if err := os.WriteFile(path, []byte(`{"model": {"type": "BPE"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadUnigramTokenizer(path, 512, ""); err == nil {
+		t.Errorf("Expected an error for a non-Unigram tokenizer")
+	}
+}
+
+// wordTokenizer は単語数 + 2（特殊トークン）をトークン数として数えます
+type wordTokenizer struct {
+	maxTokens int
+}
+
+func (t wordTokenizer) CountTokens(ctx context.Context, texts []string) ([]int, error) {
+	counts := make([]int, len(texts))
+	for i, text := range texts {
+		counts[i] = len(strings.Fields(text)) + 2
+	}
+	return counts, nil
+}
+
+func (t wordTokenizer) MaxTokens() int {
+	return t.maxTokens
+}
+
+func TestFitTokenLimit_SplitsOversizeChunks(t *testing.T) {
+	long := strings.Repeat("one two three four five. ", 8)
+	pageChunks := [][]string{{"short chunk"}, {"before", long, "after"}}
+
+	adjusted, err := fitTokenLimit(context.Background(), wordTokenizer{maxTokens: 12}, []int{1, 2}, pageChunks, OversizeChunkSplit)
+	if err != nil {
+		t.Fatalf("fitTokenLimit failed: %v", err)
+	}
+
+	if adjusted != 1 {
+		t.Errorf("Expected 1 adjusted chunk, got %d", adjusted)
+	}
+	if len(pageChunks[0]) != 1 || pageChunks[1][0] != "before" || pageChunks[1][len(pageChunks[1])-1] != "after" {
+		t.Errorf("Expected the other chunks to be kept in order, got %q", pageChunks)
+	}
+
+	split := pageChunks[1][1 : len(pageChunks[1])-1]
+	if len(split) < 4 {
+		t.Errorf("Expected the long chunk to be split into at least 4 chunks, got %q", split)
+	}
+	words := 0
+	for _, chunk := range split {
+		n := len(strings.Fields(chunk))
+		if n+2 > 12 {
+			t.Errorf("Chunk %q still exceeds the limit", chunk)
+		}
+		words += n
+	}
+	if words != 40 {
+		t.Errorf("Expected all 40 words to be kept, got %d", words)
+	}
+}
+
+func TestFitTokenLimit_RejectPolicy(t *testing.T) {
+	pageChunks := [][]string{{strings.Repeat("word ", 20)}}
+
+	_, err := fitTokenLimit(context.Background(), wordTokenizer{maxTokens: 12}, []int{3}, pageChunks, OversizeChunkReject)
+	if !errors.Is(err, ErrChunkTooLong) {
+		t.Fatalf("Expected ErrChunkTooLong, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "page 3") {
+		t.Errorf("Expected the page number in the error, got %v", err)
+	}
+}
+
+func TestTokenChunker_UsesModelTokenizer(t *testing.T) {
+	tok := newTestUnigramTokenizer()
+	text := strings.Repeat("tokenizers ", 30)
+
+	chunker, _ := NewChunker(ChunkStrategyToken, 10, 0, nil, tok.Count)
+	chunks, err := chunker.Chunk(context.Background(), text)
+	if err != nil {
+		t.Fatalf("Chunk failed: %v", err)
+	}
+
+	// 1単語 = 3トークンなので、1チャンクに3単語まで
+	if len(chunks) != 10 {
+		t.Errorf("Expected 10 chunks, got %d: %q", len(chunks), chunks)
+	}
+	for i, chunk := range chunks {
+		if n := tok.Count(chunk); n > 10 {
+			t.Errorf("Chunk %d has %d tokens, exceeds chunk size", i, n)
+		}
+	}
+}
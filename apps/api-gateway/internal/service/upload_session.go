@@ -0,0 +1,447 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"time"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/storage"
+	"github.com/google/uuid"
+)
+
+// Resumable (tus-style) uploads: the client creates a session with the total size,
+// sends the file in order with PATCH requests and finalizes it. Every chunk is stored
+// as its own temporary object, so a failed PATCH only has to resend that chunk.
+// On completion the chunks are composed into the final object and go through the same
+// SHA256 dedup / createDocumentReference path as a single-request upload.
+
+// Upload session statuses (upload_sessions.status).
+// A session moves from uploading to completing before it is completed, so only one request
+// can compose its chunks; abort and the blob GC only act on sessions they moved out of uploading themselves.
+const (
+	UploadSessionStatusUploading  = "uploading"
+	UploadSessionStatusCompleting = "completing"
+	UploadSessionStatusCompleted  = "completed"
+	UploadSessionStatusAborted    = "aborted"
+)
+
+// MinUploadChunkSize is the minimum size of every chunk except the last (chunks are composed with S3 multipart copy)
+const MinUploadChunkSize = storage.MinComposePartSize
+
+var (
+	// ErrUploadSessionNotFound is returned when the session does not exist in the workspace
+	ErrUploadSessionNotFound = errors.New("upload session not found")
+	// ErrUploadSessionExpired is returned when a chunk arrives after the session expired
+	ErrUploadSessionExpired = errors.New("upload session expired")
+	// ErrUploadSessionConflict is returned when the session is not in a state that allows the operation
+	// (wrong offset, already completed or aborted, or not all bytes uploaded yet)
+	ErrUploadSessionConflict = errors.New("upload session conflict")
+	// ErrUploadChunkInvalid is returned for chunks that cannot be composed (too small or too many)
+	ErrUploadChunkInvalid = errors.New("invalid upload chunk")
+)
+
+// UploadSessionInfo represents a resumable upload session returned to clients
+type UploadSessionInfo struct {
+	ID         uuid.UUID
+	FileName   string
+	SizeBytes  int64
+	Offset     int64
+	MimeType   *string // detected from the first chunk
+	Status     string
+	DocumentID *uuid.UUID // set when completed
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+}
+
+// CreateUploadSessionRequest contains the parameters of a new upload session
+type CreateUploadSessionRequest struct {
+	FileName    string
+	SizeBytes   int64
+	MimeType    string // declared content type (optional)
+	DirectoryID *uuid.UUID
+	Tags        []string
+}
+
+// CreateUploadSession implements FileService.CreateUploadSession
+func (fs *FileServiceImpl) CreateUploadSession(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	req CreateUploadSessionRequest,
+) (*UploadSessionInfo, error) {
+	// Step 1: The total size is known up front, so the limit is checked before any bytes arrive
+	limit, err := fs.UploadSizeLimit(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if req.SizeBytes > limit {
+		return nil, fmt.Errorf("%w: limit is %d bytes", ErrUploadTooLarge, limit)
+	}
+
+	// Step 2: Create the session
+	dirID := uuid.NullUUID{}
+	if req.DirectoryID != nil {
+		dirID = uuid.NullUUID{UUID: *req.DirectoryID, Valid: true}
+	}
+	session, err := fs.queries.CreateUploadSession(ctx, db.CreateUploadSessionParams{
+		WorkspaceID:      workspaceID,
+		DirectoryID:      dirID,
+		FileName:         req.FileName,
+		DeclaredMimeType: sql.NullString{String: req.MimeType, Valid: req.MimeType != ""},
+		Tags:             req.Tags,
+		SizeBytes:        req.SizeBytes,
+		ExpiresAt:        time.Now().Add(fs.opts.UploadSessionTTL),
+		UploadMethod:     UploadMethodChunked,
+		PartKeys:         []string{},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload session: %w", err)
+	}
+
+	log.Printf("📁 Upload session created: id=%s, name=%s, size=%d", session.ID, session.FileName, session.SizeBytes)
+	return toUploadSessionInfo(session), nil
+}
+
+// GetUploadSession implements FileService.GetUploadSession
+func (fs *FileServiceImpl) GetUploadSession(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	uploadID uuid.UUID,
+) (*UploadSessionInfo, error) {
+	session, err := fs.getUploadSession(ctx, workspaceID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	return toUploadSessionInfo(session), nil
+}
+
+// AppendUploadChunk implements FileService.AppendUploadChunk
+func (fs *FileServiceImpl) AppendUploadChunk(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	uploadID uuid.UUID,
+	offset int64,
+	content io.Reader,
+) (*UploadSessionInfo, error) {
+	// Step 1: Validate the session state and offset
+	session, err := fs.getUploadSession(ctx, workspaceID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != UploadSessionStatusUploading {
+		return nil, fmt.Errorf("%w: session is %s", ErrUploadSessionConflict, session.Status)
+	}
+	if session.UploadMethod != UploadMethodChunked {
+		return nil, fmt.Errorf("%w: session uses %s upload", ErrUploadSessionConflict, session.UploadMethod)
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return nil, ErrUploadSessionExpired
+	}
+	if offset != session.UploadOffset {
+		return nil, fmt.Errorf("%w: offset is %d, got %d", ErrUploadSessionConflict, session.UploadOffset, offset)
+	}
+
+	// Step 2: Detect the MIME type from the first chunk
+	buffered := bufio.NewReaderSize(content, MIMESniffLen)
+	mimeType := sql.NullString{}
+	if offset == 0 {
+		settings, err := fs.workspaceSettings(ctx, workspaceID)
+		if err != nil {
+			return nil, err
+		}
+		head, err := buffered.Peek(MIMESniffLen)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return nil, fmt.Errorf("failed to read chunk: %w", err)
+		}
+		detected, err := checkUploadMIMEType(settings, head, session.FileName, session.DeclaredMimeType.String)
+		if err != nil {
+			return nil, err
+		}
+		mimeType = sql.NullString{String: detected, Valid: true}
+	}
+
+	// Step 3: Continue the SHA256 from the saved state
+	hasher, err := restoreUploadHash(session.HashState)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 4: Store the chunk as its own object (a partial chunk is discarded, the offset stays)
+	remaining := session.SizeBytes - session.UploadOffset
+	counter := &limitedCountReader{r: buffered, limit: remaining}
+	partKey := fmt.Sprintf("%s%s/%016d-%s", uploadTempPrefix, session.ID, offset, uuid.New())
+
+	err = fs.storageClient.PutObject(
+		ctx,
+		fs.storageBucket,
+		partKey,
+		io.TeeReader(counter, hasher),
+		-1,
+		storage.PutObjectOptions{
+			ContentType: mimeType.String,
+		},
+	)
+	if err != nil {
+		fs.removeObjectQuietly(ctx, partKey)
+		if counter.exceeded {
+			return nil, fmt.Errorf("%w: chunk goes past the declared size of %d bytes", ErrUploadTooLarge, session.SizeBytes)
+		}
+		return nil, fmt.Errorf("failed to store chunk: %w", err)
+	}
+
+	newOffset := offset + counter.n
+	switch {
+	case counter.n == 0:
+		fs.removeObjectQuietly(ctx, partKey)
+		return toUploadSessionInfo(session), nil
+	case newOffset < session.SizeBytes && counter.n < MinUploadChunkSize:
+		fs.removeObjectQuietly(ctx, partKey)
+		return nil, fmt.Errorf("%w: chunks except the last must be at least %d bytes", ErrUploadChunkInvalid, MinUploadChunkSize)
+	case newOffset < session.SizeBytes && len(session.PartKeys)+1 >= storage.MaxComposeSources:
+		fs.removeObjectQuietly(ctx, partKey)
+		return nil, fmt.Errorf("%w: too many chunks, use larger chunks", ErrUploadChunkInvalid)
+	}
+
+	// Step 5: Advance the offset (only if no concurrent PATCH did it first)
+	state, err := hasher.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		fs.removeObjectQuietly(ctx, partKey)
+		return nil, fmt.Errorf("failed to save hash state: %w", err)
+	}
+	rows, err := fs.queries.AdvanceUploadSession(ctx, db.AdvanceUploadSessionParams{
+		NewOffset:      newOffset,
+		PartKey:        partKey,
+		HashState:      state,
+		MimeType:       mimeType,
+		ID:             session.ID,
+		ExpectedOffset: offset,
+	})
+	if err != nil || rows == 0 {
+		fs.removeObjectQuietly(ctx, partKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update upload session: %w", err)
+		}
+		return nil, fmt.Errorf("%w: offset changed by a concurrent request", ErrUploadSessionConflict)
+	}
+
+	log.Printf("📤 Upload chunk stored: id=%s, offset=%d/%d", session.ID, newOffset, session.SizeBytes)
+
+	session.UploadOffset = newOffset
+	if mimeType.Valid {
+		session.MimeType = mimeType
+	}
+	return toUploadSessionInfo(session), nil
+}
+
+// CompleteUploadSession implements FileService.CompleteUploadSession
+func (fs *FileServiceImpl) CompleteUploadSession(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	uploadID uuid.UUID,
+) (*FileUploadResponse, error) {
+	// Step 1: Claim the session, so a concurrent complete or abort gets a conflict
+	// instead of composing or removing the same chunks
+	session, err := fs.getUploadSession(ctx, workspaceID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != UploadSessionStatusUploading {
+		return nil, fmt.Errorf("%w: session is %s", ErrUploadSessionConflict, session.Status)
+	}
+	if time.Now().After(session.ExpiresAt) {
+		// Expired sessions belong to the blob GC
+		return nil, ErrUploadSessionExpired
+	}
+	if err := fs.transitionUploadSession(ctx, session.ID, UploadSessionStatusUploading, UploadSessionStatusCompleting); err != nil {
+		return nil, err
+	}
+	completed := false
+	defer func() {
+		if completed {
+			return
+		}
+		// Hand the session back so the client can retry (a rejected presigned upload stays aborted)
+		if err := fs.transitionUploadSession(context.WithoutCancel(ctx), session.ID, UploadSessionStatusCompleting, UploadSessionStatusUploading); err != nil && !errors.Is(err, ErrUploadSessionConflict) {
+			log.Printf("⚠️  Failed to release upload session: id=%s, err=%v", session.ID, err)
+		}
+	}()
+
+	// Step 2: All bytes must be stored (presigned uploads are verified by reading the object back).
+	// The session is read again because a chunk may have been added before it was claimed
+	session, err = fs.getUploadSession(ctx, workspaceID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	var staged *StagedUpload
+	if session.UploadMethod == UploadMethodPresigned {
+		staged, err = fs.verifyPresignedUpload(ctx, session)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if session.UploadOffset != session.SizeBytes {
+			return nil, fmt.Errorf("%w: %d of %d bytes uploaded", ErrUploadSessionConflict, session.UploadOffset, session.SizeBytes)
+		}
+		hasher, err := restoreUploadHash(session.HashState)
+		if err != nil {
+			return nil, err
+		}
+		staged = &StagedUpload{
+			WorkspaceID: workspaceID,
+			FileName:    session.FileName,
+			MimeType:    session.MimeType.String,
+			SizeBytes:   session.SizeBytes,
+			SHA256Hash:  fmt.Sprintf("%x", hasher.Sum(nil)),
+			TempKeys:    session.PartKeys,
+		}
+	}
+
+	// Step 3: Same dedup / document creation path as a single-request upload
+	var directoryID *uuid.UUID
+	if session.DirectoryID.Valid {
+		directoryID = &session.DirectoryID.UUID
+	}
+	resp, err := fs.CompleteUpload(ctx, staged, directoryID, session.Tags)
+	if err != nil {
+		if session.UploadMethod == UploadMethodPresigned {
+			// A retry verifies the client's object again into a new copy
+			_ = fs.AbortUpload(ctx, staged)
+		}
+		return nil, err
+	}
+	completed = true
+
+	// Step 4: Mark the session completed
+	if err := fs.queries.CompleteUploadSession(ctx, db.CompleteUploadSessionParams{
+		ID:         session.ID,
+		DocumentID: uuid.NullUUID{UUID: resp.ID, Valid: true},
+	}); err != nil {
+		log.Printf("⚠️  Failed to mark upload session completed: id=%s, err=%v", session.ID, err)
+	}
+	if session.UploadMethod == UploadMethodPresigned {
+		// The verified copy has been stored; the client's object is no longer needed
+		_ = fs.AbortUpload(ctx, &StagedUpload{TempKeys: session.PartKeys})
+	}
+
+	log.Printf("✅ Upload session completed: id=%s, document_id=%s", session.ID, resp.ID)
+	return resp, nil
+}
+
+// AbortUploadSession implements FileService.AbortUploadSession
+func (fs *FileServiceImpl) AbortUploadSession(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	uploadID uuid.UUID,
+) error {
+	session, err := fs.getUploadSession(ctx, workspaceID, uploadID)
+	if err != nil {
+		return err
+	}
+	if session.Status == UploadSessionStatusAborted {
+		return nil
+	}
+	if session.Status != UploadSessionStatusUploading {
+		return fmt.Errorf("%w: session is %s", ErrUploadSessionConflict, session.Status)
+	}
+
+	// Mark the session aborted first, so no chunk can be added or composed while they are removed
+	if err := fs.transitionUploadSession(ctx, session.ID, UploadSessionStatusUploading, UploadSessionStatusAborted); err != nil {
+		// Losing the race to another abort is still a success
+		if current, getErr := fs.getUploadSession(ctx, workspaceID, uploadID); getErr == nil && current.Status == UploadSessionStatusAborted {
+			return nil
+		}
+		return err
+	}
+	session, err = fs.getUploadSession(ctx, workspaceID, uploadID)
+	if err != nil {
+		return err
+	}
+
+	if err := fs.AbortUpload(ctx, &StagedUpload{TempKeys: session.PartKeys}); err != nil {
+		// The chunk keys are kept so that the blob GC removes them once the session expires
+		log.Printf("⚠️  Failed to remove upload chunks: id=%s, err=%v", session.ID, err)
+		return nil
+	}
+	if err := fs.queries.AbortUploadSession(ctx, session.ID); err != nil {
+		return fmt.Errorf("failed to abort upload session: %w", err)
+	}
+
+	log.Printf("🗑️  Upload session aborted: id=%s", session.ID)
+	return nil
+}
+
+// transitionUploadSession moves the session from one status to another, returning
+// ErrUploadSessionConflict when a concurrent request changed the status first
+func (fs *FileServiceImpl) transitionUploadSession(ctx context.Context, id uuid.UUID, from, to string) error {
+	rows, err := fs.queries.TransitionUploadSession(ctx, db.TransitionUploadSessionParams{
+		ToStatus:   to,
+		ID:         id,
+		FromStatus: from,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update upload session: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: session is no longer %s", ErrUploadSessionConflict, from)
+	}
+	return nil
+}
+
+func (fs *FileServiceImpl) getUploadSession(ctx context.Context, workspaceID, uploadID uuid.UUID) (db.UploadSession, error) {
+	session, err := fs.queries.GetUploadSession(ctx, db.GetUploadSessionParams{
+		ID:          uploadID,
+		WorkspaceID: workspaceID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return session, ErrUploadSessionNotFound
+		}
+		return session, fmt.Errorf("failed to get upload session: %w", err)
+	}
+	return session, nil
+}
+
+// removeObjectQuietly removes a temporary object, ignoring errors (used for cleanup on failure)
+func (fs *FileServiceImpl) removeObjectQuietly(ctx context.Context, key string) {
+	_ = fs.storageClient.RemoveObject(context.WithoutCancel(ctx), fs.storageBucket, key)
+}
+
+// restoreUploadHash returns a SHA256 hasher that continues from a saved state (nil state starts fresh)
+func restoreUploadHash(state []byte) (hash.Hash, error) {
+	h := sha256.New()
+	if len(state) == 0 {
+		return h, nil
+	}
+	if err := h.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+		return nil, fmt.Errorf("failed to restore hash state: %w", err)
+	}
+	return h, nil
+}
+
+func toUploadSessionInfo(s db.UploadSession) *UploadSessionInfo {
+	info := &UploadSessionInfo{
+		ID:        s.ID,
+		FileName:  s.FileName,
+		SizeBytes: s.SizeBytes,
+		Offset:    s.UploadOffset,
+		Status:    s.Status,
+		ExpiresAt: s.ExpiresAt,
+		CreatedAt: s.CreatedAt,
+	}
+	if s.MimeType.Valid {
+		info.MimeType = &s.MimeType.String
+	}
+	if s.DocumentID.Valid {
+		info.DocumentID = &s.DocumentID.UUID
+	}
+	return info
+}
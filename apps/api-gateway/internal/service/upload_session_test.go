@@ -0,0 +1,266 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/google/uuid"
+)
+
+func TestRestoreUploadHash_ContinuesAcrossChunks(t *testing.T) {
+	data := []byte("チャンクをまたいで SHA256 を計算する。resumable upload test data")
+	want := fmt.Sprintf("%x", sha256.Sum256(data))
+
+	// PATCH ごとに状態を保存して、次の PATCH で復元する
+	var state []byte
+	for _, chunk := range [][]byte{data[:10], data[10:31], data[31:]} {
+		h, err := restoreUploadHash(state)
+		if err != nil {
+			t.Fatalf("restoreUploadHash failed: %v", err)
+		}
+		h.Write(chunk)
+		if state, err = h.(encoding.BinaryMarshaler).MarshalBinary(); err != nil {
+			t.Fatalf("MarshalBinary failed: %v", err)
+		}
+	}
+
+	h, err := restoreUploadHash(state)
+	if err != nil {
+		t.Fatalf("restoreUploadHash failed: %v", err)
+	}
+	if got := fmt.Sprintf("%x", h.Sum(nil)); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	if _, err := restoreUploadHash([]byte("broken")); err == nil {
+		t.Errorf("Expected error for a corrupt hash state")
+	}
+}
+
+// registerUploadSession は s を1行だけ持つ upload_sessions テーブルとして fakeDB に応答させます
+func registerUploadSession(f *fakeDB, s *db.UploadSession) {
+	nullable := func(v sql.NullString) driver.Value {
+		if !v.Valid {
+			return nil
+		}
+		return v.String
+	}
+	f.queries["GetUploadSession"] = func(args []driver.Value) (*fakeRows, error) {
+		if args[0] != s.ID.String() {
+			return &fakeRows{}, nil
+		}
+		var documentID driver.Value
+		if s.DocumentID.Valid {
+			documentID = s.DocumentID.UUID.String()
+		}
+		var hashState driver.Value
+		if s.HashState != nil {
+			hashState = s.HashState
+		}
+		return newFakeRow(
+			s.ID.String(), s.WorkspaceID.String(), nil, s.FileName, nullable(s.DeclaredMimeType), nullable(s.MimeType),
+			pqTextArray(s.Tags), s.SizeBytes, s.UploadOffset, pqTextArray(s.PartKeys), hashState, s.Status, documentID,
+			s.ExpiresAt, s.CreatedAt, s.UpdatedAt, s.UploadMethod, nullable(s.ExpectedSha256),
+		), nil
+	}
+	f.execs["AdvanceUploadSession"] = func(args []driver.Value) (int64, error) {
+		if args[4] != s.ID.String() || args[5] != s.UploadOffset || s.Status != UploadSessionStatusUploading {
+			return 0, nil
+		}
+		s.UploadOffset = args[0].(int64)
+		s.PartKeys = append(s.PartKeys, args[1].(string))
+		s.HashState = args[2].([]byte)
+		if args[3] != nil && !s.MimeType.Valid {
+			s.MimeType = sql.NullString{String: args[3].(string), Valid: true}
+		}
+		return 1, nil
+	}
+	f.execs["TransitionUploadSession"] = func(args []driver.Value) (int64, error) {
+		if args[1] != s.ID.String() || args[2] != s.Status {
+			return 0, nil
+		}
+		s.Status = args[0].(string)
+		return 1, nil
+	}
+	f.execs["CompleteUploadSession"] = func(args []driver.Value) (int64, error) {
+		s.Status, s.PartKeys, s.HashState = UploadSessionStatusCompleted, []string{}, nil
+		return 1, nil
+	}
+	f.execs["AbortUploadSession"] = func(args []driver.Value) (int64, error) {
+		s.Status, s.PartKeys, s.HashState = UploadSessionStatusAborted, []string{}, nil
+		return 1, nil
+	}
+}
+
+// holdUntilBoth は最初の2回の query を、両方が揃うまで待たせます
+// （2つのリクエストが同じ状態を読んでから書き込みを競うようにする）
+func holdUntilBoth(f *fakeDB, query string) {
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	var n atomic.Int32
+	f.before = func(name string) {
+		if name == query && n.Add(1) <= 2 {
+			arrived.Done()
+			arrived.Wait()
+		}
+	}
+}
+
+func newUploadTestSession(method string, data []byte) *db.UploadSession {
+	return &db.UploadSession{
+		ID:             uuid.New(),
+		WorkspaceID:    uuid.New(),
+		FileName:       "notes.txt",
+		SizeBytes:      int64(len(data)),
+		PartKeys:       []string{},
+		Status:         UploadSessionStatusUploading,
+		UploadMethod:   method,
+		ExpectedSha256: sql.NullString{String: fmt.Sprintf("%x", sha256.Sum256(data)), Valid: method == UploadMethodPresigned},
+		ExpiresAt:      time.Now().Add(time.Hour),
+	}
+}
+
+func TestAppendUploadChunk_OnlyOneConcurrentChunkAdvancesTheOffset(t *testing.T) {
+	fs, f, mem := newPresignedTestService(t)
+	data := []byte("同じ offset に2つの PATCH が同時に届く")
+	session := newUploadTestSession(UploadMethodChunked, data)
+	registerUploadSession(f, session)
+	holdUntilBoth(f, "AdvanceUploadSession")
+
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = fs.AppendUploadChunk(context.Background(), session.WorkspaceID, session.ID, 0, bytes.NewReader(data))
+		}()
+	}
+	wg.Wait()
+
+	conflicts := 0
+	for _, err := range errs {
+		switch {
+		case errors.Is(err, ErrUploadSessionConflict):
+			conflicts++
+		case err != nil:
+			t.Fatalf("AppendUploadChunk failed: %v", err)
+		}
+	}
+	if conflicts != 1 {
+		t.Fatalf("Expected exactly one request to lose the offset race, got %v", errs)
+	}
+	if session.UploadOffset != int64(len(data)) || len(session.PartKeys) != 1 {
+		t.Errorf("Expected one chunk at offset %d, got offset %d with parts %q", len(data), session.UploadOffset, session.PartKeys)
+	}
+	// 負けた方のチャンクは消す
+	if got := mem.keys("files"); !reflect.DeepEqual(got, session.PartKeys) {
+		t.Errorf("Expected only the stored chunk to remain, got %q (parts %q)", got, session.PartKeys)
+	}
+}
+
+func TestCompleteUploadSession_ClaimsTheSession(t *testing.T) {
+	fs, f, mem := newPresignedTestService(t)
+	data := []byte("完了リクエストが同時に2つ届く")
+	session := newUploadTestSession(UploadMethodPresigned, data)
+	objectKey := uploadTempPrefix + "presigned/" + uuid.New().String()
+	session.PartKeys = []string{objectKey}
+	mem.put("files", objectKey, data)
+	registerUploadSession(f, session)
+	holdUntilBoth(f, "TransitionUploadSession")
+
+	// 確保した方は、もう一方が確保を試みるまで検証で待つ。検証中（completing）の中止は受け付けない
+	var abortErr error
+	var statusDuringVerify string
+	fs.storageClient = &hookedStorage{memoryObjectStorage: mem, beforeStat: func() {
+		deadline := time.Now().Add(5 * time.Second)
+		for f.called("TransitionUploadSession") < 2 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		f.mu.Lock()
+		statusDuringVerify = session.Status
+		f.mu.Unlock()
+		abortErr = fs.AbortUploadSession(context.Background(), session.WorkspaceID, session.ID)
+	}}
+	// ファイル登録で失敗させ、セッションが uploading に戻ることを確かめる
+	f.queries["GetFileByHash"] = func(args []driver.Value) (*fakeRows, error) {
+		return nil, errors.New("connection reset")
+	}
+
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = fs.CompleteUploadSession(context.Background(), session.WorkspaceID, session.ID)
+		}()
+	}
+	wg.Wait()
+
+	conflicts := 0
+	for _, err := range errs {
+		if errors.Is(err, ErrUploadSessionConflict) {
+			conflicts++
+		}
+	}
+	if conflicts != 1 || f.called("TransitionUploadSession") != 3 {
+		t.Fatalf("Expected one request to claim the session and the other to get a conflict, got %v", errs)
+	}
+	if statusDuringVerify != UploadSessionStatusCompleting || !errors.Is(abortErr, ErrUploadSessionConflict) {
+		t.Errorf("Expected the session to be completing and abort to conflict, got %s and %v", statusDuringVerify, abortErr)
+	}
+	// 失敗したら再試行できるように戻す（クライアントのオブジェクトも残す）
+	if session.Status != UploadSessionStatusUploading {
+		t.Errorf("Expected the session to be released after the failure, got %s", session.Status)
+	}
+	if got := mem.keys("files"); !reflect.DeepEqual(got, []string{objectKey}) {
+		t.Errorf("Expected only the uploaded object to be kept for a retry (no verified copy), got %q", got)
+	}
+}
+
+func TestAbortUploadSession(t *testing.T) {
+	fs, f, mem := newPresignedTestService(t)
+	session := newUploadTestSession(UploadMethodChunked, make([]byte, 10))
+	session.PartKeys = []string{uploadTempPrefix + "a", uploadTempPrefix + "b"}
+	for _, key := range session.PartKeys {
+		mem.put("files", key, []byte("chunk"))
+	}
+	registerUploadSession(f, session)
+	ctx := context.Background()
+
+	if err := fs.AbortUploadSession(ctx, session.WorkspaceID, session.ID); err != nil {
+		t.Fatalf("AbortUploadSession failed: %v", err)
+	}
+	if session.Status != UploadSessionStatusAborted || len(session.PartKeys) != 0 || len(mem.keys("files")) != 0 {
+		t.Errorf("Expected the session to be aborted and its chunks removed, got %s with %q (objects %q)", session.Status, session.PartKeys, mem.keys("files"))
+	}
+
+	// 中止は冪等で、中止後は完了もチャンクの追加もできない
+	if err := fs.AbortUploadSession(ctx, session.WorkspaceID, session.ID); err != nil {
+		t.Errorf("Expected a second abort to succeed, got %v", err)
+	}
+	if _, err := fs.CompleteUploadSession(ctx, session.WorkspaceID, session.ID); !errors.Is(err, ErrUploadSessionConflict) {
+		t.Errorf("Expected completing an aborted session to conflict, got %v", err)
+	}
+	if _, err := fs.AppendUploadChunk(ctx, session.WorkspaceID, session.ID, 0, bytes.NewReader([]byte("x"))); !errors.Is(err, ErrUploadSessionConflict) {
+		t.Errorf("Expected appending to an aborted session to conflict, got %v", err)
+	}
+
+	session.Status = UploadSessionStatusCompleted
+	if err := fs.AbortUploadSession(ctx, session.WorkspaceID, session.ID); !errors.Is(err, ErrUploadSessionConflict) {
+		t.Errorf("Expected aborting a completed session to conflict, got %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/Martin4208/Nexus/apps/api-gateway/internal/db"
+	"github.com/google/uuid"
+)
+
+// CompleteVersionUpload implements FileService.CompleteVersionUpload
+func (fs *FileServiceImpl) CompleteVersionUpload(
+	ctx context.Context,
+	staged *StagedUpload,
+	documentID uuid.UUID,
+) (*DocumentVersionInfo, error) {
+	// Step 1: The document must exist in the workspace
+	if _, err := fs.queries.GetDocument(ctx, db.GetDocumentParams{
+		ID:          documentID,
+		WorkspaceID: staged.WorkspaceID,
+	}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrFileNotFound
+		}
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	// Step 2: Store the content (deduplicated by hash)
+	fileID, reused, err := fs.storeStagedUpload(ctx, staged)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 3: Add the version. It stays inactive until processed, so search keeps
+	// using the current version meanwhile
+	version, err := fs.queries.CreateDocumentVersion(ctx, db.CreateDocumentVersionParams{
+		DocumentID: documentID,
+		FileID:     fileID,
+		FileName:   staged.FileName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create document version: %w", err)
+	}
+	if reused {
+		_ = fs.AbortUpload(ctx, staged)
+	}
+
+	log.Printf("🆕 Document version created: document_id=%s, version=%d", documentID, version.VersionNumber)
+
+	// Step 4: Enqueue processing of the new version; like uploads, a failure here
+	// does not fail the request and the version can be activated later via rollback
+	if fs.jobQueue != nil {
+		_, err := fs.jobQueue.Enqueue(ctx, staged.WorkspaceID, documentID, ProcessOptions{
+			ChunkSize:       DefaultChunkSize,
+			ChunkOverlap:    DefaultChunkOverlap,
+			ParentChunkSize: DefaultParentChunkSize,
+			VersionID:       &version.ID,
+		})
+		if err != nil {
+			log.Printf("⚠️ Failed to enqueue processing for version %d of document %s: %v",
+				version.VersionNumber, documentID, err)
+		}
+	}
+
+	return &DocumentVersionInfo{
+		ID:            version.ID,
+		DocumentID:    documentID,
+		VersionNumber: int(version.VersionNumber),
+		FileID:        fileID,
+		FileName:      version.FileName,
+		MimeType:      staged.MimeType,
+		SizeBytes:     staged.SizeBytes,
+		SHA256Hash:    staged.SHA256Hash,
+		CreatedAt:     version.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,333 @@
+package storage
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// HNSWParams は HNSW インデックスのパラメータ
+type HNSWParams struct {
+	M              int `json:"m"`               // 1ノードあたりの最大リンク数（レイヤー0はその2倍）
+	EfConstruction int `json:"ef_construction"` // 追加時に探索する候補数（大きいほど精度が上がり、追加が遅くなる）
+	EfSearch       int `json:"ef_search"`       // 検索時に探索する候補数（大きいほど精度が上がり、検索が遅くなる）
+}
+
+// withDefaults は未設定の項目にデフォルト値を入れた HNSWParams を返します
+func (p HNSWParams) withDefaults() HNSWParams {
+	if p.M <= 0 {
+		p.M = 16
+	}
+	if p.EfConstruction <= 0 {
+		p.EfConstruction = 200
+	}
+	if p.EfSearch <= 0 {
+		p.EfSearch = 64
+	}
+	return p
+}
+
+// hnswIndex は1種類のベクトル（名前付きベクトルなら名前ごと）の HNSW グラフです。
+// ノード番号はコレクションのポイントの位置と同じで、ベクトル本体は vector で参照します。
+// 削除されたポイントはグラフに残したまま（探索の経路としては使う）、結果から除外します。
+type hnswIndex struct {
+	params    HNSWParams
+	distance  Distance
+	levelMult float64
+	rng       *rand.Rand
+
+	entry    int32       // 最上位レイヤーの入口ノード（空の場合は -1）
+	maxLevel int         // entry のレベル
+	levels   []int32     // ノードごとのレベル（このベクトルを持たないノードは -1）
+	links    [][][]int32 // ノード -> レイヤー -> 隣接ノード
+
+	vector func(node int32) []float32
+}
+
+func newHNSWIndex(params HNSWParams, distance Distance, vector func(node int32) []float32) *hnswIndex {
+	params = params.withDefaults()
+	return &hnswIndex{
+		params:    params,
+		distance:  distance,
+		levelMult: 1 / math.Log(float64(params.M)),
+		rng:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		entry:     -1,
+		vector:    vector,
+	}
+}
+
+// hnswCandidate は探索中のノードとクエリからの距離
+type hnswCandidate struct {
+	node int32
+	dist float32
+}
+
+// minHeap は距離の近い順に取り出すヒープ
+type minHeap []hnswCandidate
+
+func (h minHeap) Len() int            { return len(h) }
+func (h minHeap) Less(i, j int) bool  { return h[i].dist < h[j].dist }
+func (h minHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x interface{}) { *h = append(*h, x.(hnswCandidate)) }
+func (h *minHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// maxHeap は距離の遠い順に取り出すヒープ（結果の上位 ef 件を保持する）
+type maxHeap struct{ minHeap }
+
+func (h maxHeap) Less(i, j int) bool { return h.minHeap[i].dist > h.minHeap[j].dist }
+
+// vectorDistance は HNSW 内部で使う距離（小さいほど近い）を計算します。
+// Cosine は保存時にベクトルを正規化しているため内積で計算できる
+func vectorDistance(distance Distance, a, b []float32) float32 {
+	switch distance {
+	case DistanceEuclidean:
+		var sum float32
+		for i := range a {
+			d := a[i] - b[i]
+			sum += d * d
+		}
+		return sum
+	case DistanceDot:
+		return -dotFloat32(a, b)
+	default:
+		return 1 - dotFloat32(a, b)
+	}
+}
+
+// distanceScore は内部の距離を Qdrant と同じ意味のスコアに変換します
+func distanceScore(distance Distance, d float32) float64 {
+	switch distance {
+	case DistanceEuclidean:
+		return math.Sqrt(float64(d))
+	case DistanceDot:
+		return float64(-d)
+	default:
+		return float64(1 - d)
+	}
+}
+
+func dotFloat32(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// normalizeFloat32 はベクトルを長さ1に正規化します（ゼロベクトルはそのまま）
+func normalizeFloat32(v []float32) {
+	norm := float32(math.Sqrt(float64(dotFloat32(v, v))))
+	if norm == 0 {
+		return
+	}
+	for i := range v {
+		v[i] /= norm
+	}
+}
+
+func (h *hnswIndex) dist(q []float32, node int32) float32 {
+	return vectorDistance(h.distance, q, h.vector(node))
+}
+
+// grow はノード番号 node までのスライスを確保します
+func (h *hnswIndex) grow(node int32) {
+	for int32(len(h.levels)) <= node {
+		h.levels = append(h.levels, -1)
+		h.links = append(h.links, nil)
+	}
+}
+
+func (h *hnswIndex) maxLinks(level int) int {
+	if level == 0 {
+		return h.params.M * 2
+	}
+	return h.params.M
+}
+
+func (h *hnswIndex) randomLevel() int {
+	return int(math.Floor(-math.Log(1-h.rng.Float64()) * h.levelMult))
+}
+
+// insert はノードをグラフに追加します
+func (h *hnswIndex) insert(node int32) {
+	h.grow(node)
+	level := h.randomLevel()
+	h.levels[node] = int32(level)
+	h.links[node] = make([][]int32, level+1)
+
+	if h.entry < 0 {
+		h.entry = node
+		h.maxLevel = level
+		return
+	}
+
+	q := h.vector(node)
+	ep := []hnswCandidate{{node: h.entry, dist: h.dist(q, h.entry)}}
+
+	// Step 1: 追加するレベルより上のレイヤーは最も近い1点をたどるだけ
+	for l := h.maxLevel; l > level; l-- {
+		ep = h.searchLayer(q, ep, 1, l, nil)
+	}
+
+	// Step 2: 追加するレベル以下の各レイヤーで近傍を選んで双方向にリンクする
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		candidates := h.searchLayer(q, ep, h.params.EfConstruction, l, nil)
+		neighbors := h.selectNeighbors(candidates, h.maxLinks(l))
+
+		h.links[node][l] = make([]int32, len(neighbors))
+		for i, n := range neighbors {
+			h.links[node][l][i] = n.node
+			h.connect(n.node, node, l)
+		}
+		ep = candidates
+	}
+
+	if level > h.maxLevel {
+		h.entry = node
+		h.maxLevel = level
+	}
+}
+
+// connect は from のレイヤー level に to へのリンクを追加し、上限を超えたら選び直します
+func (h *hnswIndex) connect(from, to int32, level int) {
+	links := append(h.links[from][level], to)
+	if len(links) <= h.maxLinks(level) {
+		h.links[from][level] = links
+		return
+	}
+
+	q := h.vector(from)
+	candidates := make([]hnswCandidate, len(links))
+	for i, n := range links {
+		candidates[i] = hnswCandidate{node: n, dist: h.dist(q, n)}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].dist < candidates[j].dist })
+
+	selected := h.selectNeighbors(candidates, h.maxLinks(level))
+	pruned := make([]int32, len(selected))
+	for i, c := range selected {
+		pruned[i] = c.node
+	}
+	h.links[from][level] = pruned
+}
+
+// selectNeighbors は距離順の候補から、互いに近すぎないものを優先して最大 m 件選びます（論文のヒューリスティック）。
+// 足りない場合は残りの候補を近い順に補います。
+func (h *hnswIndex) selectNeighbors(candidates []hnswCandidate, m int) []hnswCandidate {
+	if len(candidates) <= m {
+		return candidates
+	}
+
+	selected := make([]hnswCandidate, 0, m)
+	var skipped []hnswCandidate
+	for _, c := range candidates {
+		if len(selected) >= m {
+			break
+		}
+		good := true
+		cv := h.vector(c.node)
+		for _, s := range selected {
+			if vectorDistance(h.distance, cv, h.vector(s.node)) < c.dist {
+				good = false
+				break
+			}
+		}
+		if good {
+			selected = append(selected, c)
+		} else {
+			skipped = append(skipped, c)
+		}
+	}
+
+	for _, c := range skipped {
+		if len(selected) >= m {
+			break
+		}
+		selected = append(selected, c)
+	}
+	return selected
+}
+
+// searchLayer はレイヤー level で q に近いノードを最大 ef 件、近い順に返します。
+// allow が nil でない場合は allow を満たすノードだけを結果に入れます（探索の経路には全ノードを使う）。
+func (h *hnswIndex) searchLayer(
+	q []float32,
+	entryPoints []hnswCandidate,
+	ef int,
+	level int,
+	allow func(node int32) bool,
+) []hnswCandidate {
+	visited := make(map[int32]struct{}, ef*4)
+	candidates := &minHeap{}
+	results := &maxHeap{}
+
+	for _, e := range entryPoints {
+		visited[e.node] = struct{}{}
+		heap.Push(candidates, e)
+		if allow == nil || allow(e.node) {
+			heap.Push(results, e)
+		}
+	}
+	for results.Len() > ef {
+		heap.Pop(results)
+	}
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(hnswCandidate)
+		if results.Len() >= ef && c.dist > results.minHeap[0].dist {
+			break
+		}
+
+		if level >= len(h.links[c.node]) {
+			continue
+		}
+		for _, n := range h.links[c.node][level] {
+			if _, ok := visited[n]; ok {
+				continue
+			}
+			visited[n] = struct{}{}
+
+			d := h.dist(q, n)
+			if results.Len() < ef || d < results.minHeap[0].dist {
+				heap.Push(candidates, hnswCandidate{node: n, dist: d})
+				if allow == nil || allow(n) {
+					heap.Push(results, hnswCandidate{node: n, dist: d})
+					if results.Len() > ef {
+						heap.Pop(results)
+					}
+				}
+			}
+		}
+	}
+
+	sorted := make([]hnswCandidate, results.Len())
+	for i := len(sorted) - 1; i >= 0; i-- {
+		sorted[i] = heap.Pop(results).(hnswCandidate)
+	}
+	return sorted
+}
+
+// search は q に近いノードを最大 k 件、近い順に返します（allow は searchLayer と同じ）
+func (h *hnswIndex) search(q []float32, k, ef int, allow func(node int32) bool) []hnswCandidate {
+	if h.entry < 0 {
+		return nil
+	}
+
+	ep := []hnswCandidate{{node: h.entry, dist: h.dist(q, h.entry)}}
+	for l := h.maxLevel; l > 0; l-- {
+		ep = h.searchLayer(q, ep, 1, l, nil)
+	}
+
+	results := h.searchLayer(q, ep, max(ef, k), 0, allow)
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
@@ -0,0 +1,411 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+// ローカルのベクトルストアは、コレクションごとのディレクトリに次のファイルを置きます。
+//   - collection.json: ベクトルの設定と HNSW のパラメータ
+//   - snapshot.bin: ある時点の全ポイントと HNSW グラフ（チェックポイント時に丸ごと書き直す）
+//   - points.wal: スナップショット以降の変更の追記ログ（起動時にスナップショットに再適用する）
+//
+// スナップショットと WAL は同じ世代番号を持ち、世代が一致する WAL だけを再適用します。
+// チェックポイント中に落ちた場合は、古い世代の WAL（内容はスナップショットに含まれている）を無視できます。
+
+const (
+	collectionMetaFile = "collection.json"
+	snapshotFile       = "snapshot.bin"
+	walFile            = "points.wal"
+
+	snapshotMagic   = "NXVS"
+	walMagic        = "NXWL"
+	localFileFormat = 1
+)
+
+// WAL のレコード種別
+const (
+	walOpUpsert     byte = 1
+	walOpDelete     byte = 2
+	walOpSetPayload byte = 3
+)
+
+var errCorruptRecord = errors.New("corrupt wal record")
+
+// localCollectionMeta は collection.json の内容
+type localCollectionMeta struct {
+	Vectors map[string]VectorParams `json:"vectors"` // 名前なしのベクトルはキー ""
+	HNSW    HNSWParams              `json:"hnsw"`
+}
+
+// binWriter はリトルエンディアンで書き込み、最初のエラーを保持します
+type binWriter struct {
+	w   io.Writer
+	err error
+	buf [8]byte
+}
+
+func (b *binWriter) write(p []byte) {
+	if b.err == nil {
+		_, b.err = b.w.Write(p)
+	}
+}
+
+func (b *binWriter) u8(v byte) { b.write([]byte{v}) }
+
+func (b *binWriter) u16(v uint16) {
+	binary.LittleEndian.PutUint16(b.buf[:2], v)
+	b.write(b.buf[:2])
+}
+
+func (b *binWriter) u32(v uint32) {
+	binary.LittleEndian.PutUint32(b.buf[:4], v)
+	b.write(b.buf[:4])
+}
+
+func (b *binWriter) u64(v uint64) {
+	binary.LittleEndian.PutUint64(b.buf[:8], v)
+	b.write(b.buf[:8])
+}
+
+func (b *binWriter) str(s string) {
+	b.u16(uint16(len(s)))
+	b.write([]byte(s))
+}
+
+func (b *binWriter) bytes(p []byte) {
+	b.u32(uint32(len(p)))
+	b.write(p)
+}
+
+func (b *binWriter) floats(v []float32) {
+	b.u32(uint32(len(v)))
+	buf := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(f))
+	}
+	b.write(buf)
+}
+
+// binReader は binWriter で書いた値を読み込み、最初のエラーを保持します
+type binReader struct {
+	r   io.Reader
+	err error
+	buf [8]byte
+}
+
+func (b *binReader) read(p []byte) {
+	if b.err == nil {
+		_, b.err = io.ReadFull(b.r, p)
+	}
+}
+
+func (b *binReader) u8() byte {
+	b.read(b.buf[:1])
+	return b.buf[0]
+}
+
+func (b *binReader) u16() uint16 {
+	b.read(b.buf[:2])
+	return binary.LittleEndian.Uint16(b.buf[:2])
+}
+
+func (b *binReader) u32() uint32 {
+	b.read(b.buf[:4])
+	return binary.LittleEndian.Uint32(b.buf[:4])
+}
+
+func (b *binReader) u64() uint64 {
+	b.read(b.buf[:8])
+	return binary.LittleEndian.Uint64(b.buf[:8])
+}
+
+func (b *binReader) str() string {
+	p := make([]byte, b.u16())
+	b.read(p)
+	return string(p)
+}
+
+func (b *binReader) bytes() []byte {
+	n := b.u32()
+	if b.err != nil {
+		return nil
+	}
+	p := make([]byte, n)
+	b.read(p)
+	return p
+}
+
+func (b *binReader) floats() []float32 {
+	n := b.u32()
+	if b.err != nil {
+		return nil
+	}
+	buf := make([]byte, 4*n)
+	b.read(buf)
+	v := make([]float32, n)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return v
+}
+
+// writePoint はポイントを書き込みます（スナップショットと WAL の upsert で共通）
+func writePoint(w *binWriter, p *localPoint) error {
+	payload, err := json.Marshal(p.payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	w.str(p.id)
+	w.u16(uint16(len(p.vectors)))
+	for name, v := range p.vectors {
+		w.str(name)
+		w.floats(v)
+	}
+	w.bytes(payload)
+	return nil
+}
+
+func readPoint(r *binReader) (*localPoint, error) {
+	p := &localPoint{id: r.str()}
+	n := int(r.u16())
+	p.vectors = make(map[string][]float32, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		name := r.str()
+		p.vectors[name] = r.floats()
+	}
+	payload := r.bytes()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if err := json.Unmarshal(payload, &p.payload); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+	return p, nil
+}
+
+// writeSnapshot は全ポイントと HNSW グラフを書き込みます
+func writeSnapshot(out io.Writer, c *localCollection) error {
+	bw := bufio.NewWriter(out)
+	w := &binWriter{w: bw}
+
+	w.write([]byte(snapshotMagic))
+	w.u32(localFileFormat)
+	w.u64(c.gen)
+
+	w.u32(uint32(len(c.points)))
+	for _, p := range c.points {
+		if p.deleted {
+			w.u8(1)
+		} else {
+			w.u8(0)
+		}
+		if err := writePoint(w, p); err != nil {
+			return err
+		}
+	}
+
+	w.u16(uint16(len(c.indexes)))
+	for name, idx := range c.indexes {
+		w.str(name)
+		w.u32(uint32(idx.entry))
+		w.u32(uint32(idx.maxLevel))
+		w.u32(uint32(len(idx.levels)))
+		for node, level := range idx.levels {
+			w.u32(uint32(level))
+			for l := 0; l <= int(level); l++ {
+				links := idx.links[node][l]
+				w.u16(uint16(len(links)))
+				for _, n := range links {
+					w.u32(uint32(n))
+				}
+			}
+		}
+	}
+
+	if w.err != nil {
+		return w.err
+	}
+	return bw.Flush()
+}
+
+// readSnapshot はスナップショットを c に読み込みます（c.vectors と c.hnsw は設定済みであること）
+func readSnapshot(in io.Reader, c *localCollection) error {
+	r := &binReader{r: bufio.NewReader(in)}
+
+	magic := make([]byte, len(snapshotMagic))
+	r.read(magic)
+	if r.err == nil && string(magic) != snapshotMagic {
+		return fmt.Errorf("invalid snapshot file")
+	}
+	if version := r.u32(); r.err == nil && version != localFileFormat {
+		return fmt.Errorf("unsupported snapshot format %d", version)
+	}
+	c.gen = r.u64()
+
+	n := int(r.u32())
+	c.points = make([]*localPoint, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		deleted := r.u8() == 1
+		p, err := readPoint(r)
+		if err != nil {
+			return fmt.Errorf("failed to read point %d: %w", i, err)
+		}
+		p.deleted = deleted
+		c.addPoint(p)
+	}
+
+	nIndexes := int(r.u16())
+	for i := 0; i < nIndexes && r.err == nil; i++ {
+		name := r.str()
+		idx, ok := c.indexes[name]
+		if !ok {
+			return fmt.Errorf("snapshot has unknown vector %q", name)
+		}
+		idx.entry = int32(r.u32())
+		idx.maxLevel = int(int32(r.u32()))
+
+		nodes := int(r.u32())
+		idx.levels = make([]int32, nodes)
+		idx.links = make([][][]int32, nodes)
+		for node := 0; node < nodes && r.err == nil; node++ {
+			level := int32(r.u32())
+			idx.levels[node] = level
+			if level < 0 {
+				continue
+			}
+			idx.links[node] = make([][]int32, level+1)
+			for l := 0; l <= int(level) && r.err == nil; l++ {
+				links := make([]int32, r.u16())
+				for j := range links {
+					links[j] = int32(r.u32())
+				}
+				idx.links[node][l] = links
+			}
+		}
+	}
+
+	if r.err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", r.err)
+	}
+	return nil
+}
+
+// walRecord は WAL の1レコード
+type walRecord struct {
+	op      byte
+	point   *localPoint            // walOpUpsert
+	id      string                 // walOpDelete / walOpSetPayload
+	payload map[string]interface{} // walOpSetPayload
+}
+
+// encodeWALRecord はレコードを [長さ][CRC32][本体] の形にします
+func encodeWALRecord(rec walRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	w := &binWriter{w: &buf}
+
+	w.u8(rec.op)
+	switch rec.op {
+	case walOpUpsert:
+		if err := writePoint(w, rec.point); err != nil {
+			return nil, err
+		}
+	case walOpDelete:
+		w.str(rec.id)
+	case walOpSetPayload:
+		payload, err := json.Marshal(rec.payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		w.str(rec.id)
+		w.bytes(payload)
+	}
+	if w.err != nil {
+		return nil, w.err
+	}
+
+	body := buf.Bytes()
+	frame := make([]byte, 8, 8+len(body))
+	binary.LittleEndian.PutUint32(frame[0:4], uint32(len(body)))
+	binary.LittleEndian.PutUint32(frame[4:8], crc32.ChecksumIEEE(body))
+	return append(frame, body...), nil
+}
+
+// readWALRecord は1レコードを読み込みます。
+// ファイル末尾は io.EOF、書き込み途中で途切れたレコードや CRC 不一致は errCorruptRecord を返します
+func readWALRecord(r io.Reader) (walRecord, int64, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.EOF {
+			return walRecord{}, 0, io.EOF
+		}
+		return walRecord{}, 0, errCorruptRecord
+	}
+
+	body := make([]byte, binary.LittleEndian.Uint32(header[0:4]))
+	if _, err := io.ReadFull(r, body); err != nil {
+		return walRecord{}, 0, errCorruptRecord
+	}
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(header[4:8]) {
+		return walRecord{}, 0, errCorruptRecord
+	}
+
+	br := &binReader{r: bytes.NewReader(body)}
+	rec := walRecord{op: br.u8()}
+	switch rec.op {
+	case walOpUpsert:
+		p, err := readPoint(br)
+		if err != nil {
+			return walRecord{}, 0, errCorruptRecord
+		}
+		rec.point = p
+	case walOpDelete:
+		rec.id = br.str()
+	case walOpSetPayload:
+		rec.id = br.str()
+		if err := json.Unmarshal(br.bytes(), &rec.payload); err != nil {
+			return walRecord{}, 0, errCorruptRecord
+		}
+	default:
+		return walRecord{}, 0, errCorruptRecord
+	}
+	if br.err != nil {
+		return walRecord{}, 0, errCorruptRecord
+	}
+
+	return rec, int64(len(header) + len(body)), nil
+}
+
+// writeWALHeader は WAL の先頭（マジックと世代番号）を書き込みます
+func writeWALHeader(w io.Writer, gen uint64) error {
+	bw := &binWriter{w: w}
+	bw.write([]byte(walMagic))
+	bw.u32(localFileFormat)
+	bw.u64(gen)
+	return bw.err
+}
+
+// walHeaderSize は WAL の先頭のバイト数
+const walHeaderSize = len(walMagic) + 4 + 8
+
+func readWALHeader(r io.Reader) (uint64, error) {
+	br := &binReader{r: r}
+	magic := make([]byte, len(walMagic))
+	br.read(magic)
+	version := br.u32()
+	gen := br.u64()
+	if br.err != nil {
+		return 0, br.err
+	}
+	if string(magic) != walMagic || version != localFileFormat {
+		return 0, fmt.Errorf("invalid wal file")
+	}
+	return gen, nil
+}
@@ -0,0 +1,832 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+const (
+	// localCheckpointOps は WAL にこの件数のレコードがたまったらスナップショットを書き直す
+	localCheckpointOps = 10000
+	// localBruteForceLimit 以下の候補数なら HNSW を使わず全件と比較する（正確で十分速い）
+	localBruteForceLimit = 2000
+)
+
+// localCollectionName はディレクトリ名として安全なコレクション名
+var localCollectionName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// LocalVectorStore はローカルディスクにベクトルを保存する、Qdrant サーバー不要の VectorStore の実装です。
+// コレクションごとに dir/<collection>/ にファイルを置き（local_vector_file.go）、
+// 検索には HNSW グラフを使います（候補が少ない場合やフィルタで絞り込まれる場合は全件比較）。
+// コレクションは最初にアクセスしたときにメモリへ読み込みます。
+type LocalVectorStore struct {
+	dir    string
+	params HNSWParams
+
+	mu          sync.Mutex
+	collections map[string]*localCollection
+}
+
+// localPoint はメモリ上のポイント（Cosine のベクトルは正規化済み）
+type localPoint struct {
+	id      string
+	vectors map[string][]float32
+	payload map[string]interface{}
+	deleted bool // 置き換え・削除されたポイント（次のチェックポイントまでグラフに残る）
+}
+
+// localCollection は1コレクション分のポイントと HNSW インデックス
+type localCollection struct {
+	mu      sync.RWMutex
+	dir     string
+	vectors map[string]VectorParams
+	hnsw    HNSWParams
+
+	points  []*localPoint
+	byID    map[string]int32 // 削除されていないポイントの位置
+	deleted int
+	indexes map[string]*hnswIndex
+
+	gen    uint64
+	wal    *os.File
+	walOps int
+}
+
+// NewLocalVectorStore は dir 以下にコレクションを保存する LocalVectorStore を作成します
+func NewLocalVectorStore(dir string, params HNSWParams) (*LocalVectorStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create vector store directory: %w", err)
+	}
+	return &LocalVectorStore{
+		dir:         dir,
+		params:      params.withDefaults(),
+		collections: make(map[string]*localCollection),
+	}, nil
+}
+
+func (s *LocalVectorStore) collectionDir(name string) (string, error) {
+	if !localCollectionName.MatchString(name) {
+		return "", fmt.Errorf("invalid collection name %q", name)
+	}
+	return filepath.Join(s.dir, name), nil
+}
+
+// collection は読み込み済みのコレクションを返し、未読み込みならディスクから読み込みます
+func (s *LocalVectorStore) collection(name string) (*localCollection, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, ok := s.collections[name]; ok {
+		return c, nil
+	}
+
+	dir, err := s.collectionDir(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(dir, collectionMetaFile)); errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrCollectionNotFound, name)
+	}
+
+	c, err := openLocalCollection(dir, s.params.EfSearch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open collection %s: %w", name, err)
+	}
+	s.collections[name] = c
+	return c, nil
+}
+
+// CollectionExists はコレクションが存在するかを返します
+func (s *LocalVectorStore) CollectionExists(ctx context.Context, collection string) (bool, error) {
+	dir, err := s.collectionDir(collection)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(filepath.Join(dir, collectionMetaFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check collection: %w", err)
+	}
+	return true, nil
+}
+
+// CreateCollection はコレクションのディレクトリと空の WAL を作成します
+func (s *LocalVectorStore) CreateCollection(ctx context.Context, collection string, config CollectionConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir, err := s.collectionDir(collection)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(dir, collectionMetaFile)); err == nil {
+		return fmt.Errorf("%w: %s", ErrCollectionExists, collection)
+	}
+
+	for name, p := range config.vectorParams() {
+		if p.Size <= 0 {
+			return fmt.Errorf("vector %q: size must be greater than 0", name)
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create collection directory: %w", err)
+	}
+
+	meta, err := json.MarshalIndent(localCollectionMeta{
+		Vectors: config.vectorParams(),
+		HNSW:    HNSWParams{M: s.params.M, EfConstruction: s.params.EfConstruction},
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal collection config: %w", err)
+	}
+
+	// collection.json はコレクションが存在する目印なので、WAL を用意してから最後に置く
+	if err := writeFileAtomic(filepath.Join(dir, walFile), func(w io.Writer) error {
+		return writeWALHeader(w, 0)
+	}); err != nil {
+		return fmt.Errorf("failed to create wal: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(dir, collectionMetaFile), func(w io.Writer) error {
+		_, err := w.Write(meta)
+		return err
+	}); err != nil {
+		return fmt.Errorf("failed to write collection config: %w", err)
+	}
+	return nil
+}
+
+// EnsureCollection はコレクションが存在しなければ作成します
+func (s *LocalVectorStore) EnsureCollection(ctx context.Context, collection string, config CollectionConfig) error {
+	err := s.CreateCollection(ctx, collection, config)
+	if err != nil && !errors.Is(err, ErrCollectionExists) {
+		return err
+	}
+	return nil
+}
+
+// GetCollection はコレクションのベクトル設定を返します
+func (s *LocalVectorStore) GetCollection(ctx context.Context, collection string) (CollectionConfig, error) {
+	c, err := s.collection(collection)
+	if err != nil {
+		return CollectionConfig{}, err
+	}
+	return collectionConfigFromParams(c.vectors), nil
+}
+
+// ListCollections はディスク上のコレクション名を名前順に返します
+func (s *LocalVectorStore) ListCollections(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(s.dir, e.Name(), collectionMetaFile)); err == nil {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// DeleteCollection はコレクションのディレクトリを削除します
+func (s *LocalVectorStore) DeleteCollection(ctx context.Context, collection string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir, err := s.collectionDir(collection)
+	if err != nil {
+		return err
+	}
+
+	if c, ok := s.collections[collection]; ok {
+		c.mu.Lock()
+		c.close()
+		c.mu.Unlock()
+		delete(s.collections, collection)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to delete collection: %w", err)
+	}
+	return nil
+}
+
+// Close は読み込み済みの全コレクションのスナップショットを書き、ファイルを閉じます
+func (s *LocalVectorStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var firstErr error
+	for name, c := range s.collections {
+		c.mu.Lock()
+		if c.walOps > 0 {
+			if err := c.checkpoint(); err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("failed to checkpoint collection %s: %w", name, err)
+			}
+		}
+		c.close()
+		c.mu.Unlock()
+	}
+	s.collections = make(map[string]*localCollection)
+	return firstErr
+}
+
+// Upsert はポイントを WAL に書き込んでから、メモリ上のポイントとグラフに反映します
+func (s *LocalVectorStore) Upsert(ctx context.Context, collection string, points []Point) error {
+	c, err := s.collection(collection)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 1件でも不正なら何も保存しない
+	records := make([]walRecord, len(points))
+	for i, p := range points {
+		lp, err := c.newPoint(p)
+		if err != nil {
+			return err
+		}
+		records[i] = walRecord{op: walOpUpsert, point: lp}
+	}
+
+	return c.commit(records)
+}
+
+// Search は類似検索を行います。
+// フィルタで候補が localBruteForceLimit 件以下に絞られる場合やコレクションが小さい場合は全件比較、
+// それ以外は HNSW でフィルタを満たすノードだけを結果に入れながら探索します。
+func (s *LocalVectorStore) Search(ctx context.Context, collection string, req SearchRequest) ([]SearchResult, error) {
+	c, err := s.collection(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	params, ok := c.vectors[req.VectorName]
+	if !ok {
+		return nil, fmt.Errorf("unknown vector name %q", req.VectorName)
+	}
+	if len(req.Vector) != params.Size {
+		return nil, fmt.Errorf("query vector dimension %d does not match collection dimension %d", len(req.Vector), params.Size)
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+
+	q := toFloat32(req.Vector)
+	if params.Distance == DistanceCosine {
+		normalizeFloat32(q)
+	}
+
+	allow := func(node int32) bool {
+		p := c.points[node]
+		if p.deleted {
+			return false
+		}
+		if _, ok := p.vectors[req.VectorName]; !ok {
+			return false
+		}
+		return matchFilter(req.Filter, p.id, p.payload)
+	}
+
+	live := len(c.points) - c.deleted
+	var candidates []hnswCandidate
+
+	switch {
+	case live <= localBruteForceLimit:
+		candidates = c.bruteForce(q, req.VectorName, params.Distance, limit, allow)
+	case req.Filter != nil:
+		matched := 0
+		for node := range c.points {
+			if allow(int32(node)) {
+				matched++
+			}
+		}
+		if matched <= localBruteForceLimit {
+			candidates = c.bruteForce(q, req.VectorName, params.Distance, limit, allow)
+			break
+		}
+		// 絞り込まれる割合に応じて ef を広げる
+		ef := max(c.hnsw.EfSearch, limit) * live / matched
+		candidates = c.indexes[req.VectorName].search(q, limit, min(ef, live), allow)
+	default:
+		candidates = c.indexes[req.VectorName].search(q, limit, c.hnsw.EfSearch, allow)
+	}
+
+	results := make([]SearchResult, 0, len(candidates))
+	for _, cand := range candidates {
+		score := distanceScore(params.Distance, cand.dist)
+		if req.ScoreThreshold != nil {
+			if params.Distance == DistanceEuclidean && score > *req.ScoreThreshold {
+				continue
+			}
+			if params.Distance != DistanceEuclidean && score < *req.ScoreThreshold {
+				continue
+			}
+		}
+
+		p := c.points[cand.node]
+		result := SearchResult{ID: p.id, Score: score, Payload: p.payload}
+		if req.WithVector {
+			result.Vector = toFloat64(p.vectors[req.VectorName])
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Scroll は filter に一致するポイントをID順に返します
+func (s *LocalVectorStore) Scroll(ctx context.Context, collection string, req ScrollRequest) (*ScrollResponse, error) {
+	c, err := s.collection(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var matched []*localPoint
+	for _, node := range c.byID {
+		p := c.points[node]
+		if p.id >= req.Offset && matchFilter(req.Filter, p.id, p.payload) {
+			matched = append(matched, p)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].id < matched[j].id })
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+
+	resp := &ScrollResponse{}
+	if len(matched) > limit {
+		resp.NextOffset = matched[limit].id
+		matched = matched[:limit]
+	}
+
+	resp.Points = make([]Record, len(matched))
+	for i, p := range matched {
+		record := Record{ID: p.id, Payload: p.payload}
+		if req.WithVector {
+			if v, ok := p.vectors[""]; ok {
+				record.Vector = toFloat64(v)
+			} else {
+				record.NamedVectors = make(map[string][]float64, len(p.vectors))
+				for name, v := range p.vectors {
+					record.NamedVectors[name] = toFloat64(v)
+				}
+			}
+		}
+		resp.Points[i] = record
+	}
+	return resp, nil
+}
+
+// Count は filter に一致するポイント数を返します
+func (s *LocalVectorStore) Count(ctx context.Context, collection string, filter *Filter) (int, error) {
+	c, err := s.collection(collection)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	count := 0
+	for _, node := range c.byID {
+		p := c.points[node]
+		if matchFilter(filter, p.id, p.payload) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// DeleteByFilter は filter に一致するポイントを削除します
+func (s *LocalVectorStore) DeleteByFilter(ctx context.Context, collection string, filter *Filter) error {
+	if filter == nil {
+		return fmt.Errorf("filter is required to delete points")
+	}
+
+	c, err := s.collection(collection)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var records []walRecord
+	for _, node := range c.byID {
+		p := c.points[node]
+		if matchFilter(filter, p.id, p.payload) {
+			records = append(records, walRecord{op: walOpDelete, id: p.id})
+		}
+	}
+	return c.commit(records)
+}
+
+// UpdatePayloads は指定したキーだけ payload を上書きします（存在しないポイントは無視）
+func (s *LocalVectorStore) UpdatePayloads(ctx context.Context, collection string, updates []PayloadUpdate) error {
+	c, err := s.collection(collection)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var records []walRecord
+	for _, u := range updates {
+		if _, ok := c.byID[u.PointID]; !ok {
+			continue
+		}
+		payload, err := copyPayload(u.Payload)
+		if err != nil {
+			return fmt.Errorf("point %s: %w", u.PointID, err)
+		}
+		records = append(records, walRecord{op: walOpSetPayload, id: u.PointID, payload: payload})
+	}
+	return c.commit(records)
+}
+
+// openLocalCollection は collection.json・スナップショット・WAL からコレクションを復元します
+func openLocalCollection(dir string, efSearch int) (*localCollection, error) {
+	data, err := os.ReadFile(filepath.Join(dir, collectionMetaFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read collection config: %w", err)
+	}
+	var meta localCollectionMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse collection config: %w", err)
+	}
+	meta.HNSW.EfSearch = efSearch
+
+	c := newLocalCollection(dir, meta.Vectors, meta.HNSW.withDefaults())
+
+	// Step 1: スナップショットがあれば読み込む
+	if f, err := os.Open(filepath.Join(dir, snapshotFile)); err == nil {
+		err = readSnapshot(f, c)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+
+	// Step 2: 同じ世代の WAL を再適用する
+	if err := c.replayWAL(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func newLocalCollection(dir string, vectors map[string]VectorParams, params HNSWParams) *localCollection {
+	c := &localCollection{
+		dir:     dir,
+		vectors: vectors,
+		hnsw:    params,
+		byID:    make(map[string]int32),
+		indexes: make(map[string]*hnswIndex, len(vectors)),
+	}
+	for name, p := range vectors {
+		name := name
+		c.indexes[name] = newHNSWIndex(params, p.Distance, func(node int32) []float32 {
+			return c.points[node].vectors[name]
+		})
+	}
+	return c
+}
+
+// replayWAL は WAL のレコードを再適用し、追記用に開きます。
+// 世代の違う WAL は作り直し、末尾の壊れたレコード（書き込み途中で落ちたもの）は切り捨てます。
+func (c *localCollection) replayWAL() error {
+	path := filepath.Join(c.dir, walFile)
+
+	f, err := os.OpenFile(path, os.O_RDWR, 0o644)
+	if errors.Is(err, os.ErrNotExist) {
+		return c.resetWAL()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open wal: %w", err)
+	}
+
+	gen, err := readWALHeader(f)
+	if err != nil || gen != c.gen {
+		f.Close()
+		return c.resetWAL()
+	}
+
+	r := bufio.NewReader(f)
+	offset := int64(walHeaderSize)
+	for {
+		rec, n, err := readWALRecord(r)
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, errCorruptRecord) {
+			log.Printf("⚠️  Truncating corrupt wal record in %s at offset %d", path, offset)
+			if err := f.Truncate(offset); err != nil {
+				f.Close()
+				return fmt.Errorf("failed to truncate wal: %w", err)
+			}
+			break
+		}
+		c.apply(rec)
+		c.walOps++
+		offset += n
+	}
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to seek wal: %w", err)
+	}
+	c.wal = f
+	return nil
+}
+
+// resetWAL は現在の世代の空の WAL を作成して開きます
+func (c *localCollection) resetWAL() error {
+	path := filepath.Join(c.dir, walFile)
+	if err := writeFileAtomic(path, func(w io.Writer) error {
+		return writeWALHeader(w, c.gen)
+	}); err != nil {
+		return fmt.Errorf("failed to create wal: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open wal: %w", err)
+	}
+	c.wal = f
+	c.walOps = 0
+	return nil
+}
+
+// newPoint は Point を検証して localPoint に変換します（Cosine のベクトルは正規化する）
+func (c *localCollection) newPoint(p Point) (*localPoint, error) {
+	if p.ID == "" {
+		return nil, fmt.Errorf("point id is required")
+	}
+
+	vectors := p.NamedVectors
+	if len(vectors) == 0 {
+		vectors = map[string][]float64{"": p.Vector}
+	}
+
+	converted := make(map[string][]float32, len(vectors))
+	for name, v := range vectors {
+		params, ok := c.vectors[name]
+		if !ok {
+			return nil, fmt.Errorf("point %s: unknown vector name %q", p.ID, name)
+		}
+		if len(v) != params.Size {
+			return nil, fmt.Errorf("point %s: vector dimension %d does not match collection dimension %d", p.ID, len(v), params.Size)
+		}
+		f := toFloat32(v)
+		if params.Distance == DistanceCosine {
+			normalizeFloat32(f)
+		}
+		converted[name] = f
+	}
+
+	payload, err := copyPayload(p.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("point %s: %w", p.ID, err)
+	}
+	return &localPoint{id: p.ID, vectors: converted, payload: payload}, nil
+}
+
+// commit はレコードを WAL に書いて fsync し、メモリに反映します。
+// WAL が十分に大きくなったらチェックポイントを作ります（失敗しても WAL に残っているためログのみ）。
+func (c *localCollection) commit(records []walRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var buf []byte
+	for _, rec := range records {
+		encoded, err := encodeWALRecord(rec)
+		if err != nil {
+			return err
+		}
+		buf = append(buf, encoded...)
+	}
+
+	if _, err := c.wal.Write(buf); err != nil {
+		return fmt.Errorf("failed to write wal: %w", err)
+	}
+	if err := c.wal.Sync(); err != nil {
+		return fmt.Errorf("failed to sync wal: %w", err)
+	}
+
+	for _, rec := range records {
+		c.apply(rec)
+	}
+	c.walOps += len(records)
+
+	if c.walOps >= localCheckpointOps {
+		if err := c.checkpoint(); err != nil {
+			log.Printf("⚠️  Vector store checkpoint failed for %s: %v", c.dir, err)
+		}
+	}
+	return nil
+}
+
+// apply はレコードをメモリ上のポイントとグラフに反映します
+func (c *localCollection) apply(rec walRecord) {
+	switch rec.op {
+	case walOpUpsert:
+		c.remove(rec.point.id)
+		node := c.addPoint(rec.point)
+		for name := range rec.point.vectors {
+			c.indexes[name].insert(node)
+		}
+	case walOpDelete:
+		c.remove(rec.id)
+	case walOpSetPayload:
+		node, ok := c.byID[rec.id]
+		if !ok {
+			return
+		}
+		p := c.points[node]
+		// 検索結果として返した map を書き換えないよう、新しい map を作る
+		merged := make(map[string]interface{}, len(p.payload)+len(rec.payload))
+		for k, v := range p.payload {
+			merged[k] = v
+		}
+		for k, v := range rec.payload {
+			merged[k] = v
+		}
+		p.payload = merged
+	}
+}
+
+// addPoint はポイントを末尾に追加し、ノード番号を返します（グラフへの追加は呼び出し側）
+func (c *localCollection) addPoint(p *localPoint) int32 {
+	node := int32(len(c.points))
+	c.points = append(c.points, p)
+	if p.deleted {
+		c.deleted++
+	} else {
+		c.byID[p.id] = node
+	}
+	return node
+}
+
+// remove はポイントを削除済みにします（グラフには次の再構築まで残る）
+func (c *localCollection) remove(id string) {
+	node, ok := c.byID[id]
+	if !ok {
+		return
+	}
+	c.points[node].deleted = true
+	delete(c.byID, id)
+	c.deleted++
+}
+
+// bruteForce は allow を満たす全ポイントと比較し、近い順に最大 limit 件を返します
+func (c *localCollection) bruteForce(
+	q []float32,
+	vectorName string,
+	distance Distance,
+	limit int,
+	allow func(node int32) bool,
+) []hnswCandidate {
+	var candidates []hnswCandidate
+	for node, p := range c.points {
+		if !allow(int32(node)) {
+			continue
+		}
+		candidates = append(candidates, hnswCandidate{
+			node: int32(node),
+			dist: vectorDistance(distance, q, p.vectors[vectorName]),
+		})
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].dist < candidates[j].dist })
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates
+}
+
+// checkpoint は現在の状態を次の世代のスナップショットに書き、WAL を空にします。
+// 削除済みのポイントが全体の 1/3 を超えていたら、生きているポイントだけでグラフを作り直します。
+func (c *localCollection) checkpoint() error {
+	if c.deleted > 0 && c.deleted*3 > len(c.points) {
+		c.rebuild()
+	}
+
+	c.gen++
+	if err := writeFileAtomic(filepath.Join(c.dir, snapshotFile), func(w io.Writer) error {
+		return writeSnapshot(w, c)
+	}); err != nil {
+		c.gen--
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	// スナップショットが置き換わった時点で古い WAL の内容は不要になる
+	if c.wal != nil {
+		c.wal.Close()
+		c.wal = nil
+	}
+	return c.resetWAL()
+}
+
+// rebuild は削除済みのポイントを除いてグラフを作り直します
+func (c *localCollection) rebuild() {
+	live := make([]*localPoint, 0, len(c.byID))
+	for _, p := range c.points {
+		if !p.deleted {
+			live = append(live, p)
+		}
+	}
+
+	rebuilt := newLocalCollection(c.dir, c.vectors, c.hnsw)
+	for _, p := range live {
+		node := rebuilt.addPoint(p)
+		for name := range p.vectors {
+			rebuilt.indexes[name].insert(node)
+		}
+	}
+
+	c.points = rebuilt.points
+	c.byID = rebuilt.byID
+	c.deleted = 0
+	for name, idx := range rebuilt.indexes {
+		// vector 関数は c.points を参照させる
+		idx.vector = c.indexes[name].vector
+		c.indexes[name] = idx
+	}
+}
+
+func (c *localCollection) close() {
+	if c.wal != nil {
+		c.wal.Close()
+		c.wal = nil
+	}
+}
+
+// writeFileAtomic は一時ファイルに書いて fsync してから path に置き換えます
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func toFloat32(v []float64) []float32 {
+	f := make([]float32, len(v))
+	for i, x := range v {
+		f[i] = float32(x)
+	}
+	return f
+}
+
+func toFloat64(v []float32) []float64 {
+	f := make([]float64, len(v))
+	for i, x := range v {
+		f[i] = float64(x)
+	}
+	return f
+}
@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func randomVector(rng *rand.Rand, dim int) []float64 {
+	v := make([]float64, dim)
+	for i := range v {
+		v[i] = rng.NormFloat64()
+	}
+	return v
+}
+
+// newTestLocalStore は dim 次元のランダムなポイントを n 件入れたコレクション "test" を作成します
+func newTestLocalStore(t *testing.T, dir string, n, dim int) (*LocalVectorStore, [][]float64) {
+	t.Helper()
+
+	store, err := NewLocalVectorStore(dir, HNSWParams{})
+	if err != nil {
+		t.Fatalf("NewLocalVectorStore failed: %v", err)
+	}
+	ctx := context.Background()
+	if err := store.EnsureCollection(ctx, "test", CollectionConfig{Size: dim}); err != nil {
+		t.Fatalf("EnsureCollection failed: %v", err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	vectors := make([][]float64, n)
+	points := make([]Point, n)
+	for i := range points {
+		vectors[i] = randomVector(rng, dim)
+		points[i] = Point{
+			ID:      fmt.Sprintf("p%05d", i),
+			Vector:  vectors[i],
+			Payload: map[string]interface{}{"document_id": fmt.Sprintf("doc-%d", i%10), "chunk_index": i},
+		}
+	}
+	if err := store.Upsert(ctx, "test", points); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	return store, vectors
+}
+
+func TestLocalVectorStore_HNSWRecall(t *testing.T) {
+	// 全件比較に切り替わらない件数で HNSW の結果をメモリ実装（全件比較）と比べる
+	const n, dim, k = 3000, 16, 10
+	store, vectors := newTestLocalStore(t, t.TempDir(), n, dim)
+	defer store.Close()
+
+	exact := NewMemoryVectorStore()
+	ctx := context.Background()
+	exact.CreateCollection(ctx, "test", CollectionConfig{Size: dim})
+	points := make([]Point, n)
+	for i, v := range vectors {
+		points[i] = Point{ID: fmt.Sprintf("p%05d", i), Vector: v}
+	}
+	exact.Upsert(ctx, "test", points)
+
+	rng := rand.New(rand.NewSource(2))
+	hits, total := 0, 0
+	for q := 0; q < 20; q++ {
+		query := randomVector(rng, dim)
+		got, err := store.Search(ctx, "test", SearchRequest{Vector: query, Limit: k})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		want, _ := exact.Search(ctx, "test", SearchRequest{Vector: query, Limit: k})
+
+		found := make(map[string]bool, len(got))
+		for _, r := range got {
+			found[r.ID] = true
+		}
+		for _, r := range want {
+			if found[r.ID] {
+				hits++
+			}
+			total++
+		}
+	}
+
+	if recall := float64(hits) / float64(total); recall < 0.9 {
+		t.Errorf("Expected recall@%d >= 0.9, got %.2f", k, recall)
+	}
+
+	// 保存済みのベクトルで検索すると自分自身が最上位になる
+	got, err := store.Search(ctx, "test", SearchRequest{Vector: vectors[42], Limit: 1})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "p00042" || got[0].Score < 0.999 {
+		t.Errorf("Expected p00042 with score 1, got %+v", got)
+	}
+}
+
+func TestLocalVectorStore_FilteredSearch(t *testing.T) {
+	store, vectors := newTestLocalStore(t, t.TempDir(), 3000, 8)
+	defer store.Close()
+
+	results, err := store.Search(context.Background(), "test", SearchRequest{
+		Vector: vectors[7],
+		Limit:  5,
+		Filter: &Filter{Must: []Condition{MatchValue("document_id", "doc-7")}},
+	})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 5 || results[0].ID != "p00007" {
+		t.Fatalf("Expected 5 results starting with p00007, got %+v", results)
+	}
+	for _, r := range results {
+		if r.Payload["document_id"] != "doc-7" {
+			t.Errorf("Result %s does not match filter: %v", r.ID, r.Payload)
+		}
+	}
+}
+
+func TestLocalVectorStore_ReopenReplaysWAL(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	store, vectors := newTestLocalStore(t, dir, 100, 4)
+
+	if err := store.DeleteByFilter(ctx, "test", &Filter{Must: []Condition{MatchValue("document_id", "doc-1")}}); err != nil {
+		t.Fatalf("DeleteByFilter failed: %v", err)
+	}
+	if err := store.UpdatePayloads(ctx, "test", []PayloadUpdate{{PointID: "p00002", Payload: map[string]interface{}{"chunk_index": 99}}}); err != nil {
+		t.Fatalf("UpdatePayloads failed: %v", err)
+	}
+
+	// Close せずに開き直す（WAL だけから復元される）
+	reopened, err := NewLocalVectorStore(dir, HNSWParams{})
+	if err != nil {
+		t.Fatalf("NewLocalVectorStore failed: %v", err)
+	}
+	assertReopened(t, reopened, vectors)
+
+	// Close でスナップショットを書いてから開き直す
+	if err := reopened.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	fromSnapshot, err := NewLocalVectorStore(dir, HNSWParams{})
+	if err != nil {
+		t.Fatalf("NewLocalVectorStore failed: %v", err)
+	}
+	defer fromSnapshot.Close()
+	assertReopened(t, fromSnapshot, vectors)
+}
+
+func assertReopened(t *testing.T, store *LocalVectorStore, vectors [][]float64) {
+	t.Helper()
+	ctx := context.Background()
+
+	count, err := store.Count(ctx, "test", nil)
+	if err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if count != 90 {
+		t.Errorf("Expected 90 points after deleting doc-1, got %d", count)
+	}
+
+	results, err := store.Search(ctx, "test", SearchRequest{Vector: vectors[2], Limit: 1})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != "p00002" || results[0].Payload["chunk_index"] != float64(99) {
+		t.Errorf("Expected p00002 with updated payload, got %+v", results)
+	}
+
+	config, err := store.GetCollection(ctx, "test")
+	if err != nil {
+		t.Fatalf("GetCollection failed: %v", err)
+	}
+	if config.Size != 4 || config.Distance != DistanceCosine {
+		t.Errorf("Unexpected collection config: %+v", config)
+	}
+}
+
+func TestLocalVectorStore_CheckpointCompactsDeletedPoints(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	store, vectors := newTestLocalStore(t, dir, 60, 4)
+
+	// 同じIDで上書きすると古いポイントは削除済みとして残る
+	if err := store.Upsert(ctx, "test", []Point{{ID: "p00000", Vector: vectors[1]}}); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	if err := store.DeleteByFilter(ctx, "test", &Filter{Must: []Condition{MatchAny("document_id", []string{"doc-2", "doc-3", "doc-4", "doc-5"})}}); err != nil {
+		t.Fatalf("DeleteByFilter failed: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	reopened, err := NewLocalVectorStore(dir, HNSWParams{})
+	if err != nil {
+		t.Fatalf("NewLocalVectorStore failed: %v", err)
+	}
+	defer reopened.Close()
+
+	c, err := reopened.collection("test")
+	if err != nil {
+		t.Fatalf("collection failed: %v", err)
+	}
+	if c.deleted != 0 || len(c.points) != 36 {
+		t.Errorf("Expected 36 live points after compaction, got %d points (%d deleted)", len(c.points), c.deleted)
+	}
+
+	results, err := reopened.Search(ctx, "test", SearchRequest{Vector: vectors[1], Limit: 2})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Score < 0.999 || results[1].Score < 0.999 {
+		t.Errorf("Expected p00000 and p00001 to share the same vector, got %+v", results)
+	}
+}
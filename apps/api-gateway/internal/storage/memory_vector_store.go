@@ -0,0 +1,361 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// MemoryVectorStore はメモリ上で全件を総当たりで比較する VectorStore の実装です。
+// テストや Qdrant なしでのオフライン実行用で、プロセスを終了するとデータは消えます。
+// payload は保存時に JSON を経由してコピーするため、数値は Qdrant から読んだ場合と同じく float64 になります。
+type MemoryVectorStore struct {
+	mu          sync.RWMutex
+	collections map[string]*memoryCollection
+}
+
+type memoryCollection struct {
+	vectors map[string]VectorParams
+	points  map[string]*memoryPoint
+}
+
+type memoryPoint struct {
+	id      string
+	vectors map[string][]float64 // 名前なしのベクトルはキー ""
+	payload map[string]interface{}
+}
+
+// NewMemoryVectorStore は空の MemoryVectorStore を作成します
+func NewMemoryVectorStore() *MemoryVectorStore {
+	return &MemoryVectorStore{
+		collections: make(map[string]*memoryCollection),
+	}
+}
+
+// collection はコレクションを返します（呼び出し側でロックを取ること）
+func (s *MemoryVectorStore) collection(name string) (*memoryCollection, error) {
+	c, ok := s.collections[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrCollectionNotFound, name)
+	}
+	return c, nil
+}
+
+// CollectionExists はコレクションが存在するかを返します
+func (s *MemoryVectorStore) CollectionExists(ctx context.Context, collection string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.collections[collection]
+	return ok, nil
+}
+
+// CreateCollection はコレクションを作成します
+func (s *MemoryVectorStore) CreateCollection(ctx context.Context, collection string, config CollectionConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.collections[collection]; ok {
+		return fmt.Errorf("%w: %s", ErrCollectionExists, collection)
+	}
+	s.collections[collection] = &memoryCollection{
+		vectors: config.vectorParams(),
+		points:  make(map[string]*memoryPoint),
+	}
+	return nil
+}
+
+// EnsureCollection はコレクションが存在しなければ作成します
+func (s *MemoryVectorStore) EnsureCollection(ctx context.Context, collection string, config CollectionConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.collections[collection]; !ok {
+		s.collections[collection] = &memoryCollection{
+			vectors: config.vectorParams(),
+			points:  make(map[string]*memoryPoint),
+		}
+	}
+	return nil
+}
+
+// GetCollection はコレクションのベクトル設定を返します
+func (s *MemoryVectorStore) GetCollection(ctx context.Context, collection string) (CollectionConfig, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, err := s.collection(collection)
+	if err != nil {
+		return CollectionConfig{}, err
+	}
+	return collectionConfigFromParams(c.vectors), nil
+}
+
+// ListCollections はコレクション名を名前順に返します
+func (s *MemoryVectorStore) ListCollections(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.collections))
+	for name := range s.collections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// DeleteCollection はコレクションを削除します
+func (s *MemoryVectorStore) DeleteCollection(ctx context.Context, collection string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.collections, collection)
+	return nil
+}
+
+// Upsert はポイントを保存します。ベクトルの名前と次元数はコレクションの設定と一致している必要があります
+func (s *MemoryVectorStore) Upsert(ctx context.Context, collection string, points []Point) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, err := s.collection(collection)
+	if err != nil {
+		return err
+	}
+
+	// 1件でも不正なら何も保存しない
+	stored := make([]*memoryPoint, len(points))
+	for i, p := range points {
+		vectors := p.NamedVectors
+		if len(vectors) == 0 {
+			vectors = map[string][]float64{"": p.Vector}
+		}
+		copied := make(map[string][]float64, len(vectors))
+		for name, v := range vectors {
+			params, ok := c.vectors[name]
+			if !ok {
+				return fmt.Errorf("point %s: unknown vector name %q", p.ID, name)
+			}
+			if len(v) != params.Size {
+				return fmt.Errorf("point %s: vector dimension %d does not match collection dimension %d", p.ID, len(v), params.Size)
+			}
+			copied[name] = append([]float64(nil), v...)
+		}
+
+		payload, err := copyPayload(p.Payload)
+		if err != nil {
+			return fmt.Errorf("point %s: %w", p.ID, err)
+		}
+		stored[i] = &memoryPoint{id: p.ID, vectors: copied, payload: payload}
+	}
+
+	for _, p := range stored {
+		c.points[p.id] = p
+	}
+	return nil
+}
+
+// Search は全ポイントとの距離を計算し、スコアの良い順に返します
+func (s *MemoryVectorStore) Search(ctx context.Context, collection string, req SearchRequest) ([]SearchResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, err := s.collection(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	params, ok := c.vectors[req.VectorName]
+	if !ok {
+		return nil, fmt.Errorf("unknown vector name %q", req.VectorName)
+	}
+	if len(req.Vector) != params.Size {
+		return nil, fmt.Errorf("query vector dimension %d does not match collection dimension %d", len(req.Vector), params.Size)
+	}
+
+	// Euclid はスコア（距離）が小さいほど良い
+	better := func(a, b float64) bool { return a > b }
+	if params.Distance == DistanceEuclidean {
+		better = func(a, b float64) bool { return a < b }
+	}
+
+	var results []SearchResult
+	for _, p := range c.points {
+		vector, ok := p.vectors[req.VectorName]
+		if !ok || !matchFilter(req.Filter, p.id, p.payload) {
+			continue
+		}
+
+		score := vectorScore(params.Distance, req.Vector, vector)
+		if req.ScoreThreshold != nil && better(*req.ScoreThreshold, score) {
+			continue
+		}
+
+		result := SearchResult{ID: p.id, Score: score, Payload: p.payload}
+		if req.WithVector {
+			result.Vector = vector
+		}
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return better(results[i].Score, results[j].Score)
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	if req.Limit > 0 && len(results) > req.Limit {
+		results = results[:req.Limit]
+	}
+	return results, nil
+}
+
+// Scroll は filter に一致するポイントをID順に返します
+func (s *MemoryVectorStore) Scroll(ctx context.Context, collection string, req ScrollRequest) (*ScrollResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, err := s.collection(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []*memoryPoint
+	for _, p := range c.points {
+		if p.id >= req.Offset && matchFilter(req.Filter, p.id, p.payload) {
+			matched = append(matched, p)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].id < matched[j].id })
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+
+	resp := &ScrollResponse{}
+	if len(matched) > limit {
+		resp.NextOffset = matched[limit].id
+		matched = matched[:limit]
+	}
+
+	resp.Points = make([]Record, len(matched))
+	for i, p := range matched {
+		record := Record{ID: p.id, Payload: p.payload}
+		if req.WithVector {
+			if v, ok := p.vectors[""]; ok {
+				record.Vector = v
+			} else {
+				record.NamedVectors = p.vectors
+			}
+		}
+		resp.Points[i] = record
+	}
+	return resp, nil
+}
+
+// Count は filter に一致するポイント数を返します
+func (s *MemoryVectorStore) Count(ctx context.Context, collection string, filter *Filter) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, err := s.collection(collection)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, p := range c.points {
+		if matchFilter(filter, p.id, p.payload) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// DeleteByFilter は filter に一致するポイントを削除します
+func (s *MemoryVectorStore) DeleteByFilter(ctx context.Context, collection string, filter *Filter) error {
+	if filter == nil {
+		return fmt.Errorf("filter is required to delete points")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, err := s.collection(collection)
+	if err != nil {
+		return err
+	}
+
+	for id, p := range c.points {
+		if matchFilter(filter, p.id, p.payload) {
+			delete(c.points, id)
+		}
+	}
+	return nil
+}
+
+// UpdatePayloads は指定したキーだけ payload を上書きします（存在しないポイントは無視）
+func (s *MemoryVectorStore) UpdatePayloads(ctx context.Context, collection string, updates []PayloadUpdate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, err := s.collection(collection)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range updates {
+		p, ok := c.points[u.PointID]
+		if !ok {
+			continue
+		}
+		payload, err := copyPayload(u.Payload)
+		if err != nil {
+			return fmt.Errorf("point %s: %w", u.PointID, err)
+		}
+
+		// 検索結果として返した map を書き換えないよう、新しい map を作る
+		merged := make(map[string]interface{}, len(p.payload)+len(payload))
+		for k, v := range p.payload {
+			merged[k] = v
+		}
+		for k, v := range payload {
+			merged[k] = v
+		}
+		p.payload = merged
+	}
+	return nil
+}
+
+// vectorScore は distance に応じたスコアを計算します
+func vectorScore(distance Distance, a, b []float64) float64 {
+	switch distance {
+	case DistanceDot:
+		return dot(a, b)
+	case DistanceEuclidean:
+		var sum float64
+		for i := range a {
+			d := a[i] - b[i]
+			sum += d * d
+		}
+		return math.Sqrt(sum)
+	default:
+		norm := math.Sqrt(dot(a, a)) * math.Sqrt(dot(b, b))
+		if norm == 0 {
+			return 0
+		}
+		return dot(a, b) / norm
+	}
+}
+
+func dot(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func newTestStore(t *testing.T) *MemoryVectorStore {
+	t.Helper()
+
+	store := NewMemoryVectorStore()
+	ctx := context.Background()
+	if err := store.CreateCollection(ctx, "test", CollectionConfig{Size: 2}); err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+
+	points := []Point{
+		{ID: "a", Vector: []float64{1, 0}, Payload: map[string]interface{}{"document_id": "doc-1", "chunk_index": 0, "tags": []string{"go"}}},
+		{ID: "b", Vector: []float64{0.8, 0.6}, Payload: map[string]interface{}{"document_id": "doc-1", "chunk_index": 1}},
+		{ID: "c", Vector: []float64{0, 1}, Payload: map[string]interface{}{"document_id": "doc-2", "chunk_index": 0, "tags": []string{"rust"}}},
+	}
+	if err := store.Upsert(ctx, "test", points); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	return store
+}
+
+func TestMemoryVectorStore_SearchOrderAndThreshold(t *testing.T) {
+	store := newTestStore(t)
+
+	threshold := 0.5
+	results, err := store.Search(context.Background(), "test", SearchRequest{
+		Vector:         []float64{1, 0},
+		Limit:          10,
+		ScoreThreshold: &threshold,
+	})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	if len(results) != 2 || results[0].ID != "a" || results[1].ID != "b" {
+		t.Fatalf("Expected [a b], got %+v", results)
+	}
+	if _, ok := results[0].Payload["chunk_index"].(float64); !ok {
+		t.Errorf("Expected numeric payload to be float64 like Qdrant results")
+	}
+}
+
+func TestMemoryVectorStore_Filter(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	results, err := store.Search(ctx, "test", SearchRequest{
+		Vector: []float64{1, 0},
+		Limit:  10,
+		Filter: &Filter{
+			Should:  []Condition{MatchAny("tags", []string{"go", "rust"})},
+			MustNot: []Condition{HasID([]string{"c"})},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != "a" {
+		t.Errorf("Expected only a, got %+v", results)
+	}
+
+	count, err := store.Count(ctx, "test", &Filter{Must: []Condition{MatchValue("chunk_index", 0)}})
+	if err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 points with chunk_index 0, got %d", count)
+	}
+}
+
+func TestMemoryVectorStore_ScrollPages(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	first, err := store.Scroll(ctx, "test", ScrollRequest{Limit: 2})
+	if err != nil {
+		t.Fatalf("Scroll failed: %v", err)
+	}
+	if len(first.Points) != 2 || first.NextOffset != "c" {
+		t.Fatalf("Unexpected first page: %+v", first)
+	}
+
+	second, err := store.Scroll(ctx, "test", ScrollRequest{Limit: 2, Offset: first.NextOffset})
+	if err != nil {
+		t.Fatalf("Scroll failed: %v", err)
+	}
+	if len(second.Points) != 1 || second.Points[0].ID != "c" || second.NextOffset != "" {
+		t.Errorf("Unexpected second page: %+v", second)
+	}
+}
+
+func TestMemoryVectorStore_DeleteAndUpdatePayloads(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	if err := store.DeleteByFilter(ctx, "test", &Filter{Must: []Condition{MatchValue("document_id", "doc-1")}}); err != nil {
+		t.Fatalf("DeleteByFilter failed: %v", err)
+	}
+	if err := store.UpdatePayloads(ctx, "test", []PayloadUpdate{{PointID: "c", Payload: map[string]interface{}{"chunk_index": 5}}}); err != nil {
+		t.Fatalf("UpdatePayloads failed: %v", err)
+	}
+
+	page, err := store.Scroll(ctx, "test", ScrollRequest{Limit: 10})
+	if err != nil {
+		t.Fatalf("Scroll failed: %v", err)
+	}
+	if len(page.Points) != 1 {
+		t.Fatalf("Expected 1 remaining point, got %d", len(page.Points))
+	}
+	if page.Points[0].Payload["chunk_index"] != float64(5) || page.Points[0].Payload["document_id"] != "doc-2" {
+		t.Errorf("Expected chunk_index to be updated and other keys kept, got %v", page.Points[0].Payload)
+	}
+}
+
+func TestMemoryVectorStore_CollectionLifecycle(t *testing.T) {
+	store := NewMemoryVectorStore()
+	ctx := context.Background()
+
+	if _, err := store.Search(ctx, "missing", SearchRequest{Vector: []float64{1}}); !errors.Is(err, ErrCollectionNotFound) {
+		t.Errorf("Expected ErrCollectionNotFound, got %v", err)
+	}
+
+	config := CollectionConfig{NamedVectors: map[string]VectorParams{"dense": {Size: 2}}}
+	if err := store.EnsureCollection(ctx, "named", config); err != nil {
+		t.Fatalf("EnsureCollection failed: %v", err)
+	}
+	if err := store.CreateCollection(ctx, "named", config); !errors.Is(err, ErrCollectionExists) {
+		t.Errorf("Expected ErrCollectionExists, got %v", err)
+	}
+	if err := store.Upsert(ctx, "named", []Point{{ID: "x", Vector: []float64{1, 0}}}); err == nil {
+		t.Errorf("Expected error when upserting an unnamed vector into a named-vector collection")
+	}
+
+	if err := store.DeleteCollection(ctx, "named"); err != nil {
+		t.Fatalf("DeleteCollection failed: %v", err)
+	}
+	if exists, _ := store.CollectionExists(ctx, "named"); exists {
+		t.Errorf("Expected collection to be deleted")
+	}
+}
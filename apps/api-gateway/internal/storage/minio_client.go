@@ -2,7 +2,10 @@ package storage
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"net/url"
+	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
@@ -11,6 +14,9 @@ import (
 // MinIOClient wraps minio-go client and implements ObjectStorageClient
 type MinIOClient struct {
 	client *minio.Client
+	// presignClient signs presigned URLs. It differs from client when browsers reach
+	// MinIO through another host than the gateway (the host is part of the signature)
+	presignClient *minio.Client
 }
 
 // NewMinIOClient creates a new MinIO client
@@ -28,10 +34,26 @@ func NewMinIOClient(endpoint, accessKey, secretKey string, useSSL bool) (*MinIOC
 	}
 
 	return &MinIOClient{
-		client: client,
+		client:        client,
+		presignClient: client,
 	}, nil
 }
 
+// SetPublicEndpoint makes presigned URLs use endpoint (e.g., "files.example.com")
+// instead of the endpoint the gateway connects to
+func (m *MinIOClient) SetPublicEndpoint(endpoint, accessKey, secretKey string, useSSL bool) error {
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: useSSL,
+		Region: "us-east-1", // signing only; avoids a bucket location request to the public host
+	})
+	if err != nil {
+		return err
+	}
+	m.presignClient = client
+	return nil
+}
+
 // PutObject implements ObjectStorageClient.PutObject
 func (m *MinIOClient) PutObject(
 	ctx context.Context,
@@ -64,6 +86,78 @@ func (m *MinIOClient) GetObject(
 	return m.client.GetObject(ctx, bucket, objectName, minio.GetObjectOptions{})
 }
 
+// ComposeObject implements ObjectStorageClient.ComposeObject
+// Also used for single-source copies so that objects larger than 5GB are copied in parts
+func (m *MinIOClient) ComposeObject(
+	ctx context.Context,
+	bucket string,
+	dstObjectName string,
+	srcObjectNames []string,
+) error {
+	srcs := make([]minio.CopySrcOptions, len(srcObjectNames))
+	for i, name := range srcObjectNames {
+		srcs[i] = minio.CopySrcOptions{Bucket: bucket, Object: name}
+	}
+	_, err := m.client.ComposeObject(
+		ctx,
+		minio.CopyDestOptions{Bucket: bucket, Object: dstObjectName},
+		srcs...,
+	)
+	return err
+}
+
+// StatObject implements ObjectStorageClient.StatObject
+func (m *MinIOClient) StatObject(
+	ctx context.Context,
+	bucket string,
+	objectName string,
+) (*ObjectInfo, error) {
+	info, err := m.client.StatObject(ctx, bucket, objectName, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrObjectNotFound
+		}
+		return nil, err
+	}
+	return &ObjectInfo{
+		Size:        info.Size,
+		ContentType: info.ContentType,
+	}, nil
+}
+
+// PresignedPutObject implements ObjectStorageClient.PresignedPutObject
+func (m *MinIOClient) PresignedPutObject(
+	ctx context.Context,
+	bucket string,
+	objectName string,
+	expires time.Duration,
+) (string, error) {
+	u, err := m.presignClient.PresignedPutObject(ctx, bucket, objectName, expires)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// PresignedGetObject implements ObjectStorageClient.PresignedGetObject
+func (m *MinIOClient) PresignedGetObject(
+	ctx context.Context,
+	bucket string,
+	objectName string,
+	expires time.Duration,
+	downloadName string,
+) (string, error) {
+	params := url.Values{}
+	if downloadName != "" {
+		params.Set("response-content-disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(downloadName)))
+	}
+	u, err := m.presignClient.PresignedGetObject(ctx, bucket, objectName, expires, params)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
 // RemoveObject implements ObjectStorageClient.RemoveObject
 func (m *MinIOClient) RemoveObject(
 	ctx context.Context,
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestPresignClient(t *testing.T) *MinIOClient {
+	t.Helper()
+
+	client, err := NewMinIOClient("minio:9000", "admin", "password123", false)
+	if err != nil {
+		t.Fatalf("NewMinIOClient failed: %v", err)
+	}
+	// 公開エンドポイントはリージョン固定なので、署名にネットワークアクセスは不要
+	if err := client.SetPublicEndpoint("files.example.com", "admin", "password123", true); err != nil {
+		t.Fatalf("SetPublicEndpoint failed: %v", err)
+	}
+	return client
+}
+
+func TestPresignedPutObjectUsesPublicEndpoint(t *testing.T) {
+	client := newTestPresignClient(t)
+
+	raw, err := client.PresignedPutObject(context.Background(), "nexus-files", "tmp/uploads/presigned/abc", 15*time.Minute)
+	if err != nil {
+		t.Fatalf("PresignedPutObject failed: %v", err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("invalid URL %q: %v", raw, err)
+	}
+	if u.Scheme != "https" || u.Host != "files.example.com" {
+		t.Errorf("URL = %s, want https://files.example.com/...", raw)
+	}
+	if u.Path != "/nexus-files/tmp/uploads/presigned/abc" {
+		t.Errorf("path = %s", u.Path)
+	}
+	if got := u.Query().Get("X-Amz-Expires"); got != "900" {
+		t.Errorf("X-Amz-Expires = %s, want 900", got)
+	}
+}
+
+func TestPresignedGetObjectSetsDownloadName(t *testing.T) {
+	client := newTestPresignClient(t)
+
+	raw, err := client.PresignedGetObject(context.Background(), "nexus-files", "ws/1/key.pdf", time.Minute, "議事録 2024.pdf")
+	if err != nil {
+		t.Fatalf("PresignedGetObject failed: %v", err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("invalid URL %q: %v", raw, err)
+	}
+	disposition := u.Query().Get("response-content-disposition")
+	if !strings.HasPrefix(disposition, "attachment; filename*=UTF-8''") {
+		t.Fatalf("response-content-disposition = %q", disposition)
+	}
+	name, err := url.PathUnescape(strings.TrimPrefix(disposition, "attachment; filename*=UTF-8''"))
+	if err != nil || name != "議事録 2024.pdf" {
+		t.Errorf("download name = %q (%v), want 議事録 2024.pdf", name, err)
+	}
+}
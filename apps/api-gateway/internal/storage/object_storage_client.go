@@ -2,7 +2,9 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"io"
+	"time"
 )
 
 // ObjectStorageClient defines the interface for object storage operations
@@ -31,6 +33,43 @@ type ObjectStorageClient interface {
 		objectName string,
 	) (io.ReadCloser, error)
 
+	// ComposeObject concatenates source objects into dstObjectName on the server side
+	// (a single source is a copy). Content type and metadata of the first source are kept.
+	// Every source except the last must be at least MinComposePartSize bytes
+	ComposeObject(
+		ctx context.Context,
+		bucket string,
+		dstObjectName string,
+		srcObjectNames []string,
+	) error
+
+	// StatObject returns the size and content type of an object
+	// returns: ErrObjectNotFound if the object does not exist
+	StatObject(
+		ctx context.Context,
+		bucket string,
+		objectName string,
+	) (*ObjectInfo, error)
+
+	// PresignedPutObject returns a URL that lets a client upload objectName with a plain HTTP PUT
+	// until expires has passed, without going through the gateway
+	PresignedPutObject(
+		ctx context.Context,
+		bucket string,
+		objectName string,
+		expires time.Duration,
+	) (string, error)
+
+	// PresignedGetObject returns a URL that lets a client download objectName until expires has passed.
+	// downloadName (optional) is sent back as the attachment filename
+	PresignedGetObject(
+		ctx context.Context,
+		bucket string,
+		objectName string,
+		expires time.Duration,
+		downloadName string,
+	) (string, error)
+
 	// RemoveObject deletes a file from storage
 	RemoveObject(
 		ctx context.Context,
@@ -45,6 +84,21 @@ type ObjectStorageClient interface {
 	MakeBucket(ctx context.Context, bucket string) error
 }
 
+// MinComposePartSize is the minimum size of every ComposeObject source except the last (S3 multipart limit)
+const MinComposePartSize int64 = 5 << 20
+
+// MaxComposeSources is the maximum number of ComposeObject sources (S3 multipart limit)
+const MaxComposeSources = 10000
+
+// ErrObjectNotFound is returned by StatObject when the object does not exist
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectInfo contains object metadata returned by StatObject
+type ObjectInfo struct {
+	Size        int64
+	ContentType string
+}
+
 // PutObjectOptions contains optional parameters for PutObject
 type PutObjectOptions struct {
 	ContentType string
@@ -0,0 +1,403 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// QdrantVectorStore は Qdrant の REST API を使った VectorStore の実装です
+type QdrantVectorStore struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewQdrantVectorStore は新しい QdrantVectorStore を作成します
+func NewQdrantVectorStore(baseURL string) *QdrantVectorStore {
+	return &QdrantVectorStore{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// qdrantPointID は Qdrant のポイントID（UUID 文字列または整数）を文字列として読み込みます
+type qdrantPointID string
+
+func (id *qdrantPointID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*id = qdrantPointID(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("invalid point id %s: %w", data, err)
+	}
+	*id = qdrantPointID(n.String())
+	return nil
+}
+
+// qdrantVectors は名前なしのベクトル（配列）か名前付きベクトル（オブジェクト）を読み込みます
+type qdrantVectors struct {
+	vector []float64
+	named  map[string][]float64
+}
+
+func (v *qdrantVectors) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '[' {
+		return json.Unmarshal(data, &v.vector)
+	}
+	return json.Unmarshal(data, &v.named)
+}
+
+// pointVector は Point を Qdrant の "vector" フィールドの形に変換します
+func pointVector(p Point) interface{} {
+	if len(p.NamedVectors) > 0 {
+		return p.NamedVectors
+	}
+	return p.Vector
+}
+
+// collectionPath はコレクション配下の API パスを作成します
+func collectionPath(collection string, suffix string) string {
+	return "/collections/" + url.PathEscape(collection) + suffix
+}
+
+// do は JSON のリクエストを送り、レスポンスの "result" を out にデコードします。
+// 404 は ErrCollectionNotFound として返します
+func (s *QdrantVectorStore) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrCollectionNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		var errResp struct {
+			Status struct {
+				Error string `json:"error"`
+			} `json:"status"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err == nil && errResp.Status.Error != "" {
+			return fmt.Errorf("qdrant returned status %d: %s", resp.StatusCode, errResp.Status.Error)
+		}
+		return fmt.Errorf("qdrant returned status %d", resp.StatusCode)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	response := struct {
+		Result interface{} `json:"result"`
+	}{Result: out}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// CollectionExists はコレクションが存在するかを返します
+func (s *QdrantVectorStore) CollectionExists(ctx context.Context, collection string) (bool, error) {
+	err := s.do(ctx, http.MethodGet, collectionPath(collection, ""), nil, nil)
+	if errors.Is(err, ErrCollectionNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check collection: %w", err)
+	}
+	return true, nil
+}
+
+// CreateCollection はコレクションを作成します
+func (s *QdrantVectorStore) CreateCollection(ctx context.Context, collection string, config CollectionConfig) error {
+	exists, err := s.CollectionExists(ctx, collection)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%w: %s", ErrCollectionExists, collection)
+	}
+
+	// 名前なしのベクトルは {"size", "distance"}、名前付きは {"<name>": {"size", "distance"}}
+	params := config.vectorParams()
+	var vectors interface{} = params
+	if p, ok := params[""]; ok {
+		vectors = p
+	}
+
+	if err := s.do(ctx, http.MethodPut, collectionPath(collection, ""), map[string]interface{}{
+		"vectors": vectors,
+	}, nil); err != nil {
+		return fmt.Errorf("failed to create collection: %w", err)
+	}
+	return nil
+}
+
+// EnsureCollection はコレクションが存在しなければ作成します
+func (s *QdrantVectorStore) EnsureCollection(ctx context.Context, collection string, config CollectionConfig) error {
+	exists, err := s.CollectionExists(ctx, collection)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	err = s.CreateCollection(ctx, collection, config)
+	if err != nil && !errors.Is(err, ErrCollectionExists) {
+		return err
+	}
+	return nil
+}
+
+// GetCollection はコレクションのベクトル設定を返します
+func (s *QdrantVectorStore) GetCollection(ctx context.Context, collection string) (CollectionConfig, error) {
+	var result struct {
+		Config struct {
+			Params struct {
+				Vectors json.RawMessage `json:"vectors"`
+			} `json:"params"`
+		} `json:"config"`
+	}
+	if err := s.do(ctx, http.MethodGet, collectionPath(collection, ""), nil, &result); err != nil {
+		return CollectionConfig{}, fmt.Errorf("failed to get collection: %w", err)
+	}
+
+	// 名前なしのベクトルは {"size", "distance"}、名前付きは {"<name>": {"size", "distance"}}
+	var single VectorParams
+	if err := json.Unmarshal(result.Config.Params.Vectors, &single); err == nil && single.Size > 0 {
+		return CollectionConfig{Size: single.Size, Distance: single.Distance}, nil
+	}
+	var named map[string]VectorParams
+	if err := json.Unmarshal(result.Config.Params.Vectors, &named); err != nil {
+		return CollectionConfig{}, fmt.Errorf("failed to parse vector config: %w", err)
+	}
+	return CollectionConfig{NamedVectors: named}, nil
+}
+
+// ListCollections はコレクション名の一覧を返します
+func (s *QdrantVectorStore) ListCollections(ctx context.Context) ([]string, error) {
+	var result struct {
+		Collections []struct {
+			Name string `json:"name"`
+		} `json:"collections"`
+	}
+	if err := s.do(ctx, http.MethodGet, "/collections", nil, &result); err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	names := make([]string, len(result.Collections))
+	for i, c := range result.Collections {
+		names[i] = c.Name
+	}
+	return names, nil
+}
+
+// DeleteCollection はコレクションを削除します
+func (s *QdrantVectorStore) DeleteCollection(ctx context.Context, collection string) error {
+	err := s.do(ctx, http.MethodDelete, collectionPath(collection, ""), nil, nil)
+	if err != nil && !errors.Is(err, ErrCollectionNotFound) {
+		return fmt.Errorf("failed to delete collection: %w", err)
+	}
+	return nil
+}
+
+// Upsert はポイントを保存します（wait=true で反映を待つ）
+func (s *QdrantVectorStore) Upsert(ctx context.Context, collection string, points []Point) error {
+	if len(points) == 0 {
+		return nil
+	}
+
+	body := make([]map[string]interface{}, len(points))
+	for i, p := range points {
+		body[i] = map[string]interface{}{
+			"id":      p.ID,
+			"vector":  pointVector(p),
+			"payload": p.Payload,
+		}
+	}
+
+	if err := s.do(ctx, http.MethodPut, collectionPath(collection, "/points?wait=true"), map[string]interface{}{
+		"points": body,
+	}, nil); err != nil {
+		return fmt.Errorf("failed to upsert points: %w", err)
+	}
+	return nil
+}
+
+// Search は類似検索を実行します
+func (s *QdrantVectorStore) Search(ctx context.Context, collection string, req SearchRequest) ([]SearchResult, error) {
+	body := map[string]interface{}{
+		"vector":       req.Vector,
+		"limit":        req.Limit,
+		"with_payload": true,
+		"with_vector":  req.WithVector,
+	}
+	if req.VectorName != "" {
+		body["vector"] = map[string]interface{}{
+			"name":   req.VectorName,
+			"vector": req.Vector,
+		}
+	}
+	if req.Filter != nil {
+		body["filter"] = req.Filter
+	}
+	if req.ScoreThreshold != nil {
+		body["score_threshold"] = *req.ScoreThreshold
+	}
+
+	var result []struct {
+		ID      qdrantPointID          `json:"id"`
+		Score   float64                `json:"score"`
+		Payload map[string]interface{} `json:"payload"`
+		Vector  qdrantVectors          `json:"vector"`
+	}
+	if err := s.do(ctx, http.MethodPost, collectionPath(collection, "/points/search"), body, &result); err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+
+	results := make([]SearchResult, len(result))
+	for i, r := range result {
+		results[i] = SearchResult{
+			ID:      string(r.ID),
+			Score:   r.Score,
+			Payload: r.Payload,
+			Vector:  r.Vector.vector,
+		}
+		if req.VectorName != "" {
+			results[i].Vector = r.Vector.named[req.VectorName]
+		}
+	}
+	return results, nil
+}
+
+// Scroll は filter に一致するポイントをID順に返します
+func (s *QdrantVectorStore) Scroll(ctx context.Context, collection string, req ScrollRequest) (*ScrollResponse, error) {
+	body := map[string]interface{}{
+		"limit":        req.Limit,
+		"with_payload": true,
+		"with_vector":  req.WithVector,
+	}
+	if req.Filter != nil {
+		body["filter"] = req.Filter
+	}
+	if req.Offset != "" {
+		body["offset"] = req.Offset
+	}
+
+	var result struct {
+		Points []struct {
+			ID      qdrantPointID          `json:"id"`
+			Payload map[string]interface{} `json:"payload"`
+			Vector  qdrantVectors          `json:"vector"`
+		} `json:"points"`
+		NextPageOffset *qdrantPointID `json:"next_page_offset"`
+	}
+	if err := s.do(ctx, http.MethodPost, collectionPath(collection, "/points/scroll"), body, &result); err != nil {
+		return nil, fmt.Errorf("failed to scroll points: %w", err)
+	}
+
+	resp := &ScrollResponse{Points: make([]Record, len(result.Points))}
+	for i, p := range result.Points {
+		resp.Points[i] = Record{
+			ID:           string(p.ID),
+			Payload:      p.Payload,
+			Vector:       p.Vector.vector,
+			NamedVectors: p.Vector.named,
+		}
+	}
+	if result.NextPageOffset != nil {
+		resp.NextOffset = string(*result.NextPageOffset)
+	}
+	return resp, nil
+}
+
+// Count は filter に一致するポイント数を返します（exact=true で正確に数える）
+func (s *QdrantVectorStore) Count(ctx context.Context, collection string, filter *Filter) (int, error) {
+	body := map[string]interface{}{
+		"exact": true,
+	}
+	if filter != nil {
+		body["filter"] = filter
+	}
+
+	var result struct {
+		Count int `json:"count"`
+	}
+	if err := s.do(ctx, http.MethodPost, collectionPath(collection, "/points/count"), body, &result); err != nil {
+		return 0, fmt.Errorf("failed to count points: %w", err)
+	}
+	return result.Count, nil
+}
+
+// DeleteByFilter は filter に一致するポイントを削除します
+func (s *QdrantVectorStore) DeleteByFilter(ctx context.Context, collection string, filter *Filter) error {
+	if filter == nil {
+		return fmt.Errorf("filter is required to delete points")
+	}
+
+	if err := s.do(ctx, http.MethodPost, collectionPath(collection, "/points/delete?wait=true"), map[string]interface{}{
+		"filter": filter,
+	}, nil); err != nil {
+		return fmt.Errorf("failed to delete points: %w", err)
+	}
+	return nil
+}
+
+// UpdatePayloads はバッチ更新APIで、ポイントごとに set_payload を実行します
+func (s *QdrantVectorStore) UpdatePayloads(ctx context.Context, collection string, updates []PayloadUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	// 1ポイントにつき1オペレーション
+	operations := make([]map[string]interface{}, len(updates))
+	for i, u := range updates {
+		operations[i] = map[string]interface{}{
+			"set_payload": map[string]interface{}{
+				"payload": u.Payload,
+				"points":  []string{u.PointID},
+			},
+		}
+	}
+
+	if err := s.do(ctx, http.MethodPost, collectionPath(collection, "/points/batch?wait=true"), map[string]interface{}{
+		"operations": operations,
+	}, nil); err != nil {
+		return fmt.Errorf("failed to update payloads: %w", err)
+	}
+	return nil
+}
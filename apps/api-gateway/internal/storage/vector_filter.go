@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// copyPayload は payload を JSON 経由でコピーします
+func copyPayload(payload map[string]interface{}) (map[string]interface{}, error) {
+	if payload == nil {
+		return map[string]interface{}{}, nil
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	var copied map[string]interface{}
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+	return copied, nil
+}
+
+// matchFilter は Qdrant と同じ意味で filter を評価します
+// （must は全て、should は1つ以上、must_not はどれにも一致しない）
+func matchFilter(filter *Filter, id string, payload map[string]interface{}) bool {
+	if filter == nil {
+		return true
+	}
+	for _, cond := range filter.Must {
+		if !matchCondition(cond, id, payload) {
+			return false
+		}
+	}
+	if len(filter.Should) > 0 {
+		matched := false
+		for _, cond := range filter.Should {
+			if matchCondition(cond, id, payload) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for _, cond := range filter.MustNot {
+		if matchCondition(cond, id, payload) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchCondition は1つの条件を評価します。payload が配列の場合は要素のいずれかが一致すればよい
+func matchCondition(cond Condition, id string, payload map[string]interface{}) bool {
+	if cond.HasID != nil {
+		for _, candidate := range cond.HasID {
+			if candidate == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	if cond.Match == nil {
+		return false
+	}
+
+	value, ok := payload[cond.Key]
+	if !ok {
+		return false
+	}
+	values, isArray := value.([]interface{})
+	if !isArray {
+		values = []interface{}{value}
+	}
+
+	for _, v := range values {
+		if cond.Match.Any != nil {
+			for _, candidate := range cond.Match.Any {
+				if s, ok := v.(string); ok && s == candidate {
+					return true
+				}
+			}
+			continue
+		}
+		if payloadEqual(v, cond.Match.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// payloadEqual は JSON デコード済みの payload の値と条件の値を比較します（数値は float64 にそろえる）
+func payloadEqual(payloadValue, matchValue interface{}) bool {
+	if n, ok := toFloat(matchValue); ok {
+		m, ok := payloadValue.(float64)
+		return ok && m == n
+	}
+	return payloadValue == matchValue
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
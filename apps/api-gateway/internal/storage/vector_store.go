@@ -0,0 +1,250 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// VectorStore はベクトルの保存・類似検索を行うストアの抽象化です。
+// Qdrant（QdrantVectorStore）、ローカルディスクへの組み込み実装（LocalVectorStore）、
+// テスト・オフライン用のメモリ実装（MemoryVectorStore）があります。
+type VectorStore interface {
+	// CollectionExists はコレクションが存在するかを返します
+	CollectionExists(ctx context.Context, collection string) (bool, error)
+
+	// CreateCollection はコレクションを作成します（既に存在する場合はエラー）
+	CreateCollection(ctx context.Context, collection string, config CollectionConfig) error
+
+	// EnsureCollection はコレクションが存在しなければ作成します
+	EnsureCollection(ctx context.Context, collection string, config CollectionConfig) error
+
+	// GetCollection はコレクションのベクトル設定を返します
+	GetCollection(ctx context.Context, collection string) (CollectionConfig, error)
+
+	// ListCollections はコレクション名の一覧を返します
+	ListCollections(ctx context.Context) ([]string, error)
+
+	// DeleteCollection はコレクションをポイントごと削除します（存在しない場合は何もしない）
+	DeleteCollection(ctx context.Context, collection string) error
+
+	// Upsert はポイントを保存します（同じIDのポイントは置き換え）
+	Upsert(ctx context.Context, collection string, points []Point) error
+
+	// Search は類似検索を行い、スコアの良い順に最大 req.Limit 件を返します
+	Search(ctx context.Context, collection string, req SearchRequest) ([]SearchResult, error)
+
+	// Scroll は filter に一致するポイントをID順にページングして返します
+	Scroll(ctx context.Context, collection string, req ScrollRequest) (*ScrollResponse, error)
+
+	// Count は filter に一致するポイント数を返します（filter が nil の場合は全件）
+	Count(ctx context.Context, collection string, filter *Filter) (int, error)
+
+	// DeleteByFilter は filter に一致するポイントを削除します
+	DeleteByFilter(ctx context.Context, collection string, filter *Filter) error
+
+	// UpdatePayloads はベクトルを変えずに、ポイントごとの payload の一部を上書きします
+	UpdatePayloads(ctx context.Context, collection string, updates []PayloadUpdate) error
+}
+
+// ErrCollectionNotFound はコレクションが存在しない場合のエラー
+var ErrCollectionNotFound = errors.New("collection not found")
+
+// ErrCollectionExists は作成しようとしたコレクションが既に存在する場合のエラー
+var ErrCollectionExists = errors.New("collection already exists")
+
+// Distance はベクトル間の距離関数
+type Distance string
+
+const (
+	DistanceCosine    Distance = "Cosine"
+	DistanceDot       Distance = "Dot"
+	DistanceEuclidean Distance = "Euclid" // スコアは距離そのもの（小さいほど近い）
+)
+
+// VectorParams は1種類のベクトルの次元数と距離関数
+type VectorParams struct {
+	Size     int      `json:"size"`
+	Distance Distance `json:"distance"`
+}
+
+// CollectionConfig はコレクションの設定です。
+// NamedVectors を指定した場合は名前付きベクトル（1ポイントに複数のベクトル）のコレクションになり、
+// Size / Distance は無視されます。Distance の省略時は Cosine。
+type CollectionConfig struct {
+	Size         int
+	Distance     Distance
+	NamedVectors map[string]VectorParams
+}
+
+// vectorParams は名前ごとのベクトル設定を返します（名前なしのベクトルはキー ""）
+func (c CollectionConfig) vectorParams() map[string]VectorParams {
+	if len(c.NamedVectors) > 0 {
+		params := make(map[string]VectorParams, len(c.NamedVectors))
+		for name, p := range c.NamedVectors {
+			if p.Distance == "" {
+				p.Distance = DistanceCosine
+			}
+			params[name] = p
+		}
+		return params
+	}
+
+	distance := c.Distance
+	if distance == "" {
+		distance = DistanceCosine
+	}
+	return map[string]VectorParams{"": {Size: c.Size, Distance: distance}}
+}
+
+// collectionConfigFromParams は vectorParams の逆変換です
+func collectionConfigFromParams(params map[string]VectorParams) CollectionConfig {
+	if p, ok := params[""]; ok && len(params) == 1 {
+		return CollectionConfig{Size: p.Size, Distance: p.Distance}
+	}
+	named := make(map[string]VectorParams, len(params))
+	for name, p := range params {
+		named[name] = p
+	}
+	return CollectionConfig{NamedVectors: named}
+}
+
+// Point は保存するベクトルと payload です。
+// 名前付きベクトルのコレクションでは Vector の代わりに NamedVectors を使います。
+type Point struct {
+	ID           string
+	Vector       []float64
+	NamedVectors map[string][]float64
+	Payload      map[string]interface{}
+}
+
+// SearchRequest は類似検索の条件です。
+// VectorName は名前付きベクトルのコレクションでどのベクトルで検索するかを指定します。
+// ScoreThreshold を指定した場合はそれより悪いスコアの結果を除外します
+// （Cosine / Dot は下限、Euclid は上限）。
+type SearchRequest struct {
+	Vector         []float64
+	VectorName     string
+	Limit          int
+	Filter         *Filter
+	ScoreThreshold *float64
+	WithVector     bool
+}
+
+// SearchResult は検索結果の1件
+type SearchResult struct {
+	ID      string                 `json:"id"`
+	Score   float64                `json:"score"`
+	Payload map[string]interface{} `json:"payload"`
+	Vector  []float64              `json:"vector,omitempty"`
+}
+
+// ScrollRequest は Scroll の条件です。
+// Offset には前回の ScrollResponse.NextOffset を渡します（最初のページは空）。
+type ScrollRequest struct {
+	Filter     *Filter
+	Limit      int
+	Offset     string
+	WithVector bool
+}
+
+// ScrollResponse は Scroll の1ページ分の結果です（NextOffset が空なら最後のページ）
+type ScrollResponse struct {
+	Points     []Record
+	NextOffset string
+}
+
+// Record は Scroll で取得したポイント
+type Record struct {
+	ID           string
+	Payload      map[string]interface{}
+	Vector       []float64
+	NamedVectors map[string][]float64
+}
+
+// Filter はpayloadフィルタ
+// must は全て、should は少なくとも1つ、must_not はどれにも一致しないことを要求する
+type Filter struct {
+	Must    []Condition `json:"must,omitempty"`
+	Should  []Condition `json:"should,omitempty"`
+	MustNot []Condition `json:"must_not,omitempty"`
+}
+
+// Condition はpayloadのキーに対する条件
+// HasID を指定した場合はキーではなくポイントIDに対する条件になる
+type Condition struct {
+	Key   string          `json:"key,omitempty"`
+	Match *MatchCondition `json:"match,omitempty"`
+	HasID []string        `json:"has_id,omitempty"`
+}
+
+// MatchCondition は値の一致条件
+// Value は完全一致、Any は候補のいずれかとの一致（配列payloadの場合は要素のいずれか）
+type MatchCondition struct {
+	Value interface{} `json:"value,omitempty"`
+	Any   []string    `json:"any,omitempty"`
+}
+
+// MatchAny は key の値が values のいずれかに一致する条件を作成します
+func MatchAny(key string, values []string) Condition {
+	return Condition{
+		Key:   key,
+		Match: &MatchCondition{Any: values},
+	}
+}
+
+// MatchValue は key の値が value と完全一致する条件を作成します
+func MatchValue(key string, value interface{}) Condition {
+	return Condition{
+		Key:   key,
+		Match: &MatchCondition{Value: value},
+	}
+}
+
+// HasID はポイントIDが ids のいずれかである条件を作成します
+func HasID(ids []string) Condition {
+	return Condition{HasID: ids}
+}
+
+// PayloadUpdate は1ポイント分の payload 更新（指定したキーのみ上書き）
+type PayloadUpdate struct {
+	PointID string
+	Payload map[string]interface{}
+}
+
+// VectorStoreBackend は VectorStore の実装の種類
+type VectorStoreBackend string
+
+const (
+	VectorStoreQdrant VectorStoreBackend = "qdrant" // Qdrant サーバー
+	VectorStoreLocal  VectorStoreBackend = "local"  // ローカルディスク上の HNSW インデックス（サーバー不要）
+	VectorStoreMemory VectorStoreBackend = "memory" // メモリ上（テスト・オフライン用、再起動で消える）
+)
+
+// VectorStoreOptions は NewVectorStore の設定
+type VectorStoreOptions struct {
+	Backend   VectorStoreBackend
+	QdrantURL string
+	LocalPath string     // backend=local のデータディレクトリ
+	HNSW      HNSWParams // backend=local のインデックスのパラメータ
+}
+
+// NewVectorStore は opts.Backend に応じた VectorStore を作成します（未指定は Qdrant）
+func NewVectorStore(opts VectorStoreOptions) (VectorStore, error) {
+	switch opts.Backend {
+	case "", VectorStoreQdrant:
+		if opts.QdrantURL == "" {
+			return nil, fmt.Errorf("qdrant url is required")
+		}
+		return NewQdrantVectorStore(opts.QdrantURL), nil
+	case VectorStoreLocal:
+		if opts.LocalPath == "" {
+			return nil, fmt.Errorf("local vector store path is required")
+		}
+		return NewLocalVectorStore(opts.LocalPath, opts.HNSW)
+	case VectorStoreMemory:
+		return NewMemoryVectorStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown vector store backend %q", opts.Backend)
+	}
+}
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- チャットごとの会話メモリ設定（履歴ターン数・トークン予算・クエリ書き換え）を保持する。
+-- filter_config は検索範囲の絞り込み専用なので、生成に関する設定は別カラムに分ける
+ALTER TABLE chats ADD COLUMN settings JSONB;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE chats DROP COLUMN IF EXISTS settings;
+-- +goose StatementEnd
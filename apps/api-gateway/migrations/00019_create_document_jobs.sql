@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ドキュメント処理（取り込み）ジョブのキュー。
+-- ワーカーは SELECT ... FOR UPDATE SKIP LOCKED で1件ずつ取得するため、複数ワーカー・複数プロセスでも二重実行されない。
+--   status: queued（待機中・リトライ待ち） / running（実行中） / succeeded（完了） / dead（リトライ上限到達）
+CREATE TABLE document_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    document_id UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'queued',
+    options JSONB,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 5,
+    last_error TEXT,
+    run_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    locked_at TIMESTAMPTZ,
+    locked_by TEXT,
+    finished_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- ワーカーが取得対象を探すためのインデックス（待機中のジョブのみ）
+CREATE INDEX idx_document_jobs_claim ON document_jobs(run_at) WHERE status = 'queued';
+
+-- 1ドキュメントにつき実行待ち・実行中のジョブは1件まで
+CREATE UNIQUE INDEX idx_document_jobs_active ON document_jobs(document_id) WHERE status IN ('queued', 'running');
+
+CREATE INDEX idx_document_jobs_document ON document_jobs(document_id, created_at DESC);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS document_jobs CASCADE;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- 処理の進捗（ステージ・ページ数・チャンク数など）を保存し、GET /status で実際の状態を返せるようにする
+ALTER TABLE documents ADD COLUMN progress JSONB;
+
+-- status = 'failed' になった原因のエラーメッセージ
+ALTER TABLE documents ADD COLUMN error_message TEXT;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE documents DROP COLUMN IF EXISTS error_message;
+ALTER TABLE documents DROP COLUMN IF EXISTS progress;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- チャンク本文 + チャンク分割パラメータのハッシュ
+-- 再処理時にハッシュが一致するチャンクは embedding を再計算せず、Qdrant のポイントもそのまま残す
+ALTER TABLE document_chunks ADD COLUMN content_hash TEXT;
+
+-- これまではチャンクIDをそのまま Qdrant のポイントIDとして使っていたため、未設定の行を埋めておく
+UPDATE document_chunks SET qdrant_point_id = id WHERE qdrant_point_id IS NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE document_chunks DROP COLUMN IF EXISTS content_hash;
+-- +goose StatementEnd
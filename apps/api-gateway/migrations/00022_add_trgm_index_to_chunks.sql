@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ハイブリッド検索の語彙側（lexical）インデックス。
+-- 型番・識別子・日本語の固有名詞は埋め込みベクトルでは拾いにくいため、pg_trgm の部分一致で補う。
+-- tsvector ではなく pg_trgm を使う理由：日本語は空白で分かち書きされず、'simple' 辞書ではトークン化できないため
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX idx_chunks_content_trgm ON document_chunks USING GIN(content gin_trgm_ops);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_chunks_content_trgm;
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- 再開可能なアップロード（tus 形式）のセッション。
+-- チャンクは PATCH ごとに別オブジェクト（part_keys）として保存し、完了時に1つのオブジェクトに結合する。
+-- SHA256 は途中状態（hash_state）を保存して続きから計算するため、チャンクを読み直す必要はない。
+--   status: uploading（受付中） / completed（ファイル登録済み） / aborted（中止）
+CREATE TABLE upload_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    directory_id UUID REFERENCES directories(id) ON DELETE SET NULL,
+    file_name TEXT NOT NULL,
+    declared_mime_type TEXT,
+    mime_type TEXT,
+    tags TEXT[],
+    size_bytes BIGINT NOT NULL,
+    upload_offset BIGINT NOT NULL DEFAULT 0,
+    part_keys TEXT[] NOT NULL DEFAULT '{}',
+    hash_state BYTEA,
+    status TEXT NOT NULL DEFAULT 'uploading',
+    document_id UUID REFERENCES documents(id) ON DELETE SET NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- 期限切れのセッションを掃除するためのインデックス（受付中のみ）
+CREATE INDEX idx_upload_sessions_expires ON upload_sessions(expires_at) WHERE status = 'uploading';
+
+CREATE INDEX idx_upload_sessions_workspace ON upload_sessions(workspace_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS upload_sessions CASCADE;
+-- +goose StatementEnd
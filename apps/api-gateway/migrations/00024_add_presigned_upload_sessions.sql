@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- 署名付き URL（presigned PUT）によるアップロードもセッションとして管理する。
+-- クライアントは MinIO に直接 PUT し、確認（complete）時にサーバー側でオブジェクトを読み直して
+-- サイズと SHA256 を検証してから files / documents を作成する。
+--   upload_method: chunked（PATCH でチャンク送信） / presigned（署名付き URL に直接 PUT）
+--   expected_sha256: presigned の場合にクライアントが申告したハッシュ（確認時に照合）
+ALTER TABLE upload_sessions
+    ADD COLUMN upload_method TEXT NOT NULL DEFAULT 'chunked',
+    ADD COLUMN expected_sha256 TEXT;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE upload_sessions
+    DROP COLUMN IF EXISTS expected_sha256,
+    DROP COLUMN IF EXISTS upload_method;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- 重複排除されたファイル（files）の参照カウント GC 用。
+-- ファイルごとに参照している documents を数えるため、file_id にインデックスを張る
+CREATE INDEX idx_documents_file ON documents(file_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_documents_file;
+-- +goose StatementEnd
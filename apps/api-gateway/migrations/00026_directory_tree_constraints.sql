@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ディレクトリツリー API 用の制約とインデックス。
+-- UNIQUE(workspace_id, parent_id, name) は parent_id が NULL（ワークスペース直下）の重複を防げず、
+-- 論理削除したディレクトリと同じ名前で作り直すこともできないため、部分ユニークインデックスに置き換える
+ALTER TABLE directories DROP CONSTRAINT IF EXISTS directories_workspace_id_parent_id_name_key;
+
+CREATE UNIQUE INDEX idx_directories_unique_name ON directories(
+    workspace_id,
+    COALESCE(parent_id, '00000000-0000-0000-0000-000000000000'::uuid),
+    name
+) WHERE deleted_at IS NULL;
+
+-- 子ディレクトリの再帰取得用
+CREATE INDEX idx_directories_parent ON directories(parent_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_directories_parent;
+DROP INDEX IF EXISTS idx_directories_unique_name;
+ALTER TABLE directories ADD CONSTRAINT directories_workspace_id_parent_id_name_key UNIQUE (workspace_id, parent_id, name);
+-- +goose StatementEnd
@@ -0,0 +1,63 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ドキュメントのバージョン管理。
+-- documents は論理的なドキュメント（ID・名前・タグ・ディレクトリは変わらない）、
+-- document_versions はアップロードされた版ごとのファイルを表す。チャンクはバージョンごとに持つ
+CREATE TABLE document_versions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    document_id UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    version_number INTEGER NOT NULL,
+    file_id UUID NOT NULL REFERENCES files(id) ON DELETE RESTRICT,
+    file_name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE(document_id, version_number)
+);
+-- blob GC の参照判定用
+CREATE INDEX idx_document_versions_file ON document_versions(file_id);
+
+-- 検索・チャンク一覧の対象になるバージョン（documents.file_id はこのバージョンの file_id と一致させる）
+ALTER TABLE documents
+    ADD COLUMN current_version_id UUID REFERENCES document_versions(id) ON DELETE SET NULL;
+
+ALTER TABLE document_chunks
+    ADD COLUMN version_id UUID REFERENCES document_versions(id) ON DELETE CASCADE;
+
+-- 既存のドキュメントは、現在のファイルをバージョン1とする
+INSERT INTO document_versions (document_id, version_number, file_id, file_name, created_at)
+SELECT d.id, 1, d.file_id, COALESCE(f.original_filename, d.name), d.created_at
+FROM documents d
+INNER JOIN files f ON f.id = d.file_id;
+
+UPDATE documents d
+SET current_version_id = v.id
+FROM document_versions v
+WHERE v.document_id = d.id;
+
+UPDATE document_chunks c
+SET version_id = d.current_version_id
+FROM documents d
+WHERE d.id = c.document_id;
+
+ALTER TABLE document_chunks ALTER COLUMN version_id SET NOT NULL;
+
+-- chunk_index はバージョンごとに振る
+ALTER TABLE document_chunks DROP CONSTRAINT IF EXISTS document_chunks_document_id_chunk_index_key;
+ALTER TABLE document_chunks ADD CONSTRAINT document_chunks_version_id_chunk_index_key UNIQUE (version_id, chunk_index);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- 現在のバージョン以外のチャンクは復元できないため削除する
+DELETE FROM document_chunks c
+USING documents d
+WHERE d.id = c.document_id
+  AND c.version_id IS DISTINCT FROM d.current_version_id;
+
+ALTER TABLE document_chunks DROP CONSTRAINT IF EXISTS document_chunks_version_id_chunk_index_key;
+ALTER TABLE document_chunks ADD CONSTRAINT document_chunks_document_id_chunk_index_key UNIQUE (document_id, chunk_index);
+ALTER TABLE document_chunks DROP COLUMN IF EXISTS version_id;
+ALTER TABLE documents DROP COLUMN IF EXISTS current_version_id;
+DROP TABLE IF EXISTS document_versions;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- 親子チャンク（small-to-big）。子チャンクは embedding して検索に使い、
+-- 親チャンク（is_parent）はページ内の大きめのセクションで、検索でヒットした子チャンクの代わりに LLM に渡す。
+-- 親チャンクは embedding せず、処理のたびに作り直す（子チャンクの parent_id も付け直す）
+ALTER TABLE document_chunks
+    ADD COLUMN is_parent BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN parent_id UUID REFERENCES document_chunks(id) ON DELETE SET NULL;
+
+-- chunk_index は子チャンクと親チャンクで別々に振る
+ALTER TABLE document_chunks DROP CONSTRAINT IF EXISTS document_chunks_version_id_chunk_index_key;
+ALTER TABLE document_chunks ADD CONSTRAINT document_chunks_version_id_is_parent_chunk_index_key UNIQUE (version_id, is_parent, chunk_index);
+
+CREATE INDEX idx_chunks_parent ON document_chunks(parent_id) WHERE parent_id IS NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM document_chunks WHERE is_parent;
+
+DROP INDEX IF EXISTS idx_chunks_parent;
+ALTER TABLE document_chunks DROP CONSTRAINT IF EXISTS document_chunks_version_id_is_parent_chunk_index_key;
+ALTER TABLE document_chunks ADD CONSTRAINT document_chunks_version_id_chunk_index_key UNIQUE (version_id, chunk_index);
+ALTER TABLE document_chunks DROP COLUMN IF EXISTS parent_id;
+ALTER TABLE document_chunks DROP COLUMN IF EXISTS is_parent;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- upload_sessions.status に completing（完了処理中）を追加する。
+-- 完了処理は uploading → completing の条件付き UPDATE でセッションを確保してから行い、同時の完了・中止を防ぐ。
+-- 完了処理の途中でプロセスが落ちて completing のまま残ったセッションや、チャンクの削除に失敗した
+-- aborted のセッションも期限切れとして掃除できるよう、インデックスの対象を広げる
+DROP INDEX IF EXISTS idx_upload_sessions_expires;
+
+CREATE INDEX idx_upload_sessions_expires ON upload_sessions(expires_at)
+WHERE status IN ('uploading', 'completing') OR (status = 'aborted' AND cardinality(part_keys) > 0);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_upload_sessions_expires;
+CREATE INDEX idx_upload_sessions_expires ON upload_sessions(expires_at) WHERE status = 'uploading';
+-- +goose StatementEnd
@@ -7,10 +7,11 @@ INSERT INTO chats (
     workspace_id,
     title,
     filter_config,
+    settings,
     created_at,
     updated_at
 ) VALUES (
-    $1, $2, $3, now(), now()
+    $1, $2, $3, $4, now(), now()
 )
 RETURNING *;
 
@@ -20,6 +21,7 @@ SELECT
     c.workspace_id,
     c.title,
     c.filter_config,
+    c.settings,
     c.created_at,
     c.updated_at
 FROM chats c
@@ -91,6 +93,22 @@ WHERE
 ORDER BY message_index ASC
 LIMIT $2;
 
+-- name: GetRecentChatMessages :many
+-- 直近のメッセージを新しい順に取得（会話履歴をプロンプトに含めるため）
+SELECT 
+    id,
+    chat_id,
+    role,
+    content,
+    message_index,
+    document_refs,
+    created_at
+FROM chat_messages
+WHERE 
+    chat_id = $1
+ORDER BY message_index DESC
+LIMIT $2;
+
 -- name: GetMaxMessageIndex :one
 SELECT COALESCE(MAX(message_index), -1)::int as max_index
 FROM chat_messages
@@ -107,4 +125,4 @@ INSERT INTO chat_messages (
 ) VALUES (
     $1, $2, $3, $4, $5, now()
 )
-RETURNING *;
\ No newline at end of file
+RETURNING *;
\ No newline at end of file
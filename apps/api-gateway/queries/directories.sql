@@ -0,0 +1,107 @@
+-- ========================================
+-- Directory Operations
+-- ========================================
+
+-- name: GetDirectoryDescendantIDs :many
+-- 指定ディレクトリ自身とその配下のサブディレクトリのIDを再帰的に取得
+WITH RECURSIVE tree AS (
+    SELECT d.id
+    FROM directories d
+    WHERE 
+        d.id = ANY(@directory_ids::uuid[])
+        AND d.workspace_id = @workspace_id
+        AND d.deleted_at IS NULL
+    UNION
+    SELECT c.id
+    FROM directories c
+    INNER JOIN tree t ON c.parent_id = t.id
+    WHERE c.deleted_at IS NULL
+)
+SELECT id FROM tree;
+
+-- name: CreateDirectory :one
+INSERT INTO directories (
+    workspace_id,
+    parent_id,
+    name
+) VALUES (
+    $1, $2, $3
+)
+RETURNING *;
+
+-- name: GetDirectory :one
+SELECT * FROM directories
+WHERE id = $1
+  AND workspace_id = $2
+  AND deleted_at IS NULL;
+
+-- name: ListDirectoryTree :many
+-- ルート（root_id 未指定ならワークスペース直下のディレクトリ）から配下を再帰的に取得する。
+-- depth はルートを0とした深さ、document_count は直下の（削除されていない）ドキュメント数。
+-- 親子関係が万一循環していても CYCLE で打ち切り、同じディレクトリを二度たどらない
+WITH RECURSIVE tree AS (
+    SELECT d.id, d.parent_id, d.name, d.created_at, d.updated_at, 0 AS depth
+    FROM directories d
+    WHERE 
+        d.workspace_id = @workspace_id
+        AND d.deleted_at IS NULL
+        AND (
+            (sqlc.narg('root_id')::uuid IS NULL AND d.parent_id IS NULL)
+            OR d.id = sqlc.narg('root_id')::uuid
+        )
+    UNION ALL
+    SELECT c.id, c.parent_id, c.name, c.created_at, c.updated_at, t.depth + 1
+    FROM directories c
+    INNER JOIN tree t ON c.parent_id = t.id
+    WHERE c.deleted_at IS NULL
+) CYCLE id SET is_cycle USING path
+SELECT 
+    t.id,
+    t.parent_id,
+    t.name,
+    t.created_at,
+    t.updated_at,
+    t.depth::int AS depth,
+    (
+        SELECT COUNT(*) FROM documents doc
+        WHERE doc.directory_id = t.id AND doc.deleted_at IS NULL
+    ) AS document_count
+FROM tree t
+WHERE NOT t.is_cycle
+ORDER BY t.depth, t.name;
+
+-- name: RenameDirectory :one
+UPDATE directories
+SET 
+    name = $3,
+    updated_at = now()
+WHERE id = $1
+  AND workspace_id = $2
+  AND deleted_at IS NULL
+RETURNING *;
+
+-- name: LockWorkspaceDirectoryTree :exec
+-- ワークスペースの行をロックして、同じワークスペースのディレクトリの移動・削除を直列化する
+-- （循環の確認や配下の取得から書き込みまでの間に、別のトランザクションがツリーを変えられないようにする）
+SELECT id FROM workspaces
+WHERE id = $1
+FOR UPDATE;
+
+-- name: MoveDirectory :one
+UPDATE directories
+SET 
+    parent_id = sqlc.narg('parent_id'),
+    updated_at = now()
+WHERE id = @id
+  AND workspace_id = @workspace_id
+  AND deleted_at IS NULL
+RETURNING *;
+
+-- name: SoftDeleteDirectories :exec
+UPDATE directories
+SET 
+    deleted_at = now(),
+    updated_at = now()
+WHERE id = ANY(@ids::uuid[])
+  AND workspace_id = @workspace_id
+  AND deleted_at IS NULL;
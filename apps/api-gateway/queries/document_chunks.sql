@@ -0,0 +1,160 @@
+-- ========================================
+-- Document Chunk Operations
+-- ========================================
+
+-- name: CreateDocumentChunk :one
+INSERT INTO document_chunks (
+    document_id,
+    chunk_index,
+    content,
+    page_number,
+    version_id,
+    created_at
+) VALUES (
+    $1, $2, $3, $4, $5, now()
+)
+RETURNING *;
+
+-- name: CreateDocumentChunks :many
+-- 複数チャンクを1回の INSERT でまとめて保存する（配列は同じ長さで渡す）
+-- ID はアプリ側で採番し、そのまま Qdrant のポイントIDとして使う
+-- metadata は JSON 文字列で渡す（空文字は NULL）、parent_id は親チャンクがなければ nil UUID
+INSERT INTO document_chunks (
+    id,
+    document_id,
+    version_id,
+    chunk_index,
+    content,
+    page_number,
+    content_hash,
+    metadata,
+    parent_id,
+    qdrant_point_id,
+    created_at
+)
+SELECT 
+    u.id,
+    @document_id::uuid,
+    @version_id::uuid,
+    u.chunk_index,
+    u.content,
+    u.page_number,
+    u.content_hash,
+    NULLIF(u.metadata, '')::jsonb,
+    NULLIF(u.parent_id, '00000000-0000-0000-0000-000000000000'::uuid),
+    u.id,
+    now()
+FROM unnest(
+    @ids::uuid[],
+    @chunk_indexes::int[],
+    @contents::text[],
+    @page_numbers::int[],
+    @content_hashes::text[],
+    @metadatas::text[],
+    @parent_ids::uuid[]
+) AS u(id, chunk_index, content, page_number, content_hash, metadata, parent_id)
+RETURNING id, chunk_index;
+
+-- name: CreateParentChunks :exec
+-- 親チャンク（embedding しないページ内のセクション）をまとめて保存する。chunk_index は親チャンクの中での順番
+INSERT INTO document_chunks (
+    id,
+    document_id,
+    version_id,
+    chunk_index,
+    content,
+    page_number,
+    metadata,
+    is_parent,
+    created_at
+)
+SELECT
+    u.id,
+    @document_id::uuid,
+    @version_id::uuid,
+    u.chunk_index,
+    u.content,
+    u.page_number,
+    NULLIF(u.metadata, '')::jsonb,
+    true,
+    now()
+FROM unnest(
+    @ids::uuid[],
+    @chunk_indexes::int[],
+    @contents::text[],
+    @page_numbers::int[],
+    @metadatas::text[]
+) AS u(id, chunk_index, content, page_number, metadata);
+
+-- name: DeleteParentChunks :exec
+-- バージョンの親チャンクを削除する（子チャンクの parent_id は NULL になる）
+DELETE FROM document_chunks
+WHERE version_id = $1
+  AND is_parent;
+
+-- name: ListDocumentChunkHashes :many
+-- 差分の再処理用に、バージョンの既存チャンクのハッシュとポイントIDだけを取得する
+SELECT id, chunk_index, page_number, content_hash, qdrant_point_id
+FROM document_chunks
+WHERE version_id = $1
+  AND NOT is_parent
+ORDER BY chunk_index ASC;
+
+-- name: DeleteDocumentChunksByIDs :exec
+DELETE FROM document_chunks
+WHERE version_id = @version_id
+  AND id = ANY(@ids::uuid[]);
+
+-- name: ShiftDocumentChunkIndexes :exec
+-- chunk_index を一時的に負の値へ退避する（UNIQUE(version_id, chunk_index) に違反せず番号を振り直すため）
+UPDATE document_chunks
+SET chunk_index = -1 - chunk_index
+WHERE version_id = $1
+  AND NOT is_parent;
+
+-- name: UpdateDocumentChunkPositions :exec
+-- 再利用するチャンクの chunk_index / page_number / parent_id を新しい位置に更新する（parent_id は親チャンクがなければ nil UUID）
+UPDATE document_chunks AS c
+SET chunk_index = u.chunk_index,
+    page_number = u.page_number,
+    parent_id = NULLIF(u.parent_id, '00000000-0000-0000-0000-000000000000'::uuid)
+FROM unnest(@ids::uuid[], @chunk_indexes::int[], @page_numbers::int[], @parent_ids::uuid[]) AS u(id, chunk_index, page_number, parent_id)
+WHERE c.id = u.id
+  AND c.version_id = @version_id;
+
+-- name: GetDocumentChunks :many
+-- 現在のバージョンの（子）チャンクだけを返す
+SELECT c.* FROM document_chunks c
+INNER JOIN documents d ON d.current_version_id = c.version_id
+WHERE d.id = $1
+  AND NOT c.is_parent
+ORDER BY c.chunk_index ASC
+LIMIT $2 OFFSET $3;
+
+-- name: CountDocumentChunks :one
+SELECT COUNT(*) FROM document_chunks c
+INNER JOIN documents d ON d.current_version_id = c.version_id
+WHERE d.id = $1
+  AND NOT c.is_parent;
+
+-- name: DeleteDocumentChunks :exec
+DELETE FROM document_chunks
+WHERE document_id = $1;
+
+-- name: DeleteDocumentChunksByDocumentIDs :exec
+DELETE FROM document_chunks
+WHERE document_id = ANY(@document_ids::uuid[]);
+
+-- name: ListChunkPointIDsByDocumentIDs :many
+-- payload を更新するために、ドキュメントのチャンクの VectorStore のポイントIDを取得する
+SELECT COALESCE(qdrant_point_id, id)::uuid AS point_id
+FROM document_chunks
+WHERE document_id = ANY(@document_ids::uuid[])
+  AND NOT is_parent;
+
+-- name: ListChunkPointVersionsByDocumentID :many
+-- バージョン切り替え時に is_current を付け直すため、全バージョンのチャンクのポイントIDを取得する
+SELECT COALESCE(qdrant_point_id, id)::uuid AS point_id, version_id
+FROM document_chunks
+WHERE document_id = $1
+  AND NOT is_parent;
@@ -0,0 +1,114 @@
+-- name: EnqueueDocumentJob :one
+-- 実行待ち・実行中のジョブが既にある場合は何も挿入しない（sql.ErrNoRows が返る）
+INSERT INTO document_jobs (
+    workspace_id,
+    document_id,
+    options,
+    max_attempts,
+    status,
+    run_at,
+    created_at,
+    updated_at
+) VALUES (
+    $1, $2, $3, $4, 'queued', now(), now(), now()
+)
+ON CONFLICT (document_id) WHERE status IN ('queued', 'running') DO NOTHING
+RETURNING *;
+
+-- name: ClaimDocumentJob :one
+-- 実行可能なジョブを1件取得して running にする（他のワーカーがロック中の行はスキップ）
+UPDATE document_jobs
+SET 
+    status = 'running',
+    attempts = attempts + 1,
+    locked_at = now(),
+    locked_by = @worker_id,
+    updated_at = now()
+WHERE id = (
+    SELECT j.id
+    FROM document_jobs j
+    WHERE 
+        j.status = 'queued'
+        AND j.run_at <= now()
+    ORDER BY j.run_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteDocumentJob :execrows
+-- ロックを持っているワーカーの実行だけを完了にする（0件ならロックが回収され、別のワーカーが実行している）
+UPDATE document_jobs
+SET 
+    status = 'succeeded',
+    last_error = NULL,
+    locked_at = NULL,
+    locked_by = NULL,
+    finished_at = now(),
+    updated_at = now()
+WHERE 
+    id = $1
+    AND locked_by = $2
+    AND status = 'running';
+
+-- name: RetryDocumentJob :execrows
+-- 失敗したジョブを run_at まで待機させて再実行する（ロックを持っているワーカーのみ）
+UPDATE document_jobs
+SET 
+    status = 'queued',
+    last_error = $2,
+    run_at = $3,
+    locked_at = NULL,
+    locked_by = NULL,
+    updated_at = now()
+WHERE 
+    id = $1
+    AND locked_by = $4
+    AND status = 'running';
+
+-- name: DeadLetterDocumentJob :execrows
+-- リトライ上限に達した（または再試行しても成功しない）ジョブを dead にする（ロックを持っているワーカーのみ）
+UPDATE document_jobs
+SET 
+    status = 'dead',
+    last_error = $2,
+    locked_at = NULL,
+    locked_by = NULL,
+    finished_at = now(),
+    updated_at = now()
+WHERE 
+    id = $1
+    AND locked_by = $3
+    AND status = 'running';
+
+-- name: TouchDocumentJob :execrows
+-- 実行中のジョブのロック時刻を延長する（長時間の処理が stale 扱いされないように）
+-- 0件ならロックが回収されている（別のワーカーが再取得した可能性がある）
+UPDATE document_jobs
+SET locked_at = now()
+WHERE 
+    id = $1
+    AND locked_by = $2
+    AND status = 'running';
+
+-- name: RequeueStaleDocumentJobs :many
+-- ワーカーが落ちて running のまま残ったジョブを待機状態に戻す（試行回数を使い切っていれば dead）
+-- 戻したジョブのドキュメントのステータスを更新できるよう、document_id と新しい status を返す
+UPDATE document_jobs
+SET 
+    status = CASE WHEN attempts >= max_attempts THEN 'dead' ELSE 'queued' END,
+    last_error = 'worker lock expired',
+    finished_at = CASE WHEN attempts >= max_attempts THEN now() ELSE NULL END,
+    locked_at = NULL,
+    locked_by = NULL,
+    updated_at = now()
+WHERE 
+    status = 'running'
+    AND locked_at < $1
+RETURNING id, document_id, workspace_id, status;
+
+-- name: GetLatestDocumentJob :one
+SELECT * FROM document_jobs
+WHERE document_id = $1
+ORDER BY created_at DESC
+LIMIT 1;
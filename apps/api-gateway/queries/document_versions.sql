@@ -0,0 +1,85 @@
+-- ========================================
+-- Document Version Operations
+-- ========================================
+
+-- name: CreateDocumentVersion :one
+-- 次のバージョン番号で新しいバージョンを追加する（同時に追加された場合は UNIQUE 制約で失敗する）
+INSERT INTO document_versions (document_id, version_number, file_id, file_name)
+SELECT @document_id, COALESCE(MAX(v.version_number), 0) + 1, @file_id, @file_name
+FROM document_versions v
+WHERE v.document_id = @document_id
+RETURNING *;
+
+-- name: ListDocumentVersions :many
+-- 新しい順。chunk_count が0のバージョンは未処理（または処理に失敗した）
+SELECT
+    v.id,
+    v.document_id,
+    v.version_number,
+    v.file_id,
+    v.file_name,
+    v.created_at,
+    f.size_bytes,
+    f.mime_type,
+    f.sha256_hash,
+    (v.id = d.current_version_id)::boolean AS is_current,
+    (SELECT COUNT(*) FROM document_chunks c WHERE c.version_id = v.id AND NOT c.is_parent) AS chunk_count
+FROM document_versions v
+INNER JOIN documents d ON d.id = v.document_id
+INNER JOIN files f ON f.id = v.file_id
+WHERE v.document_id = @document_id
+  AND d.workspace_id = @workspace_id
+  AND d.deleted_at IS NULL
+ORDER BY v.version_number DESC;
+
+-- name: GetDocumentVersionByNumber :one
+SELECT
+    v.id,
+    v.document_id,
+    v.version_number,
+    v.file_id,
+    v.file_name,
+    v.created_at,
+    f.size_bytes,
+    f.mime_type,
+    f.sha256_hash,
+    (v.id = d.current_version_id)::boolean AS is_current,
+    (SELECT COUNT(*) FROM document_chunks c WHERE c.version_id = v.id AND NOT c.is_parent) AS chunk_count
+FROM document_versions v
+INNER JOIN documents d ON d.id = v.document_id
+INNER JOIN files f ON f.id = v.file_id
+WHERE v.document_id = @document_id
+  AND d.workspace_id = @workspace_id
+  AND d.deleted_at IS NULL
+  AND v.version_number = @version_number;
+
+-- name: GetDocumentVersionFile :one
+-- ドキュメント処理用に、バージョンのファイルの保存場所を取得する
+SELECT
+    v.id,
+    v.version_number,
+    v.file_id,
+    v.file_name,
+    f.mime_type,
+    f.minio_bucket,
+    f.minio_key
+FROM document_versions v
+INNER JOIN files f ON f.id = v.file_id
+WHERE v.id = @id
+  AND v.document_id = @document_id;
+
+-- name: ListDocumentVersionsForRefs :many
+-- チャットの document_refs が引用したバージョンを取得する
+-- version_id を持たない（バージョン管理より前の）参照はバージョン1として解決する
+SELECT id, document_id, version_number, file_name
+FROM document_versions
+WHERE id = ANY(@version_ids::uuid[])
+   OR (version_number = 1 AND document_id = ANY(@document_ids::uuid[]));
+
+-- name: ListVersionChunkContents :many
+-- バージョン間の差分用に、チャンクの本文を順番に取得する
+SELECT chunk_index, page_number, content
+FROM document_chunks
+WHERE version_id = $1
+  AND NOT is_parent
+ORDER BY chunk_index ASC;
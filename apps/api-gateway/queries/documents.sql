@@ -1,17 +1,26 @@
 -- name: CreateDocument :one
-INSERT INTO documents (
-    workspace_id, 
-    directory_id, 
-    file_id, 
-    name, 
-    tags, 
-    status,
-    created_at,
-    updated_at
-) VALUES (
-    $1, $2, $3, $4, $5, 'uploaded', now(), now()
+-- ドキュメントとバージョン1を1文で作成する（バージョンIDはアプリ側で採番し current_version_id に設定する）
+WITH doc AS (
+    INSERT INTO documents (
+        workspace_id, 
+        directory_id, 
+        file_id, 
+        name, 
+        tags, 
+        status,
+        current_version_id,
+        created_at,
+        updated_at
+    ) VALUES (
+        @workspace_id, @directory_id, @file_id, @name, @tags, 'uploaded', @version_id, now(), now()
+    )
+    RETURNING *
+), version AS (
+    INSERT INTO document_versions (id, document_id, version_number, file_id, file_name)
+    SELECT @version_id, doc.id, 1, doc.file_id, @file_name::text
+    FROM doc
 )
-RETURNING *;
+SELECT * FROM doc;
 
 -- name: GetDocument :one
 SELECT 
@@ -21,7 +30,12 @@ SELECT
     d.file_id,
     d.name,
     d.tags,
+    d.metadata,
     d.status,
+    d.processed_at,
+    d.progress,
+    d.error_message,
+    d.current_version_id,
     d.created_at,
     d.updated_at,
     f.size_bytes,
@@ -43,6 +57,7 @@ SELECT
     d.file_id,
     d.name,
     d.tags,
+    d.metadata,
     d.status,
     d.created_at,
     d.updated_at,
@@ -53,6 +68,11 @@ INNER JOIN files f ON d.file_id = f.id
 WHERE d.workspace_id = $1 
   AND d.deleted_at IS NULL
   AND (sqlc.narg('directory_id')::uuid IS NULL OR d.directory_id = sqlc.narg('directory_id'))
+  -- tags: 指定タグをすべて持つ（空配列・NULL なら絞り込まない）
+  AND (COALESCE(cardinality(sqlc.arg('tags')::text[]), 0) = 0 OR d.tags @> sqlc.arg('tags')::text[])
+  -- metadata_keys: 指定キーをすべて持つ / metadata: 指定の key-value を含む
+  AND (COALESCE(cardinality(sqlc.arg('metadata_keys')::text[]), 0) = 0 OR d.metadata ?& sqlc.arg('metadata_keys')::text[])
+  AND (sqlc.narg('metadata')::jsonb IS NULL OR d.metadata @> sqlc.narg('metadata')::jsonb)
 ORDER BY d.created_at DESC
 LIMIT $2 OFFSET $3;
 
@@ -61,7 +81,10 @@ SELECT COUNT(*)
 FROM documents 
 WHERE workspace_id = $1 
   AND deleted_at IS NULL
-  AND (sqlc.narg('directory_id')::uuid IS NULL OR directory_id = sqlc.narg('directory_id'));
+  AND (sqlc.narg('directory_id')::uuid IS NULL OR directory_id = sqlc.narg('directory_id'))
+  AND (COALESCE(cardinality(sqlc.arg('tags')::text[]), 0) = 0 OR tags @> sqlc.arg('tags')::text[])
+  AND (COALESCE(cardinality(sqlc.arg('metadata_keys')::text[]), 0) = 0 OR metadata ?& sqlc.arg('metadata_keys')::text[])
+  AND (sqlc.narg('metadata')::jsonb IS NULL OR metadata @> sqlc.narg('metadata')::jsonb);
 
 -- name: DeleteDocument :exec
 UPDATE documents 
@@ -69,4 +92,99 @@ SET
     deleted_at = now(),
     updated_at = now()
 WHERE id = $1 
-  AND workspace_id = $2;
\ No newline at end of file
+  AND workspace_id = $2;
+
+-- name: UpdateDocumentStatus :exec
+-- processed になった時点で processed_at を記録する（error_message は failed のときのみ設定）
+UPDATE documents
+SET 
+    status = $1,
+    error_message = $2,
+    processed_at = CASE WHEN $1 = 'processed' THEN now() ELSE processed_at END,
+    updated_at = now()
+WHERE id = $3 
+  AND workspace_id = $4;
+
+-- name: UpdateDocumentProgress :exec
+UPDATE documents
+SET 
+    progress = $2,
+    updated_at = now()
+WHERE id = $1;
+
+-- name: GetDocumentsByFileID :many
+SELECT * FROM documents
+WHERE file_id = $1;
+
+-- name: HardDeleteDocumentsByFileID :exec
+DELETE FROM documents
+WHERE file_id = $1;
+
+-- name: PurgeDeletedDocumentsByFileID :many
+-- 論理削除済みの documents を物理削除する（versions / chunks / jobs は CASCADE で削除される）
+-- 過去のバージョンとしてファイルを参照しているドキュメントも対象にする
+DELETE FROM documents
+WHERE (file_id = $1 OR id IN (SELECT document_id FROM document_versions WHERE file_id = $1))
+  AND deleted_at IS NOT NULL
+RETURNING id, workspace_id;
+
+-- name: SoftDeleteDocumentsInDirectories :many
+-- ディレクトリ削除時に配下のドキュメントを論理削除する（ファイル実体は blob GC が回収する）
+UPDATE documents
+SET 
+    deleted_at = now(),
+    updated_at = now()
+WHERE workspace_id = @workspace_id
+  AND directory_id = ANY(@directory_ids::uuid[])
+  AND deleted_at IS NULL
+RETURNING id;
+
+-- name: MoveDocuments :many
+-- directory_id が NULL の場合はワークスペース直下に移動する
+UPDATE documents
+SET 
+    directory_id = sqlc.narg('directory_id'),
+    updated_at = now()
+WHERE workspace_id = @workspace_id
+  AND id = ANY(@ids::uuid[])
+  AND deleted_at IS NULL
+RETURNING id;
+
+-- name: UpdateDocumentAttributes :one
+-- NULL の項目は変更しない。metadata_patch は既存の metadata にマージし、値が null のキーは削除する
+UPDATE documents
+SET
+    name = COALESCE(sqlc.narg('name'), name),
+    tags = COALESCE(sqlc.narg('tags')::text[], tags),
+    metadata = CASE
+        WHEN sqlc.narg('metadata_patch')::jsonb IS NULL THEN metadata
+        ELSE jsonb_strip_nulls(COALESCE(metadata, '{}'::jsonb) || sqlc.narg('metadata_patch')::jsonb)
+    END,
+    updated_at = now()
+WHERE id = @id
+  AND workspace_id = @workspace_id
+  AND deleted_at IS NULL
+RETURNING *;
+
+-- name: ListDocumentTags :many
+-- ワークスペース内のタグと、そのタグを持つドキュメント数
+SELECT
+    tag::text AS tag,
+    COUNT(*) AS document_count
+FROM documents d, unnest(d.tags) AS tag
+WHERE d.workspace_id = $1
+  AND d.deleted_at IS NULL
+GROUP BY tag
+ORDER BY document_count DESC, tag;
+
+-- name: SetDocumentCurrentVersion :execrows
+-- 検索対象のバージョンを切り替える（documents.file_id もそのバージョンのファイルに合わせる）
+UPDATE documents d
+SET
+    current_version_id = v.id,
+    file_id = v.file_id,
+    updated_at = now()
+FROM document_versions v
+WHERE v.id = @version_id
+  AND d.id = v.document_id
+  AND d.deleted_at IS NULL;
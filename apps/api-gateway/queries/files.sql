@@ -25,4 +25,39 @@ WHERE id = $1;
 -- name: UpdateFileLastAccessed :exec
 UPDATE files 
 SET last_accessed_at = now() 
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: ListUnreferencedFiles :many
+-- 生きている（deleted_at IS NULL の）documents から、現在・過去のどのバージョンとしても参照されていないファイルを、最後に使われた順に返す。
+-- 最後に使われた時刻 = 作成・アクセス・参照していた document の削除のうち最も新しいもの（GREATEST は NULL を無視する）
+SELECT c.id, c.sha256_hash, c.size_bytes, c.minio_bucket, c.minio_key, c.last_used_at
+FROM (
+    SELECT
+        f.id,
+        f.sha256_hash,
+        f.size_bytes,
+        f.minio_bucket,
+        f.minio_key,
+        GREATEST(
+            f.created_at,
+            f.last_accessed_at,
+            (SELECT MAX(d.deleted_at) FROM documents d WHERE d.file_id = f.id),
+            (SELECT MAX(d.deleted_at) FROM document_versions v JOIN documents d ON d.id = v.document_id WHERE v.file_id = f.id)
+        )::timestamptz AS last_used_at
+    FROM files f
+    WHERE NOT EXISTS (SELECT 1 FROM documents d WHERE d.file_id = f.id AND d.deleted_at IS NULL)
+      AND NOT EXISTS (
+          SELECT 1 FROM document_versions v JOIN documents d ON d.id = v.document_id
+          WHERE v.file_id = f.id AND d.deleted_at IS NULL
+      )
+) c
+WHERE c.last_used_at < @unused_since
+ORDER BY c.last_used_at
+LIMIT @max_files;
+
+-- name: DeleteUnreferencedFile :execrows
+-- どの document / document_version からも参照されていない場合だけ削除する（判定後に参照が増えていれば0行）
+DELETE FROM files f
+WHERE f.id = $1
+  AND NOT EXISTS (SELECT 1 FROM documents d WHERE d.file_id = f.id)
+  AND NOT EXISTS (SELECT 1 FROM document_versions v WHERE v.file_id = f.id);
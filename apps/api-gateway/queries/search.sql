@@ -19,4 +19,65 @@ SELECT DISTINCT
     d.workspace_id
 FROM documents d
 INNER JOIN document_chunks dc ON d.id = dc.document_id
-WHERE dc.id = ANY(@chunk_ids::uuid[]);
\ No newline at end of file
+WHERE dc.id = ANY(@chunk_ids::uuid[]);
+
+-- name: SearchChunksLexical :many
+-- pg_trgm の word_similarity で、クエリを語句として含むチャンクを検索する（ハイブリッド検索の lexical 側）
+-- 絞り込み条件は Qdrant の filter と同じ意味：tags は must、document_ids / directory_ids は should（空配列は制限なし）
+-- 現在のバージョンのチャンクだけを対象にする
+SELECT
+    dc.id,
+    COALESCE(dc.qdrant_point_id, dc.id)::uuid AS point_id,
+    dc.document_id,
+    dc.version_id,
+    dc.chunk_index,
+    dc.page_number,
+    dc.content,
+    dc.metadata,
+    word_similarity(@query::text, dc.content)::float8 AS score
+FROM document_chunks dc
+INNER JOIN documents d ON d.id = dc.document_id
+WHERE d.workspace_id = @workspace_id
+  AND d.deleted_at IS NULL
+  AND dc.version_id = d.current_version_id
+  AND NOT dc.is_parent
+  AND @query::text <% dc.content
+  AND (cardinality(@tags::text[]) = 0 OR d.tags && @tags::text[])
+  AND (
+      (cardinality(@document_ids::uuid[]) = 0 AND cardinality(@directory_ids::uuid[]) = 0)
+      OR d.id = ANY(@document_ids::uuid[])
+      OR d.directory_id = ANY(@directory_ids::uuid[])
+  )
+ORDER BY score DESC, dc.id
+LIMIT @result_limit;
+
+-- name: GetParentChunksByPointIDs :many
+-- 検索でヒットした子チャンク（VectorStore のポイントID）の親チャンクを取得する（親のない子チャンクは含まない）
+SELECT
+    COALESCE(c.qdrant_point_id, c.id)::uuid AS point_id,
+    p.id AS parent_id,
+    p.page_number,
+    p.content
+FROM document_chunks c
+INNER JOIN document_chunks p ON p.id = c.parent_id
+WHERE COALESCE(c.qdrant_point_id, c.id) = ANY(@point_ids::uuid[])
+  AND NOT c.is_parent;
+
+-- name: GetChunkWindowsByPointIDs :many
+-- 検索でヒットした子チャンク（VectorStore のポイントID）ごとに、同じバージョンの前後 @window_size 件のチャンクを取得する
+SELECT
+    COALESCE(c.qdrant_point_id, c.id)::uuid AS point_id,
+    n.id,
+    n.version_id,
+    n.chunk_index,
+    n.page_number,
+    n.content,
+    n.id = c.id AS matched
+FROM document_chunks c
+INNER JOIN document_chunks n
+    ON n.version_id = c.version_id
+   AND NOT n.is_parent
+   AND n.chunk_index BETWEEN c.chunk_index - @window_size::int AND c.chunk_index + @window_size::int
+WHERE COALESCE(c.qdrant_point_id, c.id) = ANY(@point_ids::uuid[])
+  AND NOT c.is_parent
+ORDER BY point_id, n.chunk_index;
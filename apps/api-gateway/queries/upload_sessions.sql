@@ -0,0 +1,73 @@
+-- name: CreateUploadSession :one
+INSERT INTO upload_sessions (
+    workspace_id,
+    directory_id,
+    file_name,
+    declared_mime_type,
+    tags,
+    size_bytes,
+    expires_at,
+    upload_method,
+    expected_sha256,
+    part_keys
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+RETURNING *;
+
+-- name: GetUploadSession :one
+SELECT * FROM upload_sessions
+WHERE id = $1 AND workspace_id = $2;
+
+-- name: AdvanceUploadSession :execrows
+-- チャンクを1つ追加して offset を進める。offset が expected_offset のときだけ更新する
+-- （同じ offset への PATCH が同時に来た場合、後の方は0行になる）
+UPDATE upload_sessions
+SET 
+    upload_offset = @new_offset,
+    part_keys = array_append(part_keys, @part_key::text),
+    hash_state = @hash_state,
+    mime_type = COALESCE(mime_type, sqlc.narg('mime_type')),
+    updated_at = now()
+WHERE 
+    id = @id
+    AND upload_offset = @expected_offset
+    AND status = 'uploading';
+
+-- name: CompleteUploadSession :exec
+UPDATE upload_sessions
+SET 
+    status = 'completed',
+    document_id = $2,
+    part_keys = '{}',
+    hash_state = NULL,
+    updated_at = now()
+WHERE id = $1;
+
+-- name: AbortUploadSession :exec
+UPDATE upload_sessions
+SET 
+    status = 'aborted',
+    part_keys = '{}',
+    hash_state = NULL,
+    updated_at = now()
+WHERE id = $1;
+
+-- name: TransitionUploadSession :execrows
+-- status が from_status のときだけ to_status に変える
+-- （完了と中止が同時に来た場合など、後の方は0行になる）
+UPDATE upload_sessions
+SET 
+    status = @to_status,
+    updated_at = now()
+WHERE 
+    id = @id
+    AND status = @from_status;
+
+-- name: ListExpiredUploadSessions :many
+-- 期限切れで受付中・完了処理中のまま残ったセッションと、チャンクの削除に失敗した中止済みのセッション
+SELECT * FROM upload_sessions
+WHERE (status IN ('uploading', 'completing') OR (status = 'aborted' AND cardinality(part_keys) > 0))
+  AND expires_at < @expired_before
+ORDER BY expires_at
+LIMIT @max_sessions;
@@ -17,8 +17,9 @@ UPDATE workspaces
 SET 
     name = $1, 
     description = $2,
+    settings = $3,
     updated_at = now() 
-WHERE id = $3 AND deleted_at IS NULl;
+WHERE id = $4 AND deleted_at IS NULl;
 
 -- name: DeleteWorkspace :exec
 UPDATE workspaces